@@ -1,18 +1,51 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"os"
+	"os/signal"
+	"os/user"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/tuusuario/dev-env-snapshots/internal/core"
 	"github.com/tuusuario/dev-env-snapshots/internal/db"
 	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+	"github.com/tuusuario/dev-env-snapshots/internal/schedule"
 	"github.com/tuusuario/dev-env-snapshots/internal/server"
 	"github.com/tuusuario/dev-env-snapshots/internal/snapshot"
 )
 
 func main() {
+	adapterName := flag.String("adapter", "native", "Platform adapter to use: native (windows, darwin; mock elsewhere) or mock")
+	scenarioPath := flag.String("mock-scenario", "", "Path to a JSON scenario file for the mock adapter")
+	toolTimeouts := flag.String("tool-timeouts", os.Getenv("TOOL_TIMEOUTS"), "Per-tool timeouts, e.g. capture_snapshot=45s,restore_snapshot=2m,*=10s")
+	transport := flag.String("transport", "stdio", "MCP transport: stdio or sse")
+	sseAddr := flag.String("sse-addr", "localhost:8080", "Listen address for the sse transport")
+	trace := flag.Bool("trace", false, "Log every tool call with its duration")
+	regionsPath := flag.String("regions", "", "Path to a JSON array of named screen regions ({name,x,y,width,height}) for region-based layouts")
+	metrics := flag.Bool("metrics", false, "Time every platform adapter call and expose the platform_metrics tool")
+	usageStats := flag.Bool("usage-stats", os.Getenv("USAGE_STATS") == "1", "Record tool usage (names and option flags only, never values) in the local database and expose the usage_report tool")
+	sharedDir := flag.String("shared-dir", os.Getenv("SHARED_DIR"), "Shared team directory (e.g. a network share) for publish_snapshot, list_shared and pull_shared")
+	author := flag.String("author", defaultAuthor(), "Author recorded on published snapshots")
+	pinnedTag := flag.String("pinned-tag", snapshot.DefaultPinnedTag, "Tag that protects snapshots from prune_snapshots (empty disables the protection)")
+	neverTouch := flag.String("never-touch", os.Getenv("NEVER_TOUCH"), "Comma-separated app names whose windows restores never move, e.g. zoom.exe,consent.exe")
+	autoInterval := flag.String("auto-snapshot-interval", os.Getenv("AUTO_SNAPSHOT_INTERVAL"), "Take a background snapshot tagged \"auto\" this often, e.g. 15m or 30 (minutes); empty disables it")
+	autoKeep := flag.Int("auto-snapshot-keep", envInt("AUTO_SNAPSHOT_KEEP", schedule.DefaultKeepLast), "Auto snapshots to keep; older ones are pruned (0 keeps all)")
+	autoEndSessions := flag.Bool("auto-end-sessions", false, "While auto snapshots are on, end focus sessions that run past their planned duration")
+	flag.Parse()
+
+	// USE_MOCK=1 is kept as a deprecated alias of --adapter mock
+	if os.Getenv("USE_MOCK") == "1" {
+		log.Println("USE_MOCK=1 is deprecated, use --adapter mock [--mock-scenario <path>]")
+		*adapterName = "mock"
+	}
+
 	// 1. Setup DB
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -30,25 +63,149 @@ func main() {
 
 	// 2. Setup Platform Adapter
 	var adapter core.PlatformAdapter
-	if os.Getenv("USE_MOCK") == "1" {
-		adapter = platform.NewMockAdapter()
-	} else {
-		// Automatically select the platform adapter.
-		// Detailed implementation of windows.go allows native execution on Windows.
-		// Note: Build tags would be used in a cross-compilation setup.
-		// Current assumption: Running on Windows.
-		adapter = platform.NewWindowsAdapter()
-		log.Println("Using Windows Adapter V2 (Renamed to Canonical)")
+	var scenarioAdapter *platform.MockAdapter
+	switch *adapterName {
+	case "mock":
+		if *scenarioPath != "" {
+			scenario, err := platform.LoadScenario(*scenarioPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			scenarioAdapter = platform.NewScenarioAdapter(scenario)
+			adapter = scenarioAdapter
+			log.Printf("Using mock adapter with scenario %q (%d steps)", scenario.Name, len(scenario.Steps))
+		} else {
+			adapter = platform.NewMockAdapter()
+			log.Println("Using mock adapter")
+		}
+	case "native", "windows", "darwin":
+		// The build tags of the platform package pick the adapter for this OS
+		adapter = platform.NewAdapter()
+		log.Printf("Using %s adapter", adapter.Name())
+	default:
+		log.Fatalf("Unknown adapter %q (expected native or mock)", *adapterName)
+	}
+	if *scenarioPath != "" && scenarioAdapter == nil {
+		log.Fatal("--mock-scenario requires --adapter mock")
+	}
+
+	var metered *platform.MeteredAdapter
+	if *metrics {
+		metered = platform.NewMeteredAdapter(adapter)
+		adapter = metered.Adapter()
 	}
 
 	// 3. Setup Logic
 	manager := snapshot.NewManager(repo, adapter)
+	if *regionsPath != "" {
+		regions, err := snapshot.LoadRegions(*regionsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		manager.SetRegions(regions)
+		log.Printf("Loaded %d screen regions from %s", len(regions), *regionsPath)
+	}
+	manager.SetPinnedTag(*pinnedTag)
+	if *neverTouch != "" {
+		var apps []string
+		for _, app := range strings.Split(*neverTouch, ",") {
+			if app = strings.TrimSpace(app); app != "" {
+				apps = append(apps, app)
+			}
+		}
+		manager.SetNeverTouch(apps)
+		log.Printf("Restores never touch: %s", strings.Join(apps, ", "))
+	}
+
+	// Stopping the server (signal or end of the transport) stops the scheduler
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	scheduler := schedule.New(ctx, manager, log.Default())
+	scheduler.SetEndOverrunSessions(*autoEndSessions)
+	if *autoInterval != "" {
+		interval, err := parseInterval(*autoInterval)
+		if err != nil {
+			log.Fatalf("Invalid --auto-snapshot-interval: %v", err)
+		}
+		if err := scheduler.Enable(interval, *autoKeep); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Auto snapshots every %s, keeping the last %d", interval, *autoKeep)
+	}
+	defer scheduler.Disable()
 
 	// 4. Start MCP Server
-	mcpServer := server.NewMCPServer(manager)
+	serverOpts := []server.Option{server.WithManager(manager), server.WithStorageHealth(database), server.WithAutoSnapshot(scheduler)}
+	if *toolTimeouts != "" {
+		timeouts, err := server.ParseToolTimeouts(*toolTimeouts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		serverOpts = append(serverOpts, server.WithToolTimeouts(timeouts))
+	}
+	if *trace {
+		serverOpts = append(serverOpts, server.WithTracing(log.Default()))
+	}
+	if *usageStats {
+		serverOpts = append(serverOpts, server.WithUsageStats(repo))
+		log.Println("Recording local usage statistics")
+	}
+	if *sharedDir != "" {
+		if err := os.MkdirAll(*sharedDir, 0755); err != nil {
+			log.Fatalf("Failed to create shared directory: %v", err)
+		}
+		serverOpts = append(serverOpts, server.WithSharedDir(*sharedDir, *author))
+		log.Printf("Sharing snapshots through %s as %q", *sharedDir, *author)
+	}
+
+	mcpServer, err := server.New(serverOpts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if scenarioAdapter != nil {
+		mcpServer.RegisterScenarioClock(scenarioAdapter)
+	}
+	if metered != nil {
+		mcpServer.RegisterPlatformMetrics(metered)
+	}
 
-	log.Printf("Starting Dev Environment Snapshots MCP Server... DB: %s", dbPath)
-	if err := mcpServer.Start(); err != nil {
+	log.Printf("Starting Dev Environment Snapshots MCP Server (%s)... DB: %s", *transport, dbPath)
+	switch *transport {
+	case "stdio":
+		err = mcpServer.ServeStdio()
+	case "sse":
+		err = mcpServer.ServeSSE(*sseAddr)
+	default:
+		log.Fatalf("Unknown transport %q (expected stdio or sse)", *transport)
+	}
+	if err != nil {
 		log.Fatal(err)
 	}
 }
+
+// parseInterval reads a Go duration ("15m", "1h30m") or a plain number of minutes
+func parseInterval(value string) (time.Duration, error) {
+	if minutes, err := strconv.Atoi(value); err == nil {
+		return time.Duration(minutes) * time.Minute, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// envInt reads an integer environment variable, falling back to def when unset or invalid
+func envInt(name string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(name)); err == nil {
+		return v
+	}
+	return def
+}
+
+// defaultAuthor is the OS user name, used when --author is not given
+func defaultAuthor() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
@@ -1,40 +1,15 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
-)
-
-// JSON-RPC Messages
-type Request struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      int             `json:"id"`
-	Method  string          `json:"method"`
-	Params  json.RawMessage `json:"params,omitempty"`
-}
-
-type Response struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      int             `json:"id"`
-	Result  json.RawMessage `json:"result,omitempty"`
-	Error   *RPCError       `json:"error,omitempty"`
-}
-
-type RPCError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-}
 
-type CallToolParams struct {
-	Name      string                 `json:"name"`
-	Arguments map[string]interface{} `json:"arguments"`
-}
+	"github.com/tuusuario/dev-env-snapshots/internal/rpc"
+)
 
 func main() {
 	// 1. Build path to server
@@ -72,41 +47,38 @@ func main() {
 	// but let's send initialize to be safe/correct if mcp-go enforces it.
 	// Actually mcp-go server usually waits for initialize.
 
-	// Create Reader/Writer
-	reader := bufio.NewReader(stdout)
-	writer := json.NewEncoder(stdin)
+	// mcp-go's stdio transport is line-delimited JSON
+	conn := rpc.NewConn(stdout, stdin, rpc.AutoFraming)
 
 	// 2.1 Send Initialize
 	fmt.Println(">> Sending Initialize")
-	sendRequest(writer, 0, "initialize", map[string]interface{}{
+	printResponse(conn.Call("initialize", map[string]interface{}{
 		"protocolVersion": "2024-11-05",
 		"capabilities":    map[string]interface{}{},
 		"clientInfo": map[string]string{
 			"name":    "ValidationClient",
 			"version": "1.0",
 		},
-	})
-	readResponse(reader) // Init response
+	}))
 
-	sendNotification(writer, "notifications/initialized", map[string]interface{}{})
+	if err := conn.Notify("notifications/initialized", map[string]interface{}{}); err != nil {
+		log.Fatalf("Failed to send initialized: %v", err)
+	}
 
 	// 3. Test: List Snapshots
 	fmt.Println("\n>> Testing: list_snapshots")
-	sendCallTool(writer, 1, "list_snapshots", nil)
-	readResponse(reader)
+	printResponse(conn.CallTool("list_snapshots", nil))
 
 	// 4. Test: Capture Snapshot
 	fmt.Println("\n>> Testing: capture_snapshot")
-	sendCallTool(writer, 2, "capture_snapshot", map[string]interface{}{
+	printResponse(conn.CallTool("capture_snapshot", map[string]interface{}{
 		"name":        "Test Snapshot",
 		"description": "Created by validation client",
-	})
-	readResponse(reader)
+	}))
 
 	// 5. Test: List again to verify
 	fmt.Println("\n>> Testing: list_snapshots (verify capture)")
-	sendCallTool(writer, 3, "list_snapshots", nil)
-	readResponse(reader)
+	printResponse(conn.CallTool("list_snapshots", nil))
 
 	// 6. Test: Restore (Dry Run implied or explicit)
 	// Currently restore tool in main hardcodes dryRun=false, but we can call it.
@@ -116,52 +88,10 @@ func main() {
 	fmt.Println("\n>> Test Sequence Complete.")
 }
 
-func sendRequest(w *json.Encoder, id int, method string, params interface{}) {
-	pBytes, _ := json.Marshal(params)
-	req := Request{
-		JSONRPC: "2.0",
-		ID:      id,
-		Method:  method,
-		Params:  pBytes,
-	}
-	if err := w.Encode(req); err != nil {
-		log.Fatalf("Failed to encode: %v", err)
-	}
-}
-
-func sendNotification(w *json.Encoder, method string, params interface{}) {
-	pBytes, _ := json.Marshal(params)
-	req := Request{
-		JSONRPC: "2.0",
-		Method:  method,
-		Params:  pBytes,
-	}
-	w.Encode(req)
-}
-
-func sendCallTool(w *json.Encoder, id int, tool string, args map[string]interface{}) {
-	if args == nil {
-		args = make(map[string]interface{})
-	}
-	params := map[string]interface{}{
-		"name":      tool,
-		"arguments": args,
-	}
-	sendRequest(w, id, "tools/call", params)
-}
-
-func readResponse(r *bufio.Reader) {
-	// MCP uses JSON-RPC over stdio, usually line delimited or content-length.
-	// mcp-go uses line-based JSON by default for stdio?
-	// Actually it might just parse JSON objects.
-	// We'll decode one JSON object.
-
-	var raw json.RawMessage
-	decoder := json.NewDecoder(r)
-	if err := decoder.Decode(&raw); err != nil {
+func printResponse(resp *rpc.Response, raw []byte, err error) {
+	if err != nil {
 		log.Printf("Failed to read response: %v", err)
 		return
 	}
-
 	fmt.Printf("<< Response: %s\n", string(raw))
 }
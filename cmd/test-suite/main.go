@@ -1,8 +1,6 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -10,26 +8,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
-)
-
-type Request struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      int             `json:"id"`
-	Method  string          `json:"method"`
-	Params  json.RawMessage `json:"params,omitempty"`
-}
-
-type Response struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      int             `json:"id"`
-	Result  json.RawMessage `json:"result,omitempty"`
-	Error   *RPCError       `json:"error,omitempty"`
-}
 
-type RPCError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-}
+	"github.com/tuusuario/dev-env-snapshots/internal/rpc"
+)
 
 func main() {
 	cwd, _ := os.Getwd()
@@ -49,12 +30,11 @@ func main() {
 	defer cmd.Process.Kill()
 	go io.Copy(os.Stderr, stderr)
 
-	reader := bufio.NewReader(stdout)
-	writer := json.NewEncoder(stdin)
+	conn := rpc.NewConn(stdout, stdin, rpc.AutoFraming)
 
 	// 1. Initialize
 	fmt.Println("\n[1] Protocol Handshake")
-	call(writer, reader, 0, "initialize", map[string]interface{}{
+	call(conn, "initialize", map[string]interface{}{
 		"protocolVersion": "2024-11-05",
 		"capabilities":    map[string]interface{}{},
 		"clientInfo":      map[string]string{"name": "TestSuite", "version": "2.0"},
@@ -62,7 +42,7 @@ func main() {
 
 	// 2. Capture Snapshot A
 	fmt.Println("\n[2] Tool: capture_snapshot (A)")
-	respA := call(writer, reader, 1, "tools/call", map[string]interface{}{
+	respA := call(conn, "tools/call", map[string]interface{}{
 		"name": "capture_snapshot",
 		"arguments": map[string]interface{}{
 			"name":        "Snapshot Alpha",
@@ -74,7 +54,7 @@ func main() {
 
 	// 3. Capture Snapshot B
 	fmt.Println("\n[3] Tool: capture_snapshot (B)")
-	respB := call(writer, reader, 2, "tools/call", map[string]interface{}{
+	respB := call(conn, "tools/call", map[string]interface{}{
 		"name": "capture_snapshot",
 		"arguments": map[string]interface{}{
 			"name":        "Snapshot Beta",
@@ -86,7 +66,7 @@ func main() {
 
 	// 4. Diff Snapshots
 	fmt.Println("\n[4] Tool: diff_snapshots (A vs B)")
-	call(writer, reader, 3, "tools/call", map[string]interface{}{
+	call(conn, "tools/call", map[string]interface{}{
 		"name": "diff_snapshots",
 		"arguments": map[string]interface{}{
 			"source_id": idA,
@@ -98,7 +78,7 @@ func main() {
 	fmt.Println("\n[5] Tool: restore_snapshot (Report/Dry Mode)")
 	// Note: Our current restore tool doesn't expose dryRun to MCP arguments yet,
 	// but it returns a report. We test it here.
-	call(writer, reader, 4, "tools/call", map[string]interface{}{
+	call(conn, "tools/call", map[string]interface{}{
 		"name": "restore_snapshot",
 		"arguments": map[string]interface{}{
 			"snapshot_id": idA,
@@ -107,7 +87,7 @@ func main() {
 
 	// 6. Delete
 	fmt.Println("\n[6] Tool: delete_snapshot")
-	call(writer, reader, 5, "tools/call", map[string]interface{}{
+	call(conn, "tools/call", map[string]interface{}{
 		"name": "delete_snapshot",
 		"arguments": map[string]interface{}{
 			"snapshot_id": idB,
@@ -117,14 +97,11 @@ func main() {
 	fmt.Println("\n--- TEST SUITE FINISHED ---")
 }
 
-func call(w *json.Encoder, r *bufio.Reader, id int, method string, params interface{}) string {
-	pBytes, _ := json.Marshal(params)
-	req := Request{JSONRPC: "2.0", ID: id, Method: method, Params: pBytes}
-	w.Encode(req)
-
-	var raw json.RawMessage
-	decoder := json.NewDecoder(r)
-	decoder.Decode(&raw)
+func call(conn *rpc.Conn, method string, params interface{}) string {
+	_, raw, err := conn.Call(method, params)
+	if err != nil {
+		log.Printf("Call %s failed: %v", method, err)
+	}
 	output := string(raw)
 	fmt.Printf("<< %s\n", output)
 	return output
@@ -0,0 +1,125 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// DefaultPorts are the remote debugging ports probed when none are configured
+var DefaultPorts = []int{9222}
+
+// Target is one entry of the DevTools /json/list endpoint
+type Target struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+type version struct {
+	Browser string `json:"Browser"` // e.g. "Chrome/120.0.6099.71" or "Edg/120.0.2210.61"
+}
+
+// CDPCollector reads open tabs from Chromium browsers started with --remote-debugging-port
+type CDPCollector struct {
+	Host   string
+	Ports  []int
+	client *http.Client
+}
+
+func NewCDPCollector() *CDPCollector {
+	return &CDPCollector{
+		Host:   "localhost",
+		Ports:  DefaultPorts,
+		client: &http.Client{Timeout: 2 * time.Second},
+	}
+}
+
+// CollectTabs returns the page targets of every reachable debugging port.
+// It fails only when no port answered, so callers can fall back.
+func (c *CDPCollector) CollectTabs(ctx context.Context) ([]core.BrowserTab, error) {
+	var tabs []core.BrowserTab
+	var lastErr error
+	reached := 0
+
+	for window, port := range c.Ports {
+		targets, err := c.ListTargets(ctx, port)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		reached++
+
+		name := c.browserName(ctx, port)
+		index := 0
+		for _, t := range targets {
+			if t.Type != "page" {
+				continue
+			}
+			tabs = append(tabs, core.BrowserTab{
+				BrowserName: name,
+				URL:         t.URL,
+				Title:       t.Title,
+				TabIndex:    index,
+				WindowIndex: window,
+			})
+			index++
+		}
+	}
+
+	if reached == 0 {
+		return nil, fmt.Errorf("no DevTools endpoint reachable: %w", lastErr)
+	}
+	return tabs, nil
+}
+
+// ListTargets fetches /json/list from the given port
+func (c *CDPCollector) ListTargets(ctx context.Context, port int) ([]Target, error) {
+	var targets []Target
+	if err := c.getJSON(ctx, port, "/json/list", &targets); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// browserName maps /json/version to the executable names the adapters use
+func (c *CDPCollector) browserName(ctx context.Context, port int) string {
+	var v version
+	if err := c.getJSON(ctx, port, "/json/version", &v); err != nil {
+		return "chrome.exe"
+	}
+	switch {
+	case strings.HasPrefix(v.Browser, "Edg/"):
+		return "msedge.exe"
+	case strings.HasPrefix(v.Browser, "Brave/"):
+		return "brave.exe"
+	case strings.HasPrefix(v.Browser, "Opera/"):
+		return "opera.exe"
+	}
+	return "chrome.exe"
+}
+
+func (c *CDPCollector) getJSON(ctx context.Context, port int, path string, v interface{}) error {
+	url := fmt.Sprintf("http://%s:%d%s", c.Host, port, path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
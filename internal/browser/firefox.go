@@ -0,0 +1,137 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// recoveryFile is the session Firefox rewrites every few seconds while running
+const recoveryFile = "sessionstore-backups/recovery.jsonlz4"
+
+// sessionStore is the part of Firefox's sessionstore we read
+type sessionStore struct {
+	Windows []struct {
+		Tabs []struct {
+			Entries []struct {
+				URL   string `json:"url"`
+				Title string `json:"title"`
+			} `json:"entries"`
+			Index  int  `json:"index"` // 1-based position of the current entry in the tab history
+			Pinned bool `json:"pinned"`
+			Hidden bool `json:"hidden"`
+		} `json:"tabs"`
+	} `json:"windows"`
+}
+
+// FirefoxCollector reads open tabs from the session file of the most
+// recently active Firefox profile. It only reads files, so it needs neither
+// an extension nor a debugging port.
+type FirefoxCollector struct {
+	ProfilesDir string
+	BrowserName string // BrowserName recorded on the tabs, as the adapter names Firefox
+}
+
+func NewFirefoxCollector(browserName string) *FirefoxCollector {
+	return &FirefoxCollector{
+		ProfilesDir: defaultFirefoxProfiles(),
+		BrowserName: browserName,
+	}
+}
+
+// defaultFirefoxProfiles is where Firefox keeps its profiles on this OS
+func defaultFirefoxProfiles() string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(os.Getenv("APPDATA"), "Mozilla", "Firefox", "Profiles")
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "Firefox", "Profiles")
+	}
+	return filepath.Join(home, ".mozilla", "firefox")
+}
+
+// CollectTabs returns the tabs of every open window, with the URL and title
+// of the page each tab is showing. It fails when the session file can't be
+// found or read (Firefox may hold it while rewriting), so callers can fall back.
+func (c *FirefoxCollector) CollectTabs(ctx context.Context) ([]core.BrowserTab, error) {
+	path, err := c.latestSession()
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read Firefox session: %w", err)
+	}
+	data, err := decodeMozLz4(raw)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode Firefox session %s: %w", path, err)
+	}
+	var session sessionStore
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("malformed Firefox session %s: %w", path, err)
+	}
+
+	var tabs []core.BrowserTab
+	for window, w := range session.Windows {
+		index := 0
+		for _, t := range w.Tabs {
+			if t.Hidden || len(t.Entries) == 0 {
+				continue
+			}
+			current := t.Index - 1
+			if current < 0 || current >= len(t.Entries) {
+				current = len(t.Entries) - 1
+			}
+			entry := t.Entries[current]
+			tabs = append(tabs, core.BrowserTab{
+				BrowserName: c.BrowserName,
+				URL:         entry.URL,
+				Title:       entry.Title,
+				TabIndex:    index,
+				WindowIndex: window,
+				IsPinned:    t.Pinned,
+			})
+			index++
+		}
+	}
+	return tabs, nil
+}
+
+// latestSession picks the recovery file written most recently, which
+// belongs to the running profile when there are several
+func (c *FirefoxCollector) latestSession() (string, error) {
+	profiles, err := os.ReadDir(c.ProfilesDir)
+	if err != nil {
+		return "", fmt.Errorf("no Firefox profiles: %w", err)
+	}
+	var latest string
+	var latestTime time.Time
+	for _, p := range profiles {
+		if !p.IsDir() {
+			continue
+		}
+		path := filepath.Join(c.ProfilesDir, p.Name(), filepath.FromSlash(recoveryFile))
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(latestTime) {
+			latest, latestTime = path, info.ModTime()
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no Firefox session found in %s", c.ProfilesDir)
+	}
+	return latest, nil
+}
@@ -0,0 +1,96 @@
+package browser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// mozLz4Magic starts Firefox's .jsonlz4/.baklz4 files, followed by the
+// decompressed size (uint32, little endian) and a single LZ4 block
+var mozLz4Magic = []byte("mozLz40\x00")
+
+// maxMozLz4Size bounds the declared size so a corrupt header can't make us
+// allocate gigabytes
+const maxMozLz4Size = 256 << 20
+
+var errLz4Corrupt = errors.New("corrupt lz4 block")
+
+// decodeMozLz4 returns the contents of a mozLz4 file
+func decodeMozLz4(data []byte) ([]byte, error) {
+	header := len(mozLz4Magic) + 4
+	if len(data) < header || !bytes.Equal(data[:len(mozLz4Magic)], mozLz4Magic) {
+		return nil, fmt.Errorf("not a mozLz4 file")
+	}
+	size := binary.LittleEndian.Uint32(data[len(mozLz4Magic):header])
+	if size > maxMozLz4Size {
+		return nil, fmt.Errorf("mozLz4 file declares %d bytes, refusing", size)
+	}
+	return decodeLz4Block(data[header:], int(size))
+}
+
+// decodeLz4Block decompresses one raw LZ4 block of exactly size bytes
+func decodeLz4Block(src []byte, size int) ([]byte, error) {
+	dst := make([]byte, 0, size)
+	i := 0
+	// length reads the extra bytes of a literal or match length of 15
+	length := func(n int) (int, error) {
+		if n != 15 {
+			return n, nil
+		}
+		for {
+			if i >= len(src) {
+				return 0, errLz4Corrupt
+			}
+			b := src[i]
+			i++
+			n += int(b)
+			if b != 255 {
+				return n, nil
+			}
+		}
+	}
+
+	for i < len(src) {
+		token := src[i]
+		i++
+
+		literals, err := length(int(token >> 4))
+		if err != nil {
+			return nil, err
+		}
+		if i+literals > len(src) || len(dst)+literals > size {
+			return nil, errLz4Corrupt
+		}
+		dst = append(dst, src[i:i+literals]...)
+		i += literals
+		if i == len(src) {
+			break // The last sequence only has literals
+		}
+
+		if i+2 > len(src) {
+			return nil, errLz4Corrupt
+		}
+		offset := int(binary.LittleEndian.Uint16(src[i:]))
+		i += 2
+		match, err := length(int(token & 0x0f))
+		if err != nil {
+			return nil, err
+		}
+		match += 4
+		if offset == 0 || offset > len(dst) || len(dst)+match > size {
+			return nil, errLz4Corrupt
+		}
+		// Byte by byte: the match may overlap what it is copying
+		start := len(dst) - offset
+		for k := 0; k < match; k++ {
+			dst = append(dst, dst[start+k])
+		}
+	}
+
+	if len(dst) != size {
+		return nil, fmt.Errorf("lz4 block decoded to %d bytes, expected %d", len(dst), size)
+	}
+	return dst, nil
+}
@@ -0,0 +1,23 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSnapshotNotFound reports that no snapshot has the requested ID
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// NotFoundError names the snapshot that was not found.
+// errors.Is(err, ErrSnapshotNotFound) is true for this error
+type NotFoundError struct {
+	SnapshotID string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("snapshot %s not found", e.SnapshotID)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrSnapshotNotFound
+}
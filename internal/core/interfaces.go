@@ -10,6 +10,8 @@ type PlatformAdapter interface {
 	// Windows
 	GetWindows(ctx context.Context) ([]Window, error)
 	RestoreWindow(ctx context.Context, window Window) error
+	// PositionWindow applies target's geometry to the given live window without matching
+	PositionWindow(ctx context.Context, live Window, target Window) error
 	CloseWindow(ctx context.Context, window Window) error
 
 	// Terminals
@@ -28,28 +30,194 @@ type PlatformAdapter interface {
 	StartProcess(ctx context.Context, process Process) error
 }
 
+// GitRepoLocator is implemented by adapters that know which repository the
+// environment is working in (e.g. scenario mocks pointing at fixture repos)
+type GitRepoLocator interface {
+	GitRepoPath() string
+}
+
+// DeepTabCapturer is implemented by adapters that can read real tab URLs
+// (e.g. over the Chrome DevTools protocol) instead of just window titles
+type DeepTabCapturer interface {
+	GetBrowserTabsDeep(ctx context.Context) ([]BrowserTab, error)
+}
+
+// BrowserWindowOpener is implemented by adapters that can open several URLs
+// as the tabs of one new window of a given browser, so tabs captured together
+// come back together. It fails with errors.ErrUnsupported for browsers it
+// can't drive; callers then open the URLs one by one.
+type BrowserWindowOpener interface {
+	OpenBrowserWindow(ctx context.Context, browser string, urls []string) error
+}
+
+// FocusKeeper is implemented by adapters that can note which window has the
+// keyboard focus and give it back later. SaveFocus returns the function that
+// refocuses that window.
+type FocusKeeper interface {
+	SaveFocus(ctx context.Context) (refocus func(context.Context) error, err error)
+}
+
+// AppLauncher is implemented by adapters that can start an application for a
+// window that has no live match. It returns the new process ID, or 0 when the
+// platform hands the launch off and the PID is unknown. Decorators that wrap
+// an adapter without it fail with errors.ErrUnsupported.
+type AppLauncher interface {
+	LaunchApp(ctx context.Context, window Window) (int, error)
+}
+
+// BulkWindowRestorer is implemented by adapters that restore many windows
+// from a single enumeration with a one-to-one assignment of live windows.
+// errs[i] is the outcome of windows[i] and placed[i] the live window, as
+// enumerated before moving it, that received its geometry (zero when none);
+// err reports a failure of the whole operation (enumeration, cancellation).
+// platform.RestoreWindows provides the equivalent for adapters without it.
+type BulkWindowRestorer interface {
+	RestoreWindows(ctx context.Context, windows []Window) (placed []Window, errs []error, err error)
+}
+
+// MonitorLister is implemented by adapters that can enumerate the connected
+// displays. Decorators that wrap an adapter without it fail with
+// errors.ErrUnsupported; callers treat that like a missing MonitorLister.
+type MonitorLister interface {
+	GetMonitors(ctx context.Context) ([]Monitor, error)
+}
+
+// CapabilityReporter is implemented by adapters that declare which operations
+// they actually support, so clients can hide features the platform lacks
+type CapabilityReporter interface {
+	Capabilities() Capabilities
+}
+
+// Capabilities lists the operations an adapter supports, per component
+type Capabilities struct {
+	// Windows
+	CanReadWindows      bool `json:"can_read_windows"`
+	CanPositionWindows  bool `json:"can_position_windows"`
+	CanCloseWindows     bool `json:"can_close_windows"`
+	CanLaunchApps       bool `json:"can_launch_apps"`
+	CanReadCommandLines bool `json:"can_read_command_lines"`
+	CanReadMonitors     bool `json:"can_read_monitors"`
+	// Terminals
+	CanReadTerminals       bool `json:"can_read_terminals"`
+	CanReadTerminalLayouts bool `json:"can_read_terminal_layouts"`
+	CanReadEnvVars         bool `json:"can_read_env_vars"`
+	CanRestoreTerminals    bool `json:"can_restore_terminals"`
+	// Browsers
+	CanReadTabTitles   bool `json:"can_read_tab_titles"`
+	CanReadTabURLs     bool `json:"can_read_tab_urls"`
+	CanDeepCaptureTabs bool `json:"can_deep_capture_tabs"` // Real URLs over DevTools when the browser allows it
+	CanOpenURLs        bool `json:"can_open_urls"`
+	// IDEs and processes
+	CanReadIDEFiles   bool `json:"can_read_ide_files"`
+	CanReadProcesses  bool `json:"can_read_processes"`
+	CanStartProcesses bool `json:"can_start_processes"`
+}
+
 // Repository defines the persistence layer operations
 type Repository interface {
 	// Snapshots
 	CreateSnapshot(ctx context.Context, snapshot *Snapshot) error
+	// SaveSnapshot stores a snapshot and all its components atomically
+	SaveSnapshot(ctx context.Context, snapshot *Snapshot) error
+	// UpdateSnapshot stores name, description and tags and bumps UpdatedAt.
+	// It fails with a NotFoundError when no snapshot has that ID.
+	UpdateSnapshot(ctx context.Context, snapshot *Snapshot) error
 	GetSnapshotByID(ctx context.Context, id string) (*Snapshot, error)
-	ListSnapshots(ctx context.Context, filter SnapshotFilter) ([]Snapshot, error)
+	// FindSnapshotsByRef returns the snapshots whose ID equals ref or starts
+	// with it (prefixes of at least minPrefix characters), or whose name equals
+	// ref ignoring case. Newest first.
+	FindSnapshotsByRef(ctx context.Context, ref string, minPrefix int) ([]Snapshot, error)
+	ListSnapshots(ctx context.Context, filter SnapshotFilter) (*SnapshotList, error)
+	// DeleteSnapshot removes the snapshot and its components in one transaction.
+	// It fails with a NotFoundError when no snapshot has that ID.
 	DeleteSnapshot(ctx context.Context, id string) error
+	// DeleteSnapshots removes several snapshots in one transaction, all or
+	// none, and returns how many component rows went with them
+	DeleteSnapshots(ctx context.Context, ids []string) (childRows int64, err error)
 
 	// Components
 	SaveWindows(ctx context.Context, snapshotID string, windows []Window) error
 	SaveTerminals(ctx context.Context, snapshotID string, terminals []Terminal) error
 	SaveBrowserTabs(ctx context.Context, snapshotID string, tabs []BrowserTab) error
 	SaveIDEFiles(ctx context.Context, snapshotID string, files []IDEFile) error
+	SaveProcesses(ctx context.Context, snapshotID string, processes []Process) error
+	SaveMonitors(ctx context.Context, snapshotID string, monitors []Monitor) error
 	GetWindows(ctx context.Context, snapshotID string) ([]Window, error)
+	GetTerminals(ctx context.Context, snapshotID string) ([]Terminal, error)
+	GetBrowserTabs(ctx context.Context, snapshotID string) ([]BrowserTab, error)
+	GetIDEFiles(ctx context.Context, snapshotID string) ([]IDEFile, error)
+	GetProcesses(ctx context.Context, snapshotID string) ([]Process, error)
+	GetMonitors(ctx context.Context, snapshotID string) ([]Monitor, error)
+
+	// Sessions
+	CreateSession(ctx context.Context, session *Session) error
+	// EndSession stores the end snapshot, end time and drift score of a session
+	EndSession(ctx context.Context, session *Session) error
+	GetSession(ctx context.Context, id string) (*Session, error)
+	// ListSessions returns every session, newest first
+	ListSessions(ctx context.Context) ([]Session, error)
+
+	// Storage
+	GetStorageBreakdown(ctx context.Context) ([]StorageUsage, error)
+
+	// Capture metrics
+	SaveCaptureMetrics(ctx context.Context, metrics CaptureMetrics) error
+	// ListCaptureMetrics returns the newest captures' timings first, only
+	// snapshotID's when it is not empty; limit <= 0 returns all
+	ListCaptureMetrics(ctx context.Context, snapshotID string, limit int) ([]CaptureMetrics, error)
+
+	// Integrity
+	UpdateChecksum(ctx context.Context, snapshotID string) (string, error)
+	VerifyChecksum(ctx context.Context, snapshotID string) (stored string, computed string, err error)
 	// Add other component methods as needed
 }
 
+// TagMatchMode is how SnapshotFilter.Tags combines several tags
+type TagMatchMode string
+
+const (
+	TagMatchAll TagMatchMode = "all" // Snapshots carrying every tag (the default)
+	TagMatchAny TagMatchMode = "any" // Snapshots carrying at least one of the tags
+)
+
 // SnapshotFilter defines criteria for listing snapshots
 type SnapshotFilter struct {
-	Project string
-	Branch  string
-	Tags    []string
-	Limit   int
-	Offset  int
+	Project  string
+	Branch   string
+	Tags     []string
+	TagMatch TagMatchMode // Empty means TagMatchAll
+	Limit    int
+	Offset   int
+}
+
+// SnapshotList is the result of listing snapshots. Rows that could only be
+// partially read (or not at all) are reported in Warnings instead of failing
+// the whole listing.
+type SnapshotList struct {
+	Snapshots []Snapshot
+	Total     int                        // Snapshots matching the filter, ignoring Limit and Offset
+	Offset    int                        // Offset the page starts at
+	HasMore   bool                       // Matching snapshots remain after this page
+	Counts    map[string]ComponentCounts // By snapshot ID; components aren't loaded when listing
+	Warnings  []string
+}
+
+// ComponentCounts is how many components of each kind a snapshot holds
+type ComponentCounts struct {
+	Windows     int `json:"windows"`
+	Terminals   int `json:"terminals"`
+	BrowserTabs int `json:"browser_tabs"`
+}
+
+// StorageUsage is the estimated on-disk size of one snapshot, split by component
+type StorageUsage struct {
+	SnapshotID    string `json:"snapshot_id"`
+	Name          string `json:"name"`
+	MetadataBytes int64  `json:"metadata_bytes"`
+	WindowBytes   int64  `json:"window_bytes"`
+	TerminalBytes int64  `json:"terminal_bytes"`
+	TabBytes      int64  `json:"tab_bytes"`
+	ProcessBytes  int64  `json:"process_bytes"`
+	IDEFileBytes  int64  `json:"ide_file_bytes"`
+	TotalBytes    int64  `json:"total_bytes"`
 }
@@ -17,13 +17,29 @@ type Snapshot struct {
 	GitDirty    bool         `json:"git_dirty" db:"git_dirty"`
 	GitHeadHash string       `json:"git_head_hash" db:"git_head_hash"` // Added this field
 	Tags        []string     `json:"tags" db:"tags"`
+	BackupFor   string       `json:"backup_for,omitempty" db:"backup_for"` // For auto-backups: the snapshot whose restore they preceded
+	Origin      string       `json:"origin,omitempty" db:"origin"`         // How the snapshot was created, one of the Origin* constants; empty for older snapshots
+	ParentIDs   []string     `json:"parent_ids,omitempty" db:"parent_ids"` // Snapshots it was derived from; stored as JSON
+	Author      string       `json:"author,omitempty" db:"author"`         // Who published it, for snapshots pulled from a shared directory
+	Untrusted   bool         `json:"untrusted,omitempty" db:"untrusted"`   // Imported or pulled from a shared directory: restores never launch its apps or processes
 	Windows     []Window     `json:"windows"`
 	Terminals   []Terminal   `json:"terminals"`
 	BrowserTabs []BrowserTab `json:"browser_tabs"`
 	Processes   []Process    `json:"processes"`
 	IDEFiles    []IDEFile    `json:"ide_files"`
+	Monitors    []Monitor    `json:"monitors,omitempty"`
+	Warnings    []string     `json:"warnings,omitempty"` // Non-fatal read problems (not persisted)
 }
 
+// Snapshot origins
+const (
+	OriginCaptured = "captured"
+	OriginCloned   = "cloned"
+	OriginMerged   = "merged"
+	OriginBackup   = "backup"
+	OriginImported = "imported"
+)
+
 // ... rest of file same as before
 // To avoid rewriting whole file, I will use replace logic in next steps if needed,
 // or I can just re-write the top part if I am careful.
@@ -33,19 +49,45 @@ type Snapshot struct {
 
 // Window represents a system window
 type Window struct {
-	ID          int64           `json:"id" db:"id"`
-	SnapshotID  string          `json:"snapshot_id" db:"snapshot_id"`
-	AppName     string          `json:"app_name" db:"app_name"`
-	AppPath     string          `json:"app_path" db:"app_path"`
-	WindowTitle string          `json:"window_title" db:"window_title"`
-	X           int             `json:"x" db:"x"`
-	Y           int             `json:"y" db:"y"`
-	Width       int             `json:"width" db:"width"`
-	Height      int             `json:"height" db:"height"`
-	State       string          `json:"state" db:"state"` // normal, maximized, minimized, fullscreen
-	Workspace   int             `json:"workspace" db:"workspace"`
-	ZIndex      int             `json:"z_index" db:"z_index"`
-	LaunchArgs  json.RawMessage `json:"launch_args" db:"launch_args"`
+	ID           int64           `json:"id" db:"id"`
+	SnapshotID   string          `json:"snapshot_id" db:"snapshot_id"`
+	AppName      string          `json:"app_name" db:"app_name"`
+	AppPath      string          `json:"app_path" db:"app_path"`
+	WindowTitle  string          `json:"window_title" db:"window_title"`
+	X            int             `json:"x" db:"x"`
+	Y            int             `json:"y" db:"y"`
+	Width        int             `json:"width" db:"width"`
+	Height       int             `json:"height" db:"height"`
+	State        string          `json:"state" db:"state"` // normal, maximized, minimized, fullscreen
+	Workspace    int             `json:"workspace" db:"workspace"`
+	ZIndex       int             `json:"z_index" db:"z_index"`
+	LaunchArgs   json.RawMessage `json:"launch_args" db:"launch_args"`
+	OwnerRef     int             `json:"owner_ref" db:"owner_ref"`         // 1-based position of the owner window in the snapshot, 0 = top-level
+	GroupID      int             `json:"group_id,omitempty" db:"group_id"` // Snap group of windows tiled against each other, 0 = ungrouped
+	Region       string          `json:"region,omitempty" db:"region"`     // Named screen region the window was in (ultrawide layouts)
+	Monitor      string          `json:"monitor,omitempty" db:"monitor"`   // Device name of the monitor holding the window
+	RelX         int             `json:"rel_x,omitempty" db:"rel_x"`       // Position relative to the monitor's top-left corner
+	RelY         int             `json:"rel_y,omitempty" db:"rel_y"`
+	Pid          int             `json:"pid,omitempty" db:"-"`           // Owning process of a live window; not persisted
+	OtherDesktop bool            `json:"other_desktop,omitempty" db:"-"` // Live window on another virtual desktop; not persisted
+}
+
+// Monitor is a display connected when the snapshot was captured
+type Monitor struct {
+	ID         int64  `json:"id" db:"id"`
+	SnapshotID string `json:"snapshot_id" db:"snapshot_id"`
+	DeviceName string `json:"device_name" db:"device_name"` // e.g. \\.\DISPLAY1
+	X          int    `json:"x" db:"x"`
+	Y          int    `json:"y" db:"y"`
+	Width      int    `json:"width" db:"width"`
+	Height     int    `json:"height" db:"height"`
+	Primary    bool   `json:"primary" db:"is_primary"`
+	DPI        int    `json:"dpi" db:"dpi"` // Effective DPI, 0 = unknown
+	// Work area: the bounds minus taskbars and docks. Zero size = unknown
+	WorkX      int `json:"work_x,omitempty" db:"work_x"`
+	WorkY      int `json:"work_y,omitempty" db:"work_y"`
+	WorkWidth  int `json:"work_width,omitempty" db:"work_width"`
+	WorkHeight int `json:"work_height,omitempty" db:"work_height"`
 }
 
 // Terminal represents a terminal session
@@ -56,7 +98,27 @@ type Terminal struct {
 	WorkingDirectory string            `json:"working_directory" db:"working_directory"`
 	ActiveCommand    string            `json:"active_command" db:"active_command"`
 	ShellType        string            `json:"shell_type" db:"shell_type"`
-	EnvVars          map[string]string `json:"env_vars" db:"env_vars"` // Stored as JSON
+	EnvVars          map[string]string `json:"env_vars" db:"env_vars"`       // Stored as JSON
+	Layout           *TerminalLayout   `json:"layout,omitempty" db:"layout"` // Stored as JSON; nil when the tab/pane structure is unknown
+}
+
+// TerminalLayout is the tab/pane structure of a terminal window (Windows Terminal)
+type TerminalLayout struct {
+	Tabs []TerminalTab `json:"tabs"`
+}
+
+// TerminalTab is one tab of a terminal window; the first pane is the tab itself
+type TerminalTab struct {
+	Title string         `json:"title,omitempty"`
+	Panes []TerminalPane `json:"panes"`
+}
+
+// TerminalPane is a pane inside a tab
+type TerminalPane struct {
+	Directory string  `json:"directory,omitempty"`
+	Profile   string  `json:"profile,omitempty"`
+	Split     string  `json:"split,omitempty"` // "vertical" or "horizontal"; empty for the first pane or automatic
+	Size      float64 `json:"size,omitempty"`  // Fraction of the parent pane, 0 = default
 }
 
 // BrowserTab represents a browser tab
@@ -92,3 +154,47 @@ type IDEFile struct {
 	CursorColumn int    `json:"cursor_column" db:"cursor_column"`
 	IsActive     bool   `json:"is_active" db:"is_active"`
 }
+
+// UsageRecord is one tool call recorded by the opt-in usage statistics.
+// It never holds argument values, only the names of the options passed.
+type UsageRecord struct {
+	ID       int64         `json:"id" db:"id"`
+	Tool     string        `json:"tool" db:"tool"`
+	Options  []string      `json:"options" db:"options"` // Stored as JSON
+	Outcome  string        `json:"outcome" db:"outcome"` // ok, tool_error, error
+	Duration time.Duration `json:"duration" db:"duration_ms"`
+	CalledAt time.Time     `json:"called_at" db:"called_at"`
+}
+
+// CaptureMetrics is how long one capture took, phase by phase. It outlives
+// its snapshot so timings can be compared over time.
+type CaptureMetrics struct {
+	SnapshotID   string        `json:"snapshot_id" db:"snapshot_id"`
+	SnapshotName string        `json:"snapshot_name" db:"snapshot_name"`
+	CapturedAt   time.Time     `json:"captured_at" db:"captured_at"`
+	Total        time.Duration `json:"total"`  // Sum of the phases
+	Phases       []PhaseTiming `json:"phases"` // In capture order
+}
+
+// PhaseTiming is the duration of one capture phase (windows, terminals, save...)
+type PhaseTiming struct {
+	Phase    string        `json:"phase" db:"phase"`
+	Duration time.Duration `json:"duration" db:"duration_ms"`
+}
+
+// Session is a focus block bracketed by a start and an end snapshot
+type Session struct {
+	ID              string        `json:"id" db:"id"`
+	Label           string        `json:"label" db:"label"`
+	StartSnapshotID string        `json:"start_snapshot_id" db:"start_snapshot_id"`
+	EndSnapshotID   string        `json:"end_snapshot_id,omitempty" db:"end_snapshot_id"` // Empty while the session is active
+	StartedAt       time.Time     `json:"started_at" db:"started_at"`
+	EndedAt         time.Time     `json:"ended_at" db:"ended_at"`
+	Planned         time.Duration `json:"planned,omitempty" db:"planned_seconds"` // 0 = open-ended
+	DriftScore      float64       `json:"drift_score" db:"drift_score"`           // Share of windows opened or closed, 0-1
+}
+
+// Active reports whether the session has not been ended yet
+func (s Session) Active() bool {
+	return s.EndSnapshotID == ""
+}
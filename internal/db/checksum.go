@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// computeChecksum calcula un SHA-256 sobre la forma canónica del snapshot y
+// sus componentes tal como están almacenados. Cada fila se serializa como un
+// objeto JSON con claves ordenadas omitiendo valores vacíos, así las columnas
+// agregadas por migraciones (con default vacío) no invalidan checksums viejos.
+func computeChecksum(ctx context.Context, q querier, snapshotID string) (string, error) {
+	h := sha256.New()
+
+	meta, err := canonicalRows(ctx, q,
+		`SELECT name, description, git_branch, git_repo, git_dirty, git_head_hash, tags FROM snapshots WHERE id = ?`, snapshotID)
+	if err != nil {
+		return "", err
+	}
+	if len(meta) == 0 {
+		return "", sql.ErrNoRows
+	}
+	fmt.Fprintf(h, "snapshot:%s\n", meta[0])
+
+	for _, table := range childTables {
+		rows, err := canonicalRows(ctx, q, fmt.Sprintf(`SELECT * FROM %s WHERE snapshot_id = ? ORDER BY id`, table), snapshotID)
+		if err != nil {
+			return "", err
+		}
+		for _, row := range rows {
+			fmt.Fprintf(h, "%s:%s\n", table, row)
+		}
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// querier es el subconjunto común de *sql.DB y *sql.Tx usado para leer
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// canonicalRows serializa cada fila del resultado a JSON canónico.
+// Las columnas id y snapshot_id se excluyen porque no son contenido.
+func canonicalRows(ctx context.Context, q querier, query string, args ...interface{}) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(cols))
+		for i, col := range cols {
+			if col == "id" || col == "snapshot_id" || isEmptyValue(values[i]) {
+				continue
+			}
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			row[col] = values[i]
+		}
+
+		encoded, err := json.Marshal(row)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, string(encoded))
+	}
+	return out, rows.Err()
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case int64:
+		return t == 0
+	case float64:
+		return t == 0
+	case bool:
+		return !t
+	case string:
+		return t == "" || t == "null"
+	case []byte:
+		return len(t) == 0 || string(t) == "null"
+	}
+	return false
+}
+
+// UpdateChecksum recalcula y guarda el checksum de un snapshot
+func (r *SQLiteRepository) UpdateChecksum(ctx context.Context, snapshotID string) (string, error) {
+	var sum string
+	err := r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		sum, err = computeChecksum(ctx, tx, snapshotID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE snapshots SET checksum = ? WHERE id = ?`, sum, snapshotID)
+		return err
+	})
+	return sum, err
+}
+
+// VerifyChecksum retorna el checksum guardado y el recalculado a partir de
+// los datos actuales. stored es "" si el snapshot no tiene checksum.
+func (r *SQLiteRepository) VerifyChecksum(ctx context.Context, snapshotID string) (string, string, error) {
+	var stored sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT checksum FROM snapshots WHERE id = ?`, snapshotID).Scan(&stored)
+	if err != nil {
+		return "", "", err
+	}
+
+	computed, err := computeChecksum(ctx, r.db, snapshotID)
+	if err != nil {
+		return "", "", err
+	}
+	return stored.String, computed, nil
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+func TestChecksumDetectsEditedRows(t *testing.T) {
+	ctx := context.Background()
+	d, r := newTestRepo(t)
+	save(t, r, &core.Snapshot{
+		ID:        "sealed",
+		Windows:   []core.Window{{AppName: "Code", WindowTitle: "main.go", X: 10, Width: 800, Height: 600}},
+		Terminals: []core.Terminal{{TerminalApp: "bash", WorkingDirectory: "/src"}},
+	}, time.Now())
+
+	verify := func() (string, string) {
+		t.Helper()
+		stored, computed, err := r.VerifyChecksum(ctx, "sealed")
+		if err != nil {
+			t.Fatalf("VerifyChecksum: %v", err)
+		}
+		return stored, computed
+	}
+
+	stored, computed := verify()
+	if !strings.HasPrefix(stored, "sha256:") || stored != computed {
+		t.Fatalf("intact snapshot: stored %q, computed %q", stored, computed)
+	}
+
+	exec(t, d, `UPDATE windows SET x = 11 WHERE snapshot_id = 'sealed'`)
+	if stored, computed := verify(); stored == computed {
+		t.Error("an edited window row still matches the checksum")
+	}
+	exec(t, d, `UPDATE windows SET x = 10 WHERE snapshot_id = 'sealed'`)
+	if stored, computed := verify(); stored != computed {
+		t.Error("reverting the edit did not restore the checksum")
+	}
+
+	exec(t, d, `DELETE FROM terminals WHERE snapshot_id = 'sealed'`)
+	if stored, computed := verify(); stored == computed {
+		t.Error("a deleted component row still matches the checksum")
+	}
+	if _, err := r.UpdateChecksum(ctx, "sealed"); err != nil {
+		t.Fatalf("UpdateChecksum: %v", err)
+	}
+	if stored, computed := verify(); stored != computed {
+		t.Error("resealing did not make the checksum match")
+	}
+
+	exec(t, d, `UPDATE snapshots SET name = 'renamed behind our back' WHERE id = 'sealed'`)
+	if stored, computed := verify(); stored == computed {
+		t.Error("an edited name still matches the checksum")
+	}
+	// UpdateSnapshot edita y vuelve a sellar en la misma transacción
+	if err := r.UpdateSnapshot(ctx, &core.Snapshot{ID: "sealed", Name: "renamed"}); err != nil {
+		t.Fatalf("UpdateSnapshot: %v", err)
+	}
+	if stored, computed := verify(); stored != computed {
+		t.Error("UpdateSnapshot left a stale checksum")
+	}
+}
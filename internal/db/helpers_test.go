@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// newTestRepo abre una base temporal
+func newTestRepo(t *testing.T) (*DB, *SQLiteRepository) {
+	t.Helper()
+	d, err := NewDB(filepath.Join(t.TempDir(), "snapshots.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d, NewRepository(d)
+}
+
+// exec corre SQL crudo, para simular filas editadas o rotas
+func exec(t *testing.T, d *DB, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := d.current.Load().Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+// save guarda un snapshot creado at, con sus componentes
+func save(t *testing.T, r *SQLiteRepository, s *core.Snapshot, at time.Time) {
+	t.Helper()
+	s.CreatedAt, s.UpdatedAt = at, at
+	if s.Name == "" {
+		s.Name = s.ID
+	}
+	if err := r.SaveSnapshot(context.Background(), s); err != nil {
+		t.Fatalf("SaveSnapshot %s: %v", s.ID, err)
+	}
+}
+
+// countRows cuenta las filas de table que pertenecen a snapshotID
+func countRows(t *testing.T, d *DB, table, snapshotID string) int {
+	t.Helper()
+	var n int
+	query := "SELECT count(*) FROM " + table + " WHERE snapshot_id = ?"
+	if err := d.current.Load().QueryRow(query, snapshotID).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
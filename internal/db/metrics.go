@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// SaveCaptureMetrics guarda una fila por fase del capture
+func (r *SQLiteRepository) SaveCaptureMetrics(ctx context.Context, metrics core.CaptureMetrics) error {
+	if metrics.CapturedAt.IsZero() {
+		metrics.CapturedAt = time.Now()
+	}
+	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO capture_metrics (snapshot_id, snapshot_name, phase, duration_ms, captured_at) VALUES (?, ?, ?, ?, ?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, p := range metrics.Phases {
+			if _, err := stmt.ExecContext(ctx, metrics.SnapshotID, metrics.SnapshotName, p.Phase, p.Duration.Milliseconds(), formatTimestamp(metrics.CapturedAt)); err != nil {
+				return fmt.Errorf("failed to save capture metrics: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ListCaptureMetrics agrupa las fases de cada capture, del más nuevo al más
+// viejo. El límite cuenta captures, no filas
+func (r *SQLiteRepository) ListCaptureMetrics(ctx context.Context, snapshotID string, limit int) ([]core.CaptureMetrics, error) {
+	where := ""
+	var args []interface{}
+	if snapshotID != "" {
+		where = " WHERE snapshot_id = ?"
+		args = append(args, snapshotID)
+	}
+	captures := `SELECT snapshot_id FROM capture_metrics` + where + ` GROUP BY snapshot_id ORDER BY MAX(captured_at) DESC, snapshot_id`
+	if limit > 0 {
+		captures += " LIMIT ?"
+		args = append(args, limit)
+	}
+	query := `SELECT snapshot_id, snapshot_name, phase, duration_ms, captured_at FROM capture_metrics
+		WHERE snapshot_id IN (` + captures + `) ORDER BY captured_at DESC, snapshot_id, id`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list capture metrics: %w", err)
+	}
+	defer rows.Close()
+
+	metrics := []core.CaptureMetrics{}
+	for rows.Next() {
+		var id string
+		var name sql.NullString
+		var phase core.PhaseTiming
+		var durationMs int64
+		var capturedAt interface{}
+		if err := rows.Scan(&id, &name, &phase.Phase, &durationMs, &capturedAt); err != nil {
+			return nil, err
+		}
+		phase.Duration = time.Duration(durationMs) * time.Millisecond
+		if n := len(metrics); n == 0 || metrics[n-1].SnapshotID != id {
+			at, err := parseTimestamp(capturedAt)
+			if err != nil {
+				return nil, fmt.Errorf("capture metrics of %s: %w", id, err)
+			}
+			metrics = append(metrics, core.CaptureMetrics{SnapshotID: id, SnapshotName: name.String, CapturedAt: at})
+		}
+		m := &metrics[len(metrics)-1]
+		m.Phases = append(m.Phases, phase)
+		m.Total += phase.Duration
+	}
+	return metrics, rows.Err()
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+func TestCaptureMetricsPerSnapshot(t *testing.T) {
+	ctx := context.Background()
+	_, r := newTestRepo(t)
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	record := func(id string, at time.Time, windowsMs, saveMs int) {
+		t.Helper()
+		err := r.SaveCaptureMetrics(ctx, core.CaptureMetrics{SnapshotID: id, SnapshotName: "name-" + id, CapturedAt: at, Phases: []core.PhaseTiming{
+			{Phase: "windows", Duration: time.Duration(windowsMs) * time.Millisecond},
+			{Phase: "save", Duration: time.Duration(saveMs) * time.Millisecond},
+		}})
+		if err != nil {
+			t.Fatalf("SaveCaptureMetrics %s: %v", id, err)
+		}
+	}
+	save(t, r, &core.Snapshot{ID: "old"}, base)
+	record("old", base, 120, 30)
+	record("mid", base.Add(time.Minute), 80, 20)
+	record("new", base.Add(2*time.Minute), 40, 10)
+
+	tests := []struct {
+		name       string
+		snapshotID string
+		limit      int
+		want       string // snapshot:total ms, del más nuevo al más viejo
+	}{
+		{"all", "", 0, "new:50,mid:100,old:150"},
+		{"limit counts captures", "", 2, "new:50,mid:100"},
+		{"one snapshot", "mid", 0, "mid:100"},
+		{"unknown snapshot", "missing", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics, err := r.ListCaptureMetrics(ctx, tt.snapshotID, tt.limit)
+			if err != nil {
+				t.Fatalf("ListCaptureMetrics: %v", err)
+			}
+			var got []string
+			for _, m := range metrics {
+				got = append(got, fmt.Sprintf("%s:%d", m.SnapshotID, m.Total.Milliseconds()))
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("metrics = %v, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Las fases vuelven en orden, con nombre y fecha del capture
+	metrics, err := r.ListCaptureMetrics(ctx, "old", 0)
+	if err != nil || len(metrics) != 1 {
+		t.Fatalf("ListCaptureMetrics(old) = %+v, %v", metrics, err)
+	}
+	m := metrics[0]
+	if m.SnapshotName != "name-old" || !m.CapturedAt.Equal(base) {
+		t.Errorf("capture = %s at %v, want name-old at %v", m.SnapshotName, m.CapturedAt, base)
+	}
+	if len(m.Phases) != 2 || m.Phases[0] != (core.PhaseTiming{Phase: "windows", Duration: 120 * time.Millisecond}) || m.Phases[1].Phase != "save" {
+		t.Errorf("phases = %+v, want windows then save", m.Phases)
+	}
+
+	// El historial de tiempos sobrevive al borrado del snapshot
+	if err := r.DeleteSnapshot(ctx, "old"); err != nil {
+		t.Fatalf("DeleteSnapshot: %v", err)
+	}
+	if metrics, err := r.ListCaptureMetrics(ctx, "old", 0); err != nil || len(metrics) != 1 {
+		t.Errorf("metrics after the delete = %+v, %v; want them kept", metrics, err)
+	}
+}
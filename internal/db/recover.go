@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// isStorageError indica si err viene del archivo de la base y no de la
+// consulta: archivo inexistente, errores de I/O o base movida/reemplazada
+func isStorageError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return true
+	}
+	var sqlErr *sqlite.Error
+	if !errors.As(err, &sqlErr) {
+		return false
+	}
+	code := sqlErr.Code()
+	switch code & 0xff {
+	case sqlite3.SQLITE_IOERR, sqlite3.SQLITE_CANTOPEN, sqlite3.SQLITE_NOTADB:
+		return true
+	}
+	return code == sqlite3.SQLITE_READONLY_DBMOVED
+}
+
+// conn retorna la conexión actual. Si el archivo ya no existe (el directorio
+// se borró con el servidor corriendo) la base se recrea antes de usarla
+func (d *DB) conn() *sql.DB {
+	conn := d.current.Load()
+	if _, err := os.Stat(d.path); errors.Is(err, os.ErrNotExist) {
+		if d.recover(conn) == nil {
+			conn = d.current.Load()
+		}
+	}
+	return conn
+}
+
+// recover reabre la base, recreándola con schema y migraciones si el archivo
+// se perdió, y reemplaza la conexión failed. Si otra goroutine ya la
+// reemplazó no hace nada. Las consultas en curso sobre la conexión vieja
+// fallan al cerrarla, como ya fallaban contra el archivo perdido
+func (d *DB) recover(failed *sql.DB) error {
+	d.reopenMu.Lock()
+	defer d.reopenMu.Unlock()
+	if d.current.Load() != failed {
+		return nil
+	}
+
+	_, statErr := os.Stat(d.path)
+	lost := errors.Is(statErr, os.ErrNotExist)
+
+	conn, err := openDB(d.path)
+	if err != nil {
+		log.Printf("[Storage] Could not reopen %s: %v", d.path, err)
+		return err
+	}
+	d.current.Store(conn)
+	failed.Close()
+
+	if lost {
+		d.lossMu.Lock()
+		d.lostAt = time.Now()
+		d.lossMu.Unlock()
+		log.Printf("[Storage] %s was deleted while the server was running; recreated an empty database", d.path)
+	} else {
+		log.Printf("[Storage] Reopened %s after a storage error", d.path)
+	}
+	return nil
+}
+
+// StorageWarning retorna el aviso de historial perdido, o "" si nunca se
+// perdió. Es persistente hasta reiniciar el servidor
+func (d *DB) StorageWarning() string {
+	d.lossMu.Lock()
+	defer d.lossMu.Unlock()
+	if d.lostAt.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("The snapshot database %s was deleted at %s while the server was running. It was recreated empty: snapshots and history from before then are lost.",
+		d.path, d.lostAt.Format(time.RFC1123))
+}
+
+// Path retorna la ruta del archivo de la base
+func (d *DB) Path() string {
+	return d.path
+}
+
+// ExecContext ejecuta la sentencia, reabriendo la base y reintentando una vez
+// ante un error de almacenamiento
+func (d *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	conn := d.conn()
+	res, err := conn.ExecContext(ctx, query, args...)
+	if isStorageError(err) && d.recover(conn) == nil {
+		res, err = d.current.Load().ExecContext(ctx, query, args...)
+	}
+	return res, err
+}
+
+// QueryContext es como ExecContext para consultas que retornan filas
+func (d *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	conn := d.conn()
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if isStorageError(err) && d.recover(conn) == nil {
+		rows, err = d.current.Load().QueryContext(ctx, query, args...)
+	}
+	return rows, err
+}
+
+// QueryRowContext difiere el error hasta Scan, así que solo se beneficia de
+// la recreación previa si el archivo desapareció
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return d.conn().QueryRowContext(ctx, query, args...)
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// wipe borra el archivo de la base y sus compañeros, como un script de limpieza
+func wipe(t *testing.T, d *DB) {
+	t.Helper()
+	for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+		if err := os.Remove(d.Path() + suffix); err != nil && !os.IsNotExist(err) {
+			t.Fatalf("remove: %v", err)
+		}
+	}
+}
+
+func TestRecoversWhenTheFileIsDeleted(t *testing.T) {
+	ctx := context.Background()
+	d, r := newTestRepo(t)
+	save(t, r, &core.Snapshot{ID: "before", Windows: []core.Window{{AppName: "Code", WindowTitle: "a"}}}, time.Now())
+	if w := d.StorageWarning(); w != "" {
+		t.Fatalf("warning before any loss: %q", w)
+	}
+
+	wipe(t, d)
+
+	// La primera consulta recrea la base vacía, con el schema aplicado
+	list, err := r.ListSnapshots(ctx, core.SnapshotFilter{})
+	if err != nil {
+		t.Fatalf("ListSnapshots after the wipe: %v", err)
+	}
+	if list.Total != 0 {
+		t.Errorf("%d snapshots after the wipe, want the history gone", list.Total)
+	}
+	if _, err := os.Stat(d.Path()); err != nil {
+		t.Errorf("database file not recreated: %v", err)
+	}
+	save(t, r, &core.Snapshot{ID: "after", Windows: []core.Window{{AppName: "Code", WindowTitle: "b"}}}, time.Now())
+	if s, err := r.GetSnapshotByID(ctx, "after"); err != nil || s == nil {
+		t.Errorf("GetSnapshotByID after recovery = %v, %v", s, err)
+	}
+
+	// El aviso persiste y dice qué se perdió
+	warning := d.StorageWarning()
+	if !strings.Contains(warning, d.Path()) || !strings.Contains(warning, "lost") {
+		t.Errorf("warning = %q", warning)
+	}
+	if _, err := r.ListSnapshots(ctx, core.SnapshotFilter{}); err != nil || d.StorageWarning() != warning {
+		t.Errorf("warning changed or cleared: %q, %v", d.StorageWarning(), err)
+	}
+}
+
+// Correr con -race: consultas en curso mientras la base se borra y se recrea
+func TestRecoveryIsSafeWithConcurrentQueries(t *testing.T) {
+	ctx := context.Background()
+	d, r := newTestRepo(t)
+	save(t, r, &core.Snapshot{ID: "seed"}, time.Now())
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				// Las consultas que caen justo en el reemplazo pueden fallar; no deben colgarse ni romper nada
+				r.ListSnapshots(ctx, core.SnapshotFilter{})
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	wipe(t, d)
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	if _, err := r.ListSnapshots(ctx, core.SnapshotFilter{}); err != nil {
+		t.Errorf("ListSnapshots once the dust settles: %v", err)
+	}
+	if d.StorageWarning() == "" {
+		t.Error("no warning after the concurrent wipe")
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/tuusuario/dev-env-snapshots/internal/core"
 )
@@ -40,25 +44,191 @@ func (r *SQLiteRepository) CreateSnapshot(ctx context.Context, s *core.Snapshot)
 	}
 
 	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
-		query := `
-			INSERT INTO snapshots (id, name, description, git_branch, git_repo, git_dirty, git_head_hash, tags)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-		`
-		_, err := tx.ExecContext(ctx, query, s.ID, s.Name, s.Description, s.GitBranch, s.GitRepo, s.GitDirty, s.GitHeadHash, tagsJSON)
+		return insertSnapshot(ctx, tx, s, tagsJSON)
+	})
+}
+
+func insertSnapshot(ctx context.Context, tx *sql.Tx, s *core.Snapshot, tagsJSON string) error {
+	now := time.Now()
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = now
+	}
+	if s.UpdatedAt.IsZero() {
+		s.UpdatedAt = s.CreatedAt
+	}
+
+	var parentsJSON interface{}
+	if len(s.ParentIDs) > 0 {
+		raw, err := marshalJSON(s.ParentIDs)
+		if err != nil {
+			return err
+		}
+		parentsJSON = raw
+	}
+
+	query := `
+		INSERT INTO snapshots (id, name, description, created_at, updated_at, git_branch, git_repo, git_dirty, git_head_hash, tags, backup_for, origin, parent_ids, author, untrusted)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := tx.ExecContext(ctx, query, s.ID, s.Name, s.Description, formatTimestamp(s.CreatedAt), formatTimestamp(s.UpdatedAt),
+		s.GitBranch, s.GitRepo, s.GitDirty, s.GitHeadHash, tagsJSON, s.BackupFor, s.Origin, parentsJSON, s.Author, s.Untrusted)
+	return err
+}
+
+// UpdateSnapshot stores a new name, description and tags, bumps updated_at
+// and reseals the checksum, which covers those columns
+func (r *SQLiteRepository) UpdateSnapshot(ctx context.Context, s *core.Snapshot) error {
+	tagsJSON, err := marshalJSON(s.Tags)
+	if err != nil {
+		return err
+	}
+
+	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		updatedAt := time.Now()
+		res, err := tx.ExecContext(ctx, `UPDATE snapshots SET name = ?, description = ?, tags = ?, updated_at = ? WHERE id = ?`,
+			s.Name, s.Description, tagsJSON, formatTimestamp(updatedAt), s.ID)
 		if err != nil {
 			return err
 		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return &core.NotFoundError{SnapshotID: s.ID}
+		}
+
+		sum, err := computeChecksum(ctx, tx, s.ID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE snapshots SET checksum = ? WHERE id = ?`, sum, s.ID); err != nil {
+			return err
+		}
+		s.UpdatedAt = updatedAt
 		return nil
 	})
 }
 
+// SaveSnapshot stores a snapshot with all its components and its checksum in
+// a single transaction, so a partially written snapshot is never visible
+func (r *SQLiteRepository) SaveSnapshot(ctx context.Context, s *core.Snapshot) error {
+	tagsJSON, err := marshalJSON(s.Tags)
+	if err != nil {
+		return err
+	}
+
+	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := insertSnapshot(ctx, tx, s, tagsJSON); err != nil {
+			return err
+		}
+		if err := insertWindows(ctx, tx, s.ID, s.Windows); err != nil {
+			return fmt.Errorf("windows: %w", err)
+		}
+		if err := insertTerminals(ctx, tx, s.ID, s.Terminals); err != nil {
+			return fmt.Errorf("terminals: %w", err)
+		}
+		if err := insertBrowserTabs(ctx, tx, s.ID, s.BrowserTabs); err != nil {
+			return fmt.Errorf("browser tabs: %w", err)
+		}
+		if err := insertIDEFiles(ctx, tx, s.ID, s.IDEFiles); err != nil {
+			return fmt.Errorf("ide files: %w", err)
+		}
+		if err := insertProcesses(ctx, tx, s.ID, s.Processes); err != nil {
+			return fmt.Errorf("processes: %w", err)
+		}
+		if err := insertMonitors(ctx, tx, s.ID, s.Monitors); err != nil {
+			return fmt.Errorf("monitors: %w", err)
+		}
+
+		sum, err := computeChecksum(ctx, tx, s.ID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE snapshots SET checksum = ? WHERE id = ?`, sum, s.ID)
+		return err
+	})
+}
+
+// snapshotColumns es la lista de columnas leídas para un snapshot
+const snapshotColumns = "id, name, description, created_at, updated_at, git_branch, git_repo, git_dirty, git_head_hash, tags, backup_for, origin, parent_ids, author, untrusted"
+
+// rowScanner abstrae *sql.Row y *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSnapshot lee una fila de snapshots. Un valor corrupto en tags degrada a
+// un warning en el snapshot; solo falla si la fila no se puede leer en absoluto.
+func scanSnapshot(row rowScanner) (core.Snapshot, error) {
+	s := core.Snapshot{}
+	var (
+		description, gitBranch, gitRepo, gitHeadHash, tagsRaw, backupFor sql.NullString
+		origin, parentsRaw, author                                       sql.NullString
+		gitDirty, untrusted                                              sql.NullBool
+		createdAt, updatedAt                                             interface{}
+	)
+	if err := row.Scan(&s.ID, &s.Name, &description, &createdAt, &updatedAt, &gitBranch, &gitRepo, &gitDirty, &gitHeadHash, &tagsRaw, &backupFor, &origin, &parentsRaw, &author, &untrusted); err != nil {
+		return s, err
+	}
+	s.Description = description.String
+	s.GitBranch = gitBranch.String
+	s.GitRepo = gitRepo.String
+	s.GitDirty = gitDirty.Bool
+	s.GitHeadHash = gitHeadHash.String
+	s.BackupFor = backupFor.String
+	s.Origin = origin.String
+	s.Author = author.String
+	s.Untrusted = untrusted.Bool
+
+	var err error
+	if s.CreatedAt, err = parseTimestamp(createdAt); err != nil {
+		return s, fmt.Errorf("created_at: %w", err)
+	}
+	if s.UpdatedAt, err = parseTimestamp(updatedAt); err != nil {
+		return s, fmt.Errorf("updated_at: %w", err)
+	}
+
+	if err := unmarshalJSON(tagsRaw.String, &s.Tags); err != nil {
+		s.Tags = nil
+		s.Warnings = append(s.Warnings, fmt.Sprintf("snapshot %s: unreadable tags ignored (%v)", s.ID, err))
+	}
+	sort.Strings(s.Tags)
+	if parentsRaw.Valid {
+		if err := unmarshalJSON(parentsRaw.String, &s.ParentIDs); err != nil {
+			s.ParentIDs = nil
+			s.Warnings = append(s.Warnings, fmt.Sprintf("snapshot %s: unreadable parent IDs ignored (%v)", s.ID, err))
+		}
+	}
+	return s, nil
+}
+
+// formatTimestamp writes times in UTC with the layout of SQLite's
+// CURRENT_TIMESTAMP (plus microseconds) so stored values sort as text
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05.000000")
+}
+
+// parseTimestamp acepta los formatos en que SQLite puede devolver un TIMESTAMP
+func parseTimestamp(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return t, nil
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05"} {
+			if parsed, err := time.Parse(layout, t); err == nil {
+				return parsed, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unparseable timestamp %q", t)
+	default:
+		return time.Time{}, fmt.Errorf("unexpected timestamp type %T", v)
+	}
+}
+
 func (r *SQLiteRepository) GetSnapshotByID(ctx context.Context, id string) (*core.Snapshot, error) {
-	query := `SELECT id, name, description, created_at, updated_at, git_branch, git_repo, git_dirty, tags FROM snapshots WHERE id = ?`
+	query := `SELECT ` + snapshotColumns + ` FROM snapshots WHERE id = ?`
 	row := r.db.QueryRowContext(ctx, query, id)
 
-	s := &core.Snapshot{}
-	var tagsRaw string
-	err := row.Scan(&s.ID, &s.Name, &s.Description, &s.CreatedAt, &s.UpdatedAt, &s.GitBranch, &s.GitRepo, &s.GitDirty, &tagsRaw)
+	s, err := scanSnapshot(row)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -66,31 +236,92 @@ func (r *SQLiteRepository) GetSnapshotByID(ctx context.Context, id string) (*cor
 		return nil, err
 	}
 
-	if err := unmarshalJSON(tagsRaw, &s.Tags); err != nil {
+	return &s, nil
+}
+
+// likeEscaper escapa los comodines de LIKE, para usar con ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// FindSnapshotsByRef busca por ID exacto, prefijo de ID o nombre sin
+// distinguir mayúsculas. Los comodines de LIKE en ref se escapan
+func (r *SQLiteRepository) FindSnapshotsByRef(ctx context.Context, ref string, minPrefix int) ([]core.Snapshot, error) {
+	prefix := likeEscaper.Replace(ref) + "%"
+	query := `SELECT ` + snapshotColumns + ` FROM snapshots
+		WHERE id = ? OR (? >= ? AND id LIKE ? ESCAPE '\') OR name = ? COLLATE NOCASE
+		ORDER BY created_at DESC, id`
+	rows, err := r.db.QueryContext(ctx, query, ref, len(ref), minPrefix, prefix, ref)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return s, nil
+	var snapshots []core.Snapshot
+	for rows.Next() {
+		s, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
 }
 
-func (r *SQLiteRepository) ListSnapshots(ctx context.Context, filter core.SnapshotFilter) ([]core.Snapshot, error) {
-	query := `SELECT id, name, description, created_at, updated_at, git_branch, git_repo, git_dirty, tags FROM snapshots WHERE 1=1`
+func (r *SQLiteRepository) ListSnapshots(ctx context.Context, filter core.SnapshotFilter) (*core.SnapshotList, error) {
+	where := " WHERE 1=1"
 	var args []interface{}
 
 	if filter.Project != "" {
-		query += " AND git_repo LIKE ?"
-		args = append(args, "%"+filter.Project+"%")
+		// Un "_" o "%" en el nombre del proyecto es literal, no un comodín
+		where += ` AND git_repo LIKE ? ESCAPE '\'`
+		args = append(args, "%"+likeEscaper.Replace(filter.Project)+"%")
 	}
 	if filter.Branch != "" {
-		query += " AND git_branch = ?"
+		where += " AND git_branch = ?"
 		args = append(args, filter.Branch)
 	}
-	// Note: Tags filtering in SQLite with JSON text is limited; skipping for MVP or doing simple like
+	if len(filter.Tags) > 0 {
+		var join string
+		switch filter.TagMatch {
+		case core.TagMatchAll, "":
+			join = " AND "
+		case core.TagMatchAny:
+			join = " OR "
+		default:
+			return nil, fmt.Errorf("unknown tag match mode %q (expected all or any)", filter.TagMatch)
+		}
+		// tags se guarda como array JSON: json_each compara cada tag entero
+		// ("work" no matchea "work-old"). Un JSON corrupto cuenta como sin tags
+		// en vez de hacer fallar todo el listado
+		conds := make([]string, len(filter.Tags))
+		for i, tag := range filter.Tags {
+			conds[i] = "EXISTS (SELECT 1 FROM json_each(CASE WHEN json_valid(tags) THEN tags ELSE '[]' END) WHERE value = ?)"
+			args = append(args, tag)
+		}
+		where += " AND (" + strings.Join(conds, join) + ")"
+	}
+
+	// El total ignora limit y offset, para saber si quedan páginas
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM snapshots"+where, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	// rowid se lee aparte para poder identificar filas ilegibles; los conteos
+	// evitan cargar los componentes solo para mostrarlos en el listado
+	query := `SELECT rowid,
+		(SELECT COUNT(*) FROM windows WHERE snapshot_id = snapshots.id),
+		(SELECT COUNT(*) FROM terminals WHERE snapshot_id = snapshots.id),
+		(SELECT COUNT(*) FROM browser_tabs WHERE snapshot_id = snapshots.id), ` + snapshotColumns + ` FROM snapshots` + where
 
-	query += " ORDER BY created_at DESC"
-	if filter.Limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, filter.Limit)
+	query += " ORDER BY created_at DESC, id"
+	if filter.Limit > 0 || filter.Offset > 0 {
+		// SQLite no admite OFFSET sin LIMIT; -1 = sin límite
+		limit := filter.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, max(filter.Offset, 0))
 	}
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
@@ -99,130 +330,384 @@ func (r *SQLiteRepository) ListSnapshots(ctx context.Context, filter core.Snapsh
 	}
 	defer rows.Close()
 
-	var snapshots []core.Snapshot
+	list := &core.SnapshotList{Total: total, Offset: max(filter.Offset, 0), Counts: make(map[string]core.ComponentCounts)}
+	read := 0
 	for rows.Next() {
-		s := core.Snapshot{}
-		var tagsRaw string
-		if err := rows.Scan(&s.ID, &s.Name, &s.Description, &s.CreatedAt, &s.UpdatedAt, &s.GitBranch, &s.GitRepo, &s.GitDirty, &tagsRaw); err != nil {
-			return nil, err
+		read++
+		var rowID int64
+		var counts core.ComponentCounts
+		s, err := scanSnapshot(prefixedScanner{rows: rows, prefix: []interface{}{&rowID, &counts.Windows, &counts.Terminals, &counts.BrowserTabs}})
+		if err != nil {
+			ref := s.ID
+			if ref == "" {
+				ref = fmt.Sprintf("rowid %d", rowID)
+			}
+			list.Warnings = append(list.Warnings, fmt.Sprintf("snapshot %s skipped: %v", ref, err))
+			continue
 		}
-		unmarshalJSON(tagsRaw, &s.Tags)
-		snapshots = append(snapshots, s)
+		list.Warnings = append(list.Warnings, s.Warnings...)
+		list.Snapshots = append(list.Snapshots, s)
+		list.Counts[s.ID] = counts
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return snapshots, nil
+	// Las filas ilegibles se saltean pero ocupan su lugar en la página
+	list.HasMore = list.Offset+read < total
+	return list, nil
 }
 
-func (r *SQLiteRepository) DeleteSnapshot(ctx context.Context, id string) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM snapshots WHERE id = ?", id)
-	return err
+// prefixedScanner antepone destinos extra (p.ej. rowid) al Scan de una fila
+type prefixedScanner struct {
+	rows   *sql.Rows
+	prefix []interface{}
 }
 
-func (r *SQLiteRepository) SaveWindows(ctx context.Context, snapshotID string, windows []core.Window) error {
+func (p prefixedScanner) Scan(dest ...interface{}) error {
+	return p.rows.Scan(append(p.prefix, dest...)...)
+}
+
+// childTables are the per-snapshot component tables
+var childTables = []string{"windows", "terminals", "browser_tabs", "processes", "ide_files", "monitors"}
+
+// DeleteSnapshot removes the snapshot and its components in one transaction.
+// ON DELETE CASCADE can't be relied on: PRAGMA foreign_keys is per connection
+// and database/sql may hand out pooled connections that never ran it.
+func (r *SQLiteRepository) DeleteSnapshot(ctx context.Context, id string) error {
 	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
-		stmt, err := tx.PrepareContext(ctx, `
-			INSERT INTO windows (snapshot_id, app_name, app_path, window_title, x, y, width, height, state, workspace, z_index, launch_args)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		`)
-		if err != nil {
-			return err
-		}
-		defer stmt.Close()
+		_, err := deleteSnapshotTx(ctx, tx, id)
+		return err
+	})
+}
 
-		for _, w := range windows {
-			argsLabel, _ := marshalJSON(w.LaunchArgs)
-			_, err := stmt.ExecContext(ctx, snapshotID, w.AppName, w.AppPath, w.WindowTitle, w.X, w.Y, w.Width, w.Height, w.State, w.Workspace, w.ZIndex, argsLabel)
+// DeleteSnapshots borra varios snapshots en una sola transacción: o se borran
+// todos o ninguno. Retorna cuántas filas de componentes se fueron con ellos
+func (r *SQLiteRepository) DeleteSnapshots(ctx context.Context, ids []string) (int64, error) {
+	var childRows int64
+	err := r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, id := range ids {
+			n, err := deleteSnapshotTx(ctx, tx, id)
 			if err != nil {
 				return err
 			}
+			childRows += n
 		}
 		return nil
 	})
+	if err != nil {
+		return 0, err
+	}
+	return childRows, nil
 }
 
-func (r *SQLiteRepository) SaveTerminals(ctx context.Context, snapshotID string, terminals []core.Terminal) error {
+// deleteSnapshotTx borra los componentes y después el snapshot. Sin fila
+// borrada falla con NotFoundError, y la transacción hace rollback
+func deleteSnapshotTx(ctx context.Context, tx *sql.Tx, id string) (int64, error) {
+	var childRows int64
+	for _, table := range childTables {
+		res, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE snapshot_id = ?", table), id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete %s: %w", table, err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			childRows += n
+		}
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM snapshots WHERE id = ?", id)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return 0, &core.NotFoundError{SnapshotID: id}
+	}
+	return childRows, nil
+}
+
+func (r *SQLiteRepository) SaveWindows(ctx context.Context, snapshotID string, windows []core.Window) error {
 	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
-		stmt, err := tx.PrepareContext(ctx, `
-			INSERT INTO terminals (snapshot_id, terminal_app, working_directory, active_command, shell_type, env_vars)
-			VALUES (?, ?, ?, ?, ?, ?)
-		`)
+		return insertWindows(ctx, tx, snapshotID, windows)
+	})
+}
+
+func insertWindows(ctx context.Context, tx *sql.Tx, snapshotID string, windows []core.Window) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO windows (snapshot_id, app_name, app_path, window_title, x, y, width, height, state, workspace, z_index, launch_args, owner_ref, group_id, region, monitor, rel_x, rel_y)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, w := range windows {
+		argsLabel, _ := marshalJSON(w.LaunchArgs)
+		_, err := stmt.ExecContext(ctx, snapshotID, w.AppName, w.AppPath, w.WindowTitle, w.X, w.Y, w.Width, w.Height, w.State, w.Workspace, w.ZIndex, argsLabel, w.OwnerRef, w.GroupID, w.Region, w.Monitor, w.RelX, w.RelY)
 		if err != nil {
 			return err
 		}
-		defer stmt.Close()
+	}
+	return nil
+}
 
-		for _, t := range terminals {
-			envJSON, _ := marshalJSON(t.EnvVars)
-			_, err := stmt.ExecContext(ctx, snapshotID, t.TerminalApp, t.WorkingDirectory, t.ActiveCommand, t.ShellType, envJSON)
-			if err != nil {
-				return err
-			}
-		}
-		return nil
+func (r *SQLiteRepository) SaveTerminals(ctx context.Context, snapshotID string, terminals []core.Terminal) error {
+	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		return insertTerminals(ctx, tx, snapshotID, terminals)
 	})
 }
 
-func (r *SQLiteRepository) SaveBrowserTabs(ctx context.Context, snapshotID string, tabs []core.BrowserTab) error {
-	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
-		stmt, err := tx.PrepareContext(ctx, `
-			INSERT INTO browser_tabs (snapshot_id, browser_name, url, title, tab_index, window_index, is_pinned)
-			VALUES (?, ?, ?, ?, ?, ?, ?)
-		`)
+func insertTerminals(ctx context.Context, tx *sql.Tx, snapshotID string, terminals []core.Terminal) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO terminals (snapshot_id, terminal_app, working_directory, active_command, shell_type, env_vars, layout)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, t := range terminals {
+		envJSON, _ := marshalJSON(t.EnvVars)
+		var layoutJSON sql.NullString
+		if t.Layout != nil {
+			layoutJSON.String, _ = marshalJSON(t.Layout)
+			layoutJSON.Valid = true
+		}
+		_, err := stmt.ExecContext(ctx, snapshotID, t.TerminalApp, t.WorkingDirectory, t.ActiveCommand, t.ShellType, envJSON, layoutJSON)
 		if err != nil {
 			return err
 		}
-		defer stmt.Close()
+	}
+	return nil
+}
 
-		for _, t := range tabs {
-			_, err := stmt.ExecContext(ctx, snapshotID, t.BrowserName, t.URL, t.Title, t.TabIndex, t.WindowIndex, t.IsPinned)
-			if err != nil {
-				return err
-			}
-		}
-		return nil
+func (r *SQLiteRepository) SaveBrowserTabs(ctx context.Context, snapshotID string, tabs []core.BrowserTab) error {
+	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		return insertBrowserTabs(ctx, tx, snapshotID, tabs)
 	})
 }
 
+func insertBrowserTabs(ctx context.Context, tx *sql.Tx, snapshotID string, tabs []core.BrowserTab) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO browser_tabs (snapshot_id, browser_name, url, title, tab_index, window_index, is_pinned)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, t := range tabs {
+		_, err := stmt.ExecContext(ctx, snapshotID, t.BrowserName, t.URL, t.Title, t.TabIndex, t.WindowIndex, t.IsPinned)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *SQLiteRepository) SaveIDEFiles(ctx context.Context, snapshotID string, files []core.IDEFile) error {
 	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
-		stmt, err := tx.PrepareContext(ctx, `
-			INSERT INTO ide_files (snapshot_id, ide_name, file_path, cursor_line, cursor_column, is_active)
-			VALUES (?, ?, ?, ?, ?, ?)
-		`)
+		return insertIDEFiles(ctx, tx, snapshotID, files)
+	})
+}
+
+func insertIDEFiles(ctx context.Context, tx *sql.Tx, snapshotID string, files []core.IDEFile) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO ide_files (snapshot_id, ide_name, file_path, cursor_line, cursor_column, is_active)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, f := range files {
+		_, err := stmt.ExecContext(ctx, snapshotID, f.IDEName, f.FilePath, f.CursorLine, f.CursorColumn, f.IsActive)
 		if err != nil {
 			return err
 		}
-		defer stmt.Close()
+	}
+	return nil
+}
 
-		for _, f := range files {
-			_, err := stmt.ExecContext(ctx, snapshotID, f.IDEName, f.FilePath, f.CursorLine, f.CursorColumn, f.IsActive)
-			if err != nil {
-				return err
-			}
+func (r *SQLiteRepository) SaveProcesses(ctx context.Context, snapshotID string, processes []core.Process) error {
+	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		return insertProcesses(ctx, tx, snapshotID, processes)
+	})
+}
+
+func insertProcesses(ctx context.Context, tx *sql.Tx, snapshotID string, processes []core.Process) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO processes (snapshot_id, process_name, command, working_directory, pid, auto_restart)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, p := range processes {
+		_, err := stmt.ExecContext(ctx, snapshotID, p.ProcessName, p.Command, p.WorkingDirectory, p.Pid, p.AutoRestart)
+		if err != nil {
+			return err
 		}
-		return nil
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) SaveMonitors(ctx context.Context, snapshotID string, monitors []core.Monitor) error {
+	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		return insertMonitors(ctx, tx, snapshotID, monitors)
 	})
 }
 
+func insertMonitors(ctx context.Context, tx *sql.Tx, snapshotID string, monitors []core.Monitor) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO monitors (snapshot_id, device_name, x, y, width, height, is_primary, dpi, work_x, work_y, work_width, work_height)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, m := range monitors {
+		_, err := stmt.ExecContext(ctx, snapshotID, m.DeviceName, m.X, m.Y, m.Width, m.Height, m.Primary, m.DPI, m.WorkX, m.WorkY, m.WorkWidth, m.WorkHeight)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *SQLiteRepository) GetWindows(ctx context.Context, snapshotID string) ([]core.Window, error) {
-	query := `SELECT id, snapshot_id, app_name, app_path, window_title, x, y, width, height, state, workspace, z_index, launch_args FROM windows WHERE snapshot_id = ?`
+	query := `SELECT id, snapshot_id, app_name, app_path, window_title, x, y, width, height, state, workspace, z_index, launch_args, owner_ref, group_id, region, monitor, rel_x, rel_y FROM windows WHERE snapshot_id = ? ORDER BY id`
 	rows, err := r.db.QueryContext(ctx, query, snapshotID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var windows []core.Window
+	windows := []core.Window{}
 	for rows.Next() {
 		w := core.Window{}
 		var argsRaw string
-		if err := rows.Scan(&w.ID, &w.SnapshotID, &w.AppName, &w.AppPath, &w.WindowTitle, &w.X, &w.Y, &w.Width, &w.Height, &w.State, &w.Workspace, &w.ZIndex, &argsRaw); err != nil {
+		var region, monitor sql.NullString
+		var relX, relY sql.NullInt64
+		if err := rows.Scan(&w.ID, &w.SnapshotID, &w.AppName, &w.AppPath, &w.WindowTitle, &w.X, &w.Y, &w.Width, &w.Height, &w.State, &w.Workspace, &w.ZIndex, &argsRaw, &w.OwnerRef, &w.GroupID, &region, &monitor, &relX, &relY); err != nil {
 			return nil, err
 		}
-		if argsRaw != "" {
+		if argsRaw != "" && argsRaw != "null" {
 			w.LaunchArgs = json.RawMessage(argsRaw)
 		}
+		w.Region = region.String
+		w.Monitor = monitor.String
+		w.RelX, w.RelY = int(relX.Int64), int(relY.Int64)
 		windows = append(windows, w)
 	}
-	return windows, nil
+	return windows, rows.Err()
+}
+
+func (r *SQLiteRepository) GetTerminals(ctx context.Context, snapshotID string) ([]core.Terminal, error) {
+	query := `SELECT id, snapshot_id, terminal_app, working_directory, active_command, shell_type, env_vars, layout FROM terminals WHERE snapshot_id = ? ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	terminals := []core.Terminal{}
+	for rows.Next() {
+		t := core.Terminal{}
+		var envRaw string
+		var layoutRaw sql.NullString
+		if err := rows.Scan(&t.ID, &t.SnapshotID, &t.TerminalApp, &t.WorkingDirectory, &t.ActiveCommand, &t.ShellType, &envRaw, &layoutRaw); err != nil {
+			return nil, err
+		}
+		if err := unmarshalJSON(envRaw, &t.EnvVars); err != nil {
+			return nil, fmt.Errorf("terminal %d: invalid env_vars: %w", t.ID, err)
+		}
+		if err := unmarshalJSON(layoutRaw.String, &t.Layout); err != nil {
+			return nil, fmt.Errorf("terminal %d: invalid layout: %w", t.ID, err)
+		}
+		terminals = append(terminals, t)
+	}
+	return terminals, rows.Err()
+}
+
+func (r *SQLiteRepository) GetBrowserTabs(ctx context.Context, snapshotID string) ([]core.BrowserTab, error) {
+	query := `SELECT id, snapshot_id, browser_name, url, title, tab_index, window_index, is_pinned FROM browser_tabs WHERE snapshot_id = ? ORDER BY window_index, tab_index, id`
+	rows, err := r.db.QueryContext(ctx, query, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tabs := []core.BrowserTab{}
+	for rows.Next() {
+		t := core.BrowserTab{}
+		if err := rows.Scan(&t.ID, &t.SnapshotID, &t.BrowserName, &t.URL, &t.Title, &t.TabIndex, &t.WindowIndex, &t.IsPinned); err != nil {
+			return nil, err
+		}
+		tabs = append(tabs, t)
+	}
+	return tabs, rows.Err()
+}
+
+func (r *SQLiteRepository) GetIDEFiles(ctx context.Context, snapshotID string) ([]core.IDEFile, error) {
+	query := `SELECT id, snapshot_id, ide_name, file_path, cursor_line, cursor_column, is_active FROM ide_files WHERE snapshot_id = ? ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := []core.IDEFile{}
+	for rows.Next() {
+		f := core.IDEFile{}
+		if err := rows.Scan(&f.ID, &f.SnapshotID, &f.IDEName, &f.FilePath, &f.CursorLine, &f.CursorColumn, &f.IsActive); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+func (r *SQLiteRepository) GetProcesses(ctx context.Context, snapshotID string) ([]core.Process, error) {
+	query := `SELECT id, snapshot_id, process_name, command, working_directory, pid, auto_restart FROM processes WHERE snapshot_id = ? ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	processes := []core.Process{}
+	for rows.Next() {
+		p := core.Process{}
+		if err := rows.Scan(&p.ID, &p.SnapshotID, &p.ProcessName, &p.Command, &p.WorkingDirectory, &p.Pid, &p.AutoRestart); err != nil {
+			return nil, err
+		}
+		processes = append(processes, p)
+	}
+	return processes, rows.Err()
+}
+
+func (r *SQLiteRepository) GetMonitors(ctx context.Context, snapshotID string) ([]core.Monitor, error) {
+	query := `SELECT id, snapshot_id, device_name, x, y, width, height, is_primary, dpi, work_x, work_y, work_width, work_height FROM monitors WHERE snapshot_id = ? ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	monitors := []core.Monitor{}
+	for rows.Next() {
+		m := core.Monitor{}
+		if err := rows.Scan(&m.ID, &m.SnapshotID, &m.DeviceName, &m.X, &m.Y, &m.Width, &m.Height, &m.Primary, &m.DPI, &m.WorkX, &m.WorkY, &m.WorkWidth, &m.WorkHeight); err != nil {
+			return nil, err
+		}
+		monitors = append(monitors, m)
+	}
+	return monitors, rows.Err()
 }
@@ -0,0 +1,318 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+func TestListSnapshotsSurvivesBrokenRows(t *testing.T) {
+	ctx := context.Background()
+	d, r := newTestRepo(t)
+	base := time.Now().Add(-time.Hour)
+	save(t, r, &core.Snapshot{ID: "good", Tags: []string{"ok"}}, base)
+	save(t, r, &core.Snapshot{ID: "bad-tags"}, base.Add(time.Minute))
+	save(t, r, &core.Snapshot{ID: "bad-time"}, base.Add(2*time.Minute))
+
+	exec(t, d, `UPDATE snapshots SET tags = '{not json' WHERE id = 'bad-tags'`)
+	exec(t, d, `UPDATE snapshots SET created_at = 'yesterday-ish' WHERE id = 'bad-time'`)
+
+	list, err := r.ListSnapshots(ctx, core.SnapshotFilter{})
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	var ids []string
+	for _, s := range list.Snapshots {
+		ids = append(ids, s.ID)
+	}
+	// La fila con tags corruptos se lista sin tags; la de fecha ilegible se saltea
+	if strings.Join(ids, ",") != "bad-tags,good" {
+		t.Errorf("listed %v, want [bad-tags good]", ids)
+	}
+	if list.Total != 3 {
+		t.Errorf("Total = %d, want 3 (broken rows still count)", list.Total)
+	}
+	warnings := strings.Join(list.Warnings, "\n")
+	if !strings.Contains(warnings, "snapshot bad-tags: unreadable tags ignored") {
+		t.Errorf("no warning for the corrupt tags:\n%s", warnings)
+	}
+	if !strings.Contains(warnings, "snapshot bad-time skipped: created_at") {
+		t.Errorf("no warning for the unreadable row:\n%s", warnings)
+	}
+
+	// El snapshot con tags corruptos se sigue pudiendo leer por ID
+	s, err := r.GetSnapshotByID(ctx, "bad-tags")
+	if err != nil || s == nil || len(s.Tags) != 0 || len(s.Warnings) != 1 {
+		t.Errorf("GetSnapshotByID(bad-tags) = %+v, %v", s, err)
+	}
+}
+
+func TestDeleteSnapshotRemovesChildRows(t *testing.T) {
+	ctx := context.Background()
+	d, r := newTestRepo(t)
+	for _, id := range []string{"gone", "kept"} {
+		save(t, r, fullSnapshot(id), time.Now())
+	}
+
+	if err := r.DeleteSnapshot(ctx, "gone"); err != nil {
+		t.Fatalf("DeleteSnapshot: %v", err)
+	}
+	for _, table := range childTables {
+		if n := countRows(t, d, table, "gone"); n != 0 {
+			t.Errorf("%d %s rows left behind", n, table)
+		}
+		if n := countRows(t, d, table, "kept"); n == 0 {
+			t.Errorf("the other snapshot lost its %s", table)
+		}
+	}
+	if err := r.DeleteSnapshot(ctx, "gone"); !errors.Is(err, core.ErrSnapshotNotFound) {
+		t.Errorf("deleting it again = %v, want not found", err)
+	}
+
+	// Un lote con un ID inexistente no borra nada
+	if _, err := r.DeleteSnapshots(ctx, []string{"kept", "missing"}); !errors.Is(err, core.ErrSnapshotNotFound) {
+		t.Errorf("DeleteSnapshots with a missing ID = %v, want not found", err)
+	}
+	for _, table := range childTables {
+		if n := countRows(t, d, table, "kept"); n == 0 {
+			t.Errorf("the failed batch deleted the %s of kept", table)
+		}
+	}
+
+	// Guardar y borrar en ciclos reutiliza las páginas liberadas: el archivo
+	// no crece con cada vuelta
+	cycle := func(i int) {
+		id := fmt.Sprintf("cycle-%d", i)
+		save(t, r, fullSnapshot(id), time.Now())
+		if err := r.DeleteSnapshot(ctx, id); err != nil {
+			t.Fatalf("DeleteSnapshot %s: %v", id, err)
+		}
+	}
+	cycle(0)
+	before := pageCount(t, d)
+	for i := 1; i <= 50; i++ {
+		cycle(i)
+	}
+	if after := pageCount(t, d); after > before {
+		t.Errorf("database grew from %d to %d pages over 50 save/delete cycles", before, after)
+	}
+}
+
+// fullSnapshot arma un snapshot con filas en todas las tablas de componentes
+func fullSnapshot(id string) *core.Snapshot {
+	return &core.Snapshot{ID: id,
+		Windows:     []core.Window{{AppName: "Code", WindowTitle: "a"}, {AppName: "Terminal", WindowTitle: "b"}},
+		Terminals:   []core.Terminal{{TerminalApp: "bash", EnvVars: map[string]string{"A": "1"}}},
+		BrowserTabs: []core.BrowserTab{{URL: "https://go.dev", Title: strings.Repeat("docs ", 200)}},
+		Processes:   []core.Process{{ProcessName: "node", Command: "node server.js"}},
+		IDEFiles:    []core.IDEFile{{IDEName: "Code", FilePath: "/src/main.go", CursorLine: 10}},
+		Monitors:    []core.Monitor{{DeviceName: "DISPLAY1", Width: 1920, Height: 1080, Primary: true}},
+	}
+}
+
+// pageCount es el tamaño del archivo en páginas de SQLite
+func pageCount(t *testing.T, d *DB) int {
+	t.Helper()
+	var n int
+	if err := d.current.Load().QueryRow("PRAGMA page_count").Scan(&n); err != nil {
+		t.Fatalf("PRAGMA page_count: %v", err)
+	}
+	return n
+}
+
+func TestUpdateSnapshotAdvancesUpdatedAt(t *testing.T) {
+	ctx := context.Background()
+	_, r := newTestRepo(t)
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name string
+		edit func(s *core.Snapshot)
+	}{
+		{"name", func(s *core.Snapshot) { s.Name = "renamed" }},
+		{"description", func(s *core.Snapshot) { s.Description = "now with notes" }},
+		{"tags", func(s *core.Snapshot) { s.Tags = []string{"edited"} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &core.Snapshot{ID: "edit-" + tt.name}
+			save(t, r, s, created)
+			tt.edit(s)
+			if err := r.UpdateSnapshot(ctx, s); err != nil {
+				t.Fatalf("UpdateSnapshot: %v", err)
+			}
+
+			got, err := r.GetSnapshotByID(ctx, s.ID)
+			if err != nil || got == nil {
+				t.Fatalf("GetSnapshotByID: %v, %v", got, err)
+			}
+			if !got.UpdatedAt.After(created) {
+				t.Errorf("UpdatedAt = %s, want after %s", got.UpdatedAt, created)
+			}
+			if !got.CreatedAt.Equal(created) {
+				t.Errorf("CreatedAt = %s, want it untouched at %s", got.CreatedAt, created)
+			}
+		})
+	}
+
+	if err := r.UpdateSnapshot(ctx, &core.Snapshot{ID: "missing", Name: "x"}); !errors.Is(err, core.ErrSnapshotNotFound) {
+		t.Errorf("updating a missing snapshot: %v, want not found", err)
+	}
+}
+
+func TestListSnapshotsPagination(t *testing.T) {
+	ctx := context.Background()
+	_, r := newTestRepo(t)
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	// e es el más nuevo; c1 y c2 comparten fecha y desempatan por ID
+	save(t, r, &core.Snapshot{ID: "a"}, base)
+	save(t, r, &core.Snapshot{ID: "b", Windows: []core.Window{{AppName: "Code"}, {AppName: "Chrome"}}}, base.Add(time.Minute))
+	save(t, r, &core.Snapshot{ID: "c2"}, base.Add(2*time.Minute))
+	save(t, r, &core.Snapshot{ID: "c1", Terminals: []core.Terminal{{TerminalApp: "bash"}}}, base.Add(2*time.Minute))
+	save(t, r, &core.Snapshot{ID: "e", BrowserTabs: []core.BrowserTab{{URL: "https://go.dev"}}}, base.Add(3*time.Minute))
+
+	tests := []struct {
+		name          string
+		limit, offset int
+		want          string
+		hasMore       bool
+	}{
+		{"everything", 0, 0, "e,c1,c2,b,a", false},
+		{"first page", 2, 0, "e,c1", true},
+		{"middle page", 2, 2, "c2,b", true},
+		{"last page", 2, 4, "a", false},
+		{"exact fit", 5, 0, "e,c1,c2,b,a", false},
+		{"offset without limit", 0, 3, "b,a", false},
+		{"past the end", 2, 10, "", false},
+		{"negative offset", 2, -1, "e,c1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := r.ListSnapshots(ctx, core.SnapshotFilter{Limit: tt.limit, Offset: tt.offset})
+			if err != nil {
+				t.Fatalf("ListSnapshots: %v", err)
+			}
+			var ids []string
+			for _, s := range list.Snapshots {
+				ids = append(ids, s.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("listed %s, want %s", got, tt.want)
+			}
+			if list.Total != 5 || list.HasMore != tt.hasMore || list.Offset != max(tt.offset, 0) {
+				t.Errorf("total %d, offset %d, has_more %v; want 5, %d, %v", list.Total, list.Offset, list.HasMore, max(tt.offset, 0), tt.hasMore)
+			}
+		})
+	}
+
+	// Los conteos salen del listado sin cargar los componentes
+	list, err := r.ListSnapshots(ctx, core.SnapshotFilter{})
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	want := map[string]core.ComponentCounts{"a": {}, "b": {Windows: 2}, "c1": {Terminals: 1}, "c2": {}, "e": {BrowserTabs: 1}}
+	for id, counts := range want {
+		if got := list.Counts[id]; got != counts {
+			t.Errorf("counts of %s = %+v, want %+v", id, got, counts)
+		}
+	}
+	if len(list.Snapshots[0].Windows) != 0 || len(list.Snapshots[3].Windows) != 0 {
+		t.Error("the listing loaded window rows")
+	}
+}
+
+func TestListSnapshotsByTags(t *testing.T) {
+	ctx := context.Background()
+	d, r := newTestRepo(t)
+	base := time.Now().Add(-time.Hour)
+	save(t, r, &core.Snapshot{ID: "both", Tags: []string{"work", "go"}}, base)
+	save(t, r, &core.Snapshot{ID: "work-only", Tags: []string{"work"}}, base.Add(time.Minute))
+	save(t, r, &core.Snapshot{ID: "go-only", Tags: []string{"go"}}, base.Add(2*time.Minute))
+	save(t, r, &core.Snapshot{ID: "similar", Tags: []string{"work-old", "golang"}}, base.Add(3*time.Minute))
+	save(t, r, &core.Snapshot{ID: "untagged"}, base.Add(4*time.Minute))
+	save(t, r, &core.Snapshot{ID: "broken"}, base.Add(5*time.Minute))
+	exec(t, d, `UPDATE snapshots SET tags = '{not json' WHERE id = 'broken'`)
+
+	tests := []struct {
+		name    string
+		tags    []string
+		match   core.TagMatchMode
+		want    string
+		wantErr bool
+	}{
+		{"no tags lists all", nil, "", "broken,untagged,similar,go-only,work-only,both", false},
+		{"one tag", []string{"work"}, "", "work-only,both", false},
+		{"all is the default", []string{"work", "go"}, "", "both", false},
+		{"all", []string{"work", "go"}, core.TagMatchAll, "both", false},
+		{"any", []string{"work", "go"}, core.TagMatchAny, "go-only,work-only,both", false},
+		{"whole tags only", []string{"wor", "golan"}, core.TagMatchAny, "", false},
+		{"all with a missing tag", []string{"work", "rust"}, core.TagMatchAll, "", false},
+		{"unknown mode", []string{"work"}, "some", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := r.ListSnapshots(ctx, core.SnapshotFilter{Tags: tt.tags, TagMatch: tt.match})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("an unknown tag match mode did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListSnapshots: %v", err)
+			}
+			var ids []string
+			for _, s := range list.Snapshots {
+				ids = append(ids, s.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("listed %s, want %s", got, tt.want)
+			}
+			if list.Total != len(ids) {
+				t.Errorf("Total = %d, want %d", list.Total, len(ids))
+			}
+		})
+	}
+}
+
+func TestListSnapshotsByProject(t *testing.T) {
+	ctx := context.Background()
+	_, r := newTestRepo(t)
+	base := time.Now().Add(-time.Hour)
+	save(t, r, &core.Snapshot{ID: "underscore", GitRepo: "/src/my_app"}, base)
+	save(t, r, &core.Snapshot{ID: "lookalike", GitRepo: "/src/myXapp"}, base.Add(time.Minute))
+	save(t, r, &core.Snapshot{ID: "percent", GitRepo: `/src/100%\done`}, base.Add(2*time.Minute))
+	save(t, r, &core.Snapshot{ID: "other", GitRepo: "/src/100-done"}, base.Add(3*time.Minute))
+
+	tests := []struct {
+		name    string
+		project string
+		want    string
+	}{
+		{"substring", "app", "lookalike,underscore"},
+		{"underscore is literal", "my_app", "underscore"},
+		{"percent is literal", "100%", "percent"},
+		{"backslash is literal", `%\done`, "percent"},
+		{"no match", "my%app", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := r.ListSnapshots(ctx, core.SnapshotFilter{Project: tt.project})
+			if err != nil {
+				t.Fatalf("ListSnapshots: %v", err)
+			}
+			var ids []string
+			for _, s := range list.Snapshots {
+				ids = append(ids, s.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("listed %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
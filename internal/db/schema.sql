@@ -9,7 +9,13 @@ CREATE TABLE IF NOT EXISTS snapshots (
     git_repo TEXT,
     git_dirty BOOLEAN,
     git_head_hash TEXT,
-    tags TEXT -- JSON array
+    tags TEXT, -- JSON array
+    checksum TEXT, -- sha256 del snapshot y sus componentes al guardarlo
+    backup_for TEXT, -- en respaldos automáticos, el snapshot cuyo restore precedieron
+    origin TEXT, -- captured, cloned, merged, backup o imported
+    parent_ids TEXT, -- JSON array de los snapshots de los que deriva
+    author TEXT, -- quién lo publicó, en snapshots traídos de un directorio compartido
+    untrusted BOOLEAN DEFAULT 0 -- traído de un directorio compartido: el restore no lanza sus apps ni procesos
 );
 
 -- Ventanas capturadas
@@ -27,6 +33,12 @@ CREATE TABLE IF NOT EXISTS windows (
     workspace INTEGER,
     z_index INTEGER,
     launch_args TEXT, -- JSON
+    owner_ref INTEGER DEFAULT 0, -- posición (1-based) de la ventana owner en el snapshot
+    group_id INTEGER DEFAULT 0, -- grupo de ventanas acopladas (snap group), 0 = sin grupo
+    region TEXT, -- región con nombre que contenía la ventana
+    monitor TEXT, -- nombre de dispositivo del monitor que contenía la ventana
+    rel_x INTEGER DEFAULT 0, -- posición relativa al monitor
+    rel_y INTEGER DEFAULT 0,
     FOREIGN KEY (snapshot_id) REFERENCES snapshots(id) ON DELETE CASCADE
 );
 
@@ -39,6 +51,7 @@ CREATE TABLE IF NOT EXISTS terminals (
     active_command TEXT,
     shell_type TEXT,
     env_vars TEXT, -- JSON
+    layout TEXT, -- JSON, Windows Terminal tabs/panes
     FOREIGN KEY (snapshot_id) REFERENCES snapshots(id) ON DELETE CASCADE
 );
 
@@ -55,6 +68,11 @@ CREATE TABLE IF NOT EXISTS browser_tabs (
     FOREIGN KEY (snapshot_id) REFERENCES snapshots(id) ON DELETE CASCADE
 );
 
+-- Para los conteos por snapshot del listado
+CREATE INDEX IF NOT EXISTS idx_windows_snapshot_id ON windows(snapshot_id);
+CREATE INDEX IF NOT EXISTS idx_terminals_snapshot_id ON terminals(snapshot_id);
+CREATE INDEX IF NOT EXISTS idx_browser_tabs_snapshot_id ON browser_tabs(snapshot_id);
+
 -- Procesos en background
 CREATE TABLE IF NOT EXISTS processes (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -78,3 +96,63 @@ CREATE TABLE IF NOT EXISTS ide_files (
     is_active BOOLEAN,
     FOREIGN KEY (snapshot_id) REFERENCES snapshots(id) ON DELETE CASCADE
 );
+
+-- Monitores conectados al capturar
+CREATE TABLE IF NOT EXISTS monitors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    snapshot_id TEXT NOT NULL,
+    device_name TEXT,
+    x INTEGER,
+    y INTEGER,
+    width INTEGER,
+    height INTEGER,
+    is_primary BOOLEAN,
+    dpi INTEGER DEFAULT 0,
+    work_x INTEGER DEFAULT 0, -- área de trabajo (sin barra de tareas)
+    work_y INTEGER DEFAULT 0,
+    work_width INTEGER DEFAULT 0,
+    work_height INTEGER DEFAULT 0,
+    FOREIGN KEY (snapshot_id) REFERENCES snapshots(id) ON DELETE CASCADE
+);
+
+-- Estadísticas de uso locales (opt-in). Solo nombres de tools y de opciones,
+-- nunca valores de argumentos
+CREATE TABLE IF NOT EXISTS usage_stats (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tool TEXT NOT NULL,
+    options TEXT, -- JSON array con los nombres de las opciones usadas
+    outcome TEXT NOT NULL, -- ok, tool_error, error
+    duration_ms INTEGER,
+    called_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_stats_called_at ON usage_stats(called_at);
+
+-- Duración de cada fase de un capture. Sin cascada: el historial de tiempos
+-- sirve aunque el snapshot se borre
+CREATE TABLE IF NOT EXISTS capture_metrics (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    snapshot_id TEXT NOT NULL,
+    snapshot_name TEXT,
+    phase TEXT NOT NULL, -- windows, terminals, git, browser_tabs, ide_files, processes, save
+    duration_ms INTEGER NOT NULL,
+    captured_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_capture_metrics_snapshot_id ON capture_metrics(snapshot_id);
+CREATE INDEX IF NOT EXISTS idx_capture_metrics_captured_at ON capture_metrics(captured_at);
+
+-- Sesiones de foco: snapshot de inicio y de fin. Referencian snapshots sin
+-- cascada para conservar el historial aunque se borre alguno
+CREATE TABLE IF NOT EXISTS sessions (
+    id TEXT PRIMARY KEY,
+    label TEXT,
+    start_snapshot_id TEXT NOT NULL,
+    end_snapshot_id TEXT, -- NULL mientras la sesión está activa
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME,
+    planned_seconds INTEGER DEFAULT 0, -- 0 = sin duración planificada
+    drift_score REAL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_started_at ON sessions(started_at);
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+const sessionColumns = `id, label, start_snapshot_id, end_snapshot_id, started_at, ended_at, planned_seconds, drift_score`
+
+// CreateSession guarda una sesión recién iniciada
+func (r *SQLiteRepository) CreateSession(ctx context.Context, s *core.Session) error {
+	if s.StartedAt.IsZero() {
+		s.StartedAt = time.Now()
+	}
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO sessions (id, label, start_snapshot_id, started_at, planned_seconds) VALUES (?, ?, ?, ?, ?)`,
+		s.ID, s.Label, s.StartSnapshotID, formatTimestamp(s.StartedAt), int64(s.Planned/time.Second))
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// EndSession guarda el snapshot de fin, la hora de fin y el drift de la sesión
+func (r *SQLiteRepository) EndSession(ctx context.Context, s *core.Session) error {
+	if s.EndedAt.IsZero() {
+		s.EndedAt = time.Now()
+	}
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE sessions SET end_snapshot_id = ?, ended_at = ?, drift_score = ? WHERE id = ?`,
+		s.EndSnapshotID, formatTimestamp(s.EndedAt), s.DriftScore, s.ID)
+	if err != nil {
+		return fmt.Errorf("failed to end session: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("session %s not found", s.ID)
+	}
+	return nil
+}
+
+// GetSession retorna nil si la sesión no existe
+func (r *SQLiteRepository) GetSession(ctx context.Context, id string) (*core.Session, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id = ?`, id)
+	s, err := scanSession(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ListSessions retorna todas las sesiones, de la más nueva a la más vieja
+func (r *SQLiteRepository) ListSessions(ctx context.Context) ([]core.Session, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+sessionColumns+` FROM sessions ORDER BY started_at DESC, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []core.Session{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *s)
+	}
+	return sessions, rows.Err()
+}
+
+func scanSession(row rowScanner) (*core.Session, error) {
+	var (
+		s                  core.Session
+		label, endSnapshot sql.NullString
+		startedAt, endedAt interface{}
+		plannedSeconds     int64
+	)
+	if err := row.Scan(&s.ID, &label, &s.StartSnapshotID, &endSnapshot, &startedAt, &endedAt, &plannedSeconds, &s.DriftScore); err != nil {
+		return nil, err
+	}
+	s.Label = label.String
+	s.EndSnapshotID = endSnapshot.String
+	s.Planned = time.Duration(plannedSeconds) * time.Second
+
+	var err error
+	if s.StartedAt, err = parseTimestamp(startedAt); err != nil {
+		return nil, fmt.Errorf("session %s: %w", s.ID, err)
+	}
+	if s.EndedAt, err = parseTimestamp(endedAt); err != nil {
+		return nil, fmt.Errorf("session %s: %w", s.ID, err)
+	}
+	return &s, nil
+}
@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -16,11 +19,29 @@ import (
 //go:embed schema.sql
 var schema string
 
+// DB es la base de snapshots. La conexión puede reemplazarse si el archivo
+// desaparece mientras el servidor corre (ver recover.go)
 type DB struct {
-	*sql.DB
+	path    string
+	current atomic.Pointer[sql.DB]
+
+	reopenMu sync.Mutex // Serializa las reaperturas
+	lossMu   sync.Mutex
+	lostAt   time.Time // Cuándo se detectó que el historial se perdió; cero si nunca
 }
 
 func NewDB(path string) (*DB, error) {
+	conn, err := openDB(path)
+	if err != nil {
+		return nil, err
+	}
+	d := &DB{path: path}
+	d.current.Store(conn)
+	return d, nil
+}
+
+// openDB abre (o crea) la base en path y aplica schema y migraciones
+func openDB(path string) (*sql.DB, error) {
 	// Ensure directory exists
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -33,33 +54,134 @@ func NewDB(path string) (*DB, error) {
 	}
 
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	// Enable foreign keys
 	if _, err := db.Exec("PRAGMA foreign_keys = ON;"); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
 
 	if err := applySchema(db); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to apply schema: %w", err)
 	}
 
-	return &DB{db}, nil
+	return db, nil
 }
 
 func applySchema(db *sql.DB) error {
-	_, err := db.Exec(schema)
-	return err
+	if _, err := db.Exec(schema); err != nil {
+		return err
+	}
+	if err := migrate(db); err != nil {
+		return err
+	}
+	return removeOrphans(db)
+}
+
+// columnMigration adds a column introduced after a table was first created
+type columnMigration struct {
+	table      string
+	column     string
+	definition string
+}
+
+// columnMigrations brings databases created by older versions up to date.
+// Fresh databases already get these columns from schema.sql.
+var columnMigrations = []columnMigration{
+	{"windows", "owner_ref", "INTEGER DEFAULT 0"},
+	{"snapshots", "checksum", "TEXT"},
+	{"terminals", "layout", "TEXT"},
+	{"windows", "group_id", "INTEGER DEFAULT 0"},
+	{"windows", "region", "TEXT"},
+	{"windows", "monitor", "TEXT"},
+	{"windows", "rel_x", "INTEGER DEFAULT 0"},
+	{"windows", "rel_y", "INTEGER DEFAULT 0"},
+	{"snapshots", "backup_for", "TEXT"},
+	{"monitors", "work_x", "INTEGER DEFAULT 0"},
+	{"monitors", "work_y", "INTEGER DEFAULT 0"},
+	{"monitors", "work_width", "INTEGER DEFAULT 0"},
+	{"monitors", "work_height", "INTEGER DEFAULT 0"},
+	{"snapshots", "origin", "TEXT"},
+	{"snapshots", "parent_ids", "TEXT"},
+	{"snapshots", "author", "TEXT"},
+	{"snapshots", "untrusted", "BOOLEAN DEFAULT 0"},
+}
+
+func migrate(db *sql.DB) error {
+	for _, m := range columnMigrations {
+		exists, err := hasColumn(db, m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", m.table, m.column, err)
+		}
+	}
+	return nil
+}
+
+// removeOrphans deletes component rows left behind by older versions, which
+// relied on ON DELETE CASCADE when deleting snapshots
+func removeOrphans(db *sql.DB) error {
+	for _, table := range childTables {
+		query := fmt.Sprintf("DELETE FROM %s WHERE snapshot_id NOT IN (SELECT id FROM snapshots)", table)
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to remove orphaned %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
 }
 
 func (d *DB) Close() error {
-	return d.DB.Close()
+	return d.current.Load().Close()
 }
 
-// Transaction helper
+// Transaction helper. Si falla por un error de almacenamiento, se reabre la
+// base y fn se reintenta una vez en una transacción nueva
 func (d *DB) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
-	tx, err := d.BeginTx(ctx, nil)
+	conn := d.conn()
+	err := runTx(ctx, conn, fn)
+	if isStorageError(err) && d.recover(conn) == nil {
+		err = runTx(ctx, d.current.Load(), fn)
+	}
+	return err
+}
+
+func runTx(ctx context.Context, conn *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// storageColumns son las columnas de contenido cuyo tamaño se estima por tabla
+var storageColumns = map[string][]string{
+	"windows":      {"app_name", "app_path", "window_title", "x", "y", "width", "height", "state", "workspace", "z_index", "launch_args", "owner_ref", "group_id", "region", "monitor", "rel_x", "rel_y"},
+	"terminals":    {"terminal_app", "working_directory", "active_command", "shell_type", "env_vars", "layout"},
+	"browser_tabs": {"browser_name", "url", "title", "tab_index", "window_index", "is_pinned"},
+	"processes":    {"process_name", "command", "working_directory", "pid", "auto_restart"},
+	"ide_files":    {"ide_name", "file_path", "cursor_line", "cursor_column", "is_active"},
+	"monitors":     {"device_name", "x", "y", "width", "height", "is_primary", "dpi", "work_x", "work_y", "work_width", "work_height"},
+}
+
+// rowSizeExpr suma length() de cada columna; para enteros length() cuenta los
+// dígitos de su representación textual, suficiente para una estimación
+func rowSizeExpr(columns []string) string {
+	parts := make([]string, len(columns))
+	for i, c := range columns {
+		parts[i] = fmt.Sprintf("coalesce(length(%s), 0)", c)
+	}
+	return strings.Join(parts, " + ")
+}
+
+func tableSizeSubquery(table string) string {
+	return fmt.Sprintf("(SELECT coalesce(sum(%s), 0) FROM %s c WHERE c.snapshot_id = s.id)", rowSizeExpr(storageColumns[table]), table)
+}
+
+// GetStorageBreakdown estima los bytes que ocupa cada snapshot, de mayor a menor
+func (r *SQLiteRepository) GetStorageBreakdown(ctx context.Context) ([]core.StorageUsage, error) {
+	query := fmt.Sprintf(`
+		SELECT s.id, s.name,
+			%s + %s AS metadata_bytes,
+			%s AS window_bytes,
+			%s AS terminal_bytes,
+			%s AS tab_bytes,
+			%s AS process_bytes,
+			%s AS ide_file_bytes
+		FROM snapshots s`,
+		rowSizeExpr([]string{"s.id", "s.name", "s.description", "s.git_branch", "s.git_repo", "s.git_head_hash", "s.tags", "s.checksum"}),
+		tableSizeSubquery("monitors"), // la disposición de monitores cuenta como metadata
+		tableSizeSubquery("windows"),
+		tableSizeSubquery("terminals"),
+		tableSizeSubquery("browser_tabs"),
+		tableSizeSubquery("processes"),
+		tableSizeSubquery("ide_files"),
+	)
+	query = "SELECT *, metadata_bytes + window_bytes + terminal_bytes + tab_bytes + process_bytes + ide_file_bytes AS total FROM (" +
+		query + ") ORDER BY total DESC, id"
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usages := []core.StorageUsage{}
+	for rows.Next() {
+		u := core.StorageUsage{}
+		if err := rows.Scan(&u.SnapshotID, &u.Name, &u.MetadataBytes, &u.WindowBytes, &u.TerminalBytes, &u.TabBytes, &u.ProcessBytes, &u.IDEFileBytes, &u.TotalBytes); err != nil {
+			return nil, err
+		}
+		usages = append(usages, u)
+	}
+	return usages, rows.Err()
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+func TestStorageBreakdownRanksLargerPayloadsFirst(t *testing.T) {
+	_, r := newTestRepo(t)
+	at := time.Now()
+
+	tabs := func(n int) []core.BrowserTab {
+		var out []core.BrowserTab
+		for i := 0; i < n; i++ {
+			out = append(out, core.BrowserTab{BrowserName: "Chrome", URL: fmt.Sprintf("https://example.com/%d/%s", i, strings.Repeat("a", 200)), TabIndex: i})
+		}
+		return out
+	}
+	save(t, r, &core.Snapshot{ID: "small", Windows: []core.Window{{AppName: "Code", WindowTitle: "a"}}}, at)
+	save(t, r, &core.Snapshot{ID: "large", BrowserTabs: tabs(20)}, at)
+	save(t, r, &core.Snapshot{ID: "medium", BrowserTabs: tabs(5), Terminals: []core.Terminal{
+		{TerminalApp: "bash", EnvVars: map[string]string{"PATH": strings.Repeat("/usr/bin:", 50)}},
+	}}, at)
+
+	usages, err := r.GetStorageBreakdown(context.Background())
+	if err != nil {
+		t.Fatalf("GetStorageBreakdown: %v", err)
+	}
+	var order []string
+	for _, u := range usages {
+		order = append(order, u.SnapshotID)
+		sum := u.MetadataBytes + u.WindowBytes + u.TerminalBytes + u.TabBytes + u.ProcessBytes + u.IDEFileBytes
+		if u.TotalBytes != sum {
+			t.Errorf("%s: total %d, parts add up to %d", u.SnapshotID, u.TotalBytes, sum)
+		}
+	}
+	if got := strings.Join(order, ","); got != "large,medium,small" {
+		t.Errorf("ranking = %s, want large,medium,small", got)
+	}
+	if usages[1].TerminalBytes < 450 {
+		t.Errorf("terminal bytes = %d, the env vars are not counted", usages[1].TerminalBytes)
+	}
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// RecordUsage guarda una llamada a una tool en usage_stats
+func (r *SQLiteRepository) RecordUsage(ctx context.Context, rec core.UsageRecord) error {
+	options, err := marshalJSON(rec.Options)
+	if err != nil {
+		return err
+	}
+	if rec.CalledAt.IsZero() {
+		rec.CalledAt = time.Now()
+	}
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO usage_stats (tool, options, outcome, duration_ms, called_at) VALUES (?, ?, ?, ?, ?)`,
+		rec.Tool, options, rec.Outcome, rec.Duration.Milliseconds(), formatTimestamp(rec.CalledAt))
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// ListUsage retorna las llamadas registradas en [from, to), en orden cronológico.
+// Un extremo en cero no limita el rango
+func (r *SQLiteRepository) ListUsage(ctx context.Context, from, to time.Time) ([]core.UsageRecord, error) {
+	query := `SELECT id, tool, options, outcome, duration_ms, called_at FROM usage_stats WHERE 1=1`
+	var args []interface{}
+	if !from.IsZero() {
+		query += ` AND called_at >= ?`
+		args = append(args, formatTimestamp(from))
+	}
+	if !to.IsZero() {
+		query += ` AND called_at < ?`
+		args = append(args, formatTimestamp(to))
+	}
+	query += ` ORDER BY called_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list usage: %w", err)
+	}
+	defer rows.Close()
+
+	records := []core.UsageRecord{}
+	for rows.Next() {
+		var rec core.UsageRecord
+		var options string
+		var durationMs int64
+		var calledAt interface{}
+		if err := rows.Scan(&rec.ID, &rec.Tool, &options, &rec.Outcome, &durationMs, &calledAt); err != nil {
+			return nil, err
+		}
+		if rec.CalledAt, err = parseTimestamp(calledAt); err != nil {
+			return nil, fmt.Errorf("usage record %d: %w", rec.ID, err)
+		}
+		if err := unmarshalJSON(options, &rec.Options); err != nil {
+			return nil, fmt.Errorf("usage record %d: %w", rec.ID, err)
+		}
+		rec.Duration = time.Duration(durationMs) * time.Millisecond
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,87 @@
+// Package events publica el ciclo de vida de captures y restores para que
+// integraciones externas puedan reaccionar sin acoplarse al manager.
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Type identifica el tipo de evento
+type Type string
+
+const (
+	CaptureStarted   Type = "capture_started"
+	CaptureCompleted Type = "capture_completed"
+	RestoreStarted   Type = "restore_started"
+	WindowRestored   Type = "window_restored"
+	RestoreCompleted Type = "restore_completed"
+)
+
+// Event es un evento del ciclo de vida de un snapshot
+type Event struct {
+	Type       Type                   `json:"type"`
+	SnapshotID string                 `json:"snapshot_id,omitempty"`
+	Time       time.Time              `json:"time"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+// Bus distribuye eventos a sus suscriptores sin bloquear nunca al publicador:
+// si el buffer de un suscriptor está lleno, el evento se descarta para él.
+// Sin suscriptores, publicar es un no-op.
+type Bus struct {
+	mu      sync.RWMutex
+	subs    map[int]chan Event
+	nextID  int
+	dropped atomic.Int64
+}
+
+// NewBus crea un bus sin suscriptores
+func NewBus() *Bus {
+	return &Bus{subs: make(map[int]chan Event)}
+}
+
+// Publish envía el evento a todos los suscriptores sin bloquear
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribe registra un suscriptor con el buffer indicado. La función
+// retornada lo da de baja y cierra el canal.
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = ch
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, id)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Dropped retorna cuántos eventos se descartaron por buffers llenos
+func (b *Bus) Dropped() int64 {
+	return b.dropped.Load()
+}
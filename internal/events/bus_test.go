@@ -0,0 +1,28 @@
+package events
+
+import "testing"
+
+func TestBusDropsInsteadOfBlocking(t *testing.T) {
+	b := NewBus()
+	// Sin suscriptores publicar no hace nada
+	b.Publish(Event{Type: CaptureStarted})
+
+	ch, unsubscribe := b.Subscribe(2)
+	for i := 0; i < 5; i++ {
+		b.Publish(Event{Type: WindowRestored})
+	}
+	if got := b.Dropped(); got != 3 {
+		t.Errorf("dropped %d events, want 3", got)
+	}
+	if e := <-ch; e.Time.IsZero() {
+		t.Error("published event has no time")
+	}
+
+	unsubscribe()
+	unsubscribe()
+	b.Publish(Event{Type: RestoreCompleted})
+	<-ch
+	if _, open := <-ch; open {
+		t.Error("channel still open after unsubscribe")
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/tuusuario/dev-env-snapshots/internal/paths"
 )
 
 type Context struct {
@@ -45,7 +46,7 @@ func (d *Detector) DetectContext(ctx context.Context, path string) (*Context, er
 	if err != nil {
 		if err == plumbing.ErrReferenceNotFound {
 			// Empty repo or detached
-			return &Context{RepoPath: path, Branch: "HEAD (detached)"}, nil
+			return &Context{RepoPath: paths.Clean(path, paths.Auto), Branch: "HEAD (detached)"}, nil
 		}
 		return nil, err
 	}
@@ -61,7 +62,7 @@ func (d *Detector) DetectContext(ctx context.Context, path string) (*Context, er
 	}
 
 	return &Context{
-		RepoPath: path,
+		RepoPath: paths.Clean(path, paths.Auto),
 		Branch:   head.Name().Short(),
 		IsDirty:  !status.IsClean(),
 		HeadHash: head.Hash().String(),
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package paths
+
+// expandShortName es un no-op fuera de Windows: los nombres 8.3 solo existen allí
+func expandShortName(p string) string {
+	return p
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package paths
+
+import "golang.org/x/sys/windows"
+
+// expandShortName expande nombres cortos 8.3 (PROGRA~1) a su forma larga.
+// Si la ruta no existe se retorna sin cambios.
+func expandShortName(p string) string {
+	if p == "" {
+		return p
+	}
+	short, err := windows.UTF16PtrFromString(p)
+	if err != nil {
+		return p
+	}
+
+	buf := make([]uint16, windows.MAX_PATH)
+	for {
+		n, err := windows.GetLongPathName(short, &buf[0], uint32(len(buf)))
+		if err != nil || n == 0 {
+			return p
+		}
+		if int(n) <= len(buf) {
+			return windows.UTF16ToString(buf[:n])
+		}
+		buf = make([]uint16, n)
+	}
+}
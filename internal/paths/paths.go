@@ -0,0 +1,169 @@
+// Package paths normaliza rutas de archivos para poder compararlas de forma
+// consistente sin importar de qué API vinieron (Win32, WSL, git, terminales).
+package paths
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Platform indica las reglas de normalización a aplicar
+type Platform string
+
+const (
+	// Auto detecta la plataforma a partir de la forma de la ruta
+	Auto Platform = ""
+	// Windows: separador '\', comparación case-insensitive, drive letters
+	Windows Platform = "windows"
+	// Unix: separador '/', comparación case-sensitive
+	Unix Platform = "unix"
+)
+
+var (
+	driveLetter = regexp.MustCompile(`^[a-zA-Z]:([\\/]|$)`)
+	wslMount    = regexp.MustCompile(`^/mnt/([a-zA-Z])(/|$)`)
+)
+
+// Detect adivina la plataforma de una ruta por su forma
+func Detect(p string) Platform {
+	if driveLetter.MatchString(p) || strings.HasPrefix(p, `\\`) || strings.Contains(p, `\`) {
+		return Windows
+	}
+	return Unix
+}
+
+// Clean normaliza separadores, prefijos y segmentos '.'/'..' conservando
+// las mayúsculas originales. Es la forma apta para mostrar o guardar.
+func Clean(p string, platform Platform) string {
+	if p == "" {
+		return ""
+	}
+	if platform == Auto {
+		platform = Detect(p)
+	}
+	if platform == Windows {
+		return cleanWindows(p)
+	}
+	return cleanUnix(p)
+}
+
+// Normalize retorna la clave de comparación de una ruta: Clean más la
+// expansión de nombres cortos 8.3 y case folding en Windows.
+func Normalize(p string, platform Platform) string {
+	if p == "" {
+		return ""
+	}
+	if platform == Auto {
+		platform = Detect(p)
+	}
+	if platform != Windows {
+		return cleanUnix(p)
+	}
+
+	cleaned := cleanWindows(expandShortName(p))
+	return strings.ToLower(cleaned)
+}
+
+// PathsEqual compara dos rutas después de normalizarlas.
+// Una ruta WSL (/mnt/c/...) se considera igual a su equivalente Windows.
+func PathsEqual(a, b string) bool {
+	if a == "" || b == "" {
+		return a == b
+	}
+	pa, pb := Detect(a), Detect(b)
+	if pa != pb {
+		// Comparar en forma Windows cuando una de las dos es una ruta WSL
+		if win, ok := WSLToWindows(a); ok {
+			a, pa = win, Windows
+		}
+		if win, ok := WSLToWindows(b); ok {
+			b, pb = win, Windows
+		}
+		if pa != pb {
+			return false
+		}
+	}
+	return Normalize(a, pa) == Normalize(b, pb)
+}
+
+// WSLToWindows traduce /mnt/c/Users/x a C:\Users\x.
+// Retorna false si la ruta no está bajo un mount de drive de WSL.
+func WSLToWindows(p string) (string, bool) {
+	m := wslMount.FindStringSubmatch(p)
+	if m == nil {
+		return "", false
+	}
+	rest := strings.TrimPrefix(p, "/mnt/"+m[1])
+	return cleanWindows(strings.ToUpper(m[1]) + ":" + rest + `\`), true
+}
+
+// WindowsToWSL traduce C:\Users\x a /mnt/c/Users/x.
+// Retorna false para rutas sin drive letter (UNC, relativas).
+func WindowsToWSL(p string) (string, bool) {
+	cleaned := cleanWindows(p)
+	if !driveLetter.MatchString(cleaned) {
+		return "", false
+	}
+	drive := strings.ToLower(cleaned[:1])
+	rest := strings.ReplaceAll(cleaned[2:], `\`, "/")
+	return cleanUnix("/mnt/" + drive + rest), true
+}
+
+// cleanWindows aplica las reglas de Windows: quita prefijos \\?\, unifica
+// separadores, resuelve '.'/'..' y quita el separador final (salvo en raíces)
+func cleanWindows(p string) string {
+	p = strings.ReplaceAll(p, "/", `\`)
+
+	// Prefijos de rutas extendidas: \\?\C:\x y \\?\UNC\server\share
+	switch {
+	case strings.HasPrefix(strings.ToUpper(p), `\\?\UNC\`):
+		p = `\\` + p[len(`\\?\UNC\`):]
+	case strings.HasPrefix(p, `\\?\`), strings.HasPrefix(p, `\??\`):
+		p = p[len(`\\?\`):]
+	}
+
+	prefix := ""
+	switch {
+	case strings.HasPrefix(p, `\\`):
+		// UNC: \\server\share es la raíz
+		parts := strings.SplitN(strings.TrimLeft(p, `\`), `\`, 3)
+		if len(parts) < 2 {
+			return `\\` + strings.Join(parts, `\`)
+		}
+		prefix = `\\` + parts[0] + `\` + parts[1]
+		p = ""
+		if len(parts) == 3 {
+			p = `\` + parts[2]
+		}
+	case driveLetter.MatchString(p):
+		prefix = strings.ToUpper(p[:1]) + ":"
+		p = p[2:]
+	}
+
+	if p == "" {
+		if driveLetter.MatchString(prefix) {
+			return prefix + `\`
+		}
+		return prefix
+	}
+
+	absolute := strings.HasPrefix(p, `\`)
+	cleaned := path.Clean(strings.ReplaceAll(p, `\`, "/"))
+	cleaned = strings.ReplaceAll(cleaned, "/", `\`)
+	if cleaned == "." {
+		cleaned = ""
+	}
+	if absolute && !strings.HasPrefix(cleaned, `\`) {
+		cleaned = `\` + cleaned
+	}
+	if cleaned == `\` && prefix != "" && !driveLetter.MatchString(prefix) {
+		cleaned = ""
+	}
+	return prefix + cleaned
+}
+
+// cleanUnix resuelve '.'/'..', separadores duplicados y el separador final
+func cleanUnix(p string) string {
+	return path.Clean(p)
+}
@@ -0,0 +1,89 @@
+package paths
+
+import "testing"
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		in, want string
+		platform Platform
+	}{
+		{`c:\Users\me\`, `C:\Users\me`, Auto},
+		{`C:/Users/me/./src/../docs`, `C:\Users\me\docs`, Auto},
+		{`C:`, `C:\`, Windows},
+		{`c:\`, `C:\`, Auto},
+		{`\\?\C:\Program Files\Go`, `C:\Program Files\Go`, Auto},
+		{`\\?\UNC\server\share\dir\`, `\\server\share\dir`, Auto},
+		{`\??\D:\work`, `D:\work`, Auto},
+		{`\\server\share`, `\\server\share`, Auto},
+		{`\\server\share\`, `\\server\share`, Auto},
+		{`\\server\share\a\..\b`, `\\server\share\b`, Auto},
+		{`\\server`, `\\server`, Auto},
+		{`relative\dir\..\file.txt`, `relative\file.txt`, Windows},
+		{`/home/me//src/./app/`, `/home/me/src/app`, Auto},
+		{`/Home/Me`, `/Home/Me`, Unix},
+		{``, ``, Auto},
+	}
+	for _, tt := range tests {
+		if got := Clean(tt.in, tt.platform); got != tt.want {
+			t.Errorf("Clean(%q, %q) = %q, want %q", tt.in, tt.platform, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+		platform Platform
+	}{
+		{`C:\Users\Me\Project`, `c:\users\me\project`, Auto},
+		{`c:/users/me/project/`, `c:\users\me\project`, Windows},
+		{`\\?\c:\Users\ME`, `c:\users\me`, Auto},
+		{`\\Server\Share\Dir`, `\\server\share\dir`, Auto},
+		{`/Users/Me/Project`, `/Users/Me/Project`, Auto},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in, tt.platform); got != tt.want {
+			t.Errorf("Normalize(%q, %q) = %q, want %q", tt.in, tt.platform, got, tt.want)
+		}
+	}
+}
+
+func TestPathsEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{`C:\Users\me\src`, `c:/users/ME/src/`, true},
+		{`\\?\C:\src`, `C:\src`, true},
+		{`/mnt/c/Users/me`, `C:\Users\me`, true},
+		{`C:\Users\me`, `/mnt/d/Users/me`, false},
+		{`/home/me/src`, `/home/me/SRC`, false},
+		{`/home/me/src`, `/home/me/src/`, true},
+		{`/home/me/src`, `C:\home\me\src`, false},
+		{``, ``, true},
+		{``, `/tmp`, false},
+	}
+	for _, tt := range tests {
+		if got := PathsEqual(tt.a, tt.b); got != tt.want {
+			t.Errorf("PathsEqual(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestWSLTranslation(t *testing.T) {
+	if got, ok := WSLToWindows("/mnt/c/Users/me/src/"); !ok || got != `C:\Users\me\src` {
+		t.Errorf("WSLToWindows = %q, %v", got, ok)
+	}
+	if got, ok := WSLToWindows("/mnt/d"); !ok || got != `D:\` {
+		t.Errorf("WSLToWindows(drive root) = %q, %v", got, ok)
+	}
+	if _, ok := WSLToWindows("/home/me"); ok {
+		t.Error("WSLToWindows accepted a path outside /mnt/<drive>")
+	}
+	if got, ok := WindowsToWSL(`C:\Users\me\src\`); !ok || got != "/mnt/c/Users/me/src" {
+		t.Errorf("WindowsToWSL = %q, %v", got, ok)
+	}
+	if _, ok := WindowsToWSL(`\\server\share\dir`); ok {
+		t.Error("WindowsToWSL accepted a UNC path")
+	}
+}
@@ -0,0 +1,34 @@
+package platform
+
+import (
+	"fmt"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// Límites de geometría aceptados al posicionar. Windows trabaja con
+// coordenadas de 16 bits con signo en gran parte de la API de mensajes, y
+// ningún escritorio virtual real se acerca a esos valores
+const (
+	MinCoordinate = -32768
+	MaxCoordinate = 32767
+	MaxWindowSize = 32767
+)
+
+// ClampGeometry lleva posición y tamaño a rangos seguros para pasarlos a la
+// API del sistema. Retorna la ventana ajustada y una descripción de cada
+// valor fuera de rango; problems vacío indica que no se tocó nada
+func ClampGeometry(w core.Window) (clamped core.Window, problems []string) {
+	check := func(name string, v *int, lo, hi int) {
+		if *v < lo || *v > hi {
+			fixed := clampInt(*v, lo, hi)
+			problems = append(problems, fmt.Sprintf("%s=%d clamped to %d", name, *v, fixed))
+			*v = fixed
+		}
+	}
+	check("x", &w.X, MinCoordinate, MaxCoordinate)
+	check("y", &w.Y, MinCoordinate, MaxCoordinate)
+	check("width", &w.Width, 0, MaxWindowSize)
+	check("height", &w.Height, 0, MaxWindowSize)
+	return w, problems
+}
@@ -0,0 +1,45 @@
+package platform
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+func TestClampGeometry(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       core.Window
+		want     [4]int // x, y, width, height
+		problems string
+	}{
+		{"in range", core.Window{X: -100, Y: 50, Width: 800, Height: 600}, [4]int{-100, 50, 800, 600}, ""},
+		{"at the limits", core.Window{X: MinCoordinate, Y: MaxCoordinate, Width: MaxWindowSize, Height: 0},
+			[4]int{MinCoordinate, MaxCoordinate, MaxWindowSize, 0}, ""},
+		{"one past the limits", core.Window{X: MinCoordinate - 1, Y: MaxCoordinate + 1, Width: MaxWindowSize + 1, Height: -1},
+			[4]int{MinCoordinate, MaxCoordinate, MaxWindowSize, 0},
+			fmt.Sprintf("x=%d clamped to %d, y=%d clamped to %d, width=%d clamped to %d, height=-1 clamped to 0",
+				MinCoordinate-1, MinCoordinate, MaxCoordinate+1, MaxCoordinate, MaxWindowSize+1, MaxWindowSize)},
+		{"extreme ints", core.Window{X: math.MaxInt, Y: math.MinInt, Width: math.MaxInt, Height: math.MinInt},
+			[4]int{MaxCoordinate, MinCoordinate, MaxWindowSize, 0},
+			fmt.Sprintf("x=%d clamped to %d, y=%d clamped to %d, width=%d clamped to %d, height=%d clamped to 0",
+				math.MaxInt, MaxCoordinate, math.MinInt, MinCoordinate, math.MaxInt, MaxWindowSize, math.MinInt)},
+		{"beyond int32", core.Window{X: math.MaxInt32 + 1, Y: math.MinInt32 - 1, Width: 800, Height: 600},
+			[4]int{MaxCoordinate, MinCoordinate, 800, 600},
+			fmt.Sprintf("x=%d clamped to %d, y=%d clamped to %d", math.MaxInt32+1, MaxCoordinate, math.MinInt32-1, MinCoordinate)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, problems := ClampGeometry(tt.in)
+			if g := [4]int{got.X, got.Y, got.Width, got.Height}; g != tt.want {
+				t.Errorf("clamped to %v, want %v", g, tt.want)
+			}
+			if p := strings.Join(problems, ", "); p != tt.problems {
+				t.Errorf("problems = %q, want %q", p, tt.problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/browser"
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// withFirefoxSession reemplaza las pestañas de Firefox armadas desde los
+// títulos de ventana por las de su sessionstore, con URL e índice. Si el
+// archivo no se puede leer (bloqueado, a medio escribir) deja los títulos
+func withFirefoxSession(ctx context.Context, tabs []core.BrowserTab, firefox string) []core.BrowserTab {
+	var others []core.BrowserTab
+	for _, t := range tabs {
+		if t.BrowserName != firefox {
+			others = append(others, t)
+		}
+	}
+	if len(others) == len(tabs) {
+		return tabs // Firefox no está abierto
+	}
+
+	session, err := browser.NewFirefoxCollector(firefox).CollectTabs(ctx)
+	if err != nil || len(session) == 0 {
+		if err != nil {
+			log.Printf("[BrowserCapture] Firefox session not readable, using window titles: %v", err)
+		}
+		return tabs
+	}
+	return append(others, session...)
+}
+
+// newWindowArgs arma los argumentos que abren urls como pestañas de una
+// ventana nueva. Chromium acepta varias URLs tras --new-window; Firefox abre
+// la primera con -new-window y el resto con -new-tab. Solo se aceptan URLs
+// web, así ninguna se interpreta como flag o ruta local
+func newWindowArgs(firefox bool, urls []string) ([]string, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no URLs to open")
+	}
+	for _, raw := range urls {
+		if u, err := url.Parse(raw); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("refusing to open non-web URL: %s", raw)
+		}
+	}
+	if firefox {
+		args := []string{"-new-window", urls[0]}
+		for _, u := range urls[1:] {
+			args = append(args, "-new-tab", u)
+		}
+		return args, nil
+	}
+	return append([]string{"--new-window"}, urls...), nil
+}
@@ -0,0 +1,86 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// RestoreWindows restaura varias ventanas con una sola enumeración. Usa la
+// implementación propia del adapter si la tiene; si no, enumera con
+// GetWindows, asigna con AssignWindows y posiciona con PositionWindow.
+// Las ventanas se posicionan en el orden recibido; placed[i] es la ventana
+// viva que recibió la geometría de windows[i]
+func RestoreWindows(ctx context.Context, adapter core.PlatformAdapter, windows []core.Window) (placed []core.Window, errs []error, err error) {
+	if bulk, ok := adapter.(core.BulkWindowRestorer); ok {
+		return bulk.RestoreWindows(ctx, windows)
+	}
+	return restoreWindowsWith(ctx, adapter, matcherFor(ctx, DefaultMatcher()), windows)
+}
+
+func restoreWindowsWith(ctx context.Context, adapter core.PlatformAdapter, matcher *WindowMatcher, windows []core.Window) ([]core.Window, []error, error) {
+	live, err := adapter.GetWindows(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get current windows: %w", err)
+	}
+	live = WithoutProtected(ctx, live)
+	assignment := matcher.AssignWindows(windows, live)
+	logAmbiguous(matcher, windows, live, assignment)
+	return positionAssigned(ctx, windows, live, assignment, func(i, j int) error {
+		return adapter.PositionWindow(ctx, live[j], windows[i])
+	})
+}
+
+// logAmbiguous deja en el log las asignaciones cuya segunda mejor candidata
+// tenía un score parecido, para poder diagnosticar un restore que eligió mal
+func logAmbiguous(matcher *WindowMatcher, windows, live []core.Window, assignment []int) {
+	for i, j := range assignment {
+		if j < 0 {
+			continue
+		}
+		chosen := matcher.result(live[j], matcher.calculateScore(windows[i], live[j]))
+		runnerUp := -1
+		var runnerUpResult MatchResult
+		for _, c := range matcher.prefilterIndexes(windows[i], live) {
+			if c == j {
+				continue
+			}
+			score := matcher.calculateScore(windows[i], live[c])
+			if score >= matcher.MinimumScore && (runnerUp < 0 || score > runnerUpResult.Score) {
+				runnerUp, runnerUpResult = c, matcher.result(live[c], score)
+			}
+		}
+		if runnerUp < 0 || chosen.Score-runnerUpResult.Score >= AmbiguityMargin {
+			continue
+		}
+		log.Printf("[WindowRestore] Ambiguous match for '%s': chose '%s' (score %d, confidence %.2f), runner-up '%s' (score %d, confidence %.2f)",
+			windows[i].WindowTitle, chosen.Window.WindowTitle, chosen.Score, chosen.Confidence,
+			runnerUpResult.Window.WindowTitle, runnerUpResult.Score, runnerUpResult.Confidence)
+	}
+}
+
+// positionAssigned aplica position a cada ventana con candidata asignada y
+// retorna qué ventana viva recibió cada una. Ante una cancelación retorna
+// los resultados hasta ese punto
+func positionAssigned(ctx context.Context, windows, live []core.Window, assignment []int, position func(i, j int) error) ([]core.Window, []error, error) {
+	placed := make([]core.Window, len(windows))
+	errs := make([]error, len(windows))
+	for i, w := range windows {
+		if err := ctx.Err(); err != nil {
+			for k := i; k < len(windows); k++ {
+				errs[k] = err
+			}
+			return placed, errs, err
+		}
+		j := assignment[i]
+		if j < 0 {
+			errs[i] = fmt.Errorf("no suitable window found for: %s (app: %s)", w.WindowTitle, w.AppName)
+			continue
+		}
+		placed[i] = live[j]
+		errs[i] = position(i, j)
+	}
+	return placed, errs, nil
+}
@@ -0,0 +1,470 @@
+//go:build darwin
+
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// listWindowsScript enumera las ventanas de las apps visibles vía System Events (JXA).
+// Requiere permiso de Accesibilidad para el proceso que ejecuta el servidor
+const listWindowsScript = `
+var se = Application('System Events');
+var out = [];
+se.processes.whose({visible: true})().forEach(function (p) {
+	var name = p.name();
+	var path = '', pid = 0;
+	try { path = p.applicationFile().posixPath(); } catch (e) {}
+	try { pid = p.unixId(); } catch (e) {}
+	p.windows().forEach(function (w) {
+		try {
+			var pos = w.position(), size = w.size();
+			var minimized = false, fullscreen = false;
+			try { minimized = w.attributes.byName('AXMinimized').value(); } catch (e) {}
+			try { fullscreen = w.attributes.byName('AXFullScreen').value(); } catch (e) {}
+			out.push({app: name, path: path, pid: pid, title: w.name() || '', x: pos[0], y: pos[1],
+				width: size[0], height: size[1], minimized: minimized, fullscreen: fullscreen});
+		} catch (e) {}
+	});
+});
+JSON.stringify(out);
+`
+
+// positionWindowScript mueve una ventana. argv: PID (0 = buscar la app por
+// nombre), app, título vivo ("" = sin título), x, y, ancho y alto vivos, x, y,
+// ancho, alto y estado destino. Se elige la ventana enumerada: mismo rect y,
+// entre esas, mismo título; tomar la primera con el título movía siempre la
+// misma de dos ventanas iguales
+const positionWindowScript = `
+function run(argv) {
+	var se = Application('System Events');
+	var pid = +argv[0];
+	var proc = pid > 0 ? se.processes.whose({unixId: pid})()[0] : se.processes.byName(argv[1]);
+	if (!proc) { throw new Error('process not found'); }
+	var title = argv[2], lx = +argv[3], ly = +argv[4], lw = +argv[5], lh = +argv[6];
+	var exact = null, byRect = null, byTitle = null;
+	proc.windows().forEach(function (w) {
+		var pos, size;
+		try { pos = w.position(); size = w.size(); } catch (e) { return; }
+		var sameRect = pos[0] === lx && pos[1] === ly && size[0] === lw && size[1] === lh;
+		var sameTitle = title === '' || w.name() === title;
+		if (sameRect && sameTitle) { exact = exact || w; }
+		else if (sameRect) { byRect = byRect || w; }
+		else if (sameTitle && title !== '') { byTitle = byTitle || w; }
+	});
+	var w = exact || byRect || byTitle;
+	if (!w) { throw new Error('window not found'); }
+	if (argv[11] === 'minimized') {
+		w.attributes.byName('AXMinimized').value = true;
+		return;
+	}
+	try { w.attributes.byName('AXMinimized').value = false; } catch (e) {}
+	w.position = [+argv[7], +argv[8]];
+	w.size = [+argv[9], +argv[10]];
+}
+`
+
+// frontmostPidScript retorna el PID de la app en primer plano
+const frontmostPidScript = `Application('System Events').processes.whose({frontmost: true})()[0].unixId()`
+
+// focusPidScript pasa al frente la app del PID indicado. argv: pid
+const focusPidScript = `
+function run(argv) {
+	var procs = Application('System Events').processes.whose({unixId: +argv[0]})();
+	if (procs.length === 0) { throw new Error('process not found'); }
+	procs[0].frontmost = true;
+}
+`
+
+// DarwinAdapter implementa PlatformAdapter en macOS usando osascript
+type DarwinAdapter struct {
+	matcher *WindowMatcher
+}
+
+func NewDarwinAdapter() *DarwinAdapter {
+	return &DarwinAdapter{
+		matcher: DefaultMatcher(),
+	}
+}
+
+func (d *DarwinAdapter) Name() string {
+	return "darwin"
+}
+
+// darwinWindow es una ventana tal como la devuelve listWindowsScript
+type darwinWindow struct {
+	App        string `json:"app"`
+	Path       string `json:"path"`
+	Pid        int    `json:"pid"`
+	Title      string `json:"title"`
+	X          int    `json:"x"`
+	Y          int    `json:"y"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Minimized  bool   `json:"minimized"`
+	Fullscreen bool   `json:"fullscreen"`
+}
+
+// osascript ejecuta un script JXA y retorna su salida
+func osascript(ctx context.Context, script string, args ...string) ([]byte, error) {
+	cmdArgs := append([]string{"-l", "JavaScript", "-e", script}, args...)
+	out, err := exec.CommandContext(ctx, "osascript", cmdArgs...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("osascript failed (is Accessibility permission granted?): %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("osascript failed: %w", err)
+	}
+	return out, nil
+}
+
+// GetWindows obtiene las ventanas de las apps visibles
+func (d *DarwinAdapter) GetWindows(ctx context.Context) ([]core.Window, error) {
+	out, err := osascript(ctx, listWindowsScript)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []darwinWindow
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse window list: %w", err)
+	}
+
+	wins := make([]core.Window, 0, len(raw))
+	for _, r := range raw {
+		title := r.Title
+		if title == "" {
+			title = UntitledTitle(r.App)
+		}
+		state := "normal"
+		switch {
+		case r.Minimized:
+			state = "minimized"
+		case r.Fullscreen:
+			state = "fullscreen"
+		}
+		wins = append(wins, core.Window{
+			WindowTitle: title,
+			AppName:     r.App,
+			AppPath:     r.Path,
+			X:           r.X,
+			Y:           r.Y,
+			Width:       r.Width,
+			Height:      r.Height,
+			State:       state,
+			Pid:         r.Pid,
+		})
+	}
+	return wins, nil
+}
+
+// RestoreWindow usa el matcher para encontrar y restaurar la ventana
+func (d *DarwinAdapter) RestoreWindow(ctx context.Context, window core.Window) error {
+	currentWindows, err := d.GetWindows(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get current windows: %w", err)
+	}
+	currentWindows = WithoutProtected(ctx, currentWindows)
+
+	matches := matcherFor(ctx, d.matcher).FindMatches(window, currentWindows, 2)
+	if len(matches) == 0 {
+		return fmt.Errorf("no suitable window found for: %s (app: %s)", window.WindowTitle, window.AppName)
+	}
+	match := matches[0]
+
+	log.Printf("[WindowRestore] Matched '%s' with '%s' (score: %d, confidence: %.2f)",
+		window.WindowTitle, match.Window.WindowTitle, match.Score, match.Confidence)
+	if Ambiguous(matches) {
+		log.Printf("[WindowRestore] Ambiguous: runner-up '%s' (score: %d, confidence: %.2f)",
+			matches[1].Window.WindowTitle, matches[1].Score, matches[1].Confidence)
+	}
+
+	return d.PositionWindow(ctx, match.Window, window)
+}
+
+// PositionWindow aplica la geometría de target a la ventana viva vía System Events
+func (d *DarwinAdapter) PositionWindow(ctx context.Context, live core.Window, target core.Window) error {
+	title := live.WindowTitle
+	if IsUntitled(live) {
+		title = ""
+	}
+	_, err := osascript(ctx, positionWindowScript,
+		strconv.Itoa(live.Pid), live.AppName, title,
+		strconv.Itoa(live.X), strconv.Itoa(live.Y), strconv.Itoa(live.Width), strconv.Itoa(live.Height),
+		strconv.Itoa(target.X), strconv.Itoa(target.Y), strconv.Itoa(target.Width), strconv.Itoa(target.Height),
+		target.State,
+	)
+	return err
+}
+
+// SaveFocus recuerda la app en primer plano; devolverle el foco la trae al
+// frente con la ventana que tenía activa
+func (d *DarwinAdapter) SaveFocus(ctx context.Context) (func(context.Context) error, error) {
+	out, err := osascript(ctx, frontmostPidScript)
+	if err != nil {
+		return nil, err
+	}
+	pid := strings.TrimSpace(string(out))
+	if _, err := strconv.Atoi(pid); err != nil {
+		return nil, fmt.Errorf("unexpected frontmost process %q", pid)
+	}
+	return func(ctx context.Context) error {
+		_, err := osascript(ctx, focusPidScript, pid)
+		return err
+	}, nil
+}
+
+// Capabilities declara lo que el adapter de macOS soporta vía osascript. Las
+// ventanas de terminal se ven pero sin su directorio de trabajo, así que no
+// cuenta como lectura de terminales
+func (d *DarwinAdapter) Capabilities() core.Capabilities {
+	return core.Capabilities{
+		CanReadWindows:      true,
+		CanPositionWindows:  true,
+		CanLaunchApps:       true,
+		CanRestoreTerminals: true,
+		CanReadTabTitles:    true,
+		CanOpenURLs:         true,
+		CanReadIDEFiles:     true,
+		CanReadProcesses:    true,
+		CanStartProcesses:   true,
+	}
+}
+
+func (d *DarwinAdapter) CloseWindow(ctx context.Context, window core.Window) error {
+	return nil // No implementado por seguridad
+}
+
+func (d *DarwinAdapter) GetTerminals(ctx context.Context) ([]core.Terminal, error) {
+	windowsList, err := d.GetWindows(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var terminals []core.Terminal
+	for _, win := range windowsList {
+		if isDarwinTerminal(win.AppName) {
+			terminals = append(terminals, core.Terminal{
+				TerminalApp:   win.AppName,
+				ActiveCommand: win.WindowTitle,
+				ShellType:     "unknown",
+			})
+		}
+	}
+	return terminals, nil
+}
+
+// RestoreTerminal abre la app de terminal en el directorio grabado
+func (d *DarwinAdapter) RestoreTerminal(ctx context.Context, terminal core.Terminal) error {
+	dir := terminal.WorkingDirectory
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("no working directory and no home directory: %w", err)
+		}
+		dir = home
+	}
+	return exec.CommandContext(ctx, "open", "-a", terminal.TerminalApp, dir).Run()
+}
+
+// OpenURL abre la URL en el navegador indicado, o en el predeterminado
+func (d *DarwinAdapter) OpenURL(ctx context.Context, url string, browser string) error {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("refusing to open non-web URL: %s", url)
+	}
+	args := []string{url}
+	if browser != "" {
+		args = []string{"-a", browser, url}
+	}
+	return exec.CommandContext(ctx, "open", args...).Run()
+}
+
+// OpenBrowserWindow abre las URLs como pestañas de una ventana nueva. Solo
+// los navegadores Chromium reciben argumentos vía open cuando ya están
+// abiertos; con el resto se abren de a una
+func (d *DarwinAdapter) OpenBrowserWindow(ctx context.Context, browser string, urls []string) error {
+	switch browser {
+	case "Google Chrome", "Microsoft Edge", "Brave Browser":
+	default:
+		return fmt.Errorf("no command line to open a window of %q: %w", browser, errors.ErrUnsupported)
+	}
+	args, err := newWindowArgs(false, urls)
+	if err != nil {
+		return err
+	}
+	return exec.CommandContext(ctx, "open", append([]string{"-na", browser, "--args"}, args...)...).Run()
+}
+
+func (d *DarwinAdapter) GetBrowserTabs(ctx context.Context) ([]core.BrowserTab, error) {
+	windowsList, err := d.GetWindows(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var tabs []core.BrowserTab
+	for _, win := range windowsList {
+		if isDarwinBrowser(win.AppName) {
+			tabs = append(tabs, core.BrowserTab{
+				BrowserName: win.AppName,
+				Title:       win.WindowTitle,
+			})
+		}
+	}
+	return withFirefoxSession(ctx, tabs, "Firefox"), nil
+}
+
+func (d *DarwinAdapter) GetIDEFiles(ctx context.Context) ([]core.IDEFile, error) {
+	windowsList, err := d.GetWindows(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []core.IDEFile
+	for _, win := range windowsList {
+		if isDarwinIDE(win.AppName) {
+			files = append(files, core.IDEFile{
+				IDEName:  win.AppName,
+				FilePath: win.WindowTitle,
+				IsActive: true,
+			})
+		}
+	}
+	return files, nil
+}
+
+// GetProcesses lista los procesos de desarrollo (ver isDevProcess) con ps y
+// lee sus directorios de trabajo con una sola llamada a lsof. Si lsof falla
+// los procesos quedan sin directorio
+func (d *DarwinAdapter) GetProcesses(ctx context.Context) ([]core.Process, error) {
+	out, err := exec.CommandContext(ctx, "ps", "-axww", "-o", "pid=,ucomm=,args=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	processes := parsePSOutput(string(out), os.Getpid())
+	if len(processes) == 0 {
+		return processes, nil
+	}
+
+	pids := make([]string, len(processes))
+	for i, p := range processes {
+		pids[i] = strconv.Itoa(p.Pid)
+	}
+	// lsof sale con 1 si algún proceso terminó entre medio; la salida sirve igual
+	out, _ = exec.CommandContext(ctx, "lsof", "-a", "-d", "cwd", "-p", strings.Join(pids, ","), "-Fpn").Output()
+	dirs := parseLsofCwd(string(out))
+	for i := range processes {
+		processes[i].WorkingDirectory = dirs[processes[i].Pid]
+	}
+	return processes, nil
+}
+
+// StartProcess relanza un proceso con su línea de comandos vía /bin/sh
+func (d *DarwinAdapter) StartProcess(ctx context.Context, process core.Process) error {
+	if process.Command == "" {
+		return fmt.Errorf("no command recorded for %s", process.ProcessName)
+	}
+	cmd := exec.Command("/bin/sh", "-c", process.Command)
+	cmd.Dir = process.WorkingDirectory
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", process.ProcessName, err)
+	}
+	return cmd.Process.Release()
+}
+
+// LaunchApp abre una instancia nueva de la app grabada. open no informa el
+// PID: se toma el proceso de la app que no existía antes del lanzamiento, así
+// la ventana nueva se posiciona por PID y no se confunde con otra instancia.
+// Si no aparece a tiempo se retorna 0 y la ventana se busca por app
+func (d *DarwinAdapter) LaunchApp(ctx context.Context, window core.Window) (int, error) {
+	app := window.AppPath
+	if app == "" {
+		app = window.AppName
+	}
+	before := appPids(ctx, window.AppName)
+	args := []string{"-n", "-a", app}
+	if extra := LaunchArgList(window); len(extra) > 0 {
+		args = append(append(args, "--args"), extra...)
+	}
+	if err := exec.CommandContext(ctx, "open", args...).Run(); err != nil {
+		return 0, fmt.Errorf("failed to launch %s: %w", app, err)
+	}
+
+	for i := 0; i < launchPidPolls; i++ {
+		for pid := range appPids(ctx, window.AppName) {
+			if !before[pid] {
+				return pid, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return 0, nil
+		case <-time.After(launchPidPollInterval):
+		}
+	}
+	return 0, nil
+}
+
+// Espera por el proceso nuevo tras open: hasta 2s
+const (
+	launchPidPolls        = 10
+	launchPidPollInterval = 200 * time.Millisecond
+)
+
+// appPidsScript lista los PIDs de los procesos con ese nombre. argv: nombre
+const appPidsScript = `
+function run(argv) {
+	return JSON.stringify(Application('System Events').processes.whose({name: argv[0]})().map(function (p) { return p.unixId(); }));
+}
+`
+
+// appPids retorna los PIDs actuales de la app (vacío si no se pueden leer)
+func appPids(ctx context.Context, appName string) map[int]bool {
+	pids := make(map[int]bool)
+	out, err := osascript(ctx, appPidsScript, appName)
+	if err != nil {
+		return pids
+	}
+	var list []int
+	if json.Unmarshal(out, &list) == nil {
+		for _, pid := range list {
+			pids[pid] = true
+		}
+	}
+	return pids
+}
+
+// Classification Helpers
+func isDarwinTerminal(app string) bool {
+	switch app {
+	case "Terminal", "iTerm2", "Warp", "Alacritty", "kitty", "WezTerm":
+		return true
+	}
+	return false
+}
+
+func isDarwinBrowser(app string) bool {
+	switch app {
+	case "Google Chrome", "Safari", "Firefox", "Microsoft Edge", "Brave Browser", "Arc":
+		return true
+	}
+	return false
+}
+
+func isDarwinIDE(app string) bool {
+	switch app {
+	case "Code", "Visual Studio Code", "GoLand", "IntelliJ IDEA", "Xcode", "Cursor":
+		return true
+	}
+	return false
+}
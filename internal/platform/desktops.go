@@ -0,0 +1,18 @@
+package platform
+
+import "github.com/tuusuario/dev-env-snapshots/internal/core"
+
+// markOtherDesktops marca OtherDesktop en las ventanas que isOnCurrent
+// ubica en otro escritorio virtual. handles[i] es el handle de windows[i].
+// Una consulta que falla deja la ventana sin marcar: sin soporte de
+// escritorios todo se comporta como antes
+func markOtherDesktops(windows []core.Window, handles []uintptr, isOnCurrent func(handle uintptr) (bool, error)) {
+	for i := range windows {
+		if i >= len(handles) {
+			return
+		}
+		if on, err := isOnCurrent(handles[i]); err == nil && !on {
+			windows[i].OtherDesktop = true
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package platform
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+func TestMarkOtherDesktops(t *testing.T) {
+	wins := []core.Window{{WindowTitle: "here"}, {WindowTitle: "elsewhere"}, {WindowTitle: "unknown"}}
+	// Fake de IsWindowOnCurrentVirtualDesktop: 1 está acá, 2 en otro, 3 falla
+	fake := func(handle uintptr) (bool, error) {
+		switch handle {
+		case 1:
+			return true, nil
+		case 2:
+			return false, nil
+		}
+		return false, errors.New("E_INVALIDARG")
+	}
+	markOtherDesktops(wins, []uintptr{1, 2, 3}, fake)
+
+	for i, want := range []bool{false, true, false} {
+		if wins[i].OtherDesktop != want {
+			t.Errorf("%s: OtherDesktop = %v, want %v", wins[i].WindowTitle, wins[i].OtherDesktop, want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+//go:build windows
+
+package platform
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"golang.org/x/sys/windows"
+)
+
+// IVirtualDesktopManager (Windows 10+) es la única interfaz pública de
+// escritorios virtuales: dice si una ventana está en el escritorio actual,
+// pero no en cuál de los otros
+var (
+	ole32 = windows.NewLazySystemDLL("ole32.dll")
+
+	procCoCreateInstance = ole32.NewProc("CoCreateInstance")
+
+	clsidVirtualDesktopManager = windows.GUID{Data1: 0xaa509086, Data2: 0x5ca9, Data3: 0x4c25, Data4: [8]byte{0x8f, 0x95, 0x58, 0x9d, 0x3c, 0x07, 0xb4, 0x8a}}
+	iidVirtualDesktopManager   = windows.GUID{Data1: 0xa5cd92ff, Data2: 0x29be, Data3: 0x454c, Data4: [8]byte{0x8d, 0x04, 0xd8, 0x28, 0x79, 0xfb, 0x3f, 0x1b}}
+)
+
+// virtualDesktopManager es el objeto COM: un puntero a su vtable
+type virtualDesktopManager struct {
+	vtbl *virtualDesktopManagerVtbl
+}
+
+// virtualDesktopManagerVtbl sigue el orden de la interfaz, IUnknown primero
+type virtualDesktopManagerVtbl struct {
+	QueryInterface                  uintptr
+	AddRef                          uintptr
+	Release                         uintptr
+	IsWindowOnCurrentVirtualDesktop uintptr
+	GetWindowDesktopId              uintptr
+	MoveWindowToDesktop             uintptr
+}
+
+// markWindowDesktops marca en wins (convertidas de live, en el mismo orden)
+// las ventanas de otro escritorio virtual. COM se inicializa en este hilo
+// solo para la consulta; si la interfaz no está, no se marca ninguna
+func markWindowDesktops(live []liveWindow, wins []core.Window) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// S_FALSE: COM ya estaba inicializado en el hilo, igual hay que balancearlo
+	switch err := windows.CoInitializeEx(0, windows.COINIT_APARTMENTTHREADED); err {
+	case nil, syscall.Errno(1):
+		defer windows.CoUninitialize()
+	}
+
+	var mgr *virtualDesktopManager
+	hr, _, _ := procCoCreateInstance.Call(
+		uintptr(unsafe.Pointer(&clsidVirtualDesktopManager)), 0, windows.CLSCTX_INPROC_SERVER|clsctxLocalServer,
+		uintptr(unsafe.Pointer(&iidVirtualDesktopManager)), uintptr(unsafe.Pointer(&mgr)))
+	if int32(hr) < 0 || mgr == nil {
+		return
+	}
+	defer syscall.SyscallN(mgr.vtbl.Release, uintptr(unsafe.Pointer(mgr)))
+
+	handles := make([]uintptr, len(live))
+	for i, lw := range live {
+		handles[i] = uintptr(lw.hwnd)
+	}
+	markOtherDesktops(wins, handles, mgr.isOnCurrentDesktop)
+}
+
+// clsctxLocalServer es CLSCTX_LOCAL_SERVER: el manager vive en explorer.exe
+const clsctxLocalServer = 0x4
+
+// isOnCurrentDesktop llama a IsWindowOnCurrentVirtualDesktop
+func (mgr *virtualDesktopManager) isOnCurrentDesktop(hwnd uintptr) (bool, error) {
+	var on int32
+	hr, _, _ := syscall.SyscallN(mgr.vtbl.IsWindowOnCurrentVirtualDesktop,
+		uintptr(unsafe.Pointer(mgr)), hwnd, uintptr(unsafe.Pointer(&on)))
+	if int32(hr) < 0 {
+		return false, fmt.Errorf("IsWindowOnCurrentVirtualDesktop: HRESULT 0x%08x", uint32(hr))
+	}
+	return on != 0, nil
+}
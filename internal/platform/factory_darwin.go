@@ -0,0 +1,8 @@
+package platform
+
+import "github.com/tuusuario/dev-env-snapshots/internal/core"
+
+// NewAdapter retorna el adapter nativo de la plataforma
+func NewAdapter() core.PlatformAdapter {
+	return NewDarwinAdapter()
+}
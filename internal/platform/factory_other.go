@@ -0,0 +1,16 @@
+//go:build !windows && !darwin
+
+package platform
+
+import (
+	"log"
+	"runtime"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// NewAdapter retorna el mock en plataformas sin adapter nativo (Linux, BSD...)
+func NewAdapter() core.PlatformAdapter {
+	log.Printf("No native adapter for %s/%s, falling back to the mock adapter: captures and restores will not touch real windows", runtime.GOOS, runtime.GOARCH)
+	return NewMockAdapter()
+}
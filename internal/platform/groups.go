@@ -0,0 +1,78 @@
+package platform
+
+import "github.com/tuusuario/dev-env-snapshots/internal/core"
+
+// AssignSnapGroups agrupa las ventanas que están acopladas entre sí: dos
+// ventanas normales cuyos rectángulos comparten exactamente un borde (con
+// solapamiento a lo largo de ese borde) quedan en el mismo grupo, y los grupos
+// se cierran transitivamente. Asigna GroupID 1..n en orden de captura; las
+// ventanas sueltas quedan con 0.
+func AssignSnapGroups(windows []core.Window) {
+	parent := make([]int, len(windows))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	grouped := make([]bool, len(windows))
+	for i := range windows {
+		for j := i + 1; j < len(windows); j++ {
+			if Tiled(windows[i], windows[j]) {
+				parent[find(j)] = find(i)
+				grouped[i], grouped[j] = true, true
+			}
+		}
+	}
+
+	ids := make(map[int]int)
+	for i := range windows {
+		windows[i].GroupID = 0
+		if !grouped[i] {
+			continue
+		}
+		root := find(i)
+		if _, ok := ids[root]; !ok {
+			ids[root] = len(ids) + 1
+		}
+		windows[i].GroupID = ids[root]
+	}
+}
+
+// Tiled indica si a y b están acopladas borde con borde
+func Tiled(a, b core.Window) bool {
+	if !snappable(a) || !snappable(b) {
+		return false
+	}
+
+	aRight, aBottom := a.X+a.Width, a.Y+a.Height
+	bRight, bBottom := b.X+b.Width, b.Y+b.Height
+
+	// Borde vertical compartido (lado a lado)
+	if aRight == b.X || bRight == a.X {
+		return overlap(a.Y, aBottom, b.Y, bBottom)
+	}
+	// Borde horizontal compartido (una sobre otra)
+	if aBottom == b.Y || bBottom == a.Y {
+		return overlap(a.X, aRight, b.X, bRight)
+	}
+	return false
+}
+
+// snappable excluye ventanas que no participan de un layout acoplado
+func snappable(w core.Window) bool {
+	if w.OwnerRef != 0 || w.Width <= 0 || w.Height <= 0 {
+		return false
+	}
+	return w.State == "" || w.State == "normal"
+}
+
+// overlap indica si los intervalos [a1,a2) y [b1,b2) se solapan
+func overlap(a1, a2, b1, b2 int) bool {
+	return a1 < b2 && b1 < a2
+}
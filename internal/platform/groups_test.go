@@ -0,0 +1,69 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+func winAt(x, y, w, h int) core.Window {
+	return core.Window{X: x, Y: y, Width: w, Height: h}
+}
+
+func TestTiled(t *testing.T) {
+	maximized := winAt(960, 0, 960, 1080)
+	maximized.State = "maximized"
+	owned := winAt(960, 0, 960, 1080)
+	owned.OwnerRef = 1
+
+	tests := []struct {
+		name string
+		a, b core.Window
+		want bool
+	}{
+		{"side by side", winAt(0, 0, 960, 1080), winAt(960, 0, 960, 1080), true},
+		{"side by side, reversed", winAt(960, 0, 960, 1080), winAt(0, 0, 960, 1080), true},
+		{"stacked", winAt(0, 0, 960, 540), winAt(0, 540, 960, 540), true},
+		{"partial overlap along the edge", winAt(0, 0, 960, 600), winAt(960, 400, 960, 680), true},
+		{"corners only touch", winAt(0, 0, 960, 540), winAt(960, 540, 960, 540), false},
+		{"one pixel gap", winAt(0, 0, 960, 1080), winAt(961, 0, 959, 1080), false},
+		{"overlapping windows", winAt(0, 0, 1000, 1080), winAt(960, 0, 960, 1080), false},
+		{"maximized window", winAt(0, 0, 960, 1080), maximized, false},
+		{"owned window", winAt(0, 0, 960, 1080), owned, false},
+		{"zero size", winAt(0, 0, 960, 1080), winAt(960, 0, 0, 1080), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tiled(tt.a, tt.b); got != tt.want {
+				t.Errorf("Tiled = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssignSnapGroups(t *testing.T) {
+	windows := []core.Window{
+		winAt(0, 0, 960, 1080),     // editor, izquierda
+		winAt(100, 1200, 800, 600), // suelta
+		winAt(960, 0, 960, 540),    // arriba a la derecha
+		winAt(960, 540, 960, 540),  // abajo a la derecha: grupo por transitividad
+		winAt(2000, 0, 1280, 1440), // otro par, más a la derecha
+		winAt(3280, 0, 1280, 1440),
+	}
+	windows[1].GroupID = 7 // un GroupID viejo se pisa
+
+	AssignSnapGroups(windows)
+
+	want := []int{1, 0, 1, 1, 2, 2}
+	for i, w := range windows {
+		if w.GroupID != want[i] {
+			t.Errorf("window %d group = %d, want %d", i, w.GroupID, want[i])
+		}
+	}
+
+	separate := []core.Window{winAt(0, 0, 100, 100), winAt(100, 0, 100, 100), winAt(500, 500, 100, 100), winAt(500, 600, 100, 100)}
+	AssignSnapGroups(separate)
+	if got := []int{separate[0].GroupID, separate[1].GroupID, separate[2].GroupID, separate[3].GroupID}; got[0] != 1 || got[1] != 1 || got[2] != 2 || got[3] != 2 {
+		t.Errorf("groups = %v, want [1 1 2 2] in capture order", got)
+	}
+}
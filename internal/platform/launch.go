@@ -0,0 +1,20 @@
+package platform
+
+import (
+	"encoding/json"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// LaunchArgList decodifica LaunchArgs como lista de argumentos. Retorna nil si
+// no hay argumentos grabados o no tienen forma de array de strings
+func LaunchArgList(w core.Window) []string {
+	if len(w.LaunchArgs) == 0 {
+		return nil
+	}
+	var args []string
+	if err := json.Unmarshal(w.LaunchArgs, &args); err != nil {
+		return nil
+	}
+	return args
+}
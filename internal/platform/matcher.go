@@ -1,10 +1,14 @@
 package platform
 
 import (
+	"context"
+	"fmt"
 	"math"
+	"sort"
 	"strings"
 
 	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/paths"
 )
 
 // WindowMatcher maneja el matching inteligente de ventanas
@@ -15,11 +19,51 @@ type WindowMatcher struct {
 	SameAppScore      int
 	SameSizeScore     int
 	MinimumScore      int
+
+	// RequireSameApp limita el scoring a candidatas de la misma app, así una
+	// ventana de otra app no gana solo por compartir palabras del título. Si
+	// no queda ninguna y FallbackToAll está activo, se puntúan todas
+	RequireSameApp bool
+	FallbackToAll  bool
+
+	// SizeTolerance es la diferencia relativa de tamaño hasta la que una
+	// candidata suma puntos por tamaño; 0 usa DefaultSizeTolerance
+	SizeTolerance float64
+
+	// UseEditDistance usa similitud por distancia de Levenshtein en el paso
+	// fuzzy en lugar de Jaccard sobre sets de caracteres, que ignora el orden
+	UseEditDistance bool
 }
 
-// DefaultMatcher retorna un matcher con configuración por defecto
-func DefaultMatcher() *WindowMatcher {
-	return &WindowMatcher{
+// MatchConfig son los pesos de scoring de un WindowMatcher. Cada llamada
+// puede usar los suyos sin tocar el matcher por defecto de los adapters
+type MatchConfig struct {
+	ExactTitleScore   int
+	PartialTitleScore int
+	SameAppScore      int
+	SameSizeScore     int
+	MinimumScore      int
+}
+
+// Perfiles de matching predefinidos
+const (
+	ProfileDefault = "default"
+	// ProfileApp confía en la app y la geometría más que en el título, útil
+	// tras un reinicio cuando los títulos cambiaron
+	ProfileApp = "app"
+	// ProfileTitle exige el título exacto (o casi) y casi ignora la app
+	ProfileTitle = "title"
+)
+
+var matchProfiles = map[string]MatchConfig{
+	ProfileDefault: DefaultMatchConfig(),
+	ProfileApp:     {ExactTitleScore: 60, PartialTitleScore: 30, SameAppScore: 80, SameSizeScore: 20, MinimumScore: 80},
+	ProfileTitle:   {ExactTitleScore: 100, PartialTitleScore: 40, SameAppScore: 30, SameSizeScore: 10, MinimumScore: 100},
+}
+
+// DefaultMatchConfig retorna los pesos por defecto
+func DefaultMatchConfig() MatchConfig {
+	return MatchConfig{
 		ExactTitleScore:   100,
 		PartialTitleScore: 50,
 		SameAppScore:      50,
@@ -28,54 +72,200 @@ func DefaultMatcher() *WindowMatcher {
 	}
 }
 
+// MatchProfile retorna los pesos de un perfil por nombre ("" es el default)
+func MatchProfile(name string) (MatchConfig, error) {
+	if name == "" {
+		name = ProfileDefault
+	}
+	cfg, ok := matchProfiles[strings.ToLower(name)]
+	if !ok {
+		return MatchConfig{}, fmt.Errorf("unknown match profile %q (want %s, %s or %s)", name, ProfileDefault, ProfileApp, ProfileTitle)
+	}
+	return cfg, nil
+}
+
+// NewMatcher crea un matcher con los pesos dados y el resto de la
+// configuración por defecto
+func NewMatcher(cfg MatchConfig) *WindowMatcher {
+	return &WindowMatcher{
+		ExactTitleScore:   cfg.ExactTitleScore,
+		PartialTitleScore: cfg.PartialTitleScore,
+		SameAppScore:      cfg.SameAppScore,
+		SameSizeScore:     cfg.SameSizeScore,
+		MinimumScore:      cfg.MinimumScore,
+		RequireSameApp:    true,
+		FallbackToAll:     true,
+		UseEditDistance:   true,
+		SizeTolerance:     DefaultSizeTolerance,
+	}
+}
+
+// DefaultMatcher retorna un matcher con configuración por defecto
+func DefaultMatcher() *WindowMatcher {
+	return NewMatcher(DefaultMatchConfig())
+}
+
+type matcherKey struct{}
+
+// WithMatcher hace que los restores de ventanas hechos con ctx usen m en
+// lugar del matcher del adapter
+func WithMatcher(ctx context.Context, m *WindowMatcher) context.Context {
+	return context.WithValue(ctx, matcherKey{}, m)
+}
+
+// matcherFor retorna el matcher de ctx, o fallback si no tiene uno
+func matcherFor(ctx context.Context, fallback *WindowMatcher) *WindowMatcher {
+	if m, ok := ctx.Value(matcherKey{}).(*WindowMatcher); ok && m != nil {
+		return m
+	}
+	return fallback
+}
+
 // MatchResult representa el resultado de un matching
 type MatchResult struct {
-	Window core.Window
-	Score  int
+	Window     core.Window
+	Score      int
+	Confidence float64 // Score sobre el máximo posible (título exacto, misma app y tamaño), de 0 a 1
 }
 
+// AmbiguityMargin es la diferencia de score por debajo de la cual el
+// segundo mejor match se considera tan bueno como el primero
+const AmbiguityMargin = 10
+
 // FindBestMatch encuentra la mejor ventana candidata para restaurar
 func (m *WindowMatcher) FindBestMatch(target core.Window, candidates []core.Window) *MatchResult {
-	var bestMatch *MatchResult
+	matches := m.FindMatches(target, candidates, 1)
+	if len(matches) == 0 {
+		return nil
+	}
+	return &matches[0]
+}
+
+// FindMatches retorna hasta n candidatas que superan MinimumScore, de mayor a
+// menor score (a igual score, en el orden de candidates). n <= 0 las retorna
+// todas
+func (m *WindowMatcher) FindMatches(target core.Window, candidates []core.Window, n int) []MatchResult {
+	var matches []MatchResult
+	for _, candidate := range m.prefilter(target, candidates) {
+		if score := m.calculateScore(target, candidate); score >= m.MinimumScore {
+			matches = append(matches, m.result(candidate, score))
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool { return matches[a].Score > matches[b].Score })
+	if n > 0 && len(matches) > n {
+		matches = matches[:n]
+	}
+	return matches
+}
 
-	for _, candidate := range candidates {
-		score := m.calculateScore(target, candidate)
+// Ambiguous indica si el segundo de matches (ordenados como los retorna
+// FindMatches) queda a menos de AmbiguityMargin del primero
+func Ambiguous(matches []MatchResult) bool {
+	return len(matches) > 1 && matches[0].Score-matches[1].Score < AmbiguityMargin
+}
+
+func (m *WindowMatcher) result(candidate core.Window, score int) MatchResult {
+	confidence := 0.0
+	if best := m.ExactTitleScore + m.SameAppScore + m.SameSizeScore; best > 0 {
+		confidence = math.Min(1, float64(score)/float64(best))
+	}
+	return MatchResult{Window: candidate, Score: score, Confidence: confidence}
+}
 
-		if score >= m.MinimumScore {
-			if bestMatch == nil || score > bestMatch.Score {
-				bestMatch = &MatchResult{
-					Window: candidate,
-					Score:  score,
-				}
+// UntitledPrefix marca ventanas cuyo título no pudo leerse al capturar
+const UntitledPrefix = "[untitled] "
+
+// UntitledTitle construye el título placeholder de una ventana sin título
+func UntitledTitle(appName string) string {
+	return UntitledPrefix + appName
+}
+
+// IsUntitled indica si la ventana se capturó con un título placeholder
+func IsUntitled(w core.Window) bool {
+	return strings.HasPrefix(w.WindowTitle, UntitledPrefix)
+}
+
+// prefilter descarta barato las candidatas de otras apps antes del scoring
+func (m *WindowMatcher) prefilter(target core.Window, candidates []core.Window) []core.Window {
+	indexes := m.prefilterIndexes(target, candidates)
+	filtered := make([]core.Window, len(indexes))
+	for i, idx := range indexes {
+		filtered[i] = candidates[idx]
+	}
+	return filtered
+}
+
+// prefilterIndexes es prefilter pero retorna los índices de las candidatas
+func (m *WindowMatcher) prefilterIndexes(target core.Window, candidates []core.Window) []int {
+	// Las candidatas sin ancho ni alto no tienen geometría que reemplazar
+	all := func() []int {
+		indexes := make([]int, 0, len(candidates))
+		for i, c := range candidates {
+			if !sizeless(c) {
+				indexes = append(indexes, i)
 			}
 		}
+		return indexes
+	}
+	// Sin info de app en el target no hay con qué filtrar
+	if !m.RequireSameApp || (target.AppName == "" && target.AppPath == "") {
+		return all()
 	}
 
-	return bestMatch
+	filtered := make([]int, 0, len(candidates))
+	for i, c := range candidates {
+		if SameApp(target, c) && !sizeless(c) {
+			filtered = append(filtered, i)
+		}
+	}
+
+	if len(filtered) == 0 && m.FallbackToAll {
+		return all()
+	}
+	return filtered
+}
+
+func sizeless(w core.Window) bool {
+	return w.Width == 0 && w.Height == 0
 }
 
 // calculateScore calcula el score de similitud entre dos ventanas
 func (m *WindowMatcher) calculateScore(target, candidate core.Window) int {
 	score := 0
 
-	// 1. Title matching (más importante)
-	score += m.scoreTitleMatch(target.WindowTitle, candidate.WindowTitle)
+	// 1. Title matching (más importante). Un placeholder no aporta información:
+	// esas ventanas se emparejan solo por app y geometría
+	if !IsUntitled(target) && !IsUntitled(candidate) {
+		score += m.scoreTitleMatch(target.WindowTitle, candidate.WindowTitle)
+	}
 
-	// 2. App name matching
-	if target.AppName == candidate.AppName {
+	// 2. App matching (por ruta del ejecutable si ambas la tienen)
+	if SameApp(target, candidate) {
 		score += m.SameAppScore
 	}
 
 	// 3. Size similarity (menos importante pero útil)
-	if m.isSimilarSize(target, candidate) {
-		score += m.SameSizeScore
-	}
+	score += m.sizeScore(target, candidate)
 
 	return score
 }
 
+// SameApp indica si dos ventanas pertenecen a la misma aplicación.
+// La ruta completa del exe desambigua apps que comparten nombre.
+func SameApp(a, b core.Window) bool {
+	if a.AppPath != "" && b.AppPath != "" {
+		return paths.PathsEqual(a.AppPath, b.AppPath)
+	}
+	return strings.EqualFold(a.AppName, b.AppName)
+}
+
 // scoreTitleMatch calcula score basado en similitud de títulos
 func (m *WindowMatcher) scoreTitleMatch(target, candidate string) int {
+	// Un título vacío no aporta información (y "" está contenido en cualquier título)
+	if strings.TrimSpace(target) == "" || strings.TrimSpace(candidate) == "" {
+		return 0
+	}
+
 	// Exact match
 	if target == candidate {
 		return m.ExactTitleScore
@@ -100,23 +290,18 @@ func (m *WindowMatcher) scoreTitleMatch(target, candidate string) int {
 		return m.PartialTitleScore
 	}
 
-	// Fuzzy matching usando Jaccard similarity
-	similarity := m.stringSimilarity(targetLower, candidateLower)
-	if similarity > 0.7 { // 70% similar
+	// Fuzzy matching por edit distance o Jaccard según la configuración.
+	// Edit distance exige más: "main.go" y "gain.mo" quedan en 0.71
+	similarity, threshold := m.stringSimilarity(targetLower, candidateLower), 0.7
+	if m.UseEditDistance {
+		similarity, threshold = editSimilarity(targetLower, candidateLower), 0.8
+	}
+	if similarity > threshold {
 		return int(float64(m.PartialTitleScore) * similarity)
 	}
 
 	// Token-based matching (útil para títulos como "file.go - Project - VSCode")
-	targetTokens := strings.Fields(target)
-	candidateTokens := strings.Fields(candidate)
-
-	commonTokens := m.countCommonTokens(targetTokens, candidateTokens)
-	if commonTokens > 0 {
-		tokenScore := (commonTokens * m.PartialTitleScore) / len(targetTokens)
-		return tokenScore
-	}
-
-	return 0
+	return int(float64(m.PartialTitleScore) * tokenSetRatio(targetLower, candidateLower))
 }
 
 // stringSimilarity calcula similitud entre strings (0.0 a 1.0)
@@ -155,62 +340,166 @@ func (m *WindowMatcher) stringSimilarity(s1, s2 string) float64 {
 	return float64(intersection) / float64(union)
 }
 
-// countCommonTokens cuenta tokens comunes entre dos listas
-func (m *WindowMatcher) countCommonTokens(tokens1, tokens2 []string) int {
-	set := make(map[string]bool)
-	for _, t := range tokens1 {
-		set[strings.ToLower(t)] = true
+// editSimilarity es la distancia de Levenshtein normalizada a similitud
+// (0.0 a 1.0): 1 - distancia / largo del string más largo, en runas
+func editSimilarity(s1, s2 string) float64 {
+	if s1 == s2 {
+		return 1.0
 	}
+	r1, r2 := []rune(s1), []rune(s2)
+	longest := max(len(r1), len(r2))
+	if longest == 0 {
+		return 1.0
+	}
+	return 1.0 - float64(levenshtein(r1, r2))/float64(longest)
+}
 
-	count := 0
-	for _, t := range tokens2 {
-		if set[strings.ToLower(t)] {
-			count++
+// levenshtein calcula la distancia de edición con dos filas de la matriz
+func levenshtein(a, b []rune) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
 		}
+		prev, curr = curr, prev
 	}
-	return count
+	return prev[len(b)]
 }
 
-// isSimilarSize verifica si dos ventanas tienen tamaño similar
-func (m *WindowMatcher) isSimilarSize(w1, w2 core.Window) bool {
-	// Tolerancia del 10%
-	tolerance := 0.1
+// titleSeparators son los tokens que solo separan partes del título
+var titleSeparators = map[string]bool{"-": true, "|": true, "—": true, "–": true, "•": true, "·": true, ":": true}
 
-	widthDiff := math.Abs(float64(w1.Width-w2.Width)) / float64(w1.Width)
-	heightDiff := math.Abs(float64(w1.Height-w2.Height)) / float64(w1.Height)
+// tokenSet retorna las palabras de un título sin repetir y sin separadores
+func tokenSet(title string) map[string]bool {
+	set := make(map[string]bool)
+	for _, t := range strings.Fields(title) {
+		if !titleSeparators[t] {
+			set[t] = true
+		}
+	}
+	return set
+}
 
-	return widthDiff <= tolerance && heightDiff <= tolerance
+// tokenSetRatio es la fracción de palabras compartidas sobre el título con
+// más palabras (0.0 a 1.0). Espera los títulos ya en minúsculas
+func tokenSetRatio(s1, s2 string) float64 {
+	set1, set2 := tokenSet(s1), tokenSet(s2)
+	longest := max(len(set1), len(set2))
+	if longest == 0 {
+		return 0.0
+	}
+	common := 0
+	for t := range set1 {
+		if set2[t] {
+			common++
+		}
+	}
+	return float64(common) / float64(longest)
 }
 
-// MatchWindows encuentra matches para múltiples ventanas
-func (m *WindowMatcher) MatchWindows(targets []core.Window, candidates []core.Window) map[string]*MatchResult {
-	results := make(map[string]*MatchResult)
+// DefaultSizeTolerance es la diferencia de tamaño relativa tolerada (10%)
+const DefaultSizeTolerance = 0.1
+
+// sizeScore da SameSizeScore completo a tamaños casi iguales (hasta un
+// cuarto de la tolerancia, lo que cubre bordes y redondeos de DPI) y baja
+// linealmente hasta la mitad en el límite de la tolerancia. Fuera de ella, o
+// si alguna ventana no tiene tamaño, no suma
+func (m *WindowMatcher) sizeScore(w1, w2 core.Window) int {
+	diff, ok := m.sizeDiff(w1, w2)
+	tolerance := m.sizeTolerance()
+	if !ok || diff > tolerance {
+		return 0
+	}
+	plateau := tolerance / 4
+	if diff <= plateau {
+		return m.SameSizeScore
+	}
+	closeness := 1 - 0.5*(diff-plateau)/(tolerance-plateau)
+	return int(math.Round(float64(m.SameSizeScore) * closeness))
+}
 
-	// Crear una copia de candidates para ir marcando las ya usadas
-	availableCandidates := make([]core.Window, len(candidates))
-	copy(availableCandidates, candidates)
+// sizeDiff es la mayor diferencia relativa entre anchos y altos, medida
+// contra la ventana más grande. ok es false si alguna no tiene tamaño
+// (minimizadas, de herramientas), que no se pueden comparar
+func (m *WindowMatcher) sizeDiff(w1, w2 core.Window) (float64, bool) {
+	if w1.Width <= 0 || w1.Height <= 0 || w2.Width <= 0 || w2.Height <= 0 {
+		return 0, false
+	}
+	widthDiff := math.Abs(float64(w1.Width-w2.Width)) / float64(max(w1.Width, w2.Width))
+	heightDiff := math.Abs(float64(w1.Height-w2.Height)) / float64(max(w1.Height, w2.Height))
+	return max(widthDiff, heightDiff), true
+}
 
-	for _, target := range targets {
-		match := m.FindBestMatch(target, availableCandidates)
-		if match != nil {
-			// Usar título como key (podría ser ID en el futuro)
-			results[target.WindowTitle] = match
+func (m *WindowMatcher) sizeTolerance() float64 {
+	if m.SizeTolerance <= 0 {
+		return DefaultSizeTolerance
+	}
+	return m.SizeTolerance
+}
 
-			// Remover el candidato usado para evitar matches duplicados
-			availableCandidates = m.removeWindow(availableCandidates, match.Window)
+// AssignWindows empareja targets y candidatas uno a uno: assignment[i] es el
+// índice en candidates de la ventana asignada a targets[i], o -1. Se asignan
+// primero los pares de mayor score (a igual score, el target y la candidata
+// anteriores), así una ventana no le roba a otra su mejor candidata y dos
+// targets iguales nunca reciben la misma ventana viva
+func (m *WindowMatcher) AssignWindows(targets []core.Window, candidates []core.Window) []int {
+	type pair struct{ target, candidate, score int }
+	var pairs []pair
+	for t, target := range targets {
+		for _, c := range m.prefilterIndexes(target, candidates) {
+			if score := m.calculateScore(target, candidates[c]); score >= m.MinimumScore {
+				pairs = append(pairs, pair{t, c, score})
+			}
 		}
 	}
+	sort.SliceStable(pairs, func(a, b int) bool {
+		if pairs[a].score != pairs[b].score {
+			return pairs[a].score > pairs[b].score
+		}
+		if pairs[a].target != pairs[b].target {
+			return pairs[a].target < pairs[b].target
+		}
+		return pairs[a].candidate < pairs[b].candidate
+	})
 
-	return results
+	assignment := make([]int, len(targets))
+	for i := range assignment {
+		assignment[i] = -1
+	}
+	used := make([]bool, len(candidates))
+	for _, p := range pairs {
+		if assignment[p.target] >= 0 || used[p.candidate] {
+			continue
+		}
+		assignment[p.target] = p.candidate
+		used[p.candidate] = true
+	}
+	return assignment
 }
 
-// removeWindow remueve una ventana de la lista
-func (m *WindowMatcher) removeWindow(windows []core.Window, toRemove core.Window) []core.Window {
-	result := make([]core.Window, 0, len(windows))
-	for _, w := range windows {
-		if w.WindowTitle != toRemove.WindowTitle || w.AppName != toRemove.AppName {
-			result = append(result, w)
+// MatchWindows empareja uno a uno con AssignWindows y retorna el match de
+// cada target por índice (nil si no recibió ventana). Indexar por título
+// mostraba la misma ventana viva para targets con títulos repetidos
+func (m *WindowMatcher) MatchWindows(targets []core.Window, candidates []core.Window) []*MatchResult {
+	results := make([]*MatchResult, len(targets))
+	for i, c := range m.AssignWindows(targets, candidates) {
+		if c < 0 {
+			continue
 		}
+		result := m.result(candidates[c], m.calculateScore(targets[i], candidates[c]))
+		results[i] = &result
 	}
-	return result
+	return results
 }
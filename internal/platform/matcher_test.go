@@ -0,0 +1,393 @@
+package platform
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+func TestUntitledWindowsMatchByAppAndSize(t *testing.T) {
+	m := DefaultMatcher()
+	target := core.Window{AppName: "Slack", WindowTitle: UntitledTitle("Slack"), Width: 1200, Height: 800}
+
+	// El placeholder no suma por título: gana la ventana de la misma app y
+	// tamaño aunque otra app tenga un título que lo contiene
+	candidates := []core.Window{
+		{AppName: "Chrome", WindowTitle: "[untitled] Slack - notes", Width: 1200, Height: 800},
+		{AppName: "Slack", WindowTitle: UntitledTitle("Slack"), Width: 1210, Height: 795},
+	}
+	best := m.FindBestMatch(target, candidates)
+	if best == nil || best.Window.AppName != "Slack" {
+		t.Fatalf("best match = %+v, want the Slack window", best)
+	}
+	if want := m.SameAppScore + m.SameSizeScore; best.Score != want {
+		t.Errorf("score = %d, want %d from app and size only", best.Score, want)
+	}
+
+	// Sin la misma app y sin título no hay con qué emparejar
+	if best := m.FindBestMatch(target, candidates[:1]); best != nil {
+		t.Errorf("matched %+v by a placeholder title", best.Window)
+	}
+	if !IsUntitled(target) || !IsUntitled(candidates[0]) {
+		t.Error("IsUntitled does not recognize the placeholder prefix")
+	}
+	if IsUntitled(core.Window{WindowTitle: "untitled - Notepad"}) {
+		t.Error("a real title was taken for a placeholder")
+	}
+}
+
+func TestEmptyTitlesScoreNothing(t *testing.T) {
+	tests := []struct {
+		name         string
+		saved, live  string
+		editDistance bool
+	}{
+		{"empty saved title", "", "main.go - project", false},
+		{"empty live title", "main.go - project", "", false},
+		{"both empty", "", "", false},
+		{"whitespace only", "   ", "main.go", false},
+		{"empty saved title, edit distance", "", "main.go - project", true},
+		{"empty live title, edit distance", "main.go - project", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := DefaultMatcher()
+			m.UseEditDistance = tt.editDistance
+			if got := m.scoreTitleMatch(tt.saved, tt.live); got != 0 {
+				t.Errorf("scoreTitleMatch(%q, %q) = %d, want 0", tt.saved, tt.live, got)
+			}
+
+			// La ventana sigue emparejándose por app y tamaño, sin NaN ni
+			// puntos por un título "contenido" en cualquier otro
+			saved := core.Window{AppName: "Code", WindowTitle: tt.saved, Width: 1000, Height: 800}
+			live := core.Window{AppName: "Code", WindowTitle: tt.live, Width: 1000, Height: 800}
+			best := m.FindBestMatch(saved, []core.Window{live})
+			if want := m.SameAppScore + m.SameSizeScore; best == nil || best.Score != want {
+				t.Errorf("best match = %+v, want score %d from app and size", best, want)
+			}
+		})
+	}
+
+	// Las similitudes de bajo nivel tampoco dividen por cero
+	m := DefaultMatcher()
+	for _, pair := range [][2]string{{"", ""}, {"", "abc"}, {"abc", ""}} {
+		if got := m.stringSimilarity(pair[0], pair[1]); math.IsNaN(got) || got < 0 || got > 1 {
+			t.Errorf("stringSimilarity(%q, %q) = %v", pair[0], pair[1], got)
+		}
+		if got := editSimilarity(pair[0], pair[1]); math.IsNaN(got) || got < 0 || got > 1 {
+			t.Errorf("editSimilarity(%q, %q) = %v", pair[0], pair[1], got)
+		}
+	}
+}
+
+func TestSizeScore(t *testing.T) {
+	base := core.Window{Width: 1000, Height: 800}
+	tests := []struct {
+		name      string
+		other     core.Window
+		tolerance float64
+		want      int
+	}{
+		{"identical", base, 0, 10},
+		{"zero width", core.Window{Width: 0, Height: 800}, 0, 0},
+		{"zero height", core.Window{Width: 1000, Height: 0}, 0, 0},
+		{"within plateau", core.Window{Width: 980, Height: 800}, 0, 10}, // Bordes, DPI
+		{"5% off", core.Window{Width: 950, Height: 800}, 0, 8},
+		{"at the tolerance", core.Window{Width: 900, Height: 800}, 0, 5},
+		{"just past the tolerance", core.Window{Width: 880, Height: 800}, 0, 0},
+		{"wildly different", core.Window{Width: 300, Height: 200}, 0, 0},
+		{"height decides", core.Window{Width: 1000, Height: 760}, 0, 8},
+		{"wider tolerance", core.Window{Width: 850, Height: 800}, 0.2, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := DefaultMatcher()
+			m.SizeTolerance = tt.tolerance
+			if got := m.sizeScore(base, tt.other); got != tt.want {
+				t.Errorf("sizeScore = %d, want %d", got, tt.want)
+			}
+			// Simétrico: se mide contra la ventana más grande
+			if got := m.sizeScore(tt.other, base); got != tt.want {
+				t.Errorf("reversed sizeScore = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrefilterKeepsValidMatches(t *testing.T) {
+	code := core.Window{AppName: "Code", WindowTitle: "main.go - project - Visual Studio Code", Width: 1200, Height: 800}
+	chrome := core.Window{AppName: "Chrome", WindowTitle: "main.go - project - Visual Studio Code", Width: 1200, Height: 800}
+	otherCode := core.Window{AppName: "Code", WindowTitle: "server.go - project - Visual Studio Code", Width: 1200, Height: 800}
+
+	tests := []struct {
+		name       string
+		target     core.Window
+		candidates []core.Window
+		noFallback bool
+		want       string // AppName del match, "" si no hay
+	}{
+		// Misma app gana aunque otra app tenga el título idéntico
+		{"same title in another app loses", code, []core.Window{chrome, otherCode}, false, "Code"},
+		// Sin info de app en el target el prefiltro no descarta nada
+		{"target without app info", core.Window{WindowTitle: chrome.WindowTitle, Width: 1200, Height: 800}, []core.Window{chrome}, false, "Chrome"},
+		{"target without app info and no fallback", core.Window{WindowTitle: chrome.WindowTitle, Width: 1200, Height: 800}, []core.Window{chrome}, true, "Chrome"},
+		// Sin ventanas de la misma app se puntúan todas si hay fallback
+		{"no same-app window falls back", code, []core.Window{chrome}, false, "Chrome"},
+		{"no same-app window without fallback", code, []core.Window{chrome}, true, ""},
+		// Las ventanas sin geometría nunca son candidatas
+		{"sizeless candidates dropped", code, []core.Window{{AppName: "Code", WindowTitle: code.WindowTitle}}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := DefaultMatcher()
+			m.FallbackToAll = !tt.noFallback
+			best := m.FindBestMatch(tt.target, tt.candidates)
+			got := ""
+			if best != nil {
+				got = best.Window.AppName
+			}
+			if got != tt.want {
+				t.Errorf("matched %q, want %q", got, tt.want)
+			}
+
+			results := m.MatchWindows([]core.Window{tt.target}, tt.candidates)
+			if (results[0] != nil) != (tt.want != "") || (results[0] != nil && results[0].Window.AppName != tt.want) {
+				t.Errorf("MatchWindows = %+v, want %q", results[0], tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkMatchWindows(b *testing.B) {
+	apps := []string{"Code", "Chrome", "Slack", "Terminal", "Firefox", "Explorer", "Notepad", "Outlook"}
+	var windows []core.Window
+	for i := 0; i < 400; i++ {
+		windows = append(windows, core.Window{
+			AppName:     apps[i%len(apps)],
+			WindowTitle: fmt.Sprintf("document %d - project %d - %s", i, i%17, apps[i%len(apps)]),
+			Width:       800 + i%5*100,
+			Height:      600 + i%3*100,
+		})
+	}
+	targets := windows[:50]
+
+	for _, prefilter := range []bool{true, false} {
+		b.Run(fmt.Sprintf("prefilter=%v", prefilter), func(b *testing.B) {
+			m := DefaultMatcher()
+			m.RequireSameApp = prefilter
+			for i := 0; i < b.N; i++ {
+				m.MatchWindows(targets, windows)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"main.go", "gain.mo", 2},
+		{"café", "cafe", 1}, // Por runas, no por bytes
+		{"server.go", "servers.go", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := levenshtein([]rune(tt.b), []rune(tt.a)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistanceVersusJaccard(t *testing.T) {
+	edit := DefaultMatcher()
+	jaccard := DefaultMatcher()
+	jaccard.UseEditDistance = false
+
+	const title = "server.go - proj - visual studio code"
+	tests := []struct {
+		name                  string
+		target, candidate     string
+		wantEdit, wantJaccard int
+	}{
+		// Jaccard solo mira el set de caracteres: un anagrama es idéntico
+		{"anagram", "main.go", "gain.mo", 0, 50},
+		{"anagram in an editor title", title, "revres.og - proj - visual studio code", 41, 50},
+		{"unsaved marker", title, "server.go ● - proj - visual studio code", 47, 47},
+		{"one character added", title, "servers.go - proj - visual studio code", 48, 50},
+		{"different separator", "readme.md - notes", "readme.md — notes", 47, 42},
+		{"counter changed", "inbox (3) - mail", "inbox (12) - mail", 44, 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := edit.scoreTitleMatch(tt.target, tt.candidate); got != tt.wantEdit {
+				t.Errorf("edit distance score = %d, want %d", got, tt.wantEdit)
+			}
+			if got := jaccard.scoreTitleMatch(tt.target, tt.candidate); got != tt.wantJaccard {
+				t.Errorf("jaccard score = %d, want %d", got, tt.wantJaccard)
+			}
+		})
+	}
+
+	// Con edit distance gana la misma ventana con el marcador de cambios sin
+	// guardar; con Jaccard gana el anagrama
+	target := core.Window{AppName: "Code", WindowTitle: title}
+	candidates := []core.Window{
+		{AppName: "Code", WindowTitle: "revres.og - proj - visual studio code", Width: 800, Height: 600},
+		{AppName: "Code", WindowTitle: "server.go ● - proj - visual studio code", Width: 800, Height: 600},
+	}
+	if best := edit.FindBestMatch(target, candidates); best == nil || best.Window.WindowTitle != candidates[1].WindowTitle {
+		t.Errorf("edit distance picked %+v", best)
+	}
+	if best := jaccard.FindBestMatch(target, candidates); best == nil || best.Window.WindowTitle != candidates[0].WindowTitle {
+		t.Errorf("jaccard picked %+v", best)
+	}
+}
+
+func BenchmarkTitleSimilarity(b *testing.B) {
+	titles := []string{
+		"server.go - dev-env-snapshots - visual studio code",
+		"servers.go - dev-env-snapshots - visual studio code",
+		"pull requests · tuusuario/dev-env-snapshots - google chrome",
+		"readme.md — notes",
+		"inbox (12) - mail - outlook",
+	}
+	for _, mode := range []struct {
+		name string
+		edit bool
+	}{{"edit", true}, {"jaccard", false}} {
+		b.Run(mode.name, func(b *testing.B) {
+			m := DefaultMatcher()
+			m.UseEditDistance = mode.edit
+			for i := 0; i < b.N; i++ {
+				for j, t1 := range titles {
+					for k, t2 := range titles {
+						if j != k {
+							m.scoreTitleMatch(t1, t2)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMatchConfigChangesTheWinner(t *testing.T) {
+	target := core.Window{AppName: "Code", WindowTitle: "main.go", Width: 1200, Height: 800}
+	titled := core.Window{AppName: "Code", WindowTitle: "main.go - api - Visual Studio Code", Width: 600, Height: 400}
+	sized := core.Window{AppName: "Code", WindowTitle: "Welcome", Width: 1200, Height: 800}
+
+	tests := []struct {
+		name       string
+		cfg        MatchConfig
+		candidates []core.Window
+		want       string // título ganador, "" si ninguna llega al mínimo
+	}{
+		{"default trusts the title", DefaultMatchConfig(), []core.Window{titled, sized}, titled.WindowTitle},
+		{"geometry weighted over title", MatchConfig{ExactTitleScore: 100, PartialTitleScore: 20, SameAppScore: 50, SameSizeScore: 80, MinimumScore: 60},
+			[]core.Window{titled, sized}, sized.WindowTitle},
+		// Solo app y tamaño: 60 alcanza el mínimo por defecto
+		{"default minimum", DefaultMatchConfig(), []core.Window{sized}, sized.WindowTitle},
+		{"minimum raised by one", MatchConfig{ExactTitleScore: 100, PartialTitleScore: 50, SameAppScore: 50, SameSizeScore: 10, MinimumScore: 61},
+			[]core.Window{sized}, ""},
+		{"app profile", matchProfiles[ProfileApp], []core.Window{sized}, sized.WindowTitle},
+		{"title profile", matchProfiles[ProfileTitle], []core.Window{sized}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			best := NewMatcher(tt.cfg).FindBestMatch(target, tt.candidates)
+			got := ""
+			if best != nil {
+				got = best.Window.WindowTitle
+			}
+			if got != tt.want {
+				t.Errorf("winner = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchWindowsRealisticTitles(t *testing.T) {
+	code := func(title string, w, h int) core.Window {
+		return core.Window{AppName: "Code", WindowTitle: title, Width: w, Height: h}
+	}
+	chrome := func(title string, w, h int) core.Window {
+		return core.Window{AppName: "Chrome", WindowTitle: title, Width: w, Height: h}
+	}
+
+	tests := []struct {
+		name       string
+		targets    []core.Window
+		candidates []core.Window
+		want       []string // título elegido por target, "" si ninguno
+	}{
+		{
+			name:    "IDE where only the active file changed",
+			targets: []core.Window{code("main.go - api - Visual Studio Code", 1280, 1400)},
+			candidates: []core.Window{
+				chrome("main.go - api - Visual Studio Code - GitHub", 1280, 1400),
+				code("index.ts - web - Visual Studio Code", 1280, 1400),
+				code("server.go - api - Visual Studio Code", 1280, 1400),
+			},
+			want: []string{"server.go - api - Visual Studio Code"},
+		},
+		{
+			name:    "browser where the page changed",
+			targets: []core.Window{chrome("Pull requests · acme/api - Google Chrome", 1600, 1000)},
+			candidates: []core.Window{
+				code("acme/api - Visual Studio Code", 1600, 1000),
+				chrome("Inbox - Gmail - Google Chrome", 1600, 1000),
+				chrome("Issues · acme/api - Google Chrome", 1600, 1000),
+			},
+			want: []string{"Issues · acme/api - Google Chrome"},
+		},
+		{
+			name: "two instances of the same app follow their project",
+			targets: []core.Window{
+				code("main.go - api - Visual Studio Code", 1280, 1400),
+				code("App.tsx - web - Visual Studio Code", 1280, 1400),
+			},
+			candidates: []core.Window{
+				code("index.ts - web - Visual Studio Code", 1280, 1400),
+				code("handler.go - api - Visual Studio Code", 1280, 1400),
+			},
+			want: []string{"handler.go - api - Visual Studio Code", "index.ts - web - Visual Studio Code"},
+		},
+		{
+			name:       "two instances with the same title go by size",
+			targets:    []core.Window{{AppName: "Slack", WindowTitle: "Slack", Width: 1200, Height: 800}, {AppName: "Slack", WindowTitle: "Slack", Width: 500, Height: 900}},
+			candidates: []core.Window{{AppName: "Slack", WindowTitle: "Slack", Width: 500, Height: 900}, {AppName: "Slack", WindowTitle: "Slack", Width: 1200, Height: 800}},
+			want:       []string{"Slack 1200x800", "Slack 500x900"},
+		},
+		{
+			name:       "more targets than windows",
+			targets:    []core.Window{code("main.go - api - Visual Studio Code", 1280, 1400), code("main.go - api - Visual Studio Code", 1280, 1400)},
+			candidates: []core.Window{code("main.go - api - Visual Studio Code", 1280, 1400)},
+			want:       []string{"main.go - api - Visual Studio Code", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := DefaultMatcher().MatchWindows(tt.targets, tt.candidates)
+			for i, r := range results {
+				got := ""
+				if r != nil {
+					got = r.Window.WindowTitle
+					if r.Window.WindowTitle == "Slack" {
+						got = fmt.Sprintf("Slack %dx%d", r.Window.Width, r.Window.Height)
+					}
+				}
+				if got != tt.want[i] {
+					t.Errorf("target %d matched %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
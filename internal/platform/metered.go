@@ -0,0 +1,282 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// LatencyBuckets son los límites superiores del histograma de latencias;
+// la última posición de Buckets cuenta lo que supera el último límite
+var LatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// MethodMetrics resume las llamadas a un método del adapter
+type MethodMetrics struct {
+	Method  string        `json:"method"`
+	Calls   int64         `json:"calls"`
+	Errors  int64         `json:"errors"`
+	Total   time.Duration `json:"total"`
+	Max     time.Duration `json:"max"`
+	Buckets []int64       `json:"buckets"` // len(LatencyBuckets)+1
+}
+
+// Average retorna la latencia media de las llamadas
+func (mm MethodMetrics) Average() time.Duration {
+	if mm.Calls == 0 {
+		return 0
+	}
+	return mm.Total / time.Duration(mm.Calls)
+}
+
+// MeteredAdapter envuelve cualquier PlatformAdapter y mide cada llamada. Se
+// usa a través de Adapter
+type MeteredAdapter struct {
+	delegate core.PlatformAdapter
+
+	mu      sync.Mutex
+	methods map[string]*MethodMetrics
+}
+
+func NewMeteredAdapter(delegate core.PlatformAdapter) *MeteredAdapter {
+	return &MeteredAdapter{
+		delegate: delegate,
+		methods:  make(map[string]*MethodMetrics),
+	}
+}
+
+// Metrics retorna una copia de las métricas, ordenadas por método
+func (m *MeteredAdapter) Metrics() []MethodMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]MethodMetrics, 0, len(m.methods))
+	for _, mm := range m.methods {
+		c := *mm
+		c.Buckets = append([]int64(nil), mm.Buckets...)
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Method < result[j].Method })
+	return result
+}
+
+// observe registra una llamada que empezó en start y terminó con err
+func (m *MeteredAdapter) observe(method string, start time.Time, err error) {
+	elapsed := time.Since(start)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mm, ok := m.methods[method]
+	if !ok {
+		mm = &MethodMetrics{Method: method, Buckets: make([]int64, len(LatencyBuckets)+1)}
+		m.methods[method] = mm
+	}
+	mm.Calls++
+	if err != nil {
+		mm.Errors++
+	}
+	mm.Total += elapsed
+	if elapsed > mm.Max {
+		mm.Max = elapsed
+	}
+	bucket := sort.Search(len(LatencyBuckets), func(i int) bool { return elapsed <= LatencyBuckets[i] })
+	mm.Buckets[bucket]++
+}
+
+func (m *MeteredAdapter) Name() string {
+	return m.delegate.Name()
+}
+
+// Adapter retorna el adapter medido a usar en lugar del delegate. Solo
+// declara capacidades (core.CapabilityReporter) si el delegate las declara;
+// los demás métodos opcionales existen siempre y, si el delegate no los
+// tiene, fallan con errors.ErrUnsupported o hacen lo mismo que sin ellos
+func (m *MeteredAdapter) Adapter() core.PlatformAdapter {
+	if _, ok := m.delegate.(core.CapabilityReporter); ok {
+		return meteredReporter{m}
+	}
+	return m
+}
+
+// meteredReporter es el MeteredAdapter de un delegate que declara capacidades
+type meteredReporter struct {
+	*MeteredAdapter
+}
+
+// Capabilities reenvía lo que declara el delegate
+func (m meteredReporter) Capabilities() core.Capabilities {
+	return m.delegate.(core.CapabilityReporter).Capabilities()
+}
+
+// GitRepoPath reenvía al delegate si sabe en qué repo trabaja; si no, retorna
+// vacío, igual que un adapter sin core.GitRepoLocator
+func (m *MeteredAdapter) GitRepoPath() string {
+	if locator, ok := m.delegate.(core.GitRepoLocator); ok {
+		return locator.GitRepoPath()
+	}
+	return ""
+}
+
+// LaunchApp reenvía al delegate si puede lanzar aplicaciones
+func (m *MeteredAdapter) LaunchApp(ctx context.Context, window core.Window) (int, error) {
+	launcher, ok := m.delegate.(core.AppLauncher)
+	if !ok {
+		return 0, fmt.Errorf("the %s adapter cannot launch applications: %w", m.delegate.Name(), errors.ErrUnsupported)
+	}
+	start := time.Now()
+	pid, err := launcher.LaunchApp(ctx, window)
+	m.observe("LaunchApp", start, err)
+	return pid, err
+}
+
+// GetMonitors reenvía al delegate si puede listar monitores
+func (m *MeteredAdapter) GetMonitors(ctx context.Context) ([]core.Monitor, error) {
+	lister, ok := m.delegate.(core.MonitorLister)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	start := time.Now()
+	monitors, err := lister.GetMonitors(ctx)
+	m.observe("GetMonitors", start, err)
+	return monitors, err
+}
+
+func (m *MeteredAdapter) GetWindows(ctx context.Context) ([]core.Window, error) {
+	start := time.Now()
+	wins, err := m.delegate.GetWindows(ctx)
+	m.observe("GetWindows", start, err)
+	return wins, err
+}
+
+func (m *MeteredAdapter) RestoreWindow(ctx context.Context, window core.Window) error {
+	start := time.Now()
+	err := m.delegate.RestoreWindow(ctx, window)
+	m.observe("RestoreWindow", start, err)
+	return err
+}
+
+// RestoreWindows mide la restauración en bloque del delegate; si no la tiene
+// usa la equivalente sobre el propio MeteredAdapter, que mide cada llamada
+func (m *MeteredAdapter) RestoreWindows(ctx context.Context, windows []core.Window) ([]core.Window, []error, error) {
+	bulk, ok := m.delegate.(core.BulkWindowRestorer)
+	if !ok {
+		return restoreWindowsWith(ctx, m, matcherFor(ctx, DefaultMatcher()), windows)
+	}
+	start := time.Now()
+	placed, errs, err := bulk.RestoreWindows(ctx, windows)
+	m.observe("RestoreWindows", start, err)
+	return placed, errs, err
+}
+
+func (m *MeteredAdapter) PositionWindow(ctx context.Context, live core.Window, target core.Window) error {
+	start := time.Now()
+	err := m.delegate.PositionWindow(ctx, live, target)
+	m.observe("PositionWindow", start, err)
+	return err
+}
+
+func (m *MeteredAdapter) CloseWindow(ctx context.Context, window core.Window) error {
+	start := time.Now()
+	err := m.delegate.CloseWindow(ctx, window)
+	m.observe("CloseWindow", start, err)
+	return err
+}
+
+func (m *MeteredAdapter) GetTerminals(ctx context.Context) ([]core.Terminal, error) {
+	start := time.Now()
+	terminals, err := m.delegate.GetTerminals(ctx)
+	m.observe("GetTerminals", start, err)
+	return terminals, err
+}
+
+func (m *MeteredAdapter) RestoreTerminal(ctx context.Context, terminal core.Terminal) error {
+	start := time.Now()
+	err := m.delegate.RestoreTerminal(ctx, terminal)
+	m.observe("RestoreTerminal", start, err)
+	return err
+}
+
+func (m *MeteredAdapter) GetBrowserTabs(ctx context.Context) ([]core.BrowserTab, error) {
+	start := time.Now()
+	tabs, err := m.delegate.GetBrowserTabs(ctx)
+	m.observe("GetBrowserTabs", start, err)
+	return tabs, err
+}
+
+// GetBrowserTabsDeep reenvía la captura por DevTools si el delegate la soporta
+func (m *MeteredAdapter) GetBrowserTabsDeep(ctx context.Context) ([]core.BrowserTab, error) {
+	deep, ok := m.delegate.(core.DeepTabCapturer)
+	if !ok {
+		return m.GetBrowserTabs(ctx)
+	}
+	start := time.Now()
+	tabs, err := deep.GetBrowserTabsDeep(ctx)
+	m.observe("GetBrowserTabsDeep", start, err)
+	return tabs, err
+}
+
+func (m *MeteredAdapter) OpenURL(ctx context.Context, url string, browser string) error {
+	start := time.Now()
+	err := m.delegate.OpenURL(ctx, url, browser)
+	m.observe("OpenURL", start, err)
+	return err
+}
+
+// SaveFocus reenvía al delegate si sabe devolver el foco
+func (m *MeteredAdapter) SaveFocus(ctx context.Context) (func(context.Context) error, error) {
+	keeper, ok := m.delegate.(core.FocusKeeper)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	start := time.Now()
+	refocus, err := keeper.SaveFocus(ctx)
+	m.observe("SaveFocus", start, err)
+	return refocus, err
+}
+
+// OpenBrowserWindow reenvía la apertura agrupada si el delegate la soporta
+func (m *MeteredAdapter) OpenBrowserWindow(ctx context.Context, browser string, urls []string) error {
+	opener, ok := m.delegate.(core.BrowserWindowOpener)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+	start := time.Now()
+	err := opener.OpenBrowserWindow(ctx, browser, urls)
+	m.observe("OpenBrowserWindow", start, err)
+	return err
+}
+
+func (m *MeteredAdapter) GetIDEFiles(ctx context.Context) ([]core.IDEFile, error) {
+	start := time.Now()
+	files, err := m.delegate.GetIDEFiles(ctx)
+	m.observe("GetIDEFiles", start, err)
+	return files, err
+}
+
+func (m *MeteredAdapter) GetProcesses(ctx context.Context) ([]core.Process, error) {
+	start := time.Now()
+	processes, err := m.delegate.GetProcesses(ctx)
+	m.observe("GetProcesses", start, err)
+	return processes, err
+}
+
+func (m *MeteredAdapter) StartProcess(ctx context.Context, process core.Process) error {
+	start := time.Now()
+	err := m.delegate.StartProcess(ctx, process)
+	m.observe("StartProcess", start, err)
+	return err
+}
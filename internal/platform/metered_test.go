@@ -0,0 +1,159 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// slowAdapter tarda en listar ventanas y falla al arrancar procesos
+type slowAdapter struct {
+	*MockAdapter
+	positioned []string
+}
+
+func (a *slowAdapter) GetWindows(ctx context.Context) ([]core.Window, error) {
+	time.Sleep(6 * time.Millisecond)
+	return a.MockAdapter.GetWindows(ctx)
+}
+
+func (a *slowAdapter) PositionWindow(ctx context.Context, live core.Window, target core.Window) error {
+	a.positioned = append(a.positioned, live.WindowTitle+"->"+target.WindowTitle)
+	return nil
+}
+
+func (a *slowAdapter) StartProcess(ctx context.Context, process core.Process) error {
+	return errors.New("not allowed")
+}
+
+func TestMeteredAdapterRecordsAndForwards(t *testing.T) {
+	ctx := context.Background()
+	delegate := &slowAdapter{MockAdapter: NewMockAdapter()}
+	delegate.Windows = []core.Window{{AppName: "Code", WindowTitle: "main.go"}}
+	m := NewMeteredAdapter(delegate)
+
+	for i := 0; i < 2; i++ {
+		wins, err := m.GetWindows(ctx)
+		if err != nil || len(wins) != 1 || wins[0].WindowTitle != "main.go" {
+			t.Fatalf("GetWindows = %v, %v; want the delegate's window", wins, err)
+		}
+	}
+	if err := m.PositionWindow(ctx, core.Window{WindowTitle: "live"}, core.Window{WindowTitle: "saved"}); err != nil {
+		t.Fatalf("PositionWindow: %v", err)
+	}
+	if err := m.StartProcess(ctx, core.Process{ProcessName: "node"}); err == nil || err.Error() != "not allowed" {
+		t.Errorf("StartProcess error = %v, want the delegate's", err)
+	}
+	if len(delegate.positioned) != 1 || delegate.positioned[0] != "live->saved" {
+		t.Errorf("delegate saw %v", delegate.positioned)
+	}
+	if m.Name() != "mock" {
+		t.Errorf("Name = %q", m.Name())
+	}
+
+	metrics := map[string]MethodMetrics{}
+	for _, mm := range m.Metrics() {
+		metrics[mm.Method] = mm
+	}
+	if len(metrics) != 3 {
+		t.Errorf("metrics for %d methods, want 3: %+v", len(metrics), metrics)
+	}
+
+	get := metrics["GetWindows"]
+	if get.Calls != 2 || get.Errors != 0 {
+		t.Errorf("GetWindows calls/errors = %d/%d, want 2/0", get.Calls, get.Errors)
+	}
+	if get.Max < 6*time.Millisecond || get.Average() < 6*time.Millisecond || get.Total < 12*time.Millisecond {
+		t.Errorf("GetWindows timings too short: %+v", get)
+	}
+	// 6ms cae del bucket de hasta 10ms en adelante, nunca en los de 1 y 5ms
+	var counted int64
+	for _, n := range get.Buckets {
+		counted += n
+	}
+	if counted != 2 || get.Buckets[0] != 0 || get.Buckets[1] != 0 {
+		t.Errorf("GetWindows buckets = %v", get.Buckets)
+	}
+
+	if start := metrics["StartProcess"]; start.Calls != 1 || start.Errors != 1 {
+		t.Errorf("StartProcess calls/errors = %d/%d, want 1/1", start.Calls, start.Errors)
+	}
+	if pos := metrics["PositionWindow"]; pos.Calls != 1 || pos.Errors != 0 || len(pos.Buckets) != len(LatencyBuckets)+1 {
+		t.Errorf("PositionWindow metrics = %+v", pos)
+	}
+
+	// Metrics retorna copias
+	m.Metrics()[0].Buckets[0] = 99
+	if m.Metrics()[0].Buckets[0] == 99 {
+		t.Error("Metrics exposes the live buckets")
+	}
+}
+
+// bareAdapter expone solo core.PlatformAdapter, sin ninguna interfaz opcional
+type bareAdapter struct {
+	core.PlatformAdapter
+}
+
+func TestMeteredAdapterKeepsDelegateSupport(t *testing.T) {
+	ctx := context.Background()
+
+	bare := NewMeteredAdapter(bareAdapter{NewMockAdapter()})
+	if _, ok := bare.Adapter().(core.CapabilityReporter); ok {
+		t.Error("metered bare adapter declares capabilities")
+	}
+	mock := NewMockAdapter()
+	full := NewMeteredAdapter(mock)
+	reporter, ok := full.Adapter().(core.CapabilityReporter)
+	if !ok || reporter.Capabilities() != mock.Capabilities() {
+		t.Errorf("metered mock does not forward the mock's capabilities")
+	}
+
+	tests := []struct {
+		name string
+		call func(m *MeteredAdapter) error
+	}{
+		{"GetMonitors", func(m *MeteredAdapter) error {
+			monitors, err := m.GetMonitors(ctx)
+			if len(monitors) > 0 {
+				t.Errorf("GetMonitors returned %v", monitors)
+			}
+			return err
+		}},
+		{"LaunchApp", func(m *MeteredAdapter) error {
+			_, err := m.LaunchApp(ctx, core.Window{AppName: "Code"})
+			return err
+		}},
+		{"SaveFocus", func(m *MeteredAdapter) error {
+			_, err := m.SaveFocus(ctx)
+			return err
+		}},
+		{"OpenBrowserWindow", func(m *MeteredAdapter) error {
+			return m.OpenBrowserWindow(ctx, "chrome", []string{"https://example.com"})
+		}},
+	}
+	for _, tt := range tests {
+		if err := tt.call(bare); !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("%s on a bare delegate = %v, want errors.ErrUnsupported", tt.name, err)
+		}
+	}
+
+	// Los que tienen un equivalente sin la interfaz hacen lo mismo que el delegate
+	if path := bare.GitRepoPath(); path != "" {
+		t.Errorf("GitRepoPath = %q, want empty", path)
+	}
+	if _, err := bare.GetBrowserTabsDeep(ctx); err != nil {
+		t.Errorf("GetBrowserTabsDeep: %v", err)
+	}
+	if placed, errs, err := bare.RestoreWindows(ctx, []core.Window{{AppName: "Code", WindowTitle: "project - VS Code"}}); err != nil || len(placed) != 1 || errs[0] != nil {
+		t.Errorf("RestoreWindows = %v, %v, %v", placed, errs, err)
+	}
+
+	// Con un delegate que sí lista monitores, se reenvía y se mide
+	mock.Monitors = []core.Monitor{{ID: 1, Width: 1920, Height: 1080}}
+	if monitors, err := full.GetMonitors(ctx); err != nil || len(monitors) != 1 {
+		t.Errorf("GetMonitors on the mock = %v, %v", monitors, err)
+	}
+}
@@ -3,6 +3,10 @@ package platform
 import (
 	"context"
 	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sync"
 
 	"github.com/tuusuario/dev-env-snapshots/internal/core"
 )
@@ -11,6 +15,11 @@ import (
 type MockAdapter struct {
 	Windows   []core.Window
 	Terminals []core.Terminal
+	Monitors  []core.Monitor
+
+	mu       sync.Mutex
+	scenario *Scenario
+	step     int
 }
 
 func NewMockAdapter() *MockAdapter {
@@ -20,11 +29,72 @@ func NewMockAdapter() *MockAdapter {
 	}
 }
 
+// NewScenarioAdapter creates a mock adapter driven by a scenario, starting at step t0
+func NewScenarioAdapter(scenario *Scenario) *MockAdapter {
+	m := NewMockAdapter()
+	m.scenario = scenario
+	return m
+}
+
 func (m *MockAdapter) Name() string {
 	return "mock"
 }
 
+// state returns the current scenario step, or nil when no scenario is loaded
+func (m *MockAdapter) state() *ScenarioState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.scenario == nil {
+		return nil
+	}
+	return &m.scenario.Steps[m.step]
+}
+
+// Advance moves the scenario clock to the next step and returns the new step index.
+// The clock stays on the last step once it is reached.
+func (m *MockAdapter) Advance() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.scenario == nil {
+		return 0, fmt.Errorf("no mock scenario loaded")
+	}
+	if m.step < len(m.scenario.Steps)-1 {
+		m.step++
+	}
+	return m.step, nil
+}
+
+// Step returns the current step index and the total number of steps
+func (m *MockAdapter) Step() (int, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.scenario == nil {
+		return 0, 0
+	}
+	return m.step, len(m.scenario.Steps)
+}
+
+// GetMonitors returns the displays of the current step, or the configured ones
+func (m *MockAdapter) GetMonitors(ctx context.Context) ([]core.Monitor, error) {
+	if st := m.state(); st != nil {
+		return slices.Clone(st.Monitors), nil
+	}
+	return slices.Clone(m.Monitors), nil
+}
+
+// GitRepoPath returns the fixture repo of the current step, if any
+func (m *MockAdapter) GitRepoPath() string {
+	if st := m.state(); st != nil {
+		return st.GitRepo
+	}
+	return ""
+}
+
 func (m *MockAdapter) GetWindows(ctx context.Context) ([]core.Window, error) {
+	if st := m.state(); st != nil {
+		return cloneWindows(st.Windows), nil
+	}
+
 	// Return some dummy data if empty, or the set state
 	if len(m.Windows) == 0 {
 		return []core.Window{
@@ -38,46 +108,114 @@ func (m *MockAdapter) GetWindows(ctx context.Context) ([]core.Window, error) {
 			},
 		}, nil
 	}
-	return m.Windows, nil
+	return cloneWindows(m.Windows), nil
 }
 
 func (m *MockAdapter) RestoreWindow(ctx context.Context, window core.Window) error {
-	fmt.Printf("[Mock] Restoring window: %s at (%d, %d)\n", window.AppName, window.X, window.Y)
+	log.Printf("[Mock] Restoring window: %s at (%d, %d)", window.AppName, window.X, window.Y)
+	return nil
+}
+
+func (m *MockAdapter) PositionWindow(ctx context.Context, live core.Window, target core.Window) error {
+	log.Printf("[Mock] Positioning window: %s at (%d, %d)", live.WindowTitle, target.X, target.Y)
 	return nil
 }
 
+// Capabilities reports everything the mock can fake: scenarios provide any
+// component, and every restore operation only logs. It does not launch apps.
+func (m *MockAdapter) Capabilities() core.Capabilities {
+	return core.Capabilities{
+		CanReadWindows:         true,
+		CanPositionWindows:     true,
+		CanCloseWindows:        true,
+		CanReadCommandLines:    true,
+		CanReadMonitors:        true,
+		CanReadTerminals:       true,
+		CanReadTerminalLayouts: true,
+		CanReadEnvVars:         true,
+		CanRestoreTerminals:    true,
+		CanReadTabTitles:       true,
+		CanReadTabURLs:         true,
+		CanOpenURLs:            true,
+		CanReadIDEFiles:        true,
+		CanReadProcesses:       true,
+		CanStartProcesses:      true,
+	}
+}
+
 func (m *MockAdapter) CloseWindow(ctx context.Context, window core.Window) error {
-	fmt.Printf("[Mock] Closing window: %s\n", window.AppName)
+	log.Printf("[Mock] Closing window: %s", window.AppName)
 	return nil
 }
 
 func (m *MockAdapter) GetTerminals(ctx context.Context) ([]core.Terminal, error) {
-	return m.Terminals, nil
+	if st := m.state(); st != nil {
+		return cloneTerminals(st.Terminals), nil
+	}
+	return cloneTerminals(m.Terminals), nil
 }
 
 func (m *MockAdapter) RestoreTerminal(ctx context.Context, terminal core.Terminal) error {
-	fmt.Printf("[Mock] Restoring terminal: %s in %s\n", terminal.TerminalApp, terminal.WorkingDirectory)
+	log.Printf("[Mock] Restoring terminal: %s in %s", terminal.TerminalApp, terminal.WorkingDirectory)
 	return nil
 }
 
 func (m *MockAdapter) GetIDEFiles(ctx context.Context) ([]core.IDEFile, error) {
+	if st := m.state(); st != nil {
+		return slices.Clone(st.IDEFiles), nil
+	}
 	return []core.IDEFile{}, nil
 }
 
 func (m *MockAdapter) GetBrowserTabs(ctx context.Context) ([]core.BrowserTab, error) {
+	if st := m.state(); st != nil {
+		return slices.Clone(st.BrowserTabs), nil
+	}
 	return []core.BrowserTab{}, nil
 }
 
 func (m *MockAdapter) OpenURL(ctx context.Context, url string, browser string) error {
-	fmt.Printf("[Mock] Opening URL: %s in %s\n", url, browser)
+	log.Printf("[Mock] Opening URL: %s in %s", url, browser)
 	return nil
 }
 
 func (m *MockAdapter) GetProcesses(ctx context.Context) ([]core.Process, error) {
+	if st := m.state(); st != nil {
+		return slices.Clone(st.Processes), nil
+	}
 	return []core.Process{}, nil
 }
 
 func (m *MockAdapter) StartProcess(ctx context.Context, process core.Process) error {
-	fmt.Printf("[Mock] Starting process: %s\n", process.Command)
+	log.Printf("[Mock] Starting process: %s", process.Command)
 	return nil
 }
+
+// The getters hand out copies: capture mutates what it reads (snap groups,
+// monitors, regions, sanitizing), and that must not leak into the scenario
+// or into the next read.
+
+func cloneWindows(windows []core.Window) []core.Window {
+	out := slices.Clone(windows)
+	for i := range out {
+		out[i].LaunchArgs = slices.Clone(out[i].LaunchArgs)
+	}
+	return out
+}
+
+func cloneTerminals(terminals []core.Terminal) []core.Terminal {
+	out := slices.Clone(terminals)
+	for i := range out {
+		out[i].EnvVars = maps.Clone(out[i].EnvVars)
+		if layout := out[i].Layout; layout != nil {
+			tabs := slices.Clone(layout.Tabs)
+			for j := range tabs {
+				tabs[j].Panes = slices.Clone(tabs[j].Panes)
+			}
+			copied := *layout
+			copied.Tabs = tabs
+			out[i].Layout = &copied
+		}
+	}
+	return out
+}
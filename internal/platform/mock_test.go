@@ -0,0 +1,97 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+func TestMockGettersReturnCopies(t *testing.T) {
+	ctx := context.Background()
+	scenario := &Scenario{Name: "copies", Steps: []ScenarioState{{
+		Windows: []core.Window{{AppName: "Code", WindowTitle: "main.go", LaunchArgs: json.RawMessage(`["a"]`)}},
+		Terminals: []core.Terminal{{
+			TerminalApp: "wt.exe",
+			EnvVars:     map[string]string{"TOKEN": "secret"},
+			Layout:      &core.TerminalLayout{Tabs: []core.TerminalTab{{Panes: []core.TerminalPane{{Directory: "/src"}}}}},
+		}},
+		BrowserTabs: []core.BrowserTab{{URL: "https://example.com"}},
+		IDEFiles:    []core.IDEFile{{FilePath: "/src/main.go"}},
+		Processes:   []core.Process{{ProcessName: "node"}},
+		Monitors:    []core.Monitor{{ID: 1, Width: 1920}},
+	}}}
+	m := NewScenarioAdapter(scenario)
+
+	// Mutate everything the getters return, the way collect() does
+	windows, _ := m.GetWindows(ctx)
+	windows[0].WindowTitle = "changed"
+	windows[0].LaunchArgs[2] = 'z'
+	terminals, _ := m.GetTerminals(ctx)
+	terminals[0].EnvVars["TOKEN"] = "[REDACTED]"
+	terminals[0].Layout.Tabs[0].Panes[0].Directory = "~"
+	tabs, _ := m.GetBrowserTabs(ctx)
+	tabs[0].URL = "changed"
+	files, _ := m.GetIDEFiles(ctx)
+	files[0].FilePath = "changed"
+	processes, _ := m.GetProcesses(ctx)
+	processes[0].ProcessName = "changed"
+	monitors, _ := m.GetMonitors(ctx)
+	monitors[0].Width = 0
+
+	state := scenario.Steps[0]
+	if state.Windows[0].WindowTitle != "main.go" || string(state.Windows[0].LaunchArgs) != `["a"]` {
+		t.Errorf("scenario window changed: %+v", state.Windows[0])
+	}
+	if state.Terminals[0].EnvVars["TOKEN"] != "secret" {
+		t.Errorf("scenario env vars changed: %v", state.Terminals[0].EnvVars)
+	}
+	if state.Terminals[0].Layout.Tabs[0].Panes[0].Directory != "/src" {
+		t.Errorf("scenario layout changed: %+v", state.Terminals[0].Layout)
+	}
+	if state.BrowserTabs[0].URL != "https://example.com" || state.IDEFiles[0].FilePath != "/src/main.go" ||
+		state.Processes[0].ProcessName != "node" || state.Monitors[0].Width != 1920 {
+		t.Errorf("scenario components changed: %+v", state)
+	}
+}
+
+func TestMockGettersCopyConfiguredState(t *testing.T) {
+	ctx := context.Background()
+	m := NewMockAdapter()
+	m.Windows = []core.Window{{AppName: "Code", WindowTitle: "main.go"}}
+	m.Terminals = []core.Terminal{{TerminalApp: "bash", EnvVars: map[string]string{"A": "1"}}}
+
+	windows, _ := m.GetWindows(ctx)
+	windows[0].WindowTitle = "changed"
+	terminals, _ := m.GetTerminals(ctx)
+	terminals[0].EnvVars["A"] = "2"
+
+	if m.Windows[0].WindowTitle != "main.go" || m.Terminals[0].EnvVars["A"] != "1" {
+		t.Errorf("configured state changed: %+v %+v", m.Windows[0], m.Terminals[0])
+	}
+}
+
+func TestMockCapabilities(t *testing.T) {
+	caps := NewMockAdapter().Capabilities()
+	want := core.Capabilities{
+		CanReadWindows:         true,
+		CanPositionWindows:     true,
+		CanCloseWindows:        true,
+		CanReadCommandLines:    true,
+		CanReadMonitors:        true,
+		CanReadTerminals:       true,
+		CanReadTerminalLayouts: true,
+		CanReadEnvVars:         true,
+		CanRestoreTerminals:    true,
+		CanReadTabTitles:       true,
+		CanReadTabURLs:         true,
+		CanOpenURLs:            true,
+		CanReadIDEFiles:        true,
+		CanReadProcesses:       true,
+		CanStartProcesses:      true,
+	}
+	if caps != want {
+		t.Errorf("mock capabilities = %+v, want %+v", caps, want)
+	}
+}
@@ -0,0 +1,199 @@
+package platform
+
+import (
+	"fmt"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// MonitorFor retorna el monitor que contiene el centro de la ventana o, si
+// ninguno lo contiene, el que más superficie comparte con ella. nil si la
+// ventana queda fuera de todos
+func MonitorFor(w core.Window, monitors []core.Monitor) *core.Monitor {
+	cx, cy := w.X+w.Width/2, w.Y+w.Height/2
+	for i := range monitors {
+		if contains(monitors[i], cx, cy) {
+			return &monitors[i]
+		}
+	}
+
+	var best *core.Monitor
+	bestArea := 0
+	for i := range monitors {
+		if a := overlapArea(w, monitors[i]); a > bestArea {
+			best, bestArea = &monitors[i], a
+		}
+	}
+	return best
+}
+
+// FilterWindows conserva, en orden, las ventanas para las que keep es true.
+// i es la posición de la ventana en windows. Los OwnerRef se renumeran a las
+// nuevas posiciones; el de una ventana cuyo dueño se descartó queda en 0
+func FilterWindows(windows []core.Window, keep func(i int, w core.Window) bool) []core.Window {
+	newPos := make([]int, len(windows)) // Posición 1-based en el resultado, 0 = descartada
+	kept := make([]core.Window, 0, len(windows))
+	for i, w := range windows {
+		if keep(i, w) {
+			kept = append(kept, w)
+			newPos[i] = len(kept)
+		}
+	}
+	for i := range kept {
+		if ref := kept[i].OwnerRef; ref > 0 && ref <= len(windows) {
+			kept[i].OwnerRef = newPos[ref-1]
+		}
+	}
+	return kept
+}
+
+// WindowsOnMonitors conserva las ventanas cuyo centro cae en alguno de los
+// monitores indicados, numerados desde 1 en el orden de monitors. Los
+// OwnerRef se renumeran como en FilterWindows
+func WindowsOnMonitors(windows []core.Window, monitors []core.Monitor, numbers []int) ([]core.Window, error) {
+	allowed := make([]core.Monitor, 0, len(numbers))
+	for _, n := range numbers {
+		if n < 1 || n > len(monitors) {
+			return nil, fmt.Errorf("monitor %d does not exist (%d connected, numbered from 1)", n, len(monitors))
+		}
+		allowed = append(allowed, monitors[n-1])
+	}
+
+	return FilterWindows(windows, func(_ int, w core.Window) bool {
+		cx, cy := w.X+w.Width/2, w.Y+w.Height/2
+		for _, m := range allowed {
+			if contains(m, cx, cy) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// AssignMonitors anota en cada ventana su monitor y su posición relativa a él
+func AssignMonitors(windows []core.Window, monitors []core.Monitor) {
+	for i := range windows {
+		m := MonitorFor(windows[i], monitors)
+		if m == nil {
+			continue
+		}
+		windows[i].Monitor = m.DeviceName
+		windows[i].RelX = windows[i].X - m.X
+		windows[i].RelY = windows[i].Y - m.Y
+	}
+}
+
+// RemapToMonitors adapta la ventana a los monitores conectados ahora.
+// Si su monitor sigue conectado se recoloca por su posición relativa (el
+// monitor pudo moverse en el escritorio virtual); si no, va al monitor vivo
+// más cercano al original. En ambos casos se recorta a los límites visibles.
+// moved es true si la ventana cambió de monitor
+func RemapToMonitors(w core.Window, saved, live []core.Monitor) (placed core.Window, target *core.Monitor, moved bool) {
+	if len(live) == 0 {
+		return w, nil, false
+	}
+
+	if w.Monitor != "" {
+		for i := range live {
+			if live[i].DeviceName == w.Monitor {
+				w.X, w.Y = live[i].X+w.RelX, live[i].Y+w.RelY
+				return ClampToMonitor(w, live[i]), &live[i], false
+			}
+		}
+	} else if m := MonitorFor(w, live); m != nil {
+		// Snapshot sin monitor grabado: solo se corrige si quedó fuera de pantalla
+		return ClampToMonitor(w, *m), m, false
+	}
+
+	// Punto de referencia: el centro del monitor original si se conoce,
+	// si no el centro de la ventana
+	px, py := w.X+w.Width/2, w.Y+w.Height/2
+	relX, relY := w.RelX, w.RelY
+	if orig := findMonitor(saved, w.Monitor); orig != nil {
+		px, py = orig.X+orig.Width/2, orig.Y+orig.Height/2
+	} else if w.Monitor == "" {
+		relX, relY = 0, 0
+	}
+
+	target = nearestMonitor(live, px, py)
+	w.X, w.Y = target.X+relX, target.Y+relY
+	return ClampToMonitor(w, *target), target, true
+}
+
+// ClampToMonitor ajusta tamaño y posición para que la ventana quede entera
+// dentro del área de trabajo del monitor (o de sus límites si no se conoce)
+func ClampToMonitor(w core.Window, m core.Monitor) core.Window {
+	x, y, width, height := VisibleBounds(m)
+	if width <= 0 || height <= 0 {
+		return w
+	}
+	if w.Width > width {
+		w.Width = width
+	}
+	if w.Height > height {
+		w.Height = height
+	}
+	w.X = clampInt(w.X, x, x+width-w.Width)
+	w.Y = clampInt(w.Y, y, y+height-w.Height)
+	return w
+}
+
+// VisibleBounds retorna el área de trabajo del monitor, sin barras de tareas
+// ni docks; si no se capturó, sus límites completos
+func VisibleBounds(m core.Monitor) (x, y, width, height int) {
+	if m.WorkWidth > 0 && m.WorkHeight > 0 {
+		return m.WorkX, m.WorkY, m.WorkWidth, m.WorkHeight
+	}
+	return m.X, m.Y, m.Width, m.Height
+}
+
+func findMonitor(monitors []core.Monitor, name string) *core.Monitor {
+	if name == "" {
+		return nil
+	}
+	for i := range monitors {
+		if monitors[i].DeviceName == name {
+			return &monitors[i]
+		}
+	}
+	return nil
+}
+
+// nearestMonitor elige el monitor a menor distancia del punto; en empate gana el principal
+func nearestMonitor(monitors []core.Monitor, x, y int) *core.Monitor {
+	var best *core.Monitor
+	bestDist := -1
+	for i := range monitors {
+		m := &monitors[i]
+		dx := x - clampInt(x, m.X, m.X+m.Width)
+		dy := y - clampInt(y, m.Y, m.Y+m.Height)
+		d := dx*dx + dy*dy
+		if best == nil || d < bestDist || (d == bestDist && m.Primary && !best.Primary) {
+			best, bestDist = m, d
+		}
+	}
+	return best
+}
+
+func contains(m core.Monitor, x, y int) bool {
+	return x >= m.X && x < m.X+m.Width && y >= m.Y && y < m.Y+m.Height
+}
+
+func overlapArea(w core.Window, m core.Monitor) int {
+	dx := min(w.X+w.Width, m.X+m.Width) - max(w.X, m.X)
+	dy := min(w.Y+w.Height, m.Y+m.Height) - max(w.Y, m.Y)
+	if dx <= 0 || dy <= 0 {
+		return 0
+	}
+	return dx * dy
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,127 @@
+package platform
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+func TestRemapToMonitors(t *testing.T) {
+	// Portátil con barra de tareas abajo y un externo a su derecha
+	laptop := core.Monitor{DeviceName: `\\.\DISPLAY1`, Width: 1920, Height: 1080, Primary: true, WorkWidth: 1920, WorkHeight: 1040}
+	external := core.Monitor{DeviceName: `\\.\DISPLAY2`, X: 1920, Width: 2560, Height: 1440}
+	saved := []core.Monitor{laptop, external}
+	docked := saved
+	undocked := []core.Monitor{laptop}
+	// El externo pasó a estar a la izquierda del portátil
+	movedLeft := []core.Monitor{laptop, {DeviceName: `\\.\DISPLAY2`, X: -2560, Width: 2560, Height: 1440}}
+
+	onExternal := func(relX, relY, width, height int) core.Window {
+		return core.Window{Monitor: external.DeviceName, RelX: relX, RelY: relY,
+			X: external.X + relX, Y: external.Y + relY, Width: width, Height: height}
+	}
+
+	tests := []struct {
+		name    string
+		w       core.Window
+		live    []core.Monitor
+		want    [4]int // x, y, width, height
+		monitor string
+		moved   bool
+	}{
+		{"same layout", onExternal(100, 50, 800, 600), docked, [4]int{2020, 50, 800, 600}, external.DeviceName, false},
+		{"monitor moved in the desktop", onExternal(100, 50, 800, 600), movedLeft, [4]int{-2460, 50, 800, 600}, external.DeviceName, false},
+		{"no work area clamps to the bounds", onExternal(2400, 50, 800, 600), movedLeft, [4]int{-800, 50, 800, 600}, external.DeviceName, false},
+		{"monitor gone keeps the relative offset", onExternal(100, 50, 800, 600), undocked, [4]int{100, 50, 800, 600}, laptop.DeviceName, true},
+		{"monitor gone clamps off-screen offsets", onExternal(2000, 1000, 800, 600), undocked, [4]int{1120, 440, 800, 600}, laptop.DeviceName, true},
+		{"monitor gone shrinks oversized windows", onExternal(0, 0, 2560, 1440), undocked, [4]int{0, 0, 1920, 1040}, laptop.DeviceName, true},
+		{"unknown monitor partly off-screen", core.Window{X: 1800, Y: 900, Width: 800, Height: 600}, undocked, [4]int{1120, 440, 800, 600}, laptop.DeviceName, false},
+		{"unknown monitor fully off-screen", core.Window{X: 5000, Y: 5000, Width: 800, Height: 600}, undocked, [4]int{0, 0, 800, 600}, laptop.DeviceName, true},
+		{"negative coordinates", core.Window{X: -4000, Y: -3000, Width: 800, Height: 600}, undocked, [4]int{0, 0, 800, 600}, laptop.DeviceName, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			placed, target, moved := RemapToMonitors(tt.w, saved, tt.live)
+			if got := [4]int{placed.X, placed.Y, placed.Width, placed.Height}; got != tt.want {
+				t.Errorf("placed at %v, want %v", got, tt.want)
+			}
+			if target == nil || target.DeviceName != tt.monitor {
+				t.Errorf("target = %+v, want %s", target, tt.monitor)
+			}
+			if moved != tt.moved {
+				t.Errorf("moved = %v, want %v", moved, tt.moved)
+			}
+		})
+	}
+
+	// Sin monitores vivos la ventana queda como estaba
+	w := onExternal(100, 50, 800, 600)
+	if placed, target, moved := RemapToMonitors(w, saved, nil); placed.X != w.X || placed.Y != w.Y || target != nil || moved {
+		t.Errorf("with no live monitors: %+v, %v, %v", placed, target, moved)
+	}
+}
+
+func TestNearestMonitorPrefersPrimaryOnTies(t *testing.T) {
+	left := core.Monitor{DeviceName: "left", X: -1000, Width: 1000, Height: 1000}
+	right := core.Monitor{DeviceName: "right", X: 1000, Width: 1000, Height: 1000, Primary: true}
+	if m := nearestMonitor([]core.Monitor{left, right}, 500, 500); m.DeviceName != "right" {
+		t.Errorf("nearest = %s, want the primary on a tie", m.DeviceName)
+	}
+	if m := nearestMonitor([]core.Monitor{left, right}, 400, 500); m.DeviceName != "left" {
+		t.Errorf("nearest = %s, want left", m.DeviceName)
+	}
+}
+
+func TestWindowsOnMonitors(t *testing.T) {
+	monitors := []core.Monitor{
+		{DeviceName: `\\.\DISPLAY1`, Width: 1920, Height: 1080, Primary: true},
+		{DeviceName: `\\.\DISPLAY2`, X: 1920, Width: 2560, Height: 1440},
+	}
+	windows := []core.Window{
+		{WindowTitle: "editor", X: 100, Y: 100, Width: 800, Height: 600},
+		{WindowTitle: "browser", X: 2000, Y: 100, Width: 1200, Height: 900},
+		// Cruza el borde pero el centro cae en el segundo
+		{WindowTitle: "straddling", X: 1700, Y: 100, Width: 800, Height: 600},
+		// Diálogo del browser: su OwnerRef se renumera
+		{WindowTitle: "dialog", X: 2200, Y: 300, Width: 400, Height: 300, OwnerRef: 2},
+		{WindowTitle: "off-screen", X: 9000, Y: 9000, Width: 400, Height: 300},
+	}
+
+	tests := []struct {
+		name    string
+		numbers []int
+		want    []string
+		owners  []int
+		wantErr bool
+	}{
+		{"first only", []int{1}, []string{"editor"}, []int{0}, false},
+		{"second only", []int{2}, []string{"browser", "straddling", "dialog"}, []int{0, 0, 1}, false},
+		{"both", []int{2, 1}, []string{"editor", "browser", "straddling", "dialog"}, []int{0, 0, 0, 2}, false},
+		{"missing monitor", []int{3}, nil, nil, true},
+		{"zero is not a monitor", []int{0}, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, err := WindowsOnMonitors(windows, monitors, tt.numbers)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("kept %d windows, want an error", len(kept))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WindowsOnMonitors: %v", err)
+			}
+			var titles []string
+			var owners []int
+			for _, w := range kept {
+				titles = append(titles, w.WindowTitle)
+				owners = append(owners, w.OwnerRef)
+			}
+			if !slices.Equal(titles, tt.want) || !slices.Equal(owners, tt.owners) {
+				t.Errorf("kept %v with owners %v, want %v with %v", titles, owners, tt.want, tt.owners)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+//go:build windows
+
+package platform
+
+import (
+	"context"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	shcore = windows.NewLazySystemDLL("shcore.dll")
+
+	procEnumDisplayMonitors = user32.NewProc("EnumDisplayMonitors")
+	procGetMonitorInfoW     = user32.NewProc("GetMonitorInfoW")
+	procGetDpiForMonitor    = shcore.NewProc("GetDpiForMonitor")
+)
+
+// MONITORINFOF_PRIMARY y MDT_EFFECTIVE_DPI
+const (
+	monitorInfoPrimary = 1
+	mdtEffectiveDPI    = 0
+)
+
+// monitorInfoEx es MONITORINFOEXW de user32
+type monitorInfoEx struct {
+	CbSize    uint32
+	RcMonitor rect
+	RcWork    rect
+	DwFlags   uint32
+	SzDevice  [32]uint16
+}
+
+// enumMonitorsCallback se crea una sola vez: syscall.NewCallback no libera
+// los callbacks y tiene un límite por proceso. El resultado se acumula en
+// enumMonitorsResult, protegido por enumMonitorsMu
+var (
+	enumMonitorsCallback = syscall.NewCallback(collectMonitor)
+	enumMonitorsMu       sync.Mutex
+	enumMonitorsResult   []core.Monitor
+)
+
+func collectMonitor(hmon syscall.Handle, hdc syscall.Handle, clip *rect, lparam uintptr) uintptr {
+	info := monitorInfoEx{}
+	info.CbSize = uint32(unsafe.Sizeof(info))
+	ret, _, _ := procGetMonitorInfoW.Call(uintptr(hmon), uintptr(unsafe.Pointer(&info)))
+	if ret == 0 {
+		return 1
+	}
+
+	m := core.Monitor{
+		DeviceName: syscall.UTF16ToString(info.SzDevice[:]),
+		X:          int(info.RcMonitor.Left),
+		Y:          int(info.RcMonitor.Top),
+		Width:      int(info.RcMonitor.Right - info.RcMonitor.Left),
+		Height:     int(info.RcMonitor.Bottom - info.RcMonitor.Top),
+		Primary:    info.DwFlags&monitorInfoPrimary != 0,
+		WorkX:      int(info.RcWork.Left),
+		WorkY:      int(info.RcWork.Top),
+		WorkWidth:  int(info.RcWork.Right - info.RcWork.Left),
+		WorkHeight: int(info.RcWork.Bottom - info.RcWork.Top),
+	}
+	// GetDpiForMonitor existe desde Windows 8.1
+	if procGetDpiForMonitor.Find() == nil {
+		var dpiX, dpiY uint32
+		hr, _, _ := procGetDpiForMonitor.Call(uintptr(hmon), mdtEffectiveDPI, uintptr(unsafe.Pointer(&dpiX)), uintptr(unsafe.Pointer(&dpiY)))
+		if hr == 0 {
+			m.DPI = int(dpiX)
+		}
+	}
+	enumMonitorsResult = append(enumMonitorsResult, m)
+	return 1
+}
+
+// GetMonitors enumera los monitores conectados con EnumDisplayMonitors
+func (w *WindowsAdapter) GetMonitors(ctx context.Context) ([]core.Monitor, error) {
+	enumMonitorsMu.Lock()
+	defer enumMonitorsMu.Unlock()
+
+	enumMonitorsResult = nil
+	ret, _, err := procEnumDisplayMonitors.Call(0, 0, enumMonitorsCallback, 0)
+	if ret == 0 {
+		return nil, err
+	}
+	monitors := enumMonitorsResult
+	enumMonitorsResult = nil
+	return monitors, nil
+}
@@ -0,0 +1,40 @@
+package platform
+
+import "github.com/tuusuario/dev-env-snapshots/internal/core"
+
+// Comandos de ShowWindow usados al posicionar
+const (
+	swShowNormal = 1
+	swMaximize   = 3
+)
+
+// placementCalls son las llamadas a la API de ventanas con las que
+// applyPlacement deja una ventana en su estado grabado. En Windows las
+// implementa el adapter sobre user32
+type placementCalls struct {
+	setPos       func(w core.Window) error // SetWindowPos sin activar ni tocar el z-order
+	show         func(cmd int)             // ShowWindow
+	setMinimized func(w core.Window) error // SetWindowPlacement minimizada sin activar
+}
+
+// applyPlacement aplica la geometría y el estado de window. Una ventana
+// minimizada no debe mostrarse en ningún momento: SetWindowPos seguido de
+// SW_MINIMIZE la hace parpadear y la activa, así que va solo por
+// setMinimized
+func applyPlacement(window core.Window, calls placementCalls) error {
+	// Un snapshot corrupto no debe llegar al syscall con valores extremos
+	window, _ = ClampGeometry(window)
+
+	if window.State == "minimized" {
+		return calls.setMinimized(window)
+	}
+	if err := calls.setPos(window); err != nil {
+		return err
+	}
+	if window.State == "maximized" {
+		calls.show(swMaximize)
+	} else {
+		calls.show(swShowNormal)
+	}
+	return nil
+}
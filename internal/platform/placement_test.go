@@ -0,0 +1,69 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+func TestApplyPlacement(t *testing.T) {
+	tests := []struct {
+		name   string
+		window core.Window
+		setErr error
+		want   string
+	}{
+		{
+			name:   "minimized never shown",
+			window: core.Window{X: 10, Y: 20, Width: 800, Height: 600, State: "minimized"},
+			want:   "minimized 10,20 800x600",
+		},
+		{
+			name:   "minimized with extreme geometry",
+			window: core.Window{X: -1 << 40, Y: 1 << 40, Width: 800, Height: -5, State: "minimized"},
+			want:   fmt.Sprintf("minimized %d,%d 800x0", MinCoordinate, MaxCoordinate),
+		},
+		{
+			name:   "maximized",
+			window: core.Window{X: 0, Y: 0, Width: 1920, Height: 1080, State: "maximized"},
+			want:   fmt.Sprintf("pos 0,0 1920x1080|show %d", swMaximize),
+		},
+		{
+			name:   "normal",
+			window: core.Window{X: 100, Y: 100, Width: 1200, Height: 800, State: "normal"},
+			want:   fmt.Sprintf("pos 100,100 1200x800|show %d", swShowNormal),
+		},
+		{
+			name:   "failed move is not shown",
+			window: core.Window{Width: 800, Height: 600},
+			setErr: errors.New("SetWindowPos failed"),
+			want:   "pos 0,0 800x600",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			geometry := func(w core.Window) string { return fmt.Sprintf("%d,%d %dx%d", w.X, w.Y, w.Width, w.Height) }
+			err := applyPlacement(tt.window, placementCalls{
+				setPos: func(w core.Window) error {
+					calls = append(calls, "pos "+geometry(w))
+					return tt.setErr
+				},
+				show: func(cmd int) { calls = append(calls, fmt.Sprintf("show %d", cmd)) },
+				setMinimized: func(w core.Window) error {
+					calls = append(calls, "minimized "+geometry(w))
+					return nil
+				},
+			})
+			if !errors.Is(err, tt.setErr) {
+				t.Errorf("error = %v, want %v", err, tt.setErr)
+			}
+			if got := strings.Join(calls, "|"); got != tt.want {
+				t.Errorf("calls = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
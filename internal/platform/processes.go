@@ -0,0 +1,75 @@
+package platform
+
+import (
+	"bufio"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// devProcesses son los ejecutables que un capture con procesos registra:
+// runtimes, gestores de paquetes, servidores de desarrollo y bases de datos
+// locales. El resto del sistema no se graba
+var devProcesses = map[string]bool{
+	"node": true, "npm": true, "npx": true, "yarn": true, "pnpm": true, "deno": true, "bun": true,
+	"python": true, "python3": true, "pythonw": true, "uvicorn": true, "gunicorn": true, "flask": true,
+	"ruby": true, "rails": true, "bundle": true, "php": true, "java": true, "gradle": true, "mvn": true,
+	"go": true, "air": true, "dotnet": true, "cargo": true, "hugo": true, "jekyll": true,
+	"docker-compose": true, "redis-server": true, "postgres": true, "mongod": true, "mysqld": true, "nginx": true,
+}
+
+// isDevProcess informa si el ejecutable name (nombre o ruta, con o sin .exe)
+// es de los que registra un capture
+func isDevProcess(name string) bool {
+	return devProcesses[appBaseName(filepath.Base(strings.ReplaceAll(name, `\`, "/")))]
+}
+
+// parsePSOutput lee la salida de `ps -axww -o pid=,ucomm=,args=` y retorna
+// los procesos de desarrollo salvo self. ucomm sirve para filtrar; args es
+// la línea de comandos completa
+func parsePSOutput(out string, self int) []core.Process {
+	processes := []core.Process{}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		pidField, rest, _ := strings.Cut(line, " ")
+		pid, err := strconv.Atoi(pidField)
+		if err != nil || pid == self {
+			continue
+		}
+		name, args, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		if !isDevProcess(name) {
+			continue
+		}
+		processes = append(processes, core.Process{
+			ProcessName: name,
+			Command:     strings.TrimSpace(args),
+			Pid:         pid,
+		})
+	}
+	return processes
+}
+
+// parseLsofCwd lee la salida de `lsof -a -d cwd -p <pids> -Fpn`: por cada
+// proceso una línea p<pid> y, más abajo, una n<directorio>
+func parseLsofCwd(out string) map[int]string {
+	dirs := make(map[int]string)
+	pid := 0
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			pid, _ = strconv.Atoi(line[1:])
+		case 'n':
+			if pid > 0 {
+				dirs[pid] = line[1:]
+			}
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,55 @@
+package platform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+func TestIsDevProcess(t *testing.T) {
+	for name, want := range map[string]bool{
+		"node":                              true,
+		"node.exe":                          true,
+		"Python.EXE":                        true,
+		`C:\Program Files\nodejs\node.exe`:  true,
+		"/usr/local/bin/docker-compose":     true,
+		"explorer.exe":                      false,
+		"svchost.exe":                       false,
+		"/System/Library/CoreServices/Dock": false,
+		"":                                  false,
+	} {
+		if got := isDevProcess(name); got != want {
+			t.Errorf("isDevProcess(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestParsePSOutput(t *testing.T) {
+	out := `    1 launchd          /sbin/launchd
+  412 node             node /Users/me/app/node_modules/.bin/vite --port 5173
+  500 Google Chrome    /Applications/Google Chrome.app/Contents/MacOS/Google Chrome
+  777 server           /tmp/dev-env-snapshots/server
+  901 python3          python3 -m http.server 8000
+garbage line
+`
+	got := parsePSOutput(out, 777)
+	want := []core.Process{
+		{ProcessName: "node", Command: "node /Users/me/app/node_modules/.bin/vite --port 5173", Pid: 412},
+		{ProcessName: "python3", Command: "python3 -m http.server 8000", Pid: 901},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePSOutput = %+v, want %+v", got, want)
+	}
+	if got := parsePSOutput("", 1); got == nil || len(got) != 0 {
+		t.Errorf("empty output = %#v, want an empty list", got)
+	}
+}
+
+func TestParseLsofCwd(t *testing.T) {
+	out := "p412\nfcwd\nn/Users/me/app\np901\nfcwd\nn/Users/me/my site\n"
+	want := map[int]string{412: "/Users/me/app", 901: "/Users/me/my site"}
+	if got := parseLsofCwd(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLsofCwd = %v, want %v", got, want)
+	}
+}
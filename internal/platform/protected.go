@@ -0,0 +1,56 @@
+package platform
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+type protectedKey struct{}
+
+// WithProtectedApps hace que los restores hechos con ctx ignoren las ventanas
+// vivas de esas apps: nunca se eligen como match ni se mueven
+func WithProtectedApps(ctx context.Context, apps []string) context.Context {
+	if len(apps) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, protectedKey{}, apps)
+}
+
+// IsProtectedApp informa si app figura en apps. Compara sin mayúsculas y sin
+// extensión, así "zoom", "Zoom.exe" y "zoom.app" son la misma app
+func IsProtectedApp(apps []string, app string) bool {
+	name := appBaseName(app)
+	for _, a := range apps {
+		if appBaseName(a) == name && name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func appBaseName(app string) string {
+	app = strings.ToLower(strings.TrimSpace(app))
+	return strings.TrimSuffix(strings.TrimSuffix(app, ".exe"), ".app")
+}
+
+// withoutProtected descarta los elementos de apps protegidas en ctx
+func withoutProtected[T any](ctx context.Context, items []T, appOf func(T) string) []T {
+	apps, _ := ctx.Value(protectedKey{}).([]string)
+	if len(apps) == 0 {
+		return items
+	}
+	kept := make([]T, 0, len(items))
+	for _, it := range items {
+		if !IsProtectedApp(apps, appOf(it)) {
+			kept = append(kept, it)
+		}
+	}
+	return kept
+}
+
+// WithoutProtected descarta las ventanas de apps protegidas en ctx
+func WithoutProtected(ctx context.Context, windows []core.Window) []core.Window {
+	return withoutProtected(ctx, windows, func(w core.Window) string { return w.AppName })
+}
@@ -0,0 +1,98 @@
+package platform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// Scenario describe un entorno falso reproducible para el MockAdapter.
+// Cada step es el estado completo del entorno en un instante (t0, t1, ...)
+type Scenario struct {
+	Name  string          `json:"name"`
+	Steps []ScenarioState `json:"steps"`
+}
+
+// ScenarioState es el estado del entorno en un step del escenario
+type ScenarioState struct {
+	Windows     []core.Window     `json:"windows"`
+	Terminals   []core.Terminal   `json:"terminals"`
+	BrowserTabs []core.BrowserTab `json:"browser_tabs"`
+	IDEFiles    []core.IDEFile    `json:"ide_files"`
+	Processes   []core.Process    `json:"processes"`
+	Monitors    []core.Monitor    `json:"monitors"` // Pantallas conectadas en este paso (undock/dock)
+	GitRepo     string            `json:"git_repo"` // Ruta opcional a un repo fixture
+}
+
+// ScenarioError agrupa todos los problemas de validación de un escenario
+type ScenarioError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *ScenarioError) Error() string {
+	return fmt.Sprintf("invalid mock scenario %s:\n  - %s", e.Path, strings.Join(e.Problems, "\n  - "))
+}
+
+// LoadScenario lee y valida un escenario desde un archivo JSON
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock scenario: %w", err)
+	}
+
+	var s Scenario
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse mock scenario %s: %w", path, err)
+	}
+
+	if problems := s.Validate(); len(problems) > 0 {
+		return nil, &ScenarioError{Path: path, Problems: problems}
+	}
+	return &s, nil
+}
+
+// Validate retorna la lista de problemas encontrados en el escenario
+func (s *Scenario) Validate() []string {
+	var problems []string
+
+	if len(s.Steps) == 0 {
+		problems = append(problems, "scenario must define at least one step")
+	}
+
+	for i, step := range s.Steps {
+		for j, w := range step.Windows {
+			if w.AppName == "" {
+				problems = append(problems, fmt.Sprintf("steps[%d].windows[%d]: app_name is required", i, j))
+			}
+			if w.Width < 0 || w.Height < 0 {
+				problems = append(problems, fmt.Sprintf("steps[%d].windows[%d]: width and height must not be negative", i, j))
+			}
+		}
+		for j, t := range step.Terminals {
+			if t.TerminalApp == "" {
+				problems = append(problems, fmt.Sprintf("steps[%d].terminals[%d]: terminal_app is required", i, j))
+			}
+		}
+		for j, tab := range step.BrowserTabs {
+			if tab.BrowserName == "" {
+				problems = append(problems, fmt.Sprintf("steps[%d].browser_tabs[%d]: browser_name is required", i, j))
+			}
+		}
+		for j, f := range step.IDEFiles {
+			if f.FilePath == "" {
+				problems = append(problems, fmt.Sprintf("steps[%d].ide_files[%d]: file_path is required", i, j))
+			}
+		}
+		if step.GitRepo != "" {
+			if info, err := os.Stat(step.GitRepo); err != nil || !info.IsDir() {
+				problems = append(problems, fmt.Sprintf("steps[%d].git_repo: %s is not a directory", i, step.GitRepo))
+			}
+		}
+	}
+
+	return problems
+}
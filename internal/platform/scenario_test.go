@@ -0,0 +1,78 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeScenario(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadScenarioSteps(t *testing.T) {
+	path := writeScenario(t, `{
+		"name": "two steps",
+		"steps": [
+			{"windows": [{"app_name": "Code", "window_title": "main.go", "width": 800, "height": 600}],
+			 "terminals": [{"terminal_app": "bash", "working_directory": "/src"}],
+			 "browser_tabs": [{"browser_name": "firefox", "url": "https://go.dev"}]},
+			{"windows": [{"app_name": "Code", "window_title": "util.go", "width": 800, "height": 600}]}
+		]
+	}`)
+	scenario, err := LoadScenario(path)
+	if err != nil {
+		t.Fatalf("LoadScenario: %v", err)
+	}
+	m := NewScenarioAdapter(scenario)
+	ctx := context.Background()
+
+	first, _ := m.GetWindows(ctx)
+	if len(first) != 1 || first[0].WindowTitle != "main.go" {
+		t.Fatalf("step 0 windows = %+v", first)
+	}
+	if step, err := m.Advance(); err != nil || step != 1 {
+		t.Fatalf("Advance = %d, %v", step, err)
+	}
+	second, _ := m.GetWindows(ctx)
+	if len(second) != 1 || second[0].WindowTitle != "util.go" {
+		t.Fatalf("step 1 windows = %+v", second)
+	}
+	// El reloj se queda en el último paso
+	if step, _ := m.Advance(); step != 1 {
+		t.Errorf("Advance past the end = %d, want 1", step)
+	}
+}
+
+func TestLoadScenarioReportsEveryProblem(t *testing.T) {
+	path := writeScenario(t, `{"steps": [{
+		"windows": [{"window_title": "no app", "width": -1}],
+		"terminals": [{"working_directory": "/src"}],
+		"browser_tabs": [{"url": "https://go.dev"}],
+		"ide_files": [{"ide_name": "code"}],
+		"git_repo": "/definitely/not/here"
+	}]}`)
+	_, err := LoadScenario(path)
+	var scenarioErr *ScenarioError
+	if !errors.As(err, &scenarioErr) {
+		t.Fatalf("LoadScenario error = %v, want a ScenarioError", err)
+	}
+	if len(scenarioErr.Problems) != 6 {
+		t.Errorf("got %d problems, want 6:\n%s", len(scenarioErr.Problems), strings.Join(scenarioErr.Problems, "\n"))
+	}
+
+	if _, err := LoadScenario(writeScenario(t, `{"steps": []}`)); err == nil {
+		t.Error("a scenario without steps was accepted")
+	}
+	if _, err := NewMockAdapter().Advance(); err == nil {
+		t.Error("Advance without a scenario did not fail")
+	}
+}
@@ -0,0 +1,45 @@
+package platform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// TerminalLaunch es el comando que abre una terminal en un directorio.
+// CmdLine, si no está vacío, es la línea de comandos literal: cmd.exe no
+// entiende el escapado de comillas que Go aplica a Args
+type TerminalLaunch struct {
+	Path    string
+	Args    []string
+	CmdLine string
+}
+
+// TerminalCommand arma el comando para reabrir una terminal en dir
+func TerminalCommand(t core.Terminal, dir string) (*TerminalLaunch, error) {
+	switch strings.ToLower(t.TerminalApp) {
+	case "windowsterminal.exe", "wt.exe":
+		// Con layout conocido se reconstruyen pestañas y paneles; si no, una sola terminal
+		if t.Layout != nil && len(t.Layout.Tabs) > 0 {
+			return &TerminalLaunch{Path: "wt.exe", Args: WTLayoutArgs(*t.Layout, dir)}, nil
+		}
+		return &TerminalLaunch{Path: "wt.exe", Args: []string{"-d", wtEscape(dir)}}, nil
+	case "powershell.exe", "pwsh.exe":
+		return &TerminalLaunch{
+			Path: t.TerminalApp,
+			Args: []string{"-NoExit", "-Command", "Set-Location -LiteralPath " + powershellQuote(dir)},
+		}, nil
+	case "cmd.exe":
+		return &TerminalLaunch{
+			Path:    "cmd.exe",
+			CmdLine: fmt.Sprintf(`cmd.exe /K cd /d "%s"`, dir),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported terminal app: %s", t.TerminalApp)
+}
+
+// powershellQuote encierra s en comillas simples (literal, sin expansión)
+func powershellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -1,18 +1,28 @@
+//go:build windows
+
 package platform
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
 	"syscall"
 	"unsafe"
 
+	"github.com/tuusuario/dev-env-snapshots/internal/browser"
 	"github.com/tuusuario/dev-env-snapshots/internal/core"
 	"golang.org/x/sys/windows"
 )
 
 var (
 	user32 = windows.NewLazySystemDLL("user32.dll")
+	dwmapi = windows.NewLazySystemDLL("dwmapi.dll")
 
 	procEnumWindows              = user32.NewProc("EnumWindows")
 	procGetWindowTextW           = user32.NewProc("GetWindowTextW")
@@ -22,8 +32,22 @@ var (
 	procGetWindowRect            = user32.NewProc("GetWindowRect")
 	procSetWindowPos             = user32.NewProc("SetWindowPos")
 	procShowWindow               = user32.NewProc("ShowWindow")
+	procGetWindow                = user32.NewProc("GetWindow")
+	procGetWindowPlacement       = user32.NewProc("GetWindowPlacement")
+	procSetWindowPlacement       = user32.NewProc("SetWindowPlacement")
+	procGetForegroundWindow      = user32.NewProc("GetForegroundWindow")
+	procSetForegroundWindow      = user32.NewProc("SetForegroundWindow")
+	procIsWindow                 = user32.NewProc("IsWindow")
+
+	procDwmGetWindowAttribute = dwmapi.NewProc("DwmGetWindowAttribute")
 )
 
+// GW_OWNER para GetWindow
+const gwOwner = 4
+
+// DWMWA_CLOAKED: ventanas ocultas por DWM (apps UWP suspendidas, otros escritorios)
+const dwmwaCloaked = 14
+
 type rect struct {
 	Left   int32
 	Top    int32
@@ -46,9 +70,46 @@ func (w *WindowsAdapter) Name() string {
 	return "windows"
 }
 
+// liveWindow asocia una ventana capturada con su handle y el de su owner
+type liveWindow struct {
+	hwnd   syscall.Handle
+	owner  syscall.Handle
+	window core.Window
+}
+
 // GetWindows obtiene todas las ventanas visibles
 func (w *WindowsAdapter) GetWindows(ctx context.Context) ([]core.Window, error) {
-	var wins []core.Window
+	live := w.enumWindows()
+	wins := windowsOf(live)
+	markWindowDesktops(live, wins)
+	return wins, nil
+}
+
+// windowsOf convierte las ventanas enumeradas, resolviendo OwnerRef
+func windowsOf(live []liveWindow) []core.Window {
+	// Indexar handles para resolver la relación owner -> ventana capturada
+	positions := make(map[syscall.Handle]int, len(live))
+	for i, lw := range live {
+		positions[lw.hwnd] = i
+	}
+
+	wins := make([]core.Window, 0, len(live))
+	for _, lw := range live {
+		win := lw.window
+		if lw.owner != 0 {
+			if pos, ok := positions[lw.owner]; ok {
+				win.OwnerRef = pos + 1
+			}
+		}
+		wins = append(wins, win)
+	}
+	return wins
+}
+
+// enumWindows enumera las ventanas visibles junto con sus handles
+func (w *WindowsAdapter) enumWindows() []liveWindow {
+	var wins []liveWindow
+	processes := make(map[uint32]processDetails)
 
 	cb := syscall.NewCallback(func(hwnd syscall.Handle, lparam uintptr) uintptr {
 		// Filter invisible windows
@@ -58,22 +119,28 @@ func (w *WindowsAdapter) GetWindows(ctx context.Context) ([]core.Window, error)
 		}
 
 		// Get Title
-		ret, _, _ = procGetWindowTextLengthW.Call(uintptr(hwnd))
-		len := int(ret)
-		if len == 0 {
-			return 1
-		}
-
-		buf := make([]uint16, len+1)
-		procGetWindowTextW.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len+1))
-		title := syscall.UTF16ToString(buf)
+		title := windowText(hwnd)
 
 		// Get Process ID
 		var pid uint32
 		procGetWindowThreadProcessId.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&pid)))
 
-		// Get App Name
+		// Get App Name, exe path and arguments (once per process)
 		appName := w.getProcessName(pid)
+		details, ok := processes[pid]
+		if !ok {
+			details = getProcessDetails(pid)
+			processes[pid] = details
+		}
+
+		// Algunas políticas de seguridad bloquean GetWindowText y devuelven "".
+		// Conservamos esas ventanas si son reales (no cloaked, con proceso conocido)
+		if title == "" {
+			if appName == "" || isCloaked(hwnd) {
+				return 1
+			}
+			title = UntitledTitle(appName)
+		}
 		if appName == "" {
 			appName = fmt.Sprintf("PID_%d", pid)
 		}
@@ -82,24 +149,28 @@ func (w *WindowsAdapter) GetWindows(ctx context.Context) ([]core.Window, error)
 		var r rect
 		procGetWindowRect.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&r)))
 
+		// Get Owner (dialogs, tool windows)
+		owner, _, _ := procGetWindow.Call(uintptr(hwnd), gwOwner)
+
 		win := core.Window{
 			WindowTitle: title,
 			AppName:     appName,
-			AppPath:     "", // Se podría obtener el path completo del exe
+			AppPath:     details.path,
 			X:           int(r.Left),
 			Y:           int(r.Top),
 			Width:       int(r.Right - r.Left),
 			Height:      int(r.Bottom - r.Top),
 			State:       w.getWindowState(hwnd),
-			LaunchArgs:  nil,
+			LaunchArgs:  details.args,
+			Pid:         int(pid),
 		}
 
-		wins = append(wins, win)
+		wins = append(wins, liveWindow{hwnd: hwnd, owner: syscall.Handle(owner), window: win})
 		return 1
 	})
 
 	procEnumWindows.Call(cb, 0)
-	return wins, nil
+	return wins
 }
 
 // RestoreWindow usa el matcher mejorado para encontrar y restaurar ventanas
@@ -109,79 +180,190 @@ func (w *WindowsAdapter) RestoreWindow(ctx context.Context, window core.Window)
 	if err != nil {
 		return fmt.Errorf("failed to get current windows: %w", err)
 	}
+	currentWindows = WithoutProtected(ctx, currentWindows)
 
-	// Usar el matcher para encontrar la mejor coincidencia
-	match := w.matcher.FindBestMatch(window, currentWindows)
-	if match == nil {
+	// Usar el matcher para encontrar las mejores coincidencias
+	matches := matcherFor(ctx, w.matcher).FindMatches(window, currentWindows, 2)
+	if len(matches) == 0 {
 		return fmt.Errorf("no suitable window found for: %s (app: %s)", window.WindowTitle, window.AppName)
 	}
+	match := matches[0]
 
-	log.Printf("[WindowRestore] Matched '%s' with '%s' (score: %d)",
-		window.WindowTitle, match.Window.WindowTitle, match.Score)
-
-	// Encontrar el HWND de la ventana matched
-	foundHwnd := w.findWindowHandle(match.Window.WindowTitle)
-	if foundHwnd == 0 {
-		return fmt.Errorf("window handle not found for: %s", match.Window.WindowTitle)
+	log.Printf("[WindowRestore] Matched '%s' with '%s' (score: %d, confidence: %.2f)",
+		window.WindowTitle, match.Window.WindowTitle, match.Score, match.Confidence)
+	if Ambiguous(matches) {
+		log.Printf("[WindowRestore] Ambiguous: runner-up '%s' (score: %d, confidence: %.2f)",
+			matches[1].Window.WindowTitle, matches[1].Score, matches[1].Confidence)
 	}
 
-	// Restaurar posición y tamaño
-	return w.setWindowPosition(foundHwnd, window)
+	return w.PositionWindow(ctx, match.Window, window)
 }
 
-// findWindowHandle busca el handle de una ventana por su título
-func (w *WindowsAdapter) findWindowHandle(title string) syscall.Handle {
-	var foundHwnd syscall.Handle
-
-	cb := syscall.NewCallback(func(hwnd syscall.Handle, lparam uintptr) uintptr {
-		ret, _, _ := procGetWindowTextLengthW.Call(uintptr(hwnd))
-		if int(ret) == 0 {
-			return 1
-		}
+// RestoreWindows enumera una sola vez, asigna las ventanas vivas uno a uno y
+// posiciona cada una por su HWND, así dos ventanas con el mismo título no
+// terminan en la misma ventana viva
+func (w *WindowsAdapter) RestoreWindows(ctx context.Context, windows []core.Window) ([]core.Window, []error, error) {
+	live := withoutProtected(ctx, w.enumWindows(), func(lw liveWindow) string { return lw.window.AppName })
+	current := windowsOf(live)
+	matcher := matcherFor(ctx, w.matcher)
+	assignment := matcher.AssignWindows(windows, current)
+	logAmbiguous(matcher, windows, current, assignment)
+	return positionAssigned(ctx, windows, current, assignment, func(i, j int) error {
+		return w.setWindowPosition(live[j].hwnd, windows[i])
+	})
+}
 
-		buf := make([]uint16, int(ret)+1)
-		procGetWindowTextW.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(int(ret)+1))
-		currentTitle := syscall.UTF16ToString(buf)
+// PositionWindow aplica la geometría de target a la ventana viva indicada
+func (w *WindowsAdapter) PositionWindow(ctx context.Context, live core.Window, target core.Window) error {
+	hwnd := w.findLiveHandle(live)
+	if hwnd == 0 {
+		return fmt.Errorf("window handle not found for: %s", live.WindowTitle)
+	}
+	return w.setWindowPosition(hwnd, target)
+}
 
-		if currentTitle == title {
-			foundHwnd = hwnd
-			return 0 // Stop enumeration
+// findLiveHandle resuelve el HWND de una ventana enumerada antes: mismo
+// proceso y mismo rect y, entre esas, el mismo título. Buscar solo por título
+// movía siempre la primera de dos ventanas iguales ("Command Prompt"). Si la
+// ventana se movió desde la enumeración se acepta el mismo título en el
+// mismo proceso
+func (w *WindowsAdapter) findLiveHandle(live core.Window) syscall.Handle {
+	var byRect, byTitle syscall.Handle
+	for _, lw := range w.enumWindows() {
+		win := lw.window
+		if live.Pid != 0 && win.Pid != live.Pid || live.Pid == 0 && win.AppName != live.AppName {
+			continue
 		}
-		return 1
-	})
-
-	procEnumWindows.Call(cb, 0)
-	return foundHwnd
+		sameRect := win.X == live.X && win.Y == live.Y && win.Width == live.Width && win.Height == live.Height
+		sameTitle := win.WindowTitle == live.WindowTitle
+		switch {
+		case sameRect && sameTitle:
+			return lw.hwnd
+		case sameRect && byRect == 0:
+			byRect = lw.hwnd
+		case sameTitle && byTitle == 0:
+			byTitle = lw.hwnd
+		}
+	}
+	if byRect != 0 {
+		return byRect
+	}
+	return byTitle
 }
 
-// setWindowPosition mueve y redimensiona una ventana
-func (w *WindowsAdapter) setWindowPosition(hwnd syscall.Handle, window core.Window) error {
-	// SWP_NOZORDER = 0x0004, SWP_NOACTIVATE = 0x0010
-	flags := uintptr(0x0004 | 0x0010)
+// windowText lee el título de una ventana ("" si no tiene o está bloqueado)
+func windowText(hwnd syscall.Handle) string {
+	ret, _, _ := procGetWindowTextLengthW.Call(uintptr(hwnd))
+	n := int(ret)
+	if n == 0 {
+		return ""
+	}
+
+	buf := make([]uint16, n+1)
+	procGetWindowTextW.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(n+1))
+	return syscall.UTF16ToString(buf)
+}
 
-	ret, _, err := procSetWindowPos.Call(
+// isCloaked indica si DWM oculta la ventana aunque sea "visible"
+func isCloaked(hwnd syscall.Handle) bool {
+	var cloaked uint32
+	ret, _, _ := procDwmGetWindowAttribute.Call(
 		uintptr(hwnd),
-		0,
-		uintptr(window.X),
-		uintptr(window.Y),
-		uintptr(window.Width),
-		uintptr(window.Height),
-		flags,
+		dwmwaCloaked,
+		uintptr(unsafe.Pointer(&cloaked)),
+		unsafe.Sizeof(cloaked),
 	)
+	return ret == 0 && cloaked != 0
+}
 
-	if ret == 0 {
-		return fmt.Errorf("SetWindowPos failed: %v", err)
+// setWindowPosition mueve y redimensiona una ventana y le devuelve su estado
+// (ver applyPlacement)
+func (w *WindowsAdapter) setWindowPosition(hwnd syscall.Handle, window core.Window) error {
+	return applyPlacement(window, placementCalls{
+		setPos: func(window core.Window) error {
+			// SWP_NOZORDER = 0x0004, SWP_NOACTIVATE = 0x0010
+			flags := uintptr(0x0004 | 0x0010)
+
+			ret, _, err := procSetWindowPos.Call(
+				uintptr(hwnd),
+				0,
+				uintptr(int32(window.X)),
+				uintptr(int32(window.Y)),
+				uintptr(int32(window.Width)),
+				uintptr(int32(window.Height)),
+				flags,
+			)
+			if ret == 0 {
+				return fmt.Errorf("SetWindowPos failed: %v", err)
+			}
+			return nil
+		},
+		show: func(cmd int) {
+			procShowWindow.Call(uintptr(hwnd), uintptr(cmd))
+		},
+		setMinimized: func(window core.Window) error {
+			return w.setMinimizedPlacement(hwnd, window)
+		},
+	})
+}
+
+// SaveFocus recuerda la ventana en primer plano. Windows solo deja devolver
+// el foco a un proceso con permiso de primer plano; si lo niega, el error lo dice
+func (w *WindowsAdapter) SaveFocus(ctx context.Context) (func(context.Context) error, error) {
+	hwnd, _, _ := procGetForegroundWindow.Call()
+	if hwnd == 0 {
+		return nil, fmt.Errorf("no window has the focus")
 	}
+	return func(ctx context.Context) error {
+		if ok, _, _ := procIsWindow.Call(hwnd); ok == 0 {
+			return fmt.Errorf("the previously focused window was closed")
+		}
+		if ok, _, _ := procSetForegroundWindow.Call(hwnd); ok == 0 {
+			return fmt.Errorf("Windows refused to give the focus back to the previous window")
+		}
+		return nil
+	}, nil
+}
 
-	// Restaurar estado si es necesario
-	if window.State == "maximized" {
-		procShowWindow.Call(uintptr(hwnd), 3) // SW_MAXIMIZE
-	} else if window.State == "minimized" {
-		procShowWindow.Call(uintptr(hwnd), 6) // SW_MINIMIZE
-	} else {
-		procShowWindow.Call(uintptr(hwnd), 1) // SW_SHOWNORMAL
+// windowPlacement es WINDOWPLACEMENT de user32
+type windowPlacement struct {
+	Length         uint32
+	Flags          uint32
+	ShowCmd        uint32
+	MinPosition    point
+	MaxPosition    point
+	NormalPosition rect
+}
+
+type point struct {
+	X int32
+	Y int32
+}
+
+// SW_SHOWMINNOACTIVE: minimizada sin activarla
+const swShowMinNoActive = 7
+
+// setMinimizedPlacement deja la ventana minimizada con su geometría restaurada
+// en target, sin mostrarla ni activarla. rcNormalPosition usa coordenadas de
+// área de trabajo, que coinciden con las de pantalla salvo con la barra de
+// tareas arriba o a la izquierda.
+func (w *WindowsAdapter) setMinimizedPlacement(hwnd syscall.Handle, window core.Window) error {
+	wp := windowPlacement{}
+	wp.Length = uint32(unsafe.Sizeof(wp))
+	if ret, _, err := procGetWindowPlacement.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&wp))); ret == 0 {
+		return fmt.Errorf("GetWindowPlacement failed: %v", err)
 	}
 
+	wp.ShowCmd = swShowMinNoActive
+	wp.NormalPosition = rect{
+		Left:   int32(window.X),
+		Top:    int32(window.Y),
+		Right:  int32(window.X + window.Width),
+		Bottom: int32(window.Y + window.Height),
+	}
+	if ret, _, err := procSetWindowPlacement.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&wp))); ret == 0 {
+		return fmt.Errorf("SetWindowPlacement failed: %v", err)
+	}
 	return nil
 }
 
@@ -228,7 +410,137 @@ func (w *WindowsAdapter) getProcessName(pid uint32) string {
 	return ""
 }
 
+// processDetails es lo que se puede leer de un proceso abriéndolo
+type processDetails struct {
+	path string          // Ruta completa del ejecutable
+	args json.RawMessage // Argumentos de la línea de comandos (sin argv[0]) como array JSON
+}
+
+// getProcessDetails lee la ruta del ejecutable y la línea de comandos de un
+// proceso. Los procesos que no se pueden abrir (elevados, protegidos, del
+// sistema) dejan los campos vacíos en vez de fallar
+func getProcessDetails(pid uint32) processDetails {
+	var d processDetails
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return d
+	}
+	defer windows.CloseHandle(h)
+
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(h, 0, &buf[0], &size); err == nil {
+		d.path = windows.UTF16ToString(buf[:size])
+	}
+
+	if args := processArgs(h); len(args) > 0 {
+		d.args, _ = json.Marshal(args)
+	}
+	return d
+}
+
+// processArgs separa la línea de comandos en argumentos, descartando el
+// ejecutable
+func processArgs(h windows.Handle) []string {
+	cmdLine := processCommandLine(h)
+	if cmdLine == "" {
+		return nil
+	}
+	args, err := windows.DecomposeCommandLine(cmdLine)
+	if err != nil || len(args) < 2 {
+		return nil
+	}
+	return args[1:]
+}
+
+// processCommandLine lee la línea de comandos completa con
+// ProcessCommandLineInformation (Windows 8.1+)
+func processCommandLine(h windows.Handle) string {
+	var size uint32
+	windows.NtQueryInformationProcess(h, windows.ProcessCommandLineInformation, nil, 0, &size)
+	if size < uint32(unsafe.Sizeof(windows.NTUnicodeString{})) {
+		return ""
+	}
+	// []uintptr garantiza la alineación del NTUnicodeString al inicio del buffer
+	buf := make([]uintptr, (size+uint32(unsafe.Sizeof(uintptr(0)))-1)/uint32(unsafe.Sizeof(uintptr(0))))
+	if err := windows.NtQueryInformationProcess(h, windows.ProcessCommandLineInformation, unsafe.Pointer(&buf[0]), size, &size); err != nil {
+		return ""
+	}
+	return (*windows.NTUnicodeString)(unsafe.Pointer(&buf[0])).String()
+}
+
+// processCwd lee el directorio de trabajo de otro proceso desde su PEB
+// (requiere PROCESS_VM_READ). Las direcciones del otro proceso se leen como
+// uintptr, nunca en campos puntero de Go
+func processCwd(h windows.Handle) string {
+	const word = unsafe.Sizeof(uintptr(0))
+	var pbi [unsafe.Sizeof(windows.PROCESS_BASIC_INFORMATION{}) / word]uintptr
+	if err := windows.NtQueryInformationProcess(h, windows.ProcessBasicInformation, unsafe.Pointer(&pbi[0]), uint32(unsafe.Sizeof(pbi)), nil); err != nil {
+		return ""
+	}
+	peb := pbi[unsafe.Offsetof(windows.PROCESS_BASIC_INFORMATION{}.PebBaseAddress)/word]
+	params, ok := readRemotePointer(h, peb+unsafe.Offsetof(windows.PEB{}.ProcessParameters))
+	if !ok {
+		return ""
+	}
+	// DosPath es el primer campo de CurrentDirectory
+	dosPath := params + unsafe.Offsetof(windows.RTL_USER_PROCESS_PARAMETERS{}.CurrentDirectory)
+	var length uint16
+	if !readRemote(h, dosPath, unsafe.Pointer(&length), unsafe.Sizeof(length)) || length < 2 {
+		return ""
+	}
+	buffer, ok := readRemotePointer(h, dosPath+unsafe.Offsetof(windows.NTUnicodeString{}.Buffer))
+	if !ok {
+		return ""
+	}
+	chars := make([]uint16, length/2)
+	if !readRemote(h, buffer, unsafe.Pointer(&chars[0]), uintptr(len(chars))*2) {
+		return ""
+	}
+	dir := windows.UTF16ToString(chars)
+	if len(dir) > 3 { // C:\ conserva su barra
+		dir = strings.TrimSuffix(dir, `\`)
+	}
+	return dir
+}
+
+// readRemote copia size bytes de la dirección addr del proceso h a dst
+func readRemote(h windows.Handle, addr uintptr, dst unsafe.Pointer, size uintptr) bool {
+	var n uintptr
+	return windows.ReadProcessMemory(h, addr, (*byte)(dst), size, &n) == nil && n == size
+}
+
+// readRemotePointer lee un puntero (no nulo) del proceso h
+func readRemotePointer(h windows.Handle, addr uintptr) (uintptr, bool) {
+	var p uintptr
+	ok := readRemote(h, addr, unsafe.Pointer(&p), unsafe.Sizeof(p))
+	return p, ok && p != 0
+}
+
 // Implementación de métodos restantes (sin cambios significativos)
+// Capabilities declara lo que el adapter de Windows soporta. Las URLs de
+// Firefox se leen de su sessionstore y las de Chromium solo por DevTools; sin
+// él se ven los títulos de ventana
+func (w *WindowsAdapter) Capabilities() core.Capabilities {
+	return core.Capabilities{
+		CanReadWindows:         true,
+		CanPositionWindows:     true,
+		CanLaunchApps:          true,
+		CanReadCommandLines:    true,
+		CanReadMonitors:        true,
+		CanReadTerminals:       true,
+		CanReadTerminalLayouts: true,
+		CanRestoreTerminals:    true,
+		CanReadTabTitles:       true,
+		CanReadTabURLs:         true,
+		CanDeepCaptureTabs:     true,
+		CanOpenURLs:            true,
+		CanReadIDEFiles:        true,
+		CanReadProcesses:       true,
+		CanStartProcesses:      true,
+	}
+}
+
 func (w *WindowsAdapter) CloseWindow(ctx context.Context, window core.Window) error {
 	return nil // No implementado por seguridad
 }
@@ -239,26 +551,120 @@ func (w *WindowsAdapter) GetTerminals(ctx context.Context) ([]core.Terminal, err
 		return nil, err
 	}
 
+	// Layouts persistidos de Windows Terminal, asignados a sus ventanas en orden
+	wtLayouts := loadWTLayouts()
+	wtWindows := 0
+
 	var terminals []core.Terminal
 	for _, win := range windowsList {
 		if isTerminal(win.AppName) {
-			terminals = append(terminals, core.Terminal{
+			t := core.Terminal{
 				TerminalApp:      win.AppName,
 				ActiveCommand:    win.WindowTitle,
 				WorkingDirectory: "",
 				ShellType:        guessShell(win.AppName),
-			})
+			}
+			if win.AppName == "WindowsTerminal.exe" {
+				if wtWindows < len(wtLayouts) {
+					layout := wtLayouts[wtWindows]
+					t.Layout = &layout
+					if dir := layout.Tabs[0].Panes[0].Directory; dir != "" {
+						t.WorkingDirectory = dir
+					}
+				}
+				wtWindows++
+			} else if win.Pid > 0 {
+				// Las ventanas de consola reportan el PID del shell que las abrió
+				_, t.WorkingDirectory = processLaunchInfo(uint32(win.Pid))
+			}
+			terminals = append(terminals, t)
 		}
 	}
 	return terminals, nil
 }
 
+// RestoreTerminal abre la terminal grabada en su directorio de trabajo
 func (w *WindowsAdapter) RestoreTerminal(ctx context.Context, terminal core.Terminal) error {
-	return nil // No implementado
+	dir := terminal.WorkingDirectory
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("no working directory and no home directory: %w", err)
+		}
+		dir = home
+	}
+
+	launch, err := TerminalCommand(terminal, dir)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(launch.Path, launch.Args...)
+	if launch.CmdLine != "" {
+		cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: launch.CmdLine}
+	}
+	cmd.Dir = dir
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", launch.Path, err)
+	}
+	// No esperamos a la terminal: queda abierta para el usuario
+	return cmd.Process.Release()
+}
+
+// OpenURL abre la URL en el navegador indicado, o en el predeterminado del sistema
+func (w *WindowsAdapter) OpenURL(ctx context.Context, rawURL string, browser string) error {
+	// ShellExecute ejecutaría cualquier ruta local: solo aceptamos URLs web
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("refusing to open non-web URL: %s", rawURL)
+	}
+
+	verb, _ := windows.UTF16PtrFromString("open")
+	if exe := browserExecutable(browser); exe != "" {
+		// ShellExecute resuelve chrome.exe/msedge.exe/firefox.exe vía App Paths
+		file, _ := windows.UTF16PtrFromString(exe)
+		args, _ := windows.UTF16PtrFromString(windows.EscapeArg(rawURL))
+		return windows.ShellExecute(0, verb, file, args, nil, windows.SW_SHOWNORMAL)
+	}
+
+	file, _ := windows.UTF16PtrFromString(rawURL)
+	return windows.ShellExecute(0, verb, file, nil, nil, windows.SW_SHOWNORMAL)
 }
 
-func (w *WindowsAdapter) OpenURL(ctx context.Context, url string, browser string) error {
-	return nil // No implementado
+// OpenBrowserWindow abre las URLs como pestañas de una ventana nueva del
+// navegador indicado. Ningún navegador tiene un flag para fijar pestañas
+func (w *WindowsAdapter) OpenBrowserWindow(ctx context.Context, browser string, urls []string) error {
+	exe := browserExecutable(browser)
+	if exe == "" {
+		return fmt.Errorf("no command line to open a window of %q: %w", browser, errors.ErrUnsupported)
+	}
+	args, err := newWindowArgs(exe == "firefox.exe", urls)
+	if err != nil {
+		return err
+	}
+	for i, a := range args {
+		args[i] = windows.EscapeArg(a)
+	}
+
+	verb, _ := windows.UTF16PtrFromString("open")
+	file, _ := windows.UTF16PtrFromString(exe)
+	params, _ := windows.UTF16PtrFromString(strings.Join(args, " "))
+	return windows.ShellExecute(0, verb, file, params, nil, windows.SW_SHOWNORMAL)
+}
+
+// browserExecutable normaliza el navegador grabado; "" = predeterminado
+func browserExecutable(browser string) string {
+	switch strings.ToLower(browser) {
+	case "chrome.exe", "chrome":
+		return "chrome.exe"
+	case "msedge.exe", "msedge", "edge":
+		return "msedge.exe"
+	case "firefox.exe", "firefox":
+		return "firefox.exe"
+	case "brave.exe", "brave":
+		return "brave.exe"
+	}
+	return ""
 }
 
 func (w *WindowsAdapter) GetBrowserTabs(ctx context.Context) ([]core.BrowserTab, error) {
@@ -278,6 +684,29 @@ func (w *WindowsAdapter) GetBrowserTabs(ctx context.Context) ([]core.BrowserTab,
 			})
 		}
 	}
+	return withFirefoxSession(ctx, tabs, "firefox.exe"), nil
+}
+
+// GetBrowserTabsDeep lee las URLs reales vía DevTools; si ningún navegador
+// expone el puerto de depuración vuelve a la captura por títulos. DevTools
+// solo ve navegadores Chromium: Firefox sale siempre de su sessionstore
+func (w *WindowsAdapter) GetBrowserTabsDeep(ctx context.Context) ([]core.BrowserTab, error) {
+	base, err := w.GetBrowserTabs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tabs, err := browser.NewCDPCollector().CollectTabs(ctx)
+	if err != nil || len(tabs) == 0 {
+		if err != nil {
+			log.Printf("[BrowserCapture] DevTools not available, using window titles: %v", err)
+		}
+		return base, nil
+	}
+	for _, t := range base {
+		if t.BrowserName == "firefox.exe" {
+			tabs = append(tabs, t)
+		}
+	}
 	return tabs, nil
 }
 
@@ -300,12 +729,81 @@ func (w *WindowsAdapter) GetIDEFiles(ctx context.Context) ([]core.IDEFile, error
 	return files, nil
 }
 
+// GetProcesses lista los procesos de desarrollo (ver isDevProcess) con
+// Toolhelp32, con su línea de comandos y su directorio de trabajo. Los que no
+// se pueden abrir (elevados, de otro usuario) quedan sin esos datos
 func (w *WindowsAdapter) GetProcesses(ctx context.Context) ([]core.Process, error) {
-	return []core.Process{}, nil
+	snap, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate processes: %w", err)
+	}
+	defer windows.CloseHandle(snap)
+
+	self := uint32(os.Getpid())
+	processes := []core.Process{}
+	entry := windows.ProcessEntry32{Size: uint32(unsafe.Sizeof(windows.ProcessEntry32{}))}
+	for err = windows.Process32First(snap, &entry); err == nil; err = windows.Process32Next(snap, &entry) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		name := windows.UTF16ToString(entry.ExeFile[:])
+		if entry.ProcessID == self || !isDevProcess(name) {
+			continue
+		}
+		p := core.Process{ProcessName: name, Pid: int(entry.ProcessID)}
+		p.Command, p.WorkingDirectory = processLaunchInfo(entry.ProcessID)
+		processes = append(processes, p)
+	}
+	if !errors.Is(err, windows.ERROR_NO_MORE_FILES) {
+		return nil, fmt.Errorf("failed to enumerate processes: %w", err)
+	}
+	return processes, nil
+}
+
+// processLaunchInfo lee la línea de comandos y el directorio de trabajo de
+// un proceso. Sin PROCESS_VM_READ solo se obtiene la línea de comandos
+func processLaunchInfo(pid uint32) (cmdLine, dir string) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION|windows.PROCESS_VM_READ, false, pid)
+	if err != nil {
+		if h, err = windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid); err != nil {
+			return "", ""
+		}
+		defer windows.CloseHandle(h)
+		return processCommandLine(h), ""
+	}
+	defer windows.CloseHandle(h)
+	return processCommandLine(h), processCwd(h)
 }
 
+// StartProcess relanza un proceso en background con su línea de comandos original
 func (w *WindowsAdapter) StartProcess(ctx context.Context, process core.Process) error {
-	return nil
+	if process.Command == "" {
+		return fmt.Errorf("no command recorded for %s", process.ProcessName)
+	}
+
+	// La línea grabada ya viene con su propio quoting: se pasa tal cual
+	cmd := exec.Command(process.ProcessName)
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: process.Command}
+	if process.WorkingDirectory != "" {
+		cmd.Dir = process.WorkingDirectory
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", process.ProcessName, err)
+	}
+	return cmd.Process.Release()
+}
+
+// LaunchApp arranca el ejecutable grabado de la ventana con sus argumentos
+func (w *WindowsAdapter) LaunchApp(ctx context.Context, window core.Window) (int, error) {
+	if window.AppPath == "" {
+		return 0, fmt.Errorf("no executable path recorded for %s", window.AppName)
+	}
+	cmd := exec.Command(window.AppPath, LaunchArgList(window)...)
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to launch %s: %w", window.AppPath, err)
+	}
+	pid := cmd.Process.Pid
+	return pid, cmd.Process.Release()
 }
 
 // Classification Helpers
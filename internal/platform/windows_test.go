@@ -0,0 +1,33 @@
+//go:build windows
+
+package platform
+
+import (
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+func TestWindowsCapabilities(t *testing.T) {
+	caps := NewWindowsAdapter().Capabilities()
+	want := core.Capabilities{
+		CanReadWindows:         true,
+		CanPositionWindows:     true,
+		CanLaunchApps:          true,
+		CanReadCommandLines:    true,
+		CanReadMonitors:        true,
+		CanReadTerminals:       true,
+		CanReadTerminalLayouts: true,
+		CanRestoreTerminals:    true,
+		CanReadTabTitles:       true,
+		CanReadTabURLs:         true,
+		CanDeepCaptureTabs:     true,
+		CanOpenURLs:            true,
+		CanReadIDEFiles:        true,
+		CanReadProcesses:       true,
+		CanStartProcesses:      true,
+	}
+	if caps != want {
+		t.Errorf("windows capabilities = %+v, want %+v", caps, want)
+	}
+}
@@ -0,0 +1,144 @@
+package platform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// wtState es la parte de state.json de Windows Terminal que nos interesa.
+// Solo existe cuando el usuario activó "firstWindowPreference": "persistedWindowLayouts"
+type wtState struct {
+	PersistedWindowLayouts []struct {
+		TabLayout []wtAction `json:"tabLayout"`
+	} `json:"persistedWindowLayouts"`
+}
+
+type wtAction struct {
+	Action            string  `json:"action"`
+	StartingDirectory string  `json:"startingDirectory"`
+	Profile           string  `json:"profile"`
+	TabTitle          string  `json:"tabTitle"`
+	Split             string  `json:"split"`
+	Size              float64 `json:"size"`
+}
+
+// wtStatePath retorna la ruta de state.json de Windows Terminal (instalación Store)
+func wtStatePath() string {
+	local := os.Getenv("LOCALAPPDATA")
+	if local == "" {
+		return ""
+	}
+	return filepath.Join(local, "Packages", "Microsoft.WindowsTerminal_8wekyb3d8bbwe", "LocalState", "state.json")
+}
+
+// loadWTLayouts lee las ventanas persistidas de Windows Terminal; nil si no hay
+func loadWTLayouts() []core.TerminalLayout {
+	path := wtStatePath()
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	layouts, err := ParseWTState(data)
+	if err != nil {
+		return nil
+	}
+	return layouts
+}
+
+// ParseWTState convierte el state.json de Windows Terminal en un layout por ventana
+func ParseWTState(data []byte) ([]core.TerminalLayout, error) {
+	var state wtState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid Windows Terminal state: %w", err)
+	}
+
+	var layouts []core.TerminalLayout
+	for _, window := range state.PersistedWindowLayouts {
+		var layout core.TerminalLayout
+		for _, a := range window.TabLayout {
+			pane := core.TerminalPane{Directory: a.StartingDirectory, Profile: a.Profile}
+			switch a.Action {
+			case "newTab":
+				layout.Tabs = append(layout.Tabs, core.TerminalTab{Title: a.TabTitle, Panes: []core.TerminalPane{pane}})
+			case "splitPane":
+				if len(layout.Tabs) == 0 {
+					continue
+				}
+				pane.Split = wtSplitDirection(a.Split)
+				pane.Size = a.Size
+				tab := &layout.Tabs[len(layout.Tabs)-1]
+				tab.Panes = append(tab.Panes, pane)
+			}
+		}
+		if len(layout.Tabs) > 0 {
+			layouts = append(layouts, layout)
+		}
+	}
+	return layouts, nil
+}
+
+// wtSplitDirection traduce la dirección de state.json a la de split-pane
+func wtSplitDirection(split string) string {
+	switch split {
+	case "right", "left", "vertical":
+		return "vertical"
+	case "down", "up", "horizontal":
+		return "horizontal"
+	}
+	return ""
+}
+
+// WTLayoutArgs arma los argumentos de wt.exe que reconstruyen un layout:
+// new-tab por pestaña y split-pane por panel extra, separados por ";"
+func WTLayoutArgs(layout core.TerminalLayout, fallbackDir string) []string {
+	var args []string
+	for _, tab := range layout.Tabs {
+		for i, pane := range tab.Panes {
+			if len(args) > 0 {
+				args = append(args, ";")
+			}
+			if i == 0 {
+				args = append(args, "new-tab")
+				if tab.Title != "" {
+					args = append(args, "--title", wtEscape(tab.Title))
+				}
+			} else {
+				args = append(args, "split-pane")
+				switch pane.Split {
+				case "vertical":
+					args = append(args, "-V")
+				case "horizontal":
+					args = append(args, "-H")
+				}
+				if pane.Size > 0 && pane.Size < 1 {
+					args = append(args, "--size", fmt.Sprintf("%.2f", pane.Size))
+				}
+			}
+			if pane.Profile != "" {
+				args = append(args, "-p", wtEscape(pane.Profile))
+			}
+			dir := pane.Directory
+			if dir == "" {
+				dir = fallbackDir
+			}
+			if dir != "" {
+				args = append(args, "-d", wtEscape(dir))
+			}
+		}
+	}
+	return args
+}
+
+// wtEscape escapa ';', que wt interpreta como separador de subcomandos. Se
+// aplica a todo valor que viene del snapshot: títulos, perfiles y directorios
+func wtEscape(s string) string {
+	return strings.ReplaceAll(s, ";", `\;`)
+}
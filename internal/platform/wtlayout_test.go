@@ -0,0 +1,114 @@
+package platform
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+func TestWTLayoutArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		layout   core.TerminalLayout
+		fallback string
+		want     string
+	}{
+		{
+			name:   "empty layout",
+			layout: core.TerminalLayout{},
+			want:   "",
+		},
+		{
+			name:     "single tab uses the fallback directory",
+			layout:   core.TerminalLayout{Tabs: []core.TerminalTab{{Panes: []core.TerminalPane{{}}}}},
+			fallback: `C:\src`,
+			want:     `new-tab -d C:\src`,
+		},
+		{
+			name: "splits, sizes and profiles",
+			layout: core.TerminalLayout{Tabs: []core.TerminalTab{{
+				Title: "api",
+				Panes: []core.TerminalPane{
+					{Directory: `C:\src\api`, Profile: "PowerShell"},
+					{Directory: `C:\src\web`, Split: "vertical", Size: 0.3},
+					{Split: "horizontal", Size: 1},
+				},
+			}}},
+			fallback: `C:\home`,
+			want:     `new-tab --title api -p PowerShell -d C:\src\api ; split-pane -V --size 0.30 -d C:\src\web ; split-pane -H -d C:\home`,
+		},
+		{
+			name: "several tabs, semicolons in directories escaped",
+			layout: core.TerminalLayout{Tabs: []core.TerminalTab{
+				{Panes: []core.TerminalPane{{Directory: `C:\a;b`}}},
+				{Title: "logs", Panes: []core.TerminalPane{{Directory: `C:\logs`}, {Profile: "Ubuntu"}}},
+			}},
+			want: `new-tab -d C:\a\;b ; new-tab --title logs -d C:\logs ; split-pane -p Ubuntu`,
+		},
+		{
+			// Un título con ";" no puede colar un subcomando de wt
+			name: "semicolons in titles and profiles escaped",
+			layout: core.TerminalLayout{Tabs: []core.TerminalTab{{
+				Title: "x ; new-tab cmd /c calc",
+				Panes: []core.TerminalPane{{Directory: `C:\src`, Profile: "Dev;Profile"}, {Profile: "a ; b"}},
+			}}},
+			want: `new-tab --title x \; new-tab cmd /c calc -p Dev\;Profile -d C:\src ; split-pane -p a \; b`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := WTLayoutArgs(tt.layout, tt.fallback)
+			if got := strings.Join(args, " "); got != tt.want {
+				t.Errorf("WTLayoutArgs =\n  %s\nwant\n  %s", got, tt.want)
+			}
+			// Los únicos ";" sin escapar son los separadores que agrega WTLayoutArgs
+			separators := 0
+			for _, arg := range args {
+				if arg == ";" {
+					separators++
+				} else if strings.Contains(strings.ReplaceAll(arg, `\;`, ""), ";") {
+					t.Errorf("unescaped separator in argument %q", arg)
+				}
+			}
+			panes := 0
+			for _, tab := range tt.layout.Tabs {
+				panes += len(tab.Panes)
+			}
+			if want := max(panes-1, 0); separators != want {
+				t.Errorf("%d separators, want %d (one between panes)", separators, want)
+			}
+		})
+	}
+}
+
+func TestParseWTState(t *testing.T) {
+	state := `{"persistedWindowLayouts": [
+		{"tabLayout": [
+			{"action": "newTab", "startingDirectory": "C:\\src", "tabTitle": "dev", "profile": "PowerShell"},
+			{"action": "splitPane", "split": "right", "size": 0.5, "startingDirectory": "C:\\src\\web"},
+			{"action": "newTab"},
+			{"action": "splitPane", "split": "down"}
+		]},
+		{"tabLayout": [{"action": "splitPane"}]}
+	]}`
+	layouts, err := ParseWTState([]byte(state))
+	if err != nil {
+		t.Fatalf("ParseWTState: %v", err)
+	}
+	want := []core.TerminalLayout{{Tabs: []core.TerminalTab{
+		{Title: "dev", Panes: []core.TerminalPane{
+			{Directory: `C:\src`, Profile: "PowerShell"},
+			{Directory: `C:\src\web`, Split: "vertical", Size: 0.5},
+		}},
+		{Panes: []core.TerminalPane{{}, {Split: "horizontal"}}},
+	}}}
+	if !reflect.DeepEqual(layouts, want) {
+		t.Errorf("layouts = %+v\nwant %+v", layouts, want)
+	}
+
+	if _, err := ParseWTState([]byte("{")); err == nil {
+		t.Error("invalid state.json parsed without error")
+	}
+}
@@ -0,0 +1,257 @@
+// Package rpc implementa el lado cliente de JSON-RPC 2.0 sobre un stream
+// (stdin/stdout de un proceso) para los clientes de prueba del servidor.
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Version es el valor del campo jsonrpc de todos los mensajes
+const Version = "2.0"
+
+// Request es una llamada con ID que espera respuesta
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int             `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Notification es un mensaje sin ID, que no tiene respuesta
+type Notification struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response es lo que llega del servidor: una respuesta, o una notificación
+// si Method no está vacío
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int            `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError es el objeto error de JSON-RPC
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// CallToolParams son los parámetros de tools/call
+type CallToolParams struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// Framing indica cómo se delimitan los mensajes en el stream
+type Framing int
+
+const (
+	// LineFraming: un objeto JSON por línea (stdio de MCP)
+	LineFraming Framing = iota
+	// ContentLengthFraming: cabeceras "Content-Length: N" y una línea en
+	// blanco antes de cada cuerpo, como en LSP
+	ContentLengthFraming
+	// AutoFraming detecta el framing de cada mensaje leído: cabeceras si
+	// empieza con "Content-Length:", una línea de JSON si no. Escribe con
+	// LineFraming
+	AutoFraming
+)
+
+const contentLengthHeader = "content-length:"
+
+// WriteMessage serializa v y lo escribe con el framing indicado
+func WriteMessage(w io.Writer, framing Framing, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	switch framing {
+	case ContentLengthFraming:
+		if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+			return err
+		}
+		_, err = w.Write(body)
+		return err
+	default:
+		_, err = w.Write(append(body, '\n'))
+		return err
+	}
+}
+
+// ReadMessage lee el cuerpo del próximo mensaje. Con LineFraming y
+// AutoFraming se saltean las líneas en blanco
+func ReadMessage(r *bufio.Reader, framing Framing) ([]byte, error) {
+	switch framing {
+	case ContentLengthFraming:
+		return readContentLength(r)
+	case AutoFraming:
+		hasHeader, err := startsWithContentLength(r)
+		if err != nil {
+			return nil, err
+		}
+		if hasHeader {
+			return readContentLength(r)
+		}
+	}
+	return readLine(r)
+}
+
+// startsWithContentLength saltea el espacio en blanco inicial y mira, sin
+// consumirlo, si lo que sigue es una cabecera Content-Length
+func startsWithContentLength(r *bufio.Reader) (bool, error) {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return false, err
+		}
+		if !unicode.IsSpace(rune(b[0])) {
+			break
+		}
+		r.ReadByte()
+	}
+	prefix, _ := r.Peek(len(contentLengthHeader))
+	return strings.EqualFold(string(prefix), contentLengthHeader), nil
+}
+
+func readLine(r *bufio.Reader) ([]byte, error) {
+	for {
+		line, err := r.ReadBytes('\n')
+		trimmed := strings.TrimSpace(string(line))
+		if trimmed != "" {
+			return []byte(trimmed), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func readContentLength(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("malformed message header: %w", err)
+	}
+	value := header.Get("Content-Length")
+	if value == "" {
+		return nil, fmt.Errorf("message without Content-Length header")
+	}
+	length, err := strconv.Atoi(value)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", value)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("truncated message body: %w", err)
+	}
+	return body, nil
+}
+
+// Conn es una conexión cliente: escribe requests y lee respuestas de un
+// mismo stream con el framing elegido
+type Conn struct {
+	framing Framing
+	r       *bufio.Reader
+
+	mu     sync.Mutex // Serializa las escrituras
+	w      io.Writer
+	nextID int
+}
+
+// NewConn crea una conexión sobre r (salida del servidor) y w (su entrada)
+func NewConn(r io.Reader, w io.Writer, framing Framing) *Conn {
+	return &Conn{framing: framing, r: bufio.NewReader(r), w: w, nextID: 1}
+}
+
+// Send escribe una request con el ID dado
+func (c *Conn) Send(id int, method string, params interface{}) error {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return WriteMessage(c.w, c.framing, Request{JSONRPC: Version, ID: id, Method: method, Params: raw})
+}
+
+// Notify escribe una notificación
+func (c *Conn) Notify(method string, params interface{}) error {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return WriteMessage(c.w, c.framing, Notification{JSONRPC: Version, Method: method, Params: raw})
+}
+
+// Read lee el próximo mensaje del servidor, sea respuesta o notificación
+func (c *Conn) Read() (*Response, []byte, error) {
+	body, err := ReadMessage(c.r, c.framing)
+	if err != nil {
+		return nil, nil, err
+	}
+	var resp Response
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, body, fmt.Errorf("malformed message: %w", err)
+	}
+	return &resp, body, nil
+}
+
+// Call envía una request con el próximo ID y espera su respuesta, descartando
+// las notificaciones que lleguen antes. Retorna también el mensaje crudo
+func (c *Conn) Call(method string, params interface{}) (*Response, []byte, error) {
+	c.mu.Lock()
+	id := c.nextID
+	c.nextID++
+	c.mu.Unlock()
+
+	if err := c.Send(id, method, params); err != nil {
+		return nil, nil, err
+	}
+	for {
+		resp, body, err := c.Read()
+		if err != nil {
+			return nil, body, err
+		}
+		if resp.ID != nil && *resp.ID == id {
+			return resp, body, nil
+		}
+	}
+}
+
+// CallTool invoca una tool con tools/call
+func (c *Conn) CallTool(name string, args map[string]interface{}) (*Response, []byte, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	return c.Call("tools/call", CallToolParams{Name: name, Arguments: args})
+}
+
+func marshalParams(params interface{}) (json.RawMessage, error) {
+	if params == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode params: %w", err)
+	}
+	return raw, nil
+}
@@ -0,0 +1,124 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	id := 7
+	messages := []interface{}{
+		Request{JSONRPC: Version, ID: 1, Method: "tools/call", Params: json.RawMessage(`{"name":"list_snapshots","arguments":{}}`)},
+		Notification{JSONRPC: Version, Method: "notifications/cancelled", Params: json.RawMessage(`{"requestId":1}`)},
+		Response{JSONRPC: Version, ID: &id, Result: json.RawMessage(`{"text":"línea 1\nlínea 2 ✓"}`)},
+		Response{JSONRPC: Version, ID: &id, Error: &RPCError{Code: -32601, Message: "method not found"}},
+	}
+	for _, framing := range []struct {
+		name    string
+		framing Framing
+	}{
+		{"line", LineFraming},
+		{"content-length", ContentLengthFraming},
+	} {
+		t.Run(framing.name, func(t *testing.T) {
+			var stream bytes.Buffer
+			for _, m := range messages {
+				if err := WriteMessage(&stream, framing.framing, m); err != nil {
+					t.Fatalf("WriteMessage: %v", err)
+				}
+			}
+			r := bufio.NewReader(&stream)
+			for i, m := range messages {
+				want, _ := json.Marshal(m)
+				got, err := ReadMessage(r, framing.framing)
+				if err != nil {
+					t.Fatalf("message %d: %v", i, err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("message %d =\n  %s\nwant\n  %s", i, got, want)
+				}
+			}
+			if _, err := ReadMessage(r, framing.framing); !errors.Is(err, io.EOF) {
+				t.Errorf("read past the last message = %v, want EOF", err)
+			}
+		})
+	}
+}
+
+func TestReadContentLengthErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		stream  string
+		wantErr string
+	}{
+		{"truncated body", "Content-Length: 40\r\n\r\n{\"jsonrpc\":\"2.0\"}", "truncated message body"},
+		{"no body", "Content-Length: 10\r\n\r\n", "truncated message body"},
+		{"missing header", "Content-Type: application/json\r\n\r\n{}", "without Content-Length"},
+		{"negative length", "Content-Length: -1\r\n\r\n{}", "invalid Content-Length"},
+		{"not a number", "Content-Length: ten\r\n\r\n{}", "invalid Content-Length"},
+		{"headers cut short", "Content-Length: 2\r\n", "malformed message header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Por un pipe, como el stdout de un servidor que se cerró a mitad de
+			// mensaje: la lectura tiene que fallar, no quedarse esperando
+			pr, pw := io.Pipe()
+			go func() {
+				io.WriteString(pw, tt.stream)
+				pw.Close()
+			}()
+			done := make(chan error, 1)
+			go func() {
+				_, err := ReadMessage(bufio.NewReader(pr), ContentLengthFraming)
+				done <- err
+			}()
+			select {
+			case err := <-done:
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ReadMessage = %v, want an error with %q", err, tt.wantErr)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("ReadMessage hung on a short message")
+			}
+		})
+	}
+}
+
+func TestAutoFramingMixedStream(t *testing.T) {
+	header := `{"jsonrpc":"2.0","id":1,"result":{"text":"a\nb"}}`
+	line := `{"jsonrpc":"2.0","method":"notifications/progress","params":{"progress":1}}`
+	// Un cuerpo que lleva "Content-Length:" adentro: con cabecera (en
+	// minúsculas) y después como línea, donde no se confunde con una cabecera
+	tricky := `{"jsonrpc":"2.0","id":2,"result":{"text":"Content-Length: 5"}}`
+
+	var stream bytes.Buffer
+	stream.WriteString("\r\n\n")
+	WriteMessage(&stream, ContentLengthFraming, json.RawMessage(header))
+	stream.WriteString("\n\n   \n")
+	WriteMessage(&stream, LineFraming, json.RawMessage(line))
+	stream.WriteString("\n")
+	stream.WriteString("content-length: " + strconv.Itoa(len(tricky)) + "\r\n\r\n" + tricky)
+	WriteMessage(&stream, LineFraming, json.RawMessage(tricky))
+	stream.WriteString("\n\n")
+
+	r := bufio.NewReader(&stream)
+	for i, want := range []string{header, line, tricky, tricky} {
+		got, err := ReadMessage(r, AutoFraming)
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		if string(got) != want {
+			t.Errorf("message %d =\n  %s\nwant\n  %s", i, got, want)
+		}
+	}
+	if _, err := ReadMessage(r, AutoFraming); !errors.Is(err, io.EOF) {
+		t.Errorf("read past the last message = %v, want EOF", err)
+	}
+}
@@ -1,11 +1,13 @@
 package sanitize
 
 import (
+	"encoding/json"
 	"net/url"
 	"regexp"
 	"strings"
 
 	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/paths"
 )
 
 // SanitizationOptions configura qué datos sanitizar
@@ -88,7 +90,7 @@ func (s *Sanitizer) maskSensitiveURL(rawURL string) string {
 
 	for _, param := range sensitiveParams {
 		if query.Has(param) {
-			query.Set(param, "***REDACTED***")
+			query.Set(param, redacted)
 		}
 	}
 
@@ -114,11 +116,11 @@ func (s *Sanitizer) sanitizeTerminals(terminals []core.Terminal) {
 			for key := range terminals[i].EnvVars {
 				// Case-insensitive matching
 				if strings.EqualFold(key, sensitiveKey) {
-					terminals[i].EnvVars[key] = "***REDACTED***"
+					terminals[i].EnvVars[key] = redacted
 				}
 				// También busca keys que contengan las palabras sensibles
 				if containsInsensitive(key, sensitiveKey) {
-					terminals[i].EnvVars[key] = "***REDACTED***"
+					terminals[i].EnvVars[key] = redacted
 				}
 			}
 		}
@@ -154,37 +156,101 @@ func (s *Sanitizer) maskSensitiveTitle(title string) string {
 	return result
 }
 
+// userPattern detecta el username en rutas de usuario comunes
+var userPattern = regexp.MustCompile(`(?i)(C:\\Users\\|/home/|/Users/)([^\\\/]+)`)
+
 // sanitizePaths oculta rutas de usuario
 func (s *Sanitizer) sanitizePaths(snap *core.Snapshot) {
-	// Detectar username común en rutas
-	userPattern := regexp.MustCompile(`(?i)(C:\\Users\\|/home/|/Users/)([^\\\/]+)`)
+	// Normalizar antes del regex para que C:/Users, \\?\C:\Users y
+	// /mnt/c/Users se enmascaren igual que C:\Users
+	mask := func(p string) string {
+		if win, ok := paths.WSLToWindows(p); ok {
+			p = win
+		}
+		return userPattern.ReplaceAllString(paths.Clean(p, paths.Auto), "${1}"+UserMask)
+	}
 
 	// Sanitizar rutas en ventanas
 	for i := range snap.Windows {
-		snap.Windows[i].AppPath = userPattern.ReplaceAllString(
-			snap.Windows[i].AppPath,
-			"${1}***USER***",
-		)
+		snap.Windows[i].AppPath = mask(snap.Windows[i].AppPath)
+		snap.Windows[i].LaunchArgs = maskArgs(snap.Windows[i].LaunchArgs)
 	}
 
-	// Sanitizar rutas en terminales
+	// Sanitizar rutas en terminales y en los paneles de su layout
 	for i := range snap.Terminals {
-		snap.Terminals[i].WorkingDirectory = userPattern.ReplaceAllString(
-			snap.Terminals[i].WorkingDirectory,
-			"${1}***USER***",
-		)
+		snap.Terminals[i].WorkingDirectory = mask(snap.Terminals[i].WorkingDirectory)
+		if layout := snap.Terminals[i].Layout; layout != nil {
+			for t := range layout.Tabs {
+				for p := range layout.Tabs[t].Panes {
+					if dir := layout.Tabs[t].Panes[p].Directory; dir != "" {
+						layout.Tabs[t].Panes[p].Directory = mask(dir)
+					}
+				}
+			}
+		}
 	}
 
 	// Sanitizar rutas en IDE files
 	for i := range snap.IDEFiles {
-		snap.IDEFiles[i].FilePath = userPattern.ReplaceAllString(
-			snap.IDEFiles[i].FilePath,
-			"${1}***USER***",
-		)
+		snap.IDEFiles[i].FilePath = mask(snap.IDEFiles[i].FilePath)
 	}
 
 	// Sanitizar git repo path
-	snap.GitRepo = userPattern.ReplaceAllString(snap.GitRepo, "${1}***USER***")
+	snap.GitRepo = mask(snap.GitRepo)
+}
+
+// redacted reemplaza los valores sensibles enmascarados
+const redacted = "***REDACTED***"
+
+// UserMask reemplaza el nombre de usuario en las rutas enmascaradas
+const UserMask = "***USER***"
+
+// secretPatterns detecta texto que parece contener una credencial. Los
+// valores ya enmascarados (***REDACTED***) no cuentan
+var secretPatterns = []struct {
+	kind  string
+	regex *regexp.Regexp
+}{
+	{"credential assignment", regexp.MustCompile(`(?i)\b(password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|auth)\b["']?\s*[=:]\s*["']?[^\s"'*&]{4,}`)},
+	{"credential flag", regexp.MustCompile(`(?i)(^|\s)--?(password|passwd|secret|token|api[_-]?key)(=|\s+)[^\s*-][^\s]*`)},
+	{"credentials in URL", regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:[^/\s@*]+@`)},
+	{"GitHub token", regexp.MustCompile(`\b(ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{20,}|\bgithub_pat_[A-Za-z0-9_]{20,}`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"API key", regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`)},
+	{"JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+`)},
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+}
+
+// FindSecrets retorna el tipo de cada patrón de credencial que aparece en
+// text, vacío si no parece contener ninguna
+func FindSecrets(text string) []string {
+	text = strings.NewReplacer(redacted, "", url.QueryEscape(redacted), "").Replace(text)
+	var kinds []string
+	for _, p := range secretPatterns {
+		if p.regex.MatchString(text) {
+			kinds = append(kinds, p.kind)
+		}
+	}
+	return kinds
+}
+
+// maskArgs oculta el usuario en los argumentos de una lista LaunchArgs sin
+// normalizarlos, porque no todos son rutas. Si no es una lista de strings la
+// deja como está
+func maskArgs(raw json.RawMessage) json.RawMessage {
+	var args []string
+	if len(raw) == 0 || json.Unmarshal(raw, &args) != nil {
+		return raw
+	}
+	for i, a := range args {
+		args[i] = userPattern.ReplaceAllString(a, "${1}"+UserMask)
+	}
+	masked, err := json.Marshal(args)
+	if err != nil {
+		return raw
+	}
+	return masked
 }
 
 // containsInsensitive verifica si s contiene substr (case-insensitive)
@@ -0,0 +1,358 @@
+// Package schedule toma snapshots automáticos cada cierto intervalo dentro del
+// proceso del servidor, conservando solo los últimos N.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/snapshot"
+)
+
+// AutoTag marca los snapshots automáticos; la retención solo borra estos
+const AutoTag = "auto"
+
+// DefaultKeepLast es cuántos snapshots automáticos se conservan por defecto
+const DefaultKeepLast = 24
+
+// MinInterval es el intervalo más corto aceptado entre snapshots automáticos
+const MinInterval = time.Minute
+
+const (
+	// checkEvery es cada cuánto se mira el reloj de pared. Los timers de Go no
+	// avanzan durante la suspensión en todas las plataformas: mirar el reloj
+	// seguido detecta el resume y los saltos de hora
+	checkEvery = time.Minute
+	// captureCheckEvery es cada cuánto se vigila un capture en curso
+	captureCheckEvery = 5 * time.Second
+	// sleepSlack es el retraso entre dos miradas al reloj a partir del cual se
+	// asume que la máquina estuvo suspendida
+	sleepSlack = 30 * time.Second
+)
+
+// Status es el estado del scheduler
+type Status struct {
+	Enabled        bool          `json:"enabled"`
+	Interval       time.Duration `json:"interval"`
+	KeepLast       int           `json:"keep_last"`         // 0 = sin retención
+	NextAt         time.Time     `json:"next_at,omitempty"` // Próximo capture, si está activo
+	LastSuccessAt  time.Time     `json:"last_success_at,omitempty"`
+	LastSnapshotID string        `json:"last_snapshot_id,omitempty"`
+	LastError      string        `json:"last_error,omitempty"`
+	LastErrorAt    time.Time     `json:"last_error_at,omitempty"`
+	Skipped        int           `json:"skipped"` // Ciclos salteados por un capture manual en curso
+}
+
+// Scheduler captura un snapshot con el tag AutoTag cada Interval mientras
+// está activo. El próximo capture se calcula desde el último exitoso; si la
+// máquina estuvo suspendida o la hora saltó, los intervalos perdidos se
+// colapsan en un solo capture y un capture que quedó a medias al suspender
+// se cancela. Los errores se loguean, nunca detienen al servidor.
+type Scheduler struct {
+	manager *snapshot.Manager
+	logger  *log.Logger
+	parent  context.Context
+
+	ctl    sync.Mutex // Serializa Enable y Disable
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu         sync.Mutex
+	status     Status
+	endOverrun bool
+}
+
+// New crea un scheduler detenido. Cancelar ctx (el apagado del servidor) lo
+// detiene para siempre
+func New(ctx context.Context, manager *snapshot.Manager, logger *log.Logger) *Scheduler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Scheduler{manager: manager, logger: logger, parent: ctx}
+}
+
+// SetEndOverrunSessions hace que, mientras está activo, el scheduler termine
+// la sesión de foco que se pasó de su duración planificada
+func (s *Scheduler) SetEndOverrunSessions(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endOverrun = on
+}
+
+// Enable activa los snapshots automáticos cada interval, conservando los
+// últimos keepLast (0 = todos). Si ya estaba activo, lo reinicia con la nueva
+// configuración
+func (s *Scheduler) Enable(interval time.Duration, keepLast int) error {
+	if interval < MinInterval {
+		return fmt.Errorf("auto-snapshot interval must be at least %s", MinInterval)
+	}
+	if keepLast < 0 {
+		return fmt.Errorf("keep_last cannot be negative")
+	}
+	if err := s.parent.Err(); err != nil {
+		return fmt.Errorf("server is shutting down: %w", err)
+	}
+
+	s.ctl.Lock()
+	defer s.ctl.Unlock()
+	s.stop()
+
+	next, err := s.firstCapture(interval)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(s.parent)
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	s.mu.Lock()
+	s.status.Enabled = true
+	s.status.Interval = interval
+	s.status.KeepLast = keepLast
+	s.status.NextAt = next
+	s.mu.Unlock()
+
+	go s.run(ctx, s.done, interval, keepLast)
+	return nil
+}
+
+// Disable detiene los snapshots automáticos, esperando a que termine el
+// capture en curso. Retorna false si no estaba activo
+func (s *Scheduler) Disable() bool {
+	s.ctl.Lock()
+	defer s.ctl.Unlock()
+	return s.stop()
+}
+
+// Status retorna el estado actual
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.status
+	if s.parent.Err() != nil {
+		status.Enabled = false
+		status.NextAt = time.Time{}
+	}
+	return status
+}
+
+func (s *Scheduler) stop() bool {
+	if s.cancel == nil {
+		return false
+	}
+	s.cancel()
+	<-s.done
+	s.cancel, s.done = nil, nil
+
+	s.mu.Lock()
+	s.status.Enabled = false
+	s.status.NextAt = time.Time{}
+	s.mu.Unlock()
+	return true
+}
+
+// firstCapture calcula el primer capture desde el último snapshot automático
+// guardado, así reiniciar el servidor no adelanta ni atrasa el ciclo
+func (s *Scheduler) firstCapture(interval time.Duration) (time.Time, error) {
+	now := time.Now().Round(0)
+	list, err := s.manager.ListFiltered(s.parent, core.SnapshotFilter{Tags: []string{AutoTag}, Limit: 1})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read the last auto snapshot: %w", err)
+	}
+	if len(list.Snapshots) == 0 {
+		return now.Add(interval), nil
+	}
+
+	last := list.Snapshots[0].CreatedAt
+	s.mu.Lock()
+	if s.status.LastSuccessAt.IsZero() {
+		s.status.LastSuccessAt = last
+		s.status.LastSnapshotID = list.Snapshots[0].ID
+	}
+	s.mu.Unlock()
+	return clampNext(last.Add(interval), now, interval), nil
+}
+
+// clampNext acota el próximo capture a [now, now+interval]: uno atrasado
+// (suspensión, servidor apagado) se hace ya, una sola vez, y uno demasiado
+// lejano (la hora saltó hacia atrás) se recalibra
+func clampNext(next, now time.Time, interval time.Duration) time.Time {
+	if next.Before(now) {
+		return now
+	}
+	if next.Sub(now) > interval {
+		return now.Add(interval)
+	}
+	return next
+}
+
+func (s *Scheduler) run(ctx context.Context, done chan struct{}, interval time.Duration, keepLast int) {
+	defer close(done)
+
+	tick := min(checkEvery, interval)
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	lastCheck := time.Now().Round(0)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Round(0) descarta la lectura monotónica: se compara el reloj de pared
+		now := time.Now().Round(0)
+		if gap := now.Sub(lastCheck); gap > tick+sleepSlack || gap < 0 {
+			s.logger.Printf("auto-snapshot: clock moved %s between checks (sleep or time change), recalibrating", gap.Round(time.Second))
+		}
+		lastCheck = now
+
+		s.mu.Lock()
+		next, endOverrun := s.status.NextAt, s.endOverrun
+		s.mu.Unlock()
+
+		if endOverrun {
+			s.endOverrunSession(ctx, now)
+		}
+		if next = clampNext(next, now, interval); now.Before(next) {
+			s.setNext(next)
+			continue
+		}
+
+		s.captureOnce(ctx, keepLast)
+		s.mu.Lock()
+		base := now
+		if s.status.LastSuccessAt.After(base) {
+			base = s.status.LastSuccessAt
+		}
+		s.status.NextAt = base.Add(interval)
+		s.mu.Unlock()
+	}
+}
+
+func (s *Scheduler) setNext(next time.Time) {
+	s.mu.Lock()
+	s.status.NextAt = next
+	s.mu.Unlock()
+}
+
+// captureOnce toma un snapshot automático y aplica la retención. Un capture
+// manual en curso saltea el ciclo
+func (s *Scheduler) captureOnce(ctx context.Context, keepLast int) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.fail(fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopWatch := watchSleep(ctx, cancel, s.logger)
+	defer stopWatch()
+
+	started := time.Now()
+	snap, err := s.manager.TryCapture(ctx, snapshot.CaptureOptions{
+		Name:             started.Format("auto-2006-01-02T15:04"),
+		Description:      "Automatic snapshot",
+		Tags:             []string{AutoTag},
+		IncludeBrowsable: true,
+		IncludeTerminals: true,
+		RecordRegions:    true,
+		Sanitize:         true,
+	})
+	if errors.Is(err, snapshot.ErrCaptureInFlight) {
+		s.mu.Lock()
+		s.status.Skipped++
+		s.mu.Unlock()
+		s.logger.Println("auto-snapshot: skipped, a capture is already in progress")
+		return
+	}
+	if err != nil {
+		if ctx.Err() != nil && s.parent.Err() == nil {
+			err = fmt.Errorf("cancelled after a sleep or time change: %w", err)
+		}
+		s.fail(err)
+		return
+	}
+
+	s.mu.Lock()
+	s.status.LastSuccessAt = snap.CreatedAt
+	s.status.LastSnapshotID = snap.ID
+	s.mu.Unlock()
+
+	if keepLast <= 0 {
+		return
+	}
+	result, err := s.manager.Prune(ctx, snapshot.PruneOptions{KeepLast: keepLast, Tag: AutoTag})
+	if err != nil {
+		s.logger.Printf("auto-snapshot: retention failed: %v", err)
+		return
+	}
+	if len(result.Pruned) > 0 {
+		s.logger.Printf("auto-snapshot: pruned %d old auto snapshot(s)", len(result.Pruned))
+	}
+}
+
+func (s *Scheduler) fail(err error) {
+	s.logger.Printf("auto-snapshot: capture failed: %v", err)
+	s.mu.Lock()
+	s.status.LastError = err.Error()
+	s.status.LastErrorAt = time.Now()
+	s.mu.Unlock()
+}
+
+// watchSleep cancela el capture si la máquina se suspende mientras corre: al
+// volver, lo que se estaba leyendo ya no es el entorno actual
+func watchSleep(ctx context.Context, cancel context.CancelFunc, logger *log.Logger) (stop func()) {
+	stopped := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(captureCheckEvery)
+		defer ticker.Stop()
+		last := time.Now().Round(0)
+		for {
+			select {
+			case <-stopped:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			now := time.Now().Round(0)
+			if gap := now.Sub(last); gap > captureCheckEvery+sleepSlack || gap < 0 {
+				logger.Printf("auto-snapshot: clock moved %s during a capture, cancelling it", gap.Round(time.Second))
+				cancel()
+				return
+			}
+			last = now
+		}
+	}()
+	return func() { close(stopped) }
+}
+
+// endOverrunSession termina la sesión de foco activa si ya pasó su duración planificada
+func (s *Scheduler) endOverrunSession(ctx context.Context, now time.Time) {
+	session, err := s.manager.ActiveSession(ctx)
+	if err != nil {
+		s.logger.Printf("auto-snapshot: cannot check the active session: %v", err)
+		return
+	}
+	if session == nil || session.Planned <= 0 || now.Sub(session.StartedAt) <= session.Planned {
+		return
+	}
+	if _, err := s.manager.EndSession(ctx, session.ID, snapshot.CaptureOptions{
+		IncludeBrowsable: true,
+		IncludeTerminals: true,
+		RecordRegions:    true,
+		Sanitize:         true,
+	}); err != nil {
+		s.logger.Printf("auto-snapshot: failed to end overrun session %q: %v", session.Label, err)
+		return
+	}
+	s.logger.Printf("auto-snapshot: ended session %q, planned for %s", session.Label, session.Planned)
+}
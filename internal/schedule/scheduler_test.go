@@ -0,0 +1,184 @@
+package schedule
+
+import (
+	"context"
+	"io"
+	"log"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/db"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+	"github.com/tuusuario/dev-env-snapshots/internal/snapshot"
+)
+
+func TestClampNext(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	interval := time.Hour
+	tests := []struct {
+		name string
+		next time.Time
+		want time.Time
+	}{
+		{"overdue runs now", now.Add(-3 * time.Hour), now},
+		{"due now", now, now},
+		{"within the interval", now.Add(20 * time.Minute), now.Add(20 * time.Minute)},
+		{"exactly one interval away", now.Add(interval), now.Add(interval)},
+		{"clock moved back", now.Add(5 * time.Hour), now.Add(interval)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clampNext(tt.next, now, interval); !got.Equal(tt.want) {
+				t.Errorf("clampNext = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestScheduler arma un scheduler sobre una base SQLite temporal
+func newTestScheduler(t *testing.T, adapter core.PlatformAdapter) (*Scheduler, *db.SQLiteRepository) {
+	t.Helper()
+	d, err := db.NewDB(filepath.Join(t.TempDir(), "snapshots.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	repo := db.NewRepository(d)
+	return New(context.Background(), snapshot.NewManager(repo, adapter), log.New(io.Discard, "", 0)), repo
+}
+
+func TestRetentionOnlyPrunesAutoSnapshots(t *testing.T) {
+	ctx := context.Background()
+	s, repo := newTestScheduler(t, platform.NewMockAdapter())
+
+	// Los manuales son los más viejos: si la retención no mirara el tag,
+	// serían los primeros en irse
+	base := time.Now().Add(-24 * time.Hour)
+	seed := []struct {
+		id   string
+		tags []string
+	}{
+		{"manual-1", nil},
+		{"manual-2", []string{"work"}},
+		{"auto-1", []string{AutoTag}},
+		{"auto-2", []string{AutoTag}},
+		{"auto-3", []string{AutoTag, "work"}},
+	}
+	for i, sd := range seed {
+		at := base.Add(time.Duration(i) * time.Hour)
+		snap := &core.Snapshot{ID: sd.id, Name: sd.id, Tags: sd.tags, CreatedAt: at, UpdatedAt: at}
+		if err := repo.SaveSnapshot(ctx, snap); err != nil {
+			t.Fatalf("SaveSnapshot %s: %v", sd.id, err)
+		}
+	}
+
+	s.captureOnce(ctx, 2)
+
+	status := s.Status()
+	if status.LastError != "" {
+		t.Fatalf("capture failed: %s", status.LastError)
+	}
+	list, err := repo.ListSnapshots(ctx, core.SnapshotFilter{Limit: 100})
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	var ids []string
+	for _, snap := range list.Snapshots {
+		ids = append(ids, snap.ID)
+	}
+	// Quedan el nuevo y el auto más reciente, más todos los manuales
+	want := []string{status.LastSnapshotID, "auto-3", "manual-2", "manual-1"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("remaining = %v, want %v", ids, want)
+	}
+}
+
+func TestKeepAllSkipsRetention(t *testing.T) {
+	ctx := context.Background()
+	s, repo := newTestScheduler(t, platform.NewMockAdapter())
+	for _, id := range []string{"auto-1", "auto-2"} {
+		if err := repo.SaveSnapshot(ctx, &core.Snapshot{ID: id, Name: id, Tags: []string{AutoTag}, CreatedAt: time.Now(), UpdatedAt: time.Now()}); err != nil {
+			t.Fatalf("SaveSnapshot %s: %v", id, err)
+		}
+	}
+
+	s.captureOnce(ctx, 0)
+
+	list, err := repo.ListSnapshots(ctx, core.SnapshotFilter{Tags: []string{AutoTag}, Limit: 100})
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	if len(list.Snapshots) != 3 {
+		t.Errorf("keep_last 0 left %d auto snapshots, want 3", len(list.Snapshots))
+	}
+}
+
+// blockingAdapter es un mock cuyo GetWindows espera a release, para dejar un
+// capture manual en curso
+type blockingAdapter struct {
+	*platform.MockAdapter
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (a *blockingAdapter) GetWindows(ctx context.Context) ([]core.Window, error) {
+	close(a.entered)
+	<-a.release
+	return a.MockAdapter.GetWindows(ctx)
+}
+
+func TestCaptureInFlightIsSkipped(t *testing.T) {
+	ctx := context.Background()
+	adapter := &blockingAdapter{MockAdapter: platform.NewMockAdapter(), entered: make(chan struct{}), release: make(chan struct{})}
+	s, repo := newTestScheduler(t, adapter)
+
+	manual := make(chan error, 1)
+	go func() {
+		_, err := s.manager.Capture(ctx, snapshot.CaptureOptions{Name: "manual"})
+		manual <- err
+	}()
+	<-adapter.entered
+
+	s.captureOnce(ctx, 1)
+	close(adapter.release)
+	if err := <-manual; err != nil {
+		t.Fatalf("manual Capture: %v", err)
+	}
+
+	status := s.Status()
+	if status.Skipped != 1 || status.LastError != "" || status.LastSnapshotID != "" {
+		t.Errorf("status = %+v, want one skipped cycle and nothing else", status)
+	}
+	list, err := repo.ListSnapshots(ctx, core.SnapshotFilter{Limit: 100})
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	if len(list.Snapshots) != 1 || list.Snapshots[0].Name != "manual" {
+		t.Errorf("snapshots = %v, want only the manual one", list.Snapshots)
+	}
+}
+
+func TestEnableRejectsInvalidSettings(t *testing.T) {
+	s, _ := newTestScheduler(t, platform.NewMockAdapter())
+	tests := []struct {
+		name     string
+		interval time.Duration
+		keepLast int
+	}{
+		{"interval below the minimum", MinInterval - time.Second, 1},
+		{"negative keep_last", MinInterval, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Enable(tt.interval, tt.keepLast); err == nil {
+				t.Error("Enable accepted invalid settings")
+			}
+			if s.Status().Enabled {
+				t.Error("scheduler enabled after a rejected Enable")
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/tuusuario/dev-env-snapshots/internal/schedule"
+)
+
+// AutoSnapshotter is the background scheduler behind enable_auto_snapshot
+// and disable_auto_snapshot
+type AutoSnapshotter interface {
+	Enable(interval time.Duration, keepLast int) error
+	Disable() bool
+	Status() schedule.Status
+}
+
+// WithAutoSnapshot exposes enable_auto_snapshot and disable_auto_snapshot so
+// clients can toggle the scheduler at runtime, and reports it in server_status
+func WithAutoSnapshot(scheduler AutoSnapshotter) Option {
+	return func(s *MCPServer) {
+		s.autoSnapshot = scheduler
+	}
+}
+
+func (s *MCPServer) handleEnableAutoSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	current := s.autoSnapshot.Status()
+
+	interval := time.Duration(intArg(args, "interval_minutes", 0)) * time.Minute
+	if interval == 0 {
+		interval = current.Interval
+	}
+	if interval == 0 {
+		return mcp.NewToolResultError("interval_minutes is required"), nil
+	}
+	// KeepLast 0 is "keep all", so the default only applies when the
+	// scheduler was never configured
+	keepLast := current.KeepLast
+	if current.Interval == 0 {
+		keepLast = schedule.DefaultKeepLast
+	}
+	keepLast = intArg(args, "keep_last", keepLast)
+
+	if err := s.autoSnapshot.Enable(interval, keepLast); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to enable auto-snapshots: %v", err)), nil
+	}
+	return mcp.NewToolResultText(formatAutoSnapshotStatus(s.autoSnapshot.Status())), nil
+}
+
+func (s *MCPServer) handleDisableAutoSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if !s.autoSnapshot.Disable() {
+		return mcp.NewToolResultText("Auto-snapshots were not enabled."), nil
+	}
+	return mcp.NewToolResultText("Auto-snapshots disabled. Existing auto snapshots are kept."), nil
+}
+
+// formatAutoSnapshotStatus renders the scheduler state as a few lines
+func formatAutoSnapshotStatus(status schedule.Status) string {
+	var b strings.Builder
+	if !status.Enabled {
+		b.WriteString("Auto-snapshots: disabled\n")
+	} else {
+		retention := "keeping all of them"
+		if status.KeepLast > 0 {
+			retention = fmt.Sprintf("keeping the last %d", status.KeepLast)
+		}
+		fmt.Fprintf(&b, "Auto-snapshots: every %s, tagged %q, %s\n", status.Interval, schedule.AutoTag, retention)
+		fmt.Fprintf(&b, "Next capture: %s\n", status.NextAt.Format(time.RFC822))
+	}
+	if !status.LastSuccessAt.IsZero() {
+		fmt.Fprintf(&b, "Last auto snapshot: %s at %s\n", status.LastSnapshotID, status.LastSuccessAt.Format(time.RFC822))
+	}
+	if status.LastError != "" {
+		fmt.Fprintf(&b, "Last failure: %s at %s\n", status.LastError, status.LastErrorAt.Format(time.RFC822))
+	}
+	if status.Skipped > 0 {
+		fmt.Fprintf(&b, "Skipped cycles (manual capture in progress): %d\n", status.Skipped)
+	}
+	return b.String()
+}
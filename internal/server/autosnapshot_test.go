@@ -0,0 +1,55 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/schedule"
+)
+
+// fakeScheduler records what enable_auto_snapshot asks for
+type fakeScheduler struct {
+	status schedule.Status
+}
+
+func (f *fakeScheduler) Enable(interval time.Duration, keepLast int) error {
+	f.status.Enabled = true
+	f.status.Interval = interval
+	f.status.KeepLast = keepLast
+	return nil
+}
+
+func (f *fakeScheduler) Disable() bool {
+	was := f.status.Enabled
+	f.status.Enabled = false
+	return was
+}
+
+func (f *fakeScheduler) Status() schedule.Status { return f.status }
+
+func TestEnableAutoSnapshotKeepLast(t *testing.T) {
+	tests := []struct {
+		name    string
+		current schedule.Status
+		args    map[string]interface{}
+		want    int
+	}{
+		{"first enable uses the default", schedule.Status{}, map[string]interface{}{"interval_minutes": 10.0}, schedule.DefaultKeepLast},
+		{"explicit value", schedule.Status{}, map[string]interface{}{"interval_minutes": 10.0, "keep_last": 5.0}, 5},
+		{"keep all survives a re-enable", schedule.Status{Interval: 10 * time.Minute}, map[string]interface{}{"interval_minutes": 30.0}, 0},
+		{"previous value survives a re-enable", schedule.Status{Interval: 10 * time.Minute, KeepLast: 7}, map[string]interface{}{}, 7},
+		{"explicit keep all", schedule.Status{Interval: 10 * time.Minute, KeepLast: 7}, map[string]interface{}{"keep_last": 0.0}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeScheduler{status: tt.current}
+			s, _, _ := newTestServer(t, WithAutoSnapshot(fake))
+			if text, isErr := callText(t, s, "enable_auto_snapshot", tt.args); isErr {
+				t.Fatalf("enable_auto_snapshot failed: %s", text)
+			}
+			if fake.status.KeepLast != tt.want {
+				t.Errorf("keep_last = %d, want %d", fake.status.KeepLast, tt.want)
+			}
+		})
+	}
+}
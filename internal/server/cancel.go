@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// cancelledNotification is sent by clients to cancel an in-flight request
+const cancelledNotification = "notifications/cancelled"
+
+// callKeyHeader carries the call's key from the BeforeCallTool hook to the
+// handler; mcp-go does not pass the request ID to tool handlers otherwise
+const callKeyHeader = "X-Snapshots-Call-Key"
+
+// inflightCalls tracks running tool calls so a cancellation notification can
+// cancel the context of the call it names
+type inflightCalls struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newInflightCalls() *inflightCalls {
+	return &inflightCalls{cancels: make(map[string]context.CancelFunc)}
+}
+
+// callKey scopes a request ID to its session, since IDs are only unique per client
+func callKey(ctx context.Context, id mcp.RequestId) string {
+	session := ""
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		session = cs.SessionID()
+	}
+	return session + "/" + id.String()
+}
+
+// tag is the BeforeCallTool hook: it stamps the call's key on the request
+func (c *inflightCalls) tag(ctx context.Context, id any, request *mcp.CallToolRequest) {
+	requestID, ok := id.(mcp.RequestId)
+	if !ok {
+		requestID = mcp.NewRequestId(id)
+	}
+	// The header may be the HTTP request's own; don't write into it
+	header := request.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set(callKeyHeader, callKey(ctx, requestID))
+	request.Header = header
+}
+
+// withCancellation gives the handler a context that the cancellation
+// notification for its request cancels
+func (c *inflightCalls) withCancellation(handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		key := request.Header.Get(callKeyHeader)
+		if key == "" {
+			return handler(ctx, request) // Direct calls through CallTool
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		c.mu.Lock()
+		c.cancels[key] = cancel
+		c.mu.Unlock()
+		defer func() {
+			c.mu.Lock()
+			delete(c.cancels, key)
+			c.mu.Unlock()
+		}()
+
+		return handler(ctx, request)
+	}
+}
+
+// cancel handles notifications/cancelled. Unknown or finished requests are
+// ignored, as the protocol allows
+func (c *inflightCalls) cancel(ctx context.Context, notification mcp.JSONRPCNotification) {
+	raw, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok {
+		return
+	}
+	key := callKey(ctx, mcp.NewRequestId(raw))
+
+	c.mu.Lock()
+	cancel, ok := c.cancels[key]
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+	reason, _ := notification.Params.AdditionalFields["reason"].(string)
+	log.Printf("[Cancel] Cancelling request %s: %s", key, reason)
+	cancel()
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/db"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+	"github.com/tuusuario/dev-env-snapshots/internal/snapshot"
+)
+
+// newTestServer builds a server over a temporary database and the mock adapter
+func newTestServer(t *testing.T, opts ...Option) (*MCPServer, *snapshot.Manager, *db.SQLiteRepository) {
+	t.Helper()
+	return newTestServerWith(t, platform.NewMockAdapter(), opts...)
+}
+
+// newTestServerWith builds a server over a temporary database and adapter
+func newTestServerWith(t *testing.T, adapter core.PlatformAdapter, opts ...Option) (*MCPServer, *snapshot.Manager, *db.SQLiteRepository) {
+	t.Helper()
+	d, err := db.NewDB(filepath.Join(t.TempDir(), "snapshots.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	repo := db.NewRepository(d)
+	manager := snapshot.NewManager(repo, adapter)
+	s, err := New(append([]Option{WithManager(manager)}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return s, manager, repo
+}
+
+// callText calls a tool and returns its text, failing on transport errors
+func callText(t *testing.T, s *MCPServer, name string, args map[string]interface{}) (string, bool) {
+	t.Helper()
+	result, err := s.CallTool(context.Background(), name, args)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if len(result.Content) == 0 {
+		return "", result.IsError
+	}
+	text, _ := result.Content[0].(mcp.TextContent)
+	return text.Text, result.IsError
+}
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// assertGolden compares got with testdata/<name>.golden byte for byte; run
+// the tests with -update to rewrite it
+func assertGolden(t *testing.T, name string, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v (run with -update to create it)", path, err)
+	}
+	if !bytes.Equal([]byte(got), want) {
+		t.Errorf("%s differs from %s:\n--- got\n%s\n--- want\n%s", name, path, got, want)
+	}
+}
+
+// seedDemo stores a fixed dataset whose components are saved out of order,
+// so the golden outputs only hold if the tools sort them
+func seedDemo(t *testing.T, repo *db.SQLiteRepository) {
+	t.Helper()
+	base := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	snaps := []*core.Snapshot{
+		{
+			ID: "demo-morning", Name: "morning", Description: "api work", CreatedAt: base, UpdatedAt: base,
+			Tags: []string{"work", "api", "backend"}, GitBranch: "main", GitHeadHash: "0123456789abcdef",
+			Windows: []core.Window{
+				{AppName: "Code", WindowTitle: "main.go - api - Visual Studio Code", X: 0, Y: 0, Width: 1280, Height: 1400},
+				{AppName: "Terminal", WindowTitle: "zsh", X: 1280, Y: 0, Width: 1280, Height: 700},
+			},
+			Terminals: []core.Terminal{
+				{TerminalApp: "zsh", WorkingDirectory: "/home/dev/api", EnvVars: map[string]string{"PORT": "8080", "APP_ENV": "dev", "LOG": "debug"}},
+			},
+			BrowserTabs: []core.BrowserTab{
+				{BrowserName: "chrome", URL: "https://pkg.go.dev", Title: "Go Packages", WindowIndex: 1, TabIndex: 0},
+				{BrowserName: "chrome", URL: "https://github.com/acme/api/pulls", Title: "Pull requests", WindowIndex: 0, TabIndex: 1},
+				{BrowserName: "chrome", URL: "https://github.com/acme/api", Title: "acme/api", WindowIndex: 0, TabIndex: 0, IsPinned: true},
+			},
+		},
+		{
+			ID: "demo-evening", Name: "evening", CreatedAt: base.Add(9 * time.Hour), UpdatedAt: base.Add(9 * time.Hour),
+			Tags: []string{"work", "frontend"}, GitBranch: "feature/ui", GitHeadHash: "fedcba9876543210",
+			Windows: []core.Window{
+				{AppName: "Code", WindowTitle: "main.go - api - Visual Studio Code", X: 0, Y: 0, Width: 1920, Height: 1400},
+				{AppName: "Chrome", WindowTitle: "localhost:3000", X: 1920, Y: 0, Width: 640, Height: 1400},
+			},
+			BrowserTabs: []core.BrowserTab{
+				{BrowserName: "chrome", URL: "http://localhost:3000", Title: "app", WindowIndex: 0, TabIndex: 1},
+				{BrowserName: "chrome", URL: "https://github.com/acme/api", Title: "acme/api", WindowIndex: 0, TabIndex: 0, IsPinned: true},
+			},
+		},
+		// Same created_at as demo-evening: the ID breaks the tie
+		{ID: "demo-another", Name: "another", CreatedAt: base.Add(9 * time.Hour), UpdatedAt: base.Add(9 * time.Hour)},
+	}
+	for _, snap := range snaps {
+		if err := repo.SaveSnapshot(context.Background(), snap); err != nil {
+			t.Fatalf("SaveSnapshot %s: %v", snap.ID, err)
+		}
+	}
+}
@@ -1,191 +1,1712 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+	"github.com/tuusuario/dev-env-snapshots/internal/schedule"
 	"github.com/tuusuario/dev-env-snapshots/internal/snapshot"
 )
 
+// MCPServer holds the snapshot tool set. Tools and their handlers are kept in a
+// transport-independent registry; the transports in transport.go expose them.
 type MCPServer struct {
-	manager *snapshot.Manager
-	server  *server.MCPServer
+	manager  *snapshot.Manager
+	server   *server.MCPServer
+	tools    []server.ServerTool
+	timeouts map[string]time.Duration
+	policy   ToolPolicy
+	tracer   *log.Logger
+	usage    UsageStore
+	storage  StorageHealth
+	shared   *sharedDir
+	calls    *inflightCalls
+
+	autoSnapshot AutoSnapshotter
 }
 
-func NewMCPServer(manager *snapshot.Manager) *MCPServer {
-	s := server.NewMCPServer(
+// New builds the server from options. WithManager is required.
+func New(opts ...Option) (*MCPServer, error) {
+	m := &MCPServer{
+		timeouts: DefaultToolTimeouts(),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.manager == nil {
+		return nil, fmt.Errorf("server: a snapshot manager is required (use WithManager)")
+	}
+
+	m.calls = newInflightCalls()
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(m.calls.tag)
+	m.server = server.NewMCPServer(
 		"Dev Environment Snapshots",
 		"1.0.0",
 		server.WithLogging(),
+		server.WithHooks(hooks),
 	)
-
-	m := &MCPServer{
-		manager: manager,
-		server:  s,
-	}
+	m.server.AddNotificationHandler(cancelledNotification, m.calls.cancel)
 
 	m.registerTools()
+	return m, nil
+}
+
+// NewMCPServer builds a server with default options around manager
+func NewMCPServer(manager *snapshot.Manager) *MCPServer {
+	m, err := New(WithManager(manager))
+	if err != nil {
+		panic(err)
+	}
 	return m
 }
 
-func (s *MCPServer) Start() error {
-	// stdio transport
-	return server.ServeStdio(s.server)
+// Tools returns the registered tools and their (wrapped) handlers
+func (s *MCPServer) Tools() []server.ServerTool {
+	return append([]server.ServerTool(nil), s.tools...)
+}
+
+// CallTool invokes a registered tool directly, without any transport
+func (s *MCPServer) CallTool(ctx context.Context, name string, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	for _, t := range s.tools {
+		if t.Tool.Name != name {
+			continue
+		}
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		return t.Handler(ctx, request)
+	}
+	return nil, fmt.Errorf("unknown tool %q", name)
 }
 
 func (s *MCPServer) registerTools() {
 	// capture_snapshot
-	s.server.AddTool(mcp.NewTool("capture_snapshot",
+	s.addTool(mcp.NewTool("capture_snapshot",
 		mcp.WithDescription("Captures the current development environment state"),
 		mcp.WithString("name", mcp.Required(), mcp.Description("Name of the snapshot")),
 		mcp.WithString("description", mcp.Description("Description")),
+		mcp.WithArray("tags", mcp.WithStringItems(), mcp.Description("Tags to label the snapshot with (a comma-separated string is also accepted)")),
+		mcp.WithBoolean("include_terminals", mcp.Description("Capture terminal sessions (default true)")),
+		mcp.WithBoolean("include_browsers", mcp.Description("Capture browser tabs (default true)")),
+		mcp.WithBoolean("deep_browser_capture", mcp.Description("Read real tab URLs from Chrome/Edge started with --remote-debugging-port (default false)")),
+		mcp.WithBoolean("record_regions", mcp.Description("Tag each window with the configured screen region it sits in (default true)")),
+		mcp.WithBoolean("include_processes", mcp.Description("Capture background development processes (dev servers, databases, docker-compose) for reference; restores don't relaunch them unless listed in restart_processes (default false)")),
+		mcp.WithArray("restart_processes", mcp.WithStringItems(), mcp.Description("Process names or PIDs to relaunch when the snapshot is restored, e.g. [\"node\", \"4242\"]; implies include_processes")),
+		mcp.WithBoolean("sanitize", mcp.Description("Mask tokens, secrets and user paths (default true)")),
+		mcp.WithArray("monitors", mcp.WithNumberItems(), mcp.Description("Only capture windows centered on these monitors, numbered from 1 in enumeration order (default all)")),
 	), s.handleCaptureSnapshot)
 
 	// restore_snapshot
-	s.server.AddTool(mcp.NewTool("restore_snapshot",
+	s.addTool(mcp.NewTool("restore_snapshot",
 		mcp.WithDescription("Restores a previously captured snapshot"),
-		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID of the snapshot to restore")),
+		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID, unique ID prefix or name of the snapshot to restore")),
+		mcp.WithBoolean("dry_run", mcp.Description("Only report what would be restored, without moving any window")),
+		mcp.WithBoolean("validate_before_restore", mcp.Description("Check that the snapshot's applications are running before restoring (default false)")),
+		mcp.WithBoolean("validate", mcp.Description("Alias of validate_before_restore")),
+		mcp.WithBoolean("skip_missing_apps", mcp.Description("When validating, restore what is available instead of aborting on missing apps (default true)")),
+		mcp.WithBoolean("respect_manual_changes", mcp.Description("Leave alone windows the user moved after an earlier restore in this session")),
+		mcp.WithBoolean("force_position", mcp.Description("Skip title matching: give each saved window the next live window of the same app, in order. Naive, use when titles changed")),
+		mcp.WithNumber("min_drift_pixels", mcp.Description("Only move windows whose live position or size is off by more than this many pixels; the rest are reported as already correct (default 0: move all)")),
+		mcp.WithString("match_profile", mcp.Description("Window matching weights: default, app (trust app and size over titles, e.g. after a reboot) or title (require near-exact titles)")),
+		mcp.WithBoolean("use_regions", mcp.Description("Place windows captured in a named region into that region's current bounds (default true)")),
+		mcp.WithBoolean("restore_terminals", mcp.Description("Reopen the snapshot's terminals in their recorded working directories (default true)")),
+		mcp.WithBoolean("restore_tabs", mcp.Description("Reopen the snapshot's browser tabs, each captured browser window's tabs together in a new window where the browser allows it (default true)")),
+		mcp.WithNumber("max_tabs", mcp.Description(fmt.Sprintf("Maximum browser tabs to reopen, pinned and diverse domains first (default %d)", snapshot.DefaultMaxTabs))),
+		mcp.WithBoolean("launch_missing", mcp.Description("Launch the recorded executable of windows with no live match and place the new window (default false)")),
+		mcp.WithNumber("launch_timeout_seconds", mcp.Description("How long to wait for each launched application's window (default 10)")),
+		mcp.WithArray("never_touch", mcp.WithStringItems(), mcp.Description("App names whose windows the restore never moves or matches, added to the server's --never-touch list (a comma-separated string is also accepted)")),
+		mcp.WithBoolean("preserve_focus", mcp.Description("Give the focus back to the window that had it before the restore (default false)")),
+		mcp.WithBoolean("backup_first", mcp.Description("Save the current environment as an auto-backup before restoring, so rollback_restore can undo it (default false)")),
 	), s.handleRestoreSnapshot)
 
+	// restore_window
+	s.addTool(mcp.NewTool("restore_window",
+		mcp.WithDescription("Restores only some windows of a snapshot: the one whose title best matches window_title, or every window of app_name. Terminals, tabs and processes are left alone"),
+		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID, unique ID prefix or name of the snapshot")),
+		mcp.WithString("window_title", mcp.Description("Approximate title of the saved window; an ambiguous title fails listing the candidates")),
+		mcp.WithString("app_name", mcp.Description("Restore every saved window of this app, or with window_title, only look among its windows (e.g. Code or Code.exe)")),
+		mcp.WithBoolean("dry_run", mcp.Description("Only report what would be restored, without moving any window")),
+		mcp.WithNumber("min_drift_pixels", mcp.Description("Only move windows whose live position or size is off by more than this many pixels (default 0: move all)")),
+		mcp.WithString("match_profile", mcp.Description("Window matching weights: default, app or title")),
+		mcp.WithBoolean("use_regions", mcp.Description("Place windows captured in a named region into that region's current bounds (default true)")),
+		mcp.WithBoolean("launch_missing", mcp.Description("Launch the recorded executable when no live window matches and place the new window (default false)")),
+		mcp.WithNumber("launch_timeout_seconds", mcp.Description("How long to wait for a launched application's window (default 10)")),
+		mcp.WithBoolean("preserve_focus", mcp.Description("Give the focus back to the window that had it before the restore (default false)")),
+	), s.handleRestoreWindow)
+
+	// switch_to
+	s.addTool(mcp.NewTool("switch_to",
+		mcp.WithDescription("Saves the current environment as an auto-backup snapshot, then restores the target snapshot"),
+		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID, unique ID prefix or name of the snapshot to switch to")),
+		mcp.WithString("backup_name", mcp.Description("Name for the backup snapshot (default: derived from the target)")),
+		mcp.WithBoolean("include_terminals", mcp.Description("Capture terminal sessions in the backup (default true)")),
+		mcp.WithBoolean("include_browsers", mcp.Description("Capture browser tabs in the backup (default true)")),
+		mcp.WithBoolean("sanitize", mcp.Description("Mask tokens, secrets and user paths in the backup (default true)")),
+		mcp.WithNumber("max_tabs", mcp.Description(fmt.Sprintf("Maximum browser tabs to reopen (default %d)", snapshot.DefaultMaxTabs))),
+	), s.handleSwitchTo)
+
+	// list_backups / rollback_restore
+	s.addTool(mcp.NewTool("list_backups", append([]mcp.ToolOption{
+		mcp.WithDescription("Lists the auto-backups taken before switches, newest first, with the snapshot each one preceded"),
+	}, outputToolOptions()...)...), s.handleListBackups)
+	s.addTool(mcp.NewTool("rollback_restore",
+		mcp.WithDescription("Restores an auto-backup, undoing a switch"),
+		mcp.WithString("backup_id", mcp.Description("ID of the backup to restore (default: the most recent one)")),
+		mcp.WithNumber("max_tabs", mcp.Description(fmt.Sprintf("Maximum browser tabs to reopen (default %d)", snapshot.DefaultMaxTabs))),
+	), s.handleRollbackRestore)
+
 	// list_snapshots
-	s.server.AddTool(mcp.NewTool("list_snapshots",
-		mcp.WithDescription("Lists available snapshots"),
-	), s.handleListSnapshots)
+	s.addTool(mcp.NewTool("list_snapshots", append([]mcp.ToolOption{
+		mcp.WithDescription("Lists available snapshots, newest first, with their git branch, tags and window/terminal/tab counts"),
+		mcp.WithString("project", mcp.Description("Only snapshots whose git repository path contains this text")),
+		mcp.WithString("branch", mcp.Description("Only snapshots captured on this git branch")),
+		mcp.WithString("tag", mcp.Description("Only snapshots with this tag")),
+		mcp.WithArray("tags", mcp.WithStringItems(), mcp.Description("Only snapshots with these tags, combined as tag_match says (a comma-separated string is also accepted)")),
+		mcp.WithString("tag_match", mcp.Description("How several tags combine: all (every tag, default) or any (at least one)")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of snapshots to return (default 50)")),
+	}, outputToolOptions()...)...), s.handleListSnapshots)
+
+	// get_snapshot
+	s.addTool(mcp.NewTool("get_snapshot",
+		mcp.WithDescription("Returns one snapshot with all its components (windows with their geometry, terminals, git context, browser tabs, IDE files, processes, tags) as JSON"),
+		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID, unique ID prefix or name of the snapshot")),
+	), s.handleGetSnapshot)
 
 	// delete_snapshot
-	s.server.AddTool(mcp.NewTool("delete_snapshot",
+	s.addTool(mcp.NewTool("delete_snapshot",
 		mcp.WithDescription("Deletes a snapshot by ID"),
-		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID of the snapshot to delete")),
+		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID, unique ID prefix or name of the snapshot to delete")),
 	), s.handleDeleteSnapshot)
 
+	// prune_snapshots
+	s.addTool(mcp.NewTool("prune_snapshots",
+		mcp.WithDescription("Deletes old snapshots by age and/or count. Previews by default; snapshots with the pinned tag (\"pinned\" unless configured) are never pruned"),
+		mcp.WithString("older_than", mcp.Description("Only snapshots older than this, e.g. 30d, 2w or 12h")),
+		mcp.WithNumber("keep_last", mcp.Description("Always keep this many of the newest matching snapshots")),
+		mcp.WithString("tag", mcp.Description("Only snapshots with this tag")),
+		mcp.WithBoolean("dry_run", mcp.Description("List what would be deleted without deleting (default true; pass false to delete)")),
+	), s.handlePruneSnapshots)
+
+	// update_snapshot
+	s.addTool(mcp.NewTool("update_snapshot",
+		mcp.WithDescription("Renames a snapshot or changes its description and tags"),
+		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID, unique ID prefix or name of the snapshot to update")),
+		mcp.WithString("name", mcp.Description("New name")),
+		mcp.WithString("description", mcp.Description("New description; an empty string clears it")),
+		mcp.WithArray("tags", mcp.WithStringItems(), mcp.Description("Replace all tags with these (an empty array clears them)")),
+		mcp.WithArray("add_tags", mcp.WithStringItems(), mcp.Description("Tags to add to the current ones")),
+		mcp.WithArray("remove_tags", mcp.WithStringItems(), mcp.Description("Tags to remove")),
+	), s.handleUpdateSnapshot)
+
 	// diff_snapshots
-	s.server.AddTool(mcp.NewTool("diff_snapshots",
-		mcp.WithDescription("Diffs two snapshots"),
-		mcp.WithString("source_id", mcp.Required(), mcp.Description("Source Snapshot ID")),
-		mcp.WithString("target_id", mcp.Required(), mcp.Description("Target Snapshot ID")),
-	), s.handleDiffSnapshots)
+	s.addTool(mcp.NewTool("diff_snapshots", append([]mcp.ToolOption{
+		mcp.WithDescription("Diffs two snapshots: windows added, removed and moved or resized, browser tabs, IDE files, terminals and git branch, HEAD and dirty state"),
+		mcp.WithString("source_id", mcp.Required(), mcp.Description("Source snapshot ID, unique ID prefix or name")),
+		mcp.WithString("target_id", mcp.Required(), mcp.Description("Target snapshot ID, unique ID prefix or name")),
+	}, outputToolOptions()...)...), s.handleDiffSnapshots)
+
+	// diff_live
+	s.addTool(mcp.NewTool("diff_live", append([]mcp.ToolOption{
+		mcp.WithDescription("Shows what changed since a snapshot: reads the current environment like a capture, without saving it, and diffs the snapshot against it"),
+		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID, unique ID prefix or name of the snapshot to compare with the live environment")),
+		mcp.WithBoolean("include_terminals", mcp.Description("Read and compare terminals (default true)")),
+		mcp.WithBoolean("include_browsers", mcp.Description("Read and compare browser tabs (default true)")),
+	}, outputToolOptions()...)...), s.handleDiffLive)
+
+	// diff_external
+	s.addTool(mcp.NewTool("diff_external", append([]mcp.ToolOption{
+		mcp.WithDescription("Diffs a saved snapshot against an exported snapshot (e.g. a shared file) without importing it"),
+		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID, unique ID prefix or name of the saved snapshot (the diff source)")),
+		mcp.WithString("data", mcp.Description("Exported snapshot JSON, as written by export_snapshot")),
+		mcp.WithString("path", mcp.Description("Path of an exported snapshot file, instead of data")),
+	}, outputToolOptions()...)...), s.handleDiffExternal)
+
+	// storage_breakdown
+	s.addTool(mcp.NewTool("storage_breakdown", append([]mcp.ToolOption{
+		mcp.WithDescription("Ranks snapshots by estimated database space, to decide what to prune"),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of snapshots to show (default 20)")),
+	}, outputToolOptions()...)...), s.handleStorageBreakdown)
+
+	// capture_metrics
+	s.addTool(mcp.NewTool("capture_metrics", append([]mcp.ToolOption{
+		mcp.WithDescription("Shows how long recent captures took, phase by phase, to spot captures getting slower"),
+		mcp.WithString("snapshot_id", mcp.Description("Only this snapshot's capture (ID, unique ID prefix or name; deleted snapshots by full ID)")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of captures to show (default 20)")),
+	}, outputToolOptions()...)...), s.handleCaptureMetrics)
+
+	// verify_snapshot
+	s.addTool(mcp.NewTool("verify_snapshot", append([]mcp.ToolOption{
+		mcp.WithDescription("Verifies a snapshot's stored data against its checksum"),
+		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID, unique ID prefix or name of the snapshot to verify")),
+	}, outputToolOptions()...)...), s.handleVerifySnapshot)
+
+	// export_snapshot / import_snapshot
+	s.addTool(mcp.NewTool("export_snapshot",
+		mcp.WithDescription("Exports a snapshot with all its components as a portable JSON document"),
+		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID, unique ID prefix or name of the snapshot to export")),
+		mcp.WithString("path", mcp.Description("File to write the export to; when omitted the JSON is returned in the result")),
+		mcp.WithBoolean("sanitize", mcp.Description("Mask URL tokens, secret environment variables, launch arguments, user paths and other sensitive values, and leave out sessions (default true; false keeps them for moving a snapshot between your own machines)")),
+	), s.handleExportSnapshot)
+
+	s.addTool(mcp.NewTool("export_restore_script",
+		mcp.WithDescription("Renders a snapshot as a best-effort script that relaunches its applications, terminals and browser tabs on a machine without this server. Fails if the content looks like it embeds secrets"),
+		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID, unique ID prefix or name of the snapshot to render")),
+		mcp.WithString("shell", mcp.Description("Script language: powershell (default) or bash")),
+		mcp.WithString("path", mcp.Description("File to write the script to; when omitted the script is returned in the result")),
+	), s.handleExportRestoreScript)
+
+	s.addTool(mcp.NewTool("import_snapshot",
+		mcp.WithDescription("Imports a snapshot exported with export_snapshot, under a new ID. The import is sanitized and never runs anything from the document: restores don't launch its applications or processes"),
+		mcp.WithString("path", mcp.Description("File containing the export")),
+		mcp.WithString("data", mcp.Description("The export document inline, when no path is given")),
+		mcp.WithString("json", mcp.Description("Deprecated alias of data")),
+	), s.handleImportSnapshot)
+
+	s.addTool(mcp.NewTool("lineage",
+		mcp.WithDescription("Shows where a snapshot came from: its origin (captured, backup, imported...) and the tree of snapshots it was derived from"),
+		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID, unique ID prefix or name of the snapshot")),
+	), s.handleLineage)
+
+	// start_session / end_session / list_sessions
+	s.addTool(mcp.NewTool("start_session",
+		mcp.WithDescription("Starts a focus session: captures a session-start snapshot so end_session can summarize how the environment evolved"),
+		mcp.WithString("label", mcp.Required(), mcp.Description("What the session is about")),
+		mcp.WithNumber("planned_minutes", mcp.Description("Planned duration; the summary reports overruns (default: open-ended)")),
+		mcp.WithBoolean("include_terminals", mcp.Description("Capture terminal sessions (default true)")),
+		mcp.WithBoolean("include_browsers", mcp.Description("Capture browser tabs (default true)")),
+		mcp.WithBoolean("sanitize", mcp.Description("Mask tokens, secrets and user paths (default true)")),
+	), s.handleStartSession)
+	s.addTool(mcp.NewTool("end_session",
+		mcp.WithDescription("Ends a focus session: captures an end snapshot and summarizes windows opened and closed, tabs, git movement, drift and duration"),
+		mcp.WithString("session_id", mcp.Description("Session to end (default: the active one)")),
+		mcp.WithBoolean("include_terminals", mcp.Description("Capture terminal sessions (default true)")),
+		mcp.WithBoolean("include_browsers", mcp.Description("Capture browser tabs (default true)")),
+		mcp.WithBoolean("sanitize", mcp.Description("Mask tokens, secrets and user paths (default true)")),
+	), s.handleEndSession)
+	s.addTool(mcp.NewTool("list_sessions", append([]mcp.ToolOption{
+		mcp.WithDescription("Lists focus sessions, newest first, with their snapshots and drift"),
+	}, outputToolOptions()...)...), s.handleListSessions)
+
+	// server_status
+	s.addTool(mcp.NewTool("server_status",
+		mcp.WithDescription("Reports the active adapter, the snapshot database and whether stored history was lost while running"),
+	), s.handleServerStatus)
+
+	// capabilities
+	s.addTool(mcp.NewTool("capabilities",
+		mcp.WithDescription("Reports which operations the active platform adapter supports (window positioning, tab URLs, terminal restore, ...) as JSON"),
+	), s.handleCapabilities)
+
+	// usage_report (only with WithUsageStats)
+	if s.usage != nil {
+		s.addTool(mcp.NewTool("usage_report",
+			mcp.WithDescription("Summarizes the locally recorded tool usage: calls, failures, durations and which options were used"),
+			mcp.WithString("from", mcp.Description("First day to include, YYYY-MM-DD (default: all recorded history)")),
+			mcp.WithString("to", mcp.Description("Last day to include, YYYY-MM-DD (default: today)")),
+			mcp.WithBoolean("export_usage_csv", mcp.Description("Return the aggregates as CSV for sharing manually (default false)")),
+		), s.handleUsageReport)
+	}
+
+	// publish_snapshot / list_shared / pull_shared (only with WithSharedDir)
+	if s.shared != nil {
+		s.addTool(mcp.NewTool("publish_snapshot",
+			mcp.WithDescription("Publishes a sanitized export of a snapshot to the shared team directory"),
+			mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("ID, unique ID prefix or name of the snapshot to publish")),
+			mcp.WithString("author", mcp.Description("Author recorded with the snapshot (default: the server's configured author)")),
+		), s.handlePublishSnapshot)
+		s.addTool(mcp.NewTool("list_shared",
+			mcp.WithDescription("Lists the snapshots published to the shared team directory, newest first"),
+		), s.handleListShared)
+		s.addTool(mcp.NewTool("pull_shared",
+			mcp.WithDescription("Imports a snapshot from the shared team directory under a new ID, after verifying its checksum and sanitizing it again. Nothing in the file is executed"),
+			mcp.WithString("file", mcp.Required(), mcp.Description("File name as shown by list_shared")),
+		), s.handlePullShared)
+	}
+
+	if s.autoSnapshot != nil {
+		s.addTool(mcp.NewTool("enable_auto_snapshot",
+			mcp.WithDescription(fmt.Sprintf("Starts (or reconfigures) background snapshots every interval, tagged %q, keeping only the newest ones. Cycles are skipped while a manual capture runs", schedule.AutoTag)),
+			mcp.WithNumber("interval_minutes", mcp.Description("Minutes between auto snapshots (default: the last interval used)")),
+			mcp.WithNumber("keep_last", mcp.Description(fmt.Sprintf("Auto snapshots to keep, older ones are pruned; 0 keeps all (default %d)", schedule.DefaultKeepLast))),
+		), s.handleEnableAutoSnapshot)
+		s.addTool(mcp.NewTool("disable_auto_snapshot",
+			mcp.WithDescription("Stops the background auto snapshots, waiting for a capture in progress"),
+		), s.handleDisableAutoSnapshot)
+	}
 }
 
-func (s *MCPServer) handleCaptureSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var name, desc string
-	if request.Params.Arguments != nil {
-		if args, ok := request.Params.Arguments.(map[string]interface{}); ok {
-			if v, ok := args["name"].(string); ok {
-				name = v
+// PlatformMetrics is implemented by adapters that time their own calls
+type PlatformMetrics interface {
+	Metrics() []platform.MethodMetrics
+}
+
+// RegisterPlatformMetrics adds the platform_metrics tool reporting adapter call latencies
+func (s *MCPServer) RegisterPlatformMetrics(source PlatformMetrics) {
+	s.addTool(mcp.NewTool("platform_metrics",
+		mcp.WithDescription("Shows call counts and latencies of the platform adapter, to diagnose slow captures and restores"),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(formatPlatformMetrics(source.Metrics())), nil
+	})
+}
+
+// formatPlatformMetrics renders one line per adapter method plus its latency histogram
+func formatPlatformMetrics(metrics []platform.MethodMetrics) string {
+	if len(metrics) == 0 {
+		return "No platform calls recorded yet."
+	}
+
+	result := "Platform adapter metrics:\n"
+	for _, mm := range metrics {
+		result += fmt.Sprintf("- %s: %d call(s), %d error(s), avg %s, max %s\n",
+			mm.Method, mm.Calls, mm.Errors, mm.Average().Round(time.Microsecond), mm.Max.Round(time.Microsecond))
+
+		var buckets []string
+		for i, count := range mm.Buckets {
+			if count == 0 {
+				continue
 			}
-			if v, ok := args["description"].(string); ok {
-				desc = v
+			if i < len(platform.LatencyBuckets) {
+				buckets = append(buckets, fmt.Sprintf("<=%s: %d", platform.LatencyBuckets[i], count))
+			} else {
+				buckets = append(buckets, fmt.Sprintf(">%s: %d", platform.LatencyBuckets[i-1], count))
 			}
 		}
+		result += fmt.Sprintf("    %s\n", strings.Join(buckets, ", "))
 	}
+	return result
+}
+
+// ScenarioClock is the control surface of a scenario-driven mock adapter
+type ScenarioClock interface {
+	Advance() (int, error)
+	Step() (int, int)
+}
+
+// RegisterScenarioClock adds the dev-only tool that advances a mock scenario
+func (s *MCPServer) RegisterScenarioClock(clock ScenarioClock) {
+	s.addTool(mcp.NewTool("advance_mock_scenario",
+		mcp.WithDescription("[dev] Advances the mock scenario to its next step so the next capture differs"),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		step, err := clock.Advance()
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to advance scenario: %v", err)), nil
+		}
+		_, total := clock.Step()
+		return mcp.NewToolResultText(fmt.Sprintf("Mock scenario now at step t%d (of %d)", step, total)), nil
+	})
+}
+
+func (s *MCPServer) handleCaptureSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
 
 	snap, err := s.manager.Capture(ctx, snapshot.CaptureOptions{
-		Name:        name,
-		Description: desc,
-		// Defaults
-		IncludeBrowsable: true,
-		IncludeTerminals: true,
-		Sanitize:         true,
+		Name:               stringArg(args, "name"),
+		Description:        stringArg(args, "description"),
+		Tags:               tagsArg(args, "tags"),
+		IncludeBrowsable:   boolArg(args, "include_browsers", true),
+		BrowserDeepCapture: boolArg(args, "deep_browser_capture", false),
+		IncludeTerminals:   boolArg(args, "include_terminals", true),
+		IncludeProcesses:   boolArg(args, "include_processes", false),
+		RestartProcesses:   tagsArg(args, "restart_processes"),
+		RecordRegions:      boolArg(args, "record_regions", true),
+		Sanitize:           boolArg(args, "sanitize", true),
+		Monitors:           intSliceArg(args, "monitors"),
 	})
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to capture: %v", err)), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Snapshot captured successfully! ID: %s, Name: %s", snap.ID, snap.Name)), nil
+	result := fmt.Sprintf("Snapshot captured successfully! ID: %s, Name: %s", snap.ID, snap.Name)
+	if len(snap.Tags) > 0 {
+		result += fmt.Sprintf(", Tags: %s", strings.Join(snap.Tags, ", "))
+	}
+	for _, w := range snap.Warnings {
+		result += fmt.Sprintf("\n  ! %s", w)
+	}
+	return mcp.NewToolResultText(result), nil
 }
 
 func (s *MCPServer) handleRestoreSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var id string
-	if request.Params.Arguments != nil {
-		if args, ok := request.Params.Arguments.(map[string]interface{}); ok {
-			id, _ = args["snapshot_id"].(string)
+	args := toolArgs(request)
+	id, failure := s.resolveRef(ctx, args, "snapshot_id", "restore")
+	if failure != nil {
+		return failure, nil
+	}
+
+	opts := snapshot.RestoreOptions{
+		ValidateBeforeRestore: boolArg(args, "validate_before_restore", boolArg(args, "validate", false)), // Default false for basic restore tool
+		SkipMissingApps:       boolArg(args, "skip_missing_apps", true),
+		DryRun:                boolArg(args, "dry_run", false),
+		RespectManualChanges:  boolArg(args, "respect_manual_changes", false),
+		ForcePosition:         boolArg(args, "force_position", false),
+		MaxTabs:               intArg(args, "max_tabs", 0),
+		SkipTabs:              !boolArg(args, "restore_tabs", true),
+		SkipTerminals:         !boolArg(args, "restore_terminals", true),
+		UseRegions:            boolArg(args, "use_regions", true),
+		LaunchMissing:         boolArg(args, "launch_missing", false),
+		LaunchTimeout:         time.Duration(intArg(args, "launch_timeout_seconds", 0)) * time.Second,
+		MatchProfile:          stringArg(args, "match_profile"),
+		PreserveCurrentFocus:  boolArg(args, "preserve_focus", false),
+		NeverTouch:            tagsArg(args, "never_touch"),
+		MinDriftPixels:        intArg(args, "min_drift_pixels", 0),
+	}
+
+	var report *snapshot.RestoreReport
+	var backup string
+	var err error
+	if boolArg(args, "backup_first", false) && !opts.DryRun {
+		var res *snapshot.SwitchResult
+		res, err = s.manager.SwitchTo(ctx, id, backupCaptureOptions(args), opts)
+		if res != nil {
+			report = res.Report
+			backup = fmt.Sprintf("Backup saved: ID: %s, Name: %s (use rollback_restore to undo)\n", res.Backup.ID, res.Backup.Name)
 		}
+	} else {
+		report, err = s.manager.Restore(ctx, id, opts)
 	}
+	if err != nil {
+		if report != nil && len(report.MissingApps) > 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to restore: these applications are not running, launch them first and retry:\n- %s",
+				strings.Join(report.MissingApps, "\n- "))), nil
+		}
+		if errors.Is(err, snapshot.ErrNoLiveWindows) {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to restore: %v. Retry in a moment, or pass launch_missing: true to launch the snapshot's applications", err)), nil
+		}
+		return toolFailure("restore", err), nil
+	}
+
+	result := backup + formatRestoreResult(report)
+
+	reportJSON, err := formatRestoreReportJSON(report)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to encode restore report: %v", err)), nil
+	}
+	return mcp.NewToolResultText(result + "\nReport:\n" + reportJSON), nil
+}
 
-	report, err := s.manager.Restore(ctx, id, snapshot.RestoreOptions{
-		ValidateBeforeRestore: false, // Default false for basic restore tool
-		SkipMissingApps:       true,
-		DryRun:                false,
+func (s *MCPServer) handleRestoreWindow(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	sel := snapshot.WindowSelector{Title: stringArg(args, "window_title"), AppName: stringArg(args, "app_name")}
+	if strings.TrimSpace(sel.Title) == "" && strings.TrimSpace(sel.AppName) == "" {
+		return mcp.NewToolResultError("window_title or app_name is required"), nil
+	}
+	id, failure := s.resolveRef(ctx, args, "snapshot_id", "restore")
+	if failure != nil {
+		return failure, nil
+	}
+
+	report, err := s.manager.RestoreWindow(ctx, id, sel, snapshot.RestoreOptions{
+		DryRun:               boolArg(args, "dry_run", false),
+		UseRegions:           boolArg(args, "use_regions", true),
+		LaunchMissing:        boolArg(args, "launch_missing", false),
+		LaunchTimeout:        time.Duration(intArg(args, "launch_timeout_seconds", 0)) * time.Second,
+		MatchProfile:         stringArg(args, "match_profile"),
+		PreserveCurrentFocus: boolArg(args, "preserve_focus", false),
+		MinDriftPixels:       intArg(args, "min_drift_pixels", 0),
 	})
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to restore: %v", err)), nil
+		if errors.Is(err, snapshot.ErrNoLiveWindows) {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to restore: %v. Retry in a moment, or pass launch_missing: true to launch the application", err)), nil
+		}
+		return toolFailure("restore", err), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Restore Completed: %s", report.Message)), nil
+	reportJSON, err := formatRestoreReportJSON(report)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to encode restore report: %v", err)), nil
+	}
+	return mcp.NewToolResultText(formatRestoreResult(report) + "\nReport:\n" + reportJSON), nil
+}
+
+// toolFailure renders a failed operation, telling the caller to retry when a snapshot is busy
+func toolFailure(action string, err error) *mcp.CallToolResult {
+	if errors.Is(err, snapshot.ErrSnapshotBusy) {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to %s: %v. Another call is using this snapshot; retry once it finishes", action, err))
+	}
+	if errors.Is(err, core.ErrSnapshotNotFound) {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to %s: %v. Use list_snapshots to see the saved snapshots", action, err))
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("Failed to %s: %v", action, err))
+}
+
+// resolveRef turns the snapshot ID, unique ID prefix or exact name given in
+// args[key] into a snapshot ID. On failure it returns the tool error to send.
+func (s *MCPServer) resolveRef(ctx context.Context, args map[string]interface{}, key, action string) (string, *mcp.CallToolResult) {
+	id, err := s.manager.ResolveSnapshotRef(ctx, stringArg(args, key))
+	if err != nil {
+		return "", toolFailure(action, err)
+	}
+	return id, nil
+}
+
+// formatRestoreResult renders the human-readable summary of a restore or dry run
+func formatRestoreResult(report *snapshot.RestoreReport) string {
+	if report.DryRun {
+		return formatRestorePlan(report)
+	}
+	result := fmt.Sprintf("Restore Completed: %s\n", report.Message)
+	if report.Cancelled {
+		result = fmt.Sprintf("Restore Cancelled: %s (partial, nothing was undone)\n", report.Message)
+	}
+	if len(report.CancelledWindows) > 0 {
+		result += fmt.Sprintf("- Not attempted: %s\n", strings.Join(report.CancelledWindows, ", "))
+	}
+	if report.LaunchedWindows > 0 {
+		result += fmt.Sprintf("- Launched %d missing application(s), repositioned %d existing window(s)\n",
+			report.LaunchedWindows, report.RestoredWindows-report.LaunchedWindows)
+	}
+	if len(report.MissingApps) > 0 {
+		result += fmt.Sprintf("- Skipped missing applications: %s\n", strings.Join(report.MissingApps, ", "))
+	}
+	for _, title := range report.ManuallyAdjusted {
+		result += fmt.Sprintf("- %s: manually adjusted, left alone\n", title)
+	}
+	for _, title := range report.AlreadyCorrect {
+		result += fmt.Sprintf("- %s: already correct\n", title)
+	}
+	for _, title := range report.ProtectedWindows {
+		result += fmt.Sprintf("- %s: protected, skipped\n", title)
+	}
+	for _, title := range report.OtherDesktop {
+		result += fmt.Sprintf("- %s: matched window is on another virtual desktop, positioned there\n", title)
+	}
+	for _, group := range report.PartialGroups {
+		result += fmt.Sprintf("- Snap %s\n", group)
+	}
+	if report.RestoredTerminals > 0 {
+		result += fmt.Sprintf("- Reopened %d terminal(s)\n", report.RestoredTerminals)
+	}
+	if report.RestoredProcesses > 0 {
+		result += fmt.Sprintf("- Relaunched %d background process(es)\n", report.RestoredProcesses)
+	}
+	if report.RestoredTabs > 0 || report.FailedTabs > 0 {
+		result += fmt.Sprintf("- Reopened %d browser tab(s), %d failed\n", report.RestoredTabs, report.FailedTabs)
+	}
+	if len(report.WithheldTabs) > 0 {
+		result += fmt.Sprintf("- %d tab(s) not reopened (raise max_tabs to open them):\n", len(report.WithheldTabs))
+		for _, u := range report.WithheldTabs {
+			result += fmt.Sprintf("    %s\n", u)
+		}
+	}
+	for _, note := range report.Notes {
+		result += fmt.Sprintf("- %s\n", note)
+	}
+	result += formatNotices(report.Warnings)
+	return result
+}
+
+// formatRestoreReportJSON serializes the full report so clients can reason about partial failures
+func formatRestoreReportJSON(report *snapshot.RestoreReport) (string, error) {
+	payload := struct {
+		*snapshot.RestoreReport
+		Duration string `json:"duration"`
+	}{report, report.Duration.String()}
+
+	b, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// formatRestorePlan renders a dry-run report: the windows that would move and the apps not running
+func formatRestorePlan(report *snapshot.RestoreReport) string {
+	result := fmt.Sprintf("Dry run for snapshot %s (%d windows, no changes made):\n", report.SnapshotID, report.TotalWindows)
+	for _, p := range report.Plan {
+		result += fmt.Sprintf("- %s [%s] -> (%d, %d) %dx%d", p.WindowTitle, p.AppName, p.X, p.Y, p.Width, p.Height)
+		if p.State != "" && p.State != "normal" {
+			result += " " + p.State
+		}
+		if p.GroupID != 0 {
+			result += fmt.Sprintf(" [snap group %d]", p.GroupID)
+		}
+		if p.MatchedTitle != "" {
+			result += fmt.Sprintf(" via live window %q (score %d)", p.MatchedTitle, p.MatchScore)
+			if p.OtherDesktop {
+				result += " on another virtual desktop"
+			}
+		} else if p.LaunchPath != "" {
+			result += " (no matching live window, would launch " + formatCommandLine(p.LaunchPath, p.LaunchArgs) + ")"
+		} else {
+			result += " (no matching live window)"
+		}
+		result += "\n"
+	}
+	for _, title := range report.ProtectedWindows {
+		result += fmt.Sprintf("- %s: protected, skipped\n", title)
+	}
+	if len(report.MissingApps) > 0 {
+		result += "- Missing applications (not running):\n"
+		for _, app := range report.MissingApps {
+			result += fmt.Sprintf("  ! %s\n", app)
+		}
+	}
+	for _, note := range report.Notes {
+		result += fmt.Sprintf("- %s\n", note)
+	}
+	result += formatNotices(report.Warnings)
+	return result
+}
+
+// formatCommandLine shows a planned launch; arguments are quoted so spaces
+// and empty arguments stay visible
+func formatCommandLine(path string, args []string) string {
+	line := path
+	for _, arg := range args {
+		line += " " + strconv.Quote(arg)
+	}
+	return line
+}
+
+func (s *MCPServer) handleSwitchTo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	id, failure := s.resolveRef(ctx, args, "snapshot_id", "switch")
+	if failure != nil {
+		return failure, nil
+	}
+
+	res, err := s.manager.SwitchTo(ctx, id, backupCaptureOptions(args), snapshot.RestoreOptions{
+		SkipMissingApps: true,
+		MaxTabs:         intArg(args, "max_tabs", 0),
+		UseRegions:      true,
+	})
+	if err != nil {
+		return toolFailure("switch", err), nil
+	}
+
+	result := fmt.Sprintf("Backup saved: ID: %s, Name: %s (restore it to switch back)\n", res.Backup.ID, res.Backup.Name)
+	result += formatRestoreResult(res.Report)
+
+	reportJSON, err := formatRestoreReportJSON(res.Report)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to encode restore report: %v", err)), nil
+	}
+	return mcp.NewToolResultText(result + "\nReport:\n" + reportJSON), nil
+}
+
+// backupCaptureOptions are the capture settings of the auto-backup taken before a switch
+func backupCaptureOptions(args map[string]interface{}) snapshot.CaptureOptions {
+	return snapshot.CaptureOptions{
+		Name:             stringArg(args, "backup_name"),
+		IncludeBrowsable: boolArg(args, "include_browsers", true),
+		IncludeTerminals: boolArg(args, "include_terminals", true),
+		RecordRegions:    true,
+		Sanitize:         boolArg(args, "sanitize", true),
+	}
+}
+
+func (s *MCPServer) handleListBackups(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	out := outputArgs(toolArgs(request))
+
+	backups, err := s.manager.ListBackups(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list backups: %v", err)), nil
+	}
+	names, err := s.manager.BackupTargets(ctx, backups.Snapshots)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to look up backup targets: %v", err)), nil
+	}
+
+	summary := section{priority: prioritySummary}
+	if out.compact {
+		summary.lines = []string{fmt.Sprintf("backups total=%d max=%d offset=%d", len(backups.Snapshots), snapshot.MaxBackups, out.offset)}
+	} else {
+		summary.lines = []string{fmt.Sprintf("%d auto-backup(s), the newest %d are kept:", len(backups.Snapshots), snapshot.MaxBackups)}
+	}
+
+	items := section{priority: prioritySummary, paged: true}
+	for _, b := range pageItems(backups.Snapshots, out.offset) {
+		if out.compact {
+			items.lines = append(items.lines, fmt.Sprintf("backup id=%s created=%s before=%s",
+				b.ID, b.CreatedAt.UTC().Format(time.RFC3339), b.BackupFor))
+			continue
+		}
+		line := fmt.Sprintf("- [%s] %s (%s)", b.ID, b.Name, b.CreatedAt.Format(time.RFC822))
+		switch name, ok := names[b.BackupFor]; {
+		case b.BackupFor == "":
+			line += ", switch target unknown"
+		case ok:
+			line += fmt.Sprintf(", before switching to %s [%s]", name, b.BackupFor)
+		default:
+			line += fmt.Sprintf(", before switching to deleted snapshot %s", b.BackupFor)
+		}
+		items.lines = append(items.lines, line)
+	}
+
+	return mcp.NewToolResultText(renderSections([]section{summary, items, noticeSection(backups.Warnings, out.compact)}, out)), nil
+}
+
+func (s *MCPServer) handleRollbackRestore(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+
+	backup, report, err := s.manager.Rollback(ctx, stringArg(args, "backup_id"), snapshot.RestoreOptions{
+		SkipMissingApps: true,
+		MaxTabs:         intArg(args, "max_tabs", 0),
+		UseRegions:      true,
+	})
+	if err != nil {
+		return toolFailure("roll back", err), nil
+	}
+
+	result := fmt.Sprintf("Rolled back to backup %s (%s)\n", backup.ID, backup.Name)
+	result += formatRestoreResult(report)
+
+	reportJSON, err := formatRestoreReportJSON(report)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to encode restore report: %v", err)), nil
+	}
+	return mcp.NewToolResultText(result + "\nReport:\n" + reportJSON), nil
 }
 
 func (s *MCPServer) handleListSnapshots(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	snaps, err := s.manager.List(ctx)
+	args := toolArgs(request)
+	out := outputArgs(args)
+
+	// The offset pages in the database, so the items below are already paged
+	filter := core.SnapshotFilter{
+		Project:  stringArg(args, "project"),
+		Branch:   stringArg(args, "branch"),
+		Tags:     tagsArg(args, "tags"),
+		TagMatch: core.TagMatchMode(strings.ToLower(stringArg(args, "tag_match"))),
+		Limit:    intArg(args, "limit", 0),
+		Offset:   out.offset,
+	}
+	if tag := stringArg(args, "tag"); tag != "" {
+		filter.Tags = append(filter.Tags, tag)
+	}
+	if filter.TagMatch != "" && filter.TagMatch != core.TagMatchAll && filter.TagMatch != core.TagMatchAny {
+		return mcp.NewToolResultError("tag_match must be all or any"), nil
+	}
+	list, err := s.manager.ListFiltered(ctx, filter)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to list snapshots: %v", err)), nil
 	}
 
-	// Format as JSON or Table
-	// Simple text list for now
-	var result string
-	for _, snap := range snaps {
-		result += fmt.Sprintf("- [%s] %s (%s)\n", snap.ID, snap.Name, snap.CreatedAt.Format(time.RFC822))
+	items := section{priority: prioritySummary, paged: true}
+	for _, snap := range list.Snapshots {
+		counts := list.Counts[snap.ID]
+		var line string
+		if out.compact {
+			line = fmt.Sprintf("snapshot id=%s name=%q created=%s tags=%s branch=%s windows=%d terminals=%d tabs=%d",
+				snap.ID, snap.Name, snap.CreatedAt.UTC().Format(time.RFC3339), strings.Join(snap.Tags, ","),
+				snap.GitBranch, counts.Windows, counts.Terminals, counts.BrowserTabs)
+		} else {
+			line = fmt.Sprintf("- [%s] %s (%s)", snap.ID, snap.Name, snap.CreatedAt.Format(time.RFC822))
+			if snap.GitBranch != "" {
+				line += fmt.Sprintf(" on %s", snap.GitBranch)
+			}
+			line += fmt.Sprintf(", %d windows, %d terminals, %d tabs", counts.Windows, counts.Terminals, counts.BrowserTabs)
+			if len(snap.Tags) > 0 {
+				line += fmt.Sprintf(" #%s", strings.Join(snap.Tags, " #"))
+			}
+		}
+		items.lines = append(items.lines, line)
 	}
-	if result == "" {
-		result = "No snapshots found."
+
+	var summary section
+	switch {
+	case out.compact:
+		summary.lines = []string{fmt.Sprintf("snapshots total=%d offset=%d count=%d has_more=%t", list.Total, out.offset, len(list.Snapshots), list.HasMore)}
+	case list.Total == 0:
+		summary.lines = []string{"No snapshots found."}
+	case len(list.Snapshots) == 0:
+		summary.lines = []string{fmt.Sprintf("No snapshots at offset %d (total %d).", out.offset, list.Total)}
+	case list.HasMore:
+		summary.lines = []string{fmt.Sprintf("Showing %d-%d of %d snapshots (more available).", out.offset+1, out.offset+len(list.Snapshots), list.Total)}
+	default:
+		summary.lines = []string{fmt.Sprintf("Showing %d-%d of %d snapshots.", out.offset+1, out.offset+len(list.Snapshots), list.Total)}
 	}
 
-	return mcp.NewToolResultText(result), nil
+	return mcp.NewToolResultText(renderSections([]section{summary, items, noticeSection(list.Warnings, out.compact)}, out)), nil
 }
 
 func (s *MCPServer) handleDeleteSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var id string
-	if request.Params.Arguments != nil {
-		if args, ok := request.Params.Arguments.(map[string]interface{}); ok {
-			id, _ = args["snapshot_id"].(string)
-		}
+	id, failure := s.resolveRef(ctx, toolArgs(request), "snapshot_id", "delete")
+	if failure != nil {
+		return failure, nil
 	}
 
 	err := s.manager.Delete(ctx, id)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to delete: %v", err)), nil
+		return toolFailure("delete", err), nil
 	}
 
 	return mcp.NewToolResultText(fmt.Sprintf("Snapshot %s deleted successfully", id)), nil
 }
 
-func (s *MCPServer) handleDiffSnapshots(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var id1, id2 string
-	if request.Params.Arguments != nil {
-		if args, ok := request.Params.Arguments.(map[string]interface{}); ok {
-			id1, _ = args["source_id"].(string)
-			id2, _ = args["target_id"].(string)
+func (s *MCPServer) handlePruneSnapshots(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	opts := snapshot.PruneOptions{
+		KeepLast: intArg(args, "keep_last", 0),
+		Tag:      strings.TrimSpace(stringArg(args, "tag")),
+		DryRun:   boolArg(args, "dry_run", true),
+	}
+	if age := stringArg(args, "older_than"); age != "" {
+		d, err := parseAge(age)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to prune: %v", err)), nil
+		}
+		opts.OlderThan = d
+	}
+
+	result, err := s.manager.Prune(ctx, opts)
+	if err != nil {
+		return toolFailure("prune", err), nil
+	}
+
+	var b strings.Builder
+	if result.DryRun {
+		fmt.Fprintf(&b, "Dry run: %d snapshot(s) would be deleted (pass dry_run=false to delete):\n", len(result.Pruned))
+	} else {
+		fmt.Fprintf(&b, "Deleted %d snapshot(s) and %d component row(s):\n", len(result.Pruned), result.ChildRows)
+	}
+	for _, snap := range result.Pruned {
+		fmt.Fprintf(&b, "- [%s] %s (%s)\n", snap.ID, snap.Name, snap.CreatedAt.Format(time.RFC822))
+	}
+	if result.Pinned > 0 {
+		fmt.Fprintf(&b, "Kept %d pinned snapshot(s) that matched\n", result.Pinned)
+	}
+	if len(result.Busy) > 0 {
+		fmt.Fprintf(&b, "Skipped %d snapshot(s) in use by another call: %s\n", len(result.Busy), strings.Join(result.Busy, ", "))
+	}
+	return mcp.NewToolResultText(b.String()), nil
+}
+
+// parseAge reads a duration that also accepts days and weeks, e.g. 30d or 2w
+func parseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(value, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(value, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit == 0 {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("invalid age %q (expected e.g. 30d, 2w or 12h)", value)
+		}
+		return d, nil
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid age %q (expected e.g. 30d, 2w or 12h)", value)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+func (s *MCPServer) handleUpdateSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	id, failure := s.resolveRef(ctx, args, "snapshot_id", "update")
+	if failure != nil {
+		return failure, nil
+	}
+
+	var update snapshot.SnapshotUpdate
+	if v, ok := args["name"].(string); ok {
+		update.Name = &v
+	}
+	if v, ok := args["description"].(string); ok {
+		update.Description = &v
+	}
+	if _, ok := args["tags"]; ok {
+		update.Tags, update.SetTags = tagsArg(args, "tags"), true
+	}
+	update.AddTags = tagsArg(args, "add_tags")
+	update.RemoveTags = tagsArg(args, "remove_tags")
+	if update.Name == nil && update.Description == nil && !update.SetTags && len(update.AddTags) == 0 && len(update.RemoveTags) == 0 {
+		return mcp.NewToolResultError("Nothing to update: pass name, description, tags, add_tags or remove_tags"), nil
+	}
+
+	updated, err := s.manager.UpdateSnapshot(ctx, id, update)
+	if err != nil {
+		return toolFailure("update", err), nil
+	}
+
+	result := fmt.Sprintf("Snapshot %s updated\nName: %s\n", updated.ID, updated.Name)
+	if updated.Description != "" {
+		result += fmt.Sprintf("Description: %s\n", updated.Description)
+	}
+	tags := "none"
+	if len(updated.Tags) > 0 {
+		tags = strings.Join(updated.Tags, ", ")
+	}
+	result += fmt.Sprintf("Tags: %s\n", tags)
+	return mcp.NewToolResultText(result), nil
+}
+
+func (s *MCPServer) handleExportSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	id, failure := s.resolveRef(ctx, args, "snapshot_id", "export")
+	if failure != nil {
+		return failure, nil
+	}
+	path := stringArg(args, "path")
+	opts := snapshot.ExportOptions{Sanitize: boolArg(args, "sanitize", true)}
+
+	if path == "" {
+		var buf bytes.Buffer
+		if err := s.manager.ExportSnapshot(ctx, id, &buf, opts); err != nil {
+			return toolFailure("export", err), nil
+		}
+		return mcp.NewToolResultText(buf.String()), nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to export: %v", err)), nil
+	}
+	err = s.manager.ExportSnapshot(ctx, id, f, opts)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return toolFailure("export", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Snapshot %s exported to %s", id, path)), nil
+}
+
+func (s *MCPServer) handleExportRestoreScript(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	id, failure := s.resolveRef(ctx, args, "snapshot_id", "generate restore script")
+	if failure != nil {
+		return failure, nil
+	}
+	path := stringArg(args, "path")
+	shell := stringArg(args, "shell")
+	if shell == "" {
+		shell = snapshot.ScriptPowerShell
+	}
+
+	// Render to memory first so a refused script never leaves a partial file
+	var buf bytes.Buffer
+	if err := s.manager.RestoreScript(ctx, id, shell, &buf); err != nil {
+		return toolFailure("generate restore script", err), nil
+	}
+	if path == "" {
+		return mcp.NewToolResultText(buf.String()), nil
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to write restore script: %v", err)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Restore script for snapshot %s written to %s (best-effort, review it before running)", id, path)), nil
+}
+
+func (s *MCPServer) handleLineage(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, failure := s.resolveRef(ctx, toolArgs(request), "snapshot_id", "get lineage")
+	if failure != nil {
+		return failure, nil
+	}
+	root, err := s.manager.Lineage(ctx, id)
+	if err != nil {
+		return toolFailure("get lineage", err), nil
+	}
+	var b strings.Builder
+	writeLineage(&b, root, 0)
+	if len(root.Parents) == 0 {
+		b.WriteString("No recorded parents.\n")
+	}
+	return mcp.NewToolResultText(b.String()), nil
+}
+
+// writeLineage renders one node per line, parents indented under their child
+func writeLineage(b *strings.Builder, node *snapshot.LineageNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch {
+	case node.Cycle:
+		fmt.Fprintf(b, "%s- [%s] (cycle, already listed above)\n", indent, node.ID)
+		return
+	case node.Snapshot == nil:
+		fmt.Fprintf(b, "%s- [%s] not in this database\n", indent, node.ID)
+		return
+	}
+	snap := node.Snapshot
+	origin := snap.Origin
+	if origin == "" {
+		origin = "origin unknown"
+	}
+	line := fmt.Sprintf("%s- [%s] %s (%s, %s)", indent, snap.ID, snap.Name, origin, snap.CreatedAt.Format(time.RFC822))
+	if snap.BackupFor != "" {
+		line += fmt.Sprintf(", backup before switching to %s", snap.BackupFor)
+	}
+	b.WriteString(line + "\n")
+	for _, parent := range node.Parents {
+		writeLineage(b, parent, depth+1)
+	}
+}
+
+func (s *MCPServer) handleImportSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	path, inline := stringArg(args, "path"), stringArg(args, "data")
+	if inline == "" {
+		inline = stringArg(args, "json")
+	}
+
+	var r io.Reader
+	switch {
+	case path != "":
+		f, err := os.Open(path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to import: %v", err)), nil
 		}
+		defer f.Close()
+		r = f
+	case inline != "":
+		r = strings.NewReader(inline)
+	default:
+		return mcp.NewToolResultError("Failed to import: provide either path or data"), nil
 	}
 
+	snap, err := s.manager.ImportSnapshot(ctx, r)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to import: %v", err)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Snapshot imported successfully! ID: %s, Name: %s\n%s", snap.ID, snap.Name, formatImportedCounts(snap))), nil
+}
+
+// formatImportedCounts summarizes the components an import stored
+func formatImportedCounts(snap *core.Snapshot) string {
+	return fmt.Sprintf("Imported %d window(s), %d terminal(s), %d browser tab(s), %d IDE file(s), %d process(es)\n",
+		len(snap.Windows), len(snap.Terminals), len(snap.BrowserTabs), len(snap.IDEFiles), len(snap.Processes))
+}
+
+func (s *MCPServer) handleDiffSnapshots(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	id1, failure := s.resolveRef(ctx, args, "source_id", "diff")
+	if failure != nil {
+		return failure, nil
+	}
+	id2, failure := s.resolveRef(ctx, args, "target_id", "diff")
+	if failure != nil {
+		return failure, nil
+	}
+	out := outputArgs(args)
+
 	diff, err := s.manager.Diff(ctx, id1, id2)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to diff: %v", err)), nil
 	}
+	return mcp.NewToolResultText(renderDiff(diff, out)), nil
+}
+
+func (s *MCPServer) handleDiffLive(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	id, failure := s.resolveRef(ctx, args, "snapshot_id", "diff")
+	if failure != nil {
+		return failure, nil
+	}
+
+	// Same defaults as capture_snapshot, so both sides are read alike
+	diff, err := s.manager.DiffLive(ctx, id, snapshot.CaptureOptions{
+		IncludeBrowsable: boolArg(args, "include_browsers", true),
+		IncludeTerminals: boolArg(args, "include_terminals", true),
+		RecordRegions:    true,
+		Sanitize:         true,
+	})
+	if err != nil {
+		return toolFailure("diff", err), nil
+	}
+	return mcp.NewToolResultText(renderDiff(diff, outputArgs(args))), nil
+}
+
+func (s *MCPServer) handleDiffExternal(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	id, failure := s.resolveRef(ctx, args, "snapshot_id", "diff")
+	if failure != nil {
+		return failure, nil
+	}
+	out := outputArgs(args)
+
+	var r io.Reader
+	switch path, inline := stringArg(args, "path"), stringArg(args, "data"); {
+	case path != "":
+		f, err := os.Open(path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to diff: %v", err)), nil
+		}
+		defer f.Close()
+		r = f
+	case inline != "":
+		r = strings.NewReader(inline)
+	default:
+		return mcp.NewToolResultError("Failed to diff: provide either path or data"), nil
+	}
+
+	diff, err := s.manager.DiffExternal(ctx, id, r)
+	if err != nil {
+		return toolFailure("diff", err), nil
+	}
+	return mcp.NewToolResultText(renderDiff(diff, out)), nil
+}
+
+// renderDiff renders every non-empty category of a diff as sections
+func renderDiff(diff *snapshot.DiffResult, out outputOptions) string {
+	summary := section{priority: prioritySummary}
+	git := section{priority: priorityGit}
+	if out.compact {
+		summary.lines = []string{fmt.Sprintf("diff source=%s target=%s common=%d added=%d removed=%d moved=%d tabs=+%d/-%d files=+%d/-%d terminals=+%d/-%d",
+			diff.SourceID, diff.TargetID, diff.CommonWindows, len(diff.AddedWindows), len(diff.RemovedWindows), len(diff.MovedWindows),
+			len(diff.AddedTabs), len(diff.RemovedTabs), len(diff.AddedFiles), len(diff.RemovedFiles), len(diff.AddedTerminals), len(diff.RemovedTerminals))}
+		g := diff.Git
+		git.lines = []string{fmt.Sprintf("git changed=%t branch=%s->%s head=%s->%s dirty=%t->%t",
+			diff.GitChanged, g.BranchFrom, g.BranchTo, shortHash(g.HeadFrom), shortHash(g.HeadTo), g.DirtyFrom, g.DirtyTo)}
+		if len(diff.NotCompared) > 0 {
+			summary.lines = append(summary.lines, fmt.Sprintf("not_compared=%s", strings.Join(diff.NotCompared, ",")))
+		}
+	} else {
+		summary.lines = []string{fmt.Sprintf("Diff between %s and %s:", diff.SourceID, diff.TargetID)}
+		if len(diff.NotCompared) > 0 {
+			summary.lines = append(summary.lines, fmt.Sprintf("- Not compared (not read on both sides): %s", strings.Join(diff.NotCompared, ", ")))
+		}
+		git.lines = formatGitDiff(diff.Git)
+		git.lines = append(git.lines, fmt.Sprintf("- Common Windows: %d", diff.CommonWindows))
+	}
+
+	// Compact items are quoted and prefixed with their kind, text items get a
+	// +/-/~ mark under a header
+	item := func(kind, mark string, values []string) []string {
+		lines := make([]string, len(values))
+		for i, v := range values {
+			if out.compact {
+				lines[i] = fmt.Sprintf("%s %q", kind, v)
+			} else {
+				lines[i] = "  " + mark + " " + v
+			}
+		}
+		return lines
+	}
+	moved := make([]string, len(diff.MovedWindows))
+	for i, mv := range diff.MovedWindows {
+		if out.compact {
+			moved[i] = fmt.Sprintf("moved %q from=%s to=%s", mv.WindowTitle, formatCompactGeometry(mv.Before), formatCompactGeometry(mv.After))
+		} else {
+			moved[i] = fmt.Sprintf("  ~ %s: %s -> %s", mv.WindowTitle, formatGeometry(mv.Before), formatGeometry(mv.After))
+		}
+	}
+
+	// offset pages through the lists in this order; empty ones are skipped
+	lists := []struct {
+		priority int
+		header   string
+		lines    []string
+	}{
+		{priorityWindows, "- Added Windows:\n", item("added", "+", diff.AddedWindows)},
+		{priorityWindows, "- Removed Windows:\n", item("removed", "-", diff.RemovedWindows)},
+		{priorityWindows, "- Moved/Resized Windows:\n", moved},
+		{priorityTabs, "- Added Tabs:\n", item("added_tab", "+", diff.AddedTabs)},
+		{priorityTabs, "- Removed Tabs:\n", item("removed_tab", "-", diff.RemovedTabs)},
+		{priorityTabs, "- Added IDE Files:\n", item("added_file", "+", diff.AddedFiles)},
+		{priorityTabs, "- Removed IDE Files:\n", item("removed_file", "-", diff.RemovedFiles)},
+		{priorityTabs, "- Added Terminals:\n", item("added_terminal", "+", diff.AddedTerminals)},
+		{priorityTabs, "- Removed Terminals:\n", item("removed_terminal", "-", diff.RemovedTerminals)},
+	}
+	sections := []section{summary, git}
+	skip := out.offset
+	for _, list := range lists {
+		sec := section{priority: list.priority, paged: true, lines: pageItems(list.lines, skip)}
+		if !out.compact {
+			sec.header = list.header
+		}
+		sections = append(sections, sec)
+		skip = max(skip-len(list.lines), 0)
+	}
+	sections = append(sections, noticeSection(diff.Warnings, out.compact))
 
-	result := fmt.Sprintf("Diff between %s and %s:\n", diff.SourceID, diff.TargetID)
-	if diff.GitChanged {
-		result += "- Git Context Changed: Yes\n"
+	return renderSections(sections, out)
+}
+
+// formatGitDiff renders the git context of a diff, one line per aspect
+func formatGitDiff(g snapshot.GitDiff) []string {
+	if !g.Changed() {
+		return []string{"- Git Context Changed: No"}
+	}
+	lines := []string{"- Git Context Changed: Yes"}
+	if g.RepoFrom != g.RepoTo {
+		lines = append(lines, fmt.Sprintf("  Repository: %s -> %s", orNone(g.RepoFrom), orNone(g.RepoTo)))
+	}
+	if g.BranchFrom != g.BranchTo {
+		lines = append(lines, fmt.Sprintf("  Branch: %s -> %s", orNone(g.BranchFrom), orNone(g.BranchTo)))
+	}
+	if g.HeadFrom != g.HeadTo {
+		lines = append(lines, fmt.Sprintf("  HEAD: %s -> %s", shortHash(g.HeadFrom), shortHash(g.HeadTo)))
+	}
+	if g.DirtyFrom != g.DirtyTo {
+		lines = append(lines, fmt.Sprintf("  Uncommitted changes: %s -> %s", yesNo(g.DirtyFrom), yesNo(g.DirtyTo)))
+	}
+	return lines
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+func formatGeometry(g snapshot.WindowGeometry) string {
+	return fmt.Sprintf("(%d, %d) %dx%d", g.X, g.Y, g.Width, g.Height)
+}
+
+func formatCompactGeometry(g snapshot.WindowGeometry) string {
+	return fmt.Sprintf("%d,%d,%dx%d", g.X, g.Y, g.Width, g.Height)
+}
+
+func (s *MCPServer) handleStorageBreakdown(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	limit := intArg(args, "limit", 20)
+	out := outputArgs(args)
+
+	usages, err := s.manager.StorageBreakdown(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to compute storage breakdown: %v", err)), nil
+	}
+	if len(usages) == 0 {
+		return mcp.NewToolResultText("No snapshots found."), nil
+	}
+
+	var total int64
+	for _, u := range usages {
+		total += u.TotalBytes
+	}
+
+	summary := section{priority: prioritySummary}
+	items := section{priority: prioritySummary, paged: true}
+	more := section{priority: prioritySummary}
+	if out.compact {
+		summary.lines = []string{fmt.Sprintf("storage total_bytes=%d snapshots=%d offset=%d", total, len(usages), out.offset)}
 	} else {
-		result += "- Git Context Changed: No\n"
+		summary.lines = []string{fmt.Sprintf("Estimated storage: %s across %d snapshots", formatBytes(total), len(usages))}
+	}
+
+	page := pageItems(usages, out.offset)
+	for i, u := range page {
+		if limit > 0 && i >= limit {
+			if !out.compact {
+				more.lines = []string{fmt.Sprintf("... and %d more", len(page)-limit)}
+			}
+			break
+		}
+		if out.compact {
+			items.lines = append(items.lines, fmt.Sprintf("snapshot id=%s name=%q total=%d windows=%d terminals=%d tabs=%d ide_files=%d processes=%d metadata=%d",
+				u.SnapshotID, u.Name, u.TotalBytes, u.WindowBytes, u.TerminalBytes, u.TabBytes, u.IDEFileBytes, u.ProcessBytes, u.MetadataBytes))
+			continue
+		}
+		items.lines = append(items.lines, fmt.Sprintf("%d. [%s] %s: %s (windows %s, terminals %s, tabs %s, ide files %s, processes %s, metadata %s)",
+			out.offset+i+1, u.SnapshotID, u.Name, formatBytes(u.TotalBytes),
+			formatBytes(u.WindowBytes), formatBytes(u.TerminalBytes), formatBytes(u.TabBytes),
+			formatBytes(u.IDEFileBytes), formatBytes(u.ProcessBytes), formatBytes(u.MetadataBytes)))
+	}
+	return mcp.NewToolResultText(renderSections([]section{summary, items, more}, out)), nil
+}
+
+func (s *MCPServer) handleCaptureMetrics(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	out := outputArgs(args)
+
+	// Timings outlive their snapshot, so an ID that no longer resolves is used as is
+	id := stringArg(args, "snapshot_id")
+	if id != "" {
+		if resolved, err := s.manager.ResolveSnapshotRef(ctx, id); err == nil {
+			id = resolved
+		}
 	}
-	result += fmt.Sprintf("- Common Windows: %d\n", diff.CommonWindows)
 
-	if len(diff.AddedWindows) > 0 {
-		result += "- Added Windows:\n"
-		for _, w := range diff.AddedWindows {
-			result += fmt.Sprintf("  + %s\n", w)
+	captures, err := s.manager.CaptureMetrics(ctx, id, intArg(args, "limit", 20))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to read capture metrics: %v", err)), nil
+	}
+	if len(captures) == 0 {
+		return mcp.NewToolResultText("No capture metrics recorded."), nil
+	}
+
+	// Average of each phase over the captures that ran it, in first-seen order
+	var phases []string
+	sums := make(map[string]time.Duration)
+	runs := make(map[string]int)
+	var total time.Duration
+	for _, c := range captures {
+		total += c.Total
+		for _, p := range c.Phases {
+			if runs[p.Phase] == 0 {
+				phases = append(phases, p.Phase)
+			}
+			sums[p.Phase] += p.Duration
+			runs[p.Phase]++
 		}
 	}
-	if len(diff.RemovedWindows) > 0 {
-		result += "- Removed Windows:\n"
-		for _, w := range diff.RemovedWindows {
-			result += fmt.Sprintf("  - %s\n", w)
+
+	summary := section{priority: prioritySummary}
+	items := section{priority: prioritySummary, paged: true}
+	average := total / time.Duration(len(captures))
+	if out.compact {
+		line := fmt.Sprintf("capture_metrics captures=%d avg_total_ms=%d", len(captures), average.Milliseconds())
+		for _, phase := range phases {
+			line += fmt.Sprintf(" avg_%s_ms=%d", phase, (sums[phase] / time.Duration(runs[phase])).Milliseconds())
 		}
+		summary.lines = []string{line}
+	} else {
+		parts := make([]string, len(phases))
+		for i, phase := range phases {
+			parts[i] = fmt.Sprintf("%s %s", phase, (sums[phase] / time.Duration(runs[phase])).Round(time.Millisecond))
+		}
+		summary.lines = []string{fmt.Sprintf("Last %d capture(s): average %s (%s)", len(captures), average.Round(time.Millisecond), strings.Join(parts, ", "))}
 	}
 
+	for i, c := range pageItems(captures, out.offset) {
+		if out.compact {
+			line := fmt.Sprintf("capture id=%s name=%q at=%s total_ms=%d", c.SnapshotID, c.SnapshotName, c.CapturedAt.UTC().Format(time.RFC3339), c.Total.Milliseconds())
+			for _, p := range c.Phases {
+				line += fmt.Sprintf(" %s_ms=%d", p.Phase, p.Duration.Milliseconds())
+			}
+			items.lines = append(items.lines, line)
+			continue
+		}
+		parts := make([]string, len(c.Phases))
+		for j, p := range c.Phases {
+			parts[j] = fmt.Sprintf("%s %s", p.Phase, p.Duration.Round(time.Millisecond))
+		}
+		items.lines = append(items.lines, fmt.Sprintf("%d. [%s] %s (%s): %s (%s)",
+			out.offset+i+1, c.SnapshotID, c.SnapshotName, c.CapturedAt.Format(time.RFC822), c.Total.Round(time.Millisecond), strings.Join(parts, ", ")))
+	}
+	return mcp.NewToolResultText(renderSections([]section{summary, items}, out)), nil
+}
+
+// formatBytes renders a byte count in B/KB/MB
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// sessionCaptureOptions are the capture settings of session start and end snapshots
+func sessionCaptureOptions(args map[string]interface{}) snapshot.CaptureOptions {
+	return snapshot.CaptureOptions{
+		IncludeBrowsable: boolArg(args, "include_browsers", true),
+		IncludeTerminals: boolArg(args, "include_terminals", true),
+		RecordRegions:    true,
+		Sanitize:         boolArg(args, "sanitize", true),
+	}
+}
+
+func (s *MCPServer) handleStartSession(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	label := stringArg(args, "label")
+	if label == "" {
+		return mcp.NewToolResultError("label is required"), nil
+	}
+	planned := time.Duration(intArg(args, "planned_minutes", 0)) * time.Minute
+	if planned < 0 {
+		return mcp.NewToolResultError("planned_minutes must not be negative"), nil
+	}
+
+	session, start, err := s.manager.StartSession(ctx, label, planned, sessionCaptureOptions(args))
+	if err != nil {
+		return toolFailure("start session", err), nil
+	}
+
+	result := fmt.Sprintf("Session %q started. ID: %s, start snapshot: %s", session.Label, session.ID, start.ID)
+	if planned > 0 {
+		result += fmt.Sprintf(", planned until %s", session.StartedAt.Add(planned).Format(time.Kitchen))
+	}
+	for _, w := range start.Warnings {
+		result += fmt.Sprintf("\n  ! %s", w)
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+func (s *MCPServer) handleEndSession(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	summary, err := s.manager.EndSession(ctx, stringArg(args, "session_id"), sessionCaptureOptions(args))
+	if err != nil {
+		return toolFailure("end session", err), nil
+	}
+	return mcp.NewToolResultText(formatSessionSummary(summary)), nil
+}
+
+// formatSessionSummary renders how the environment evolved during a session
+func formatSessionSummary(summary *snapshot.SessionSummary) string {
+	session := summary.Session
+	var b strings.Builder
+	fmt.Fprintf(&b, "Session %q ended after %s", session.Label, summary.Duration.Round(time.Second))
+	if session.Planned > 0 {
+		if summary.Overran {
+			fmt.Fprintf(&b, " (planned %s, overran by %s)", session.Planned, (summary.Duration - session.Planned).Round(time.Second))
+		} else {
+			fmt.Fprintf(&b, " (planned %s)", session.Planned)
+		}
+	}
+	fmt.Fprintf(&b, ".\nSnapshots: %s -> %s\n", session.StartSnapshotID, session.EndSnapshotID)
+	fmt.Fprintf(&b, "Drift: %.0f%% of windows changed\n", session.DriftScore*100)
+
+	fmt.Fprintf(&b, "Windows: %d opened, %d closed, %d kept\n", len(summary.WindowsOpened), len(summary.WindowsClosed), summary.CommonWindows)
+	for _, title := range summary.WindowsOpened {
+		fmt.Fprintf(&b, "  + %s\n", title)
+	}
+	for _, title := range summary.WindowsClosed {
+		fmt.Fprintf(&b, "  - %s\n", title)
+	}
+	fmt.Fprintf(&b, "Tabs: %d -> %d (%d opened, %d closed)\n", summary.TabsAtStart, summary.TabsAtEnd, summary.TabsOpened, summary.TabsClosed)
+
+	switch {
+	case summary.BranchFrom != summary.BranchTo:
+		fmt.Fprintf(&b, "Git: switched branch %s -> %s\n", summary.BranchFrom, summary.BranchTo)
+	case summary.CommitFrom != summary.CommitTo:
+		fmt.Fprintf(&b, "Git: %s moved %s -> %s\n", summary.BranchTo, shortHash(summary.CommitFrom), shortHash(summary.CommitTo))
+	case summary.BranchTo != "":
+		fmt.Fprintf(&b, "Git: no movement on %s\n", summary.BranchTo)
+	}
+
+	for _, w := range summary.Warnings {
+		fmt.Fprintf(&b, "  ! %s\n", w)
+	}
+	return b.String()
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	if hash == "" {
+		return "(none)"
+	}
+	return hash
+}
+
+func (s *MCPServer) handleListSessions(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	out := outputArgs(toolArgs(request))
+
+	sessions, err := s.manager.ListSessions(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list sessions: %v", err)), nil
+	}
+	if len(sessions) == 0 {
+		return mcp.NewToolResultText("No sessions recorded."), nil
+	}
+
+	summary := section{priority: prioritySummary}
+	if out.compact {
+		summary.lines = []string{fmt.Sprintf("sessions total=%d offset=%d", len(sessions), out.offset)}
+	} else {
+		summary.lines = []string{fmt.Sprintf("%d session(s):", len(sessions))}
+	}
+
+	items := section{priority: prioritySummary, paged: true}
+	for _, session := range pageItems(sessions, out.offset) {
+		if out.compact {
+			line := fmt.Sprintf("session id=%s started=%s start=%s", session.ID, session.StartedAt.UTC().Format(time.RFC3339), session.StartSnapshotID)
+			if !session.Active() {
+				line += fmt.Sprintf(" ended=%s end=%s drift=%.2f", session.EndedAt.UTC().Format(time.RFC3339), session.EndSnapshotID, session.DriftScore)
+			}
+			items.lines = append(items.lines, line+" label="+session.Label)
+			continue
+		}
+		line := fmt.Sprintf("- [%s] %s, started %s", session.ID, session.Label, session.StartedAt.Format(time.RFC822))
+		if session.Active() {
+			line += ", running"
+			if session.Planned > 0 {
+				line += fmt.Sprintf(" (planned %s)", session.Planned)
+			}
+		} else {
+			line += fmt.Sprintf(", lasted %s, drift %.0f%%", session.EndedAt.Sub(session.StartedAt).Round(time.Second), session.DriftScore*100)
+		}
+		items.lines = append(items.lines, line)
+	}
+
+	return mcp.NewToolResultText(renderSections([]section{summary, items}, out)), nil
+}
+
+func (s *MCPServer) handleServerStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	adapter, _, _ := s.manager.Capabilities()
+	result := fmt.Sprintf("Adapter: %s\nTools: %d\n", adapter, len(s.tools))
+	if s.autoSnapshot != nil {
+		result += formatAutoSnapshotStatus(s.autoSnapshot.Status())
+	}
+	if s.storage == nil {
+		return mcp.NewToolResultText(result), nil
+	}
+	result += fmt.Sprintf("Database: %s\n", s.storage.Path())
+	if s.storage.StorageWarning() != "" {
+		// withStorageWarning already puts the details in front of this text
+		result += "Storage: history lost, see the warning above\n"
+	} else {
+		result += "Storage: ok\n"
+	}
 	return mcp.NewToolResultText(result), nil
 }
+
+func (s *MCPServer) handleCapabilities(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	adapter, caps, declared := s.manager.Capabilities()
+	payload := struct {
+		Adapter      string            `json:"adapter"`
+		Declared     bool              `json:"declared"` // false: the adapter doesn't declare its capabilities
+		Capabilities core.Capabilities `json:"capabilities"`
+	}{adapter, declared, caps}
+
+	b, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to encode capabilities: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(b)), nil
+}
+
+func (s *MCPServer) handleGetSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, failure := s.resolveRef(ctx, toolArgs(request), "snapshot_id", "get snapshot")
+	if failure != nil {
+		return failure, nil
+	}
+
+	snap, err := s.manager.Load(ctx, id)
+	if err != nil {
+		return toolFailure("load snapshot", err), nil
+	}
+	if snap.Tags == nil {
+		snap.Tags = []string{} // "tags": [] rather than null for clients
+	}
+
+	b, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to encode snapshot: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(b)), nil
+}
+
+func (s *MCPServer) handleVerifySnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	id, failure := s.resolveRef(ctx, args, "snapshot_id", "verify")
+	if failure != nil {
+		return failure, nil
+	}
+	out := outputArgs(args)
+
+	result, err := s.manager.Verify(ctx, id)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to verify: %v", err)), nil
+	}
+
+	sec := section{priority: prioritySummary}
+	if out.compact {
+		sec.lines = []string{fmt.Sprintf("verify id=%s valid=%t stored=%s computed=%s", result.SnapshotID, result.Valid, result.Stored, result.Computed)}
+	} else {
+		sec.lines = []string{fmt.Sprintf("Snapshot %s: %s", result.SnapshotID, result.Message)}
+		if result.Stored != "" {
+			sec.lines = append(sec.lines, "- Stored:   "+result.Stored, "- Computed: "+result.Computed)
+		}
+	}
+	return mcp.NewToolResultText(renderSections([]section{sec}, out)), nil
+}
+
+// toolArgs returns the call arguments as a map (empty when absent)
+func toolArgs(request mcp.CallToolRequest) map[string]interface{} {
+	if args, ok := request.Params.Arguments.(map[string]interface{}); ok {
+		return args
+	}
+	return map[string]interface{}{}
+}
+
+func stringArg(args map[string]interface{}, key string) string {
+	v, _ := args[key].(string)
+	return v
+}
+
+// intArg reads an optional numeric argument (JSON numbers arrive as float64)
+func intArg(args map[string]interface{}, key string, def int) int {
+	if v, ok := args[key].(float64); ok {
+		return int(v)
+	}
+	return def
+}
+
+// intSliceArg reads an optional array-of-numbers argument
+func intSliceArg(args map[string]interface{}, key string) []int {
+	raw, ok := args[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	var out []int
+	for _, v := range raw {
+		if n, ok := v.(float64); ok {
+			out = append(out, int(n))
+		}
+	}
+	return out
+}
+
+// boolArg reads an optional boolean argument, falling back to def when omitted
+func boolArg(args map[string]interface{}, key string, def bool) bool {
+	if v, ok := args[key].(bool); ok {
+		return v
+	}
+	return def
+}
+
+// stringSliceArg reads an optional array-of-strings argument
+func stringSliceArg(args map[string]interface{}, key string) []string {
+	raw, ok := args[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	var values []string
+	for _, item := range raw {
+		if v, ok := item.(string); ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// tagsArg reads tags given either as an array or a comma-separated string,
+// trimming whitespace and dropping empty entries
+func tagsArg(args map[string]interface{}, key string) []string {
+	raw := stringSliceArg(args, key)
+	if v, ok := args[key].(string); ok {
+		raw = strings.Split(v, ",")
+	}
+
+	var tags []string
+	for _, t := range raw {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// formatNotices renders non-fatal read warnings appended to tool output
+func formatNotices(warnings []string) string {
+	if len(warnings) == 0 {
+		return ""
+	}
+	result := fmt.Sprintf("\nNotice: %d problem(s) while reading stored data:\n", len(warnings))
+	for _, w := range warnings {
+		result += fmt.Sprintf("  ! %s\n", w)
+	}
+	return result
+}
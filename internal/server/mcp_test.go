@@ -0,0 +1,247 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/db"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+	"github.com/tuusuario/dev-env-snapshots/internal/snapshot"
+)
+
+func TestListBackupsNamesTargetsBeyondListLimit(t *testing.T) {
+	ctx := context.Background()
+	s, _, repo := newTestServer(t)
+
+	base := time.Now().Add(-time.Hour)
+	save := func(snap *core.Snapshot) {
+		t.Helper()
+		if err := repo.SaveSnapshot(ctx, snap); err != nil {
+			t.Fatalf("SaveSnapshot %s: %v", snap.ID, err)
+		}
+	}
+	// The target is older than the newest DefaultListLimit snapshots
+	save(&core.Snapshot{ID: "target", Name: "old-target", CreatedAt: base, UpdatedAt: base})
+	for i := 0; i < snapshot.DefaultListLimit+5; i++ {
+		at := base.Add(time.Duration(i+1) * time.Second)
+		save(&core.Snapshot{ID: fmt.Sprintf("filler-%02d", i), Name: "filler", CreatedAt: at, UpdatedAt: at})
+	}
+	now := time.Now()
+	save(&core.Snapshot{ID: "backup", Name: "before-switch", CreatedAt: now, UpdatedAt: now,
+		Tags: []string{snapshot.BackupTag}, BackupFor: "target", Origin: core.OriginBackup})
+	save(&core.Snapshot{ID: "orphan", Name: "before-switch", CreatedAt: now, UpdatedAt: now,
+		Tags: []string{snapshot.BackupTag}, BackupFor: "gone", Origin: core.OriginBackup})
+
+	text, isErr := callText(t, s, "list_backups", map[string]interface{}{})
+	if isErr {
+		t.Fatalf("list_backups failed: %s", text)
+	}
+	if !strings.Contains(text, "before switching to old-target [target]") {
+		t.Errorf("old target not named:\n%s", text)
+	}
+	if !strings.Contains(text, "before switching to deleted snapshot gone") {
+		t.Errorf("deleted target not reported:\n%s", text)
+	}
+}
+
+func TestRestoreOutputNamesOtherDesktopWindows(t *testing.T) {
+	plan := formatRestoreResult(&snapshot.RestoreReport{DryRun: true, Plan: []snapshot.PlannedWindow{
+		{WindowTitle: "Slack", AppName: "Slack", MatchedTitle: "Slack", MatchScore: 90, OtherDesktop: true},
+	}})
+	if !strings.Contains(plan, `via live window "Slack" (score 90) on another virtual desktop`) {
+		t.Errorf("plan does not mention the other desktop:\n%s", plan)
+	}
+
+	result := formatRestoreResult(&snapshot.RestoreReport{Message: "done", OtherDesktop: []string{"Slack"}})
+	if !strings.Contains(result, "Slack: matched window is on another virtual desktop") {
+		t.Errorf("result does not mention the other desktop:\n%s", result)
+	}
+}
+
+func TestDryRunOutputShowsPlannedLaunches(t *testing.T) {
+	plan := formatRestoreResult(&snapshot.RestoreReport{DryRun: true, Plan: []snapshot.PlannedWindow{
+		{WindowTitle: "notes.txt", AppName: "Editor", LaunchPath: "/usr/bin/editor", LaunchArgs: []string{"--new-window", "/src/my app"}},
+		{WindowTitle: "todo.txt", AppName: "Editor"},
+	}, Notes: []string{"Applications would not be launched: launch data came from an imported snapshot"}})
+	for _, want := range []string{
+		`(no matching live window, would launch /usr/bin/editor "--new-window" "/src/my app")`,
+		"todo.txt [Editor] -> (0, 0) 0x0 (no matching live window)\n",
+		"- Applications would not be launched: launch data came from an imported snapshot",
+	} {
+		if !strings.Contains(plan, want) {
+			t.Errorf("plan does not contain %q:\n%s", want, plan)
+		}
+	}
+}
+
+func TestExportSnapshotSanitizesByDefault(t *testing.T) {
+	ctx := context.Background()
+	s, _, repo := newTestServer(t)
+	now := time.Now()
+	if err := repo.SaveSnapshot(ctx, &core.Snapshot{ID: "export-me", Name: "export", CreatedAt: now, UpdatedAt: now,
+		BrowserTabs: []core.BrowserTab{{URL: "https://example.com/?token=abc123secret", BrowserName: "chrome"}},
+		IDEFiles:    []core.IDEFile{{FilePath: "/home/alice/src/main.go"}},
+	}); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+
+	for _, tt := range []struct {
+		args      map[string]interface{}
+		sanitized bool
+	}{
+		{map[string]interface{}{"snapshot_id": "export-me"}, true},
+		{map[string]interface{}{"snapshot_id": "export-me", "sanitize": true}, true},
+		{map[string]interface{}{"snapshot_id": "export-me", "sanitize": false}, false},
+	} {
+		text, isErr := callText(t, s, "export_snapshot", tt.args)
+		if isErr {
+			t.Fatalf("export_snapshot %v failed: %s", tt.args, text)
+		}
+		leaked := strings.Contains(text, "abc123secret") || strings.Contains(text, "alice")
+		if marked := strings.Contains(text, `"sanitized": true`); marked != tt.sanitized || leaked == tt.sanitized {
+			t.Errorf("export_snapshot %v: sanitized=%v, leaked=%v; want sanitized=%v", tt.args, marked, leaked, tt.sanitized)
+		}
+	}
+}
+
+func TestToolOutputsMatchGolden(t *testing.T) {
+	s, _, repo := newTestServer(t)
+	seedDemo(t, repo)
+	// The export time is the only thing that changes between calls
+	exportedAt := regexp.MustCompile(`"exported_at": "[^"]*"`)
+
+	tests := []struct {
+		golden string
+		tool   string
+		args   map[string]interface{}
+	}{
+		{"list_snapshots", "list_snapshots", map[string]interface{}{}},
+		{"list_snapshots_compact", "list_snapshots", map[string]interface{}{"style": "compact", "tags": []interface{}{"work"}}},
+		{"get_snapshot", "get_snapshot", map[string]interface{}{"snapshot_id": "demo-morning"}},
+		{"diff_snapshots", "diff_snapshots", map[string]interface{}{"source_id": "demo-morning", "target_id": "demo-evening"}},
+		{"diff_snapshots_compact", "diff_snapshots", map[string]interface{}{"source_id": "demo-morning", "target_id": "demo-evening", "style": "compact"}},
+		{"export_snapshot", "export_snapshot", map[string]interface{}{"snapshot_id": "demo-morning", "sanitize": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			// Two calls in a row agree, and that is what the golden holds
+			first, isErr := callText(t, s, tt.tool, tt.args)
+			if isErr {
+				t.Fatalf("%s failed: %s", tt.tool, first)
+			}
+			second, _ := callText(t, s, tt.tool, tt.args)
+			first = exportedAt.ReplaceAllString(first, `"exported_at": "<exported_at>"`)
+			second = exportedAt.ReplaceAllString(second, `"exported_at": "<exported_at>"`)
+			if first != second {
+				t.Fatalf("%s changed between calls:\n%s\n---\n%s", tt.tool, first, second)
+			}
+			assertGolden(t, tt.golden, first)
+		})
+	}
+}
+
+// blockingAdapter holds every PositionWindow until release is closed
+type blockingAdapter struct {
+	*platform.MockAdapter
+	entered chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (a *blockingAdapter) PositionWindow(ctx context.Context, live core.Window, target core.Window) error {
+	a.once.Do(func() { close(a.entered) })
+	<-a.release
+	return nil
+}
+
+func TestDeleteDuringRestoreExplainsTheBusySnapshot(t *testing.T) {
+	windows := []core.Window{{AppName: "Code", WindowTitle: "main.go", Width: 800, Height: 600, Pid: 1}}
+	adapter := &blockingAdapter{MockAdapter: platform.NewMockAdapter(), entered: make(chan struct{}), release: make(chan struct{})}
+	adapter.Windows = windows
+	s, _, repo := newTestServerWith(t, adapter)
+	if err := repo.SaveSnapshot(context.Background(), &core.Snapshot{ID: "busy", Name: "busy", CreatedAt: time.Now(), Windows: windows}); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+
+	// Two clients: one restoring, the other deleting the same snapshot
+	restored := make(chan string)
+	go func() {
+		result, err := s.CallTool(context.Background(), "restore_snapshot", map[string]interface{}{"snapshot_id": "busy"})
+		if err != nil || len(result.Content) == 0 {
+			restored <- fmt.Sprintf("restore_snapshot: %v", err)
+			return
+		}
+		text, _ := result.Content[0].(mcp.TextContent)
+		restored <- text.Text
+	}()
+	<-adapter.entered
+	text, isErr := callText(t, s, "delete_snapshot", map[string]interface{}{"snapshot_id": "busy"})
+	if !isErr || !strings.Contains(text, "restore in progress") || !strings.Contains(text, "retry once it finishes") {
+		t.Errorf("delete mid-restore = %q", text)
+	}
+	close(adapter.release)
+	if text := <-restored; !strings.Contains(text, `"restored_windows": 1`) {
+		t.Errorf("restore did not finish:\n%s", text)
+	}
+	if text, isErr := callText(t, s, "delete_snapshot", map[string]interface{}{"snapshot_id": "busy"}); isErr {
+		t.Errorf("delete after the restore failed: %s", text)
+	}
+}
+
+func TestDeletedDatabaseWarnsInEveryResult(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.db")
+	d, err := db.NewDB(path)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	manager := snapshot.NewManager(db.NewRepository(d), platform.NewMockAdapter())
+	s, err := New(WithManager(manager), WithStorageHealth(d))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if text, _ := callText(t, s, "server_status", map[string]interface{}{}); !strings.Contains(text, "Storage: ok") {
+		t.Fatalf("server_status before the wipe:\n%s", text)
+	}
+
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		os.Remove(path + suffix)
+	}
+
+	// The warning comes as its own content block, ahead of the tool's text
+	call := func(name string) []string {
+		t.Helper()
+		result, err := s.CallTool(context.Background(), name, map[string]interface{}{})
+		if err != nil || result.IsError {
+			t.Fatalf("%s: %v %+v", name, err, result)
+		}
+		var texts []string
+		for _, c := range result.Content {
+			text, _ := c.(mcp.TextContent)
+			texts = append(texts, text.Text)
+		}
+		return texts
+	}
+	for _, tt := range []struct{ tool, want string }{
+		{"list_snapshots", "No snapshots found."},
+		{"server_status", "Storage: history lost"},
+	} {
+		texts := call(tt.tool)
+		warning := "WARNING: " + d.StorageWarning()
+		if len(texts) != 2 || texts[0] != warning || !strings.Contains(texts[1], tt.want) {
+			t.Errorf("%s after the wipe: %q", tt.tool, texts)
+		}
+	}
+	if !strings.Contains(d.StorageWarning(), "was deleted at") {
+		t.Errorf("warning = %q", d.StorageWarning())
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/tuusuario/dev-env-snapshots/internal/snapshot"
+)
+
+// Option configures an MCPServer built with New
+type Option func(*MCPServer)
+
+// WithManager sets the snapshot manager backing every tool
+func WithManager(manager *snapshot.Manager) Option {
+	return func(s *MCPServer) {
+		s.manager = manager
+	}
+}
+
+// WithToolTimeouts overrides per-tool timeouts (see ParseToolTimeouts)
+func WithToolTimeouts(timeouts map[string]time.Duration) Option {
+	return func(s *MCPServer) {
+		for name, d := range timeouts {
+			s.timeouts[name] = d
+		}
+	}
+}
+
+// ToolPolicy restricts which tools get registered. An empty Allow list allows
+// every tool; Deny always wins.
+type ToolPolicy struct {
+	Allow []string
+	Deny  []string
+}
+
+func (p ToolPolicy) allows(name string) bool {
+	for _, d := range p.Deny {
+		if d == name {
+			return false
+		}
+	}
+	if len(p.Allow) == 0 {
+		return true
+	}
+	for _, a := range p.Allow {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+// WithToolPolicy limits the exposed tool set, e.g. to a read-only subset
+func WithToolPolicy(policy ToolPolicy) Option {
+	return func(s *MCPServer) {
+		s.policy = policy
+	}
+}
+
+// WithTracing logs every tool call with its duration and outcome
+func WithTracing(logger *log.Logger) Option {
+	return func(s *MCPServer) {
+		s.tracer = logger
+	}
+}
+
+// StorageHealth reports whether the snapshot database lost its data while running
+type StorageHealth interface {
+	Path() string
+	// StorageWarning is "" while the stored history is intact
+	StorageWarning() string
+}
+
+// WithStorageHealth reports storage loss in server_status and prefixes the
+// warning to every tool result once it happens
+func WithStorageHealth(health StorageHealth) Option {
+	return func(s *MCPServer) {
+		s.storage = health
+	}
+}
+
+// addTool registers a tool, honoring the policy and wrapping the handler with
+// tracing, usage statistics, storage warnings and its per-tool timeout
+func (s *MCPServer) addTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	if !s.policy.allows(tool.Name) {
+		return
+	}
+
+	wrapped := s.calls.withCancellation(s.withTimeout(tool.Name, handler))
+	if s.tracer != nil {
+		wrapped = s.withTracing(tool.Name, wrapped)
+	}
+	if s.usage != nil {
+		wrapped = s.withUsage(tool, wrapped)
+	}
+	if s.storage != nil {
+		wrapped = s.withStorageWarning(wrapped)
+	}
+
+	s.tools = append(s.tools, server.ServerTool{Tool: tool, Handler: wrapped})
+	s.server.AddTool(tool, wrapped)
+}
+
+// withStorageWarning prefixes the storage loss warning to the tool's result
+func (s *MCPServer) withStorageWarning(handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := handler(ctx, request)
+		if result == nil {
+			return result, err
+		}
+		if warning := s.storage.StorageWarning(); warning != "" {
+			result.Content = append([]mcp.Content{mcp.NewTextContent("WARNING: " + warning)}, result.Content...)
+		}
+		return result, err
+	}
+}
+
+func (s *MCPServer) withTracing(name string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, err := handler(ctx, request)
+
+		outcome := "ok"
+		switch {
+		case err != nil:
+			outcome = "error: " + err.Error()
+		case result != nil && result.IsError:
+			outcome = "tool error"
+		}
+		s.tracer.Printf("[trace] %s %s in %s", name, outcome, time.Since(start))
+		return result, err
+	}
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Section priorities of the read tools. Higher values are truncated first
+// when a response doesn't fit in max_chars.
+const (
+	prioritySummary = iota
+	priorityGit
+	priorityNotices
+	priorityWindows
+	priorityTabs
+)
+
+// outputOptions are the style and budget arguments shared by the read tools
+type outputOptions struct {
+	compact  bool
+	maxChars int // 0 = unlimited
+	offset   int // items of the paged sections to skip
+}
+
+// outputToolOptions declares the style, max_chars and offset arguments
+func outputToolOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithString("style", mcp.Description(`"text" (default) or "compact": one item per line, fixed field order, no prose`)),
+		mcp.WithNumber("max_chars", mcp.Description("Character budget for the response (about 4 per token); lowest-priority sections are truncated first. 0 = unlimited")),
+		mcp.WithNumber("offset", mcp.Description("Number of list items to skip, as given by a truncated response")),
+	}
+}
+
+func outputArgs(args map[string]interface{}) outputOptions {
+	opts := outputOptions{
+		compact:  stringArg(args, "style") == "compact",
+		maxChars: intArg(args, "max_chars", 0),
+		offset:   intArg(args, "offset", 0),
+	}
+	if opts.offset < 0 {
+		opts.offset = 0
+	}
+	return opts
+}
+
+// section is a block of output lines truncated as a unit of priority
+type section struct {
+	priority int
+	header   string   // Printed verbatim before the lines, dropped with the last line
+	lines    []string // One item per line, without the trailing newline
+	paged    bool     // The lines are list items addressed by offset
+}
+
+// pageItems applies the offset to the items of a paged listing
+func pageItems[T any](items []T, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	return items[offset:]
+}
+
+// renderSections joins the sections and, if the result exceeds the budget,
+// drops lines from the end of the lowest-priority sections until it fits
+// together with a final "truncated, use offset=N" marker. N continues the
+// paged items right after the last one shown.
+func renderSections(sections []section, opts outputOptions) string {
+	kept := make([]int, len(sections))
+	total := 0
+	pagedTotal := 0
+	for i, sec := range sections {
+		kept[i] = len(sec.lines)
+		total += sectionSize(sec, kept[i])
+		if sec.paged {
+			pagedTotal += len(sec.lines)
+		}
+	}
+	if opts.maxChars <= 0 || total <= opts.maxChars {
+		return joinSections(sections, kept)
+	}
+
+	// The marker can only get shorter than this, so reserving it keeps the
+	// final text within the budget
+	markerSize := utf8.RuneCountInString(truncationMarker(opts.offset + pagedTotal))
+	if markerSize > opts.maxChars {
+		marker := truncationMarker(opts.offset)
+		return string([]rune(marker)[:min(opts.maxChars, utf8.RuneCountInString(marker))])
+	}
+
+	// Lowest priority first; among equals the later section goes first so
+	// paged items are always dropped from the end of the listing
+	order := make([]int, len(sections))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		pa, pb := sections[order[a]].priority, sections[order[b]].priority
+		if pa != pb {
+			return pa > pb
+		}
+		return order[a] > order[b]
+	})
+
+	for _, i := range order {
+		for kept[i] > 0 && total+markerSize > opts.maxChars {
+			total -= sectionSize(sections[i], kept[i]) - sectionSize(sections[i], kept[i]-1)
+			kept[i]--
+		}
+	}
+
+	next := opts.offset
+	for i, sec := range sections {
+		if !sec.paged {
+			continue
+		}
+		next += kept[i]
+		if kept[i] < len(sec.lines) {
+			break
+		}
+	}
+	return joinSections(sections, kept) + truncationMarker(next)
+}
+
+func truncationMarker(offset int) string {
+	return fmt.Sprintf("... truncated, use offset=%d\n", offset)
+}
+
+// sectionSize counts the characters of the first n lines of the section
+func sectionSize(sec section, n int) int {
+	if n == 0 {
+		return 0
+	}
+	size := utf8.RuneCountInString(sec.header)
+	for _, line := range sec.lines[:n] {
+		size += utf8.RuneCountInString(line) + 1
+	}
+	return size
+}
+
+func joinSections(sections []section, kept []int) string {
+	var b strings.Builder
+	for i, sec := range sections {
+		if kept[i] == 0 {
+			continue
+		}
+		b.WriteString(sec.header)
+		for _, line := range sec.lines[:kept[i]] {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// noticeSection renders read problems; compact style uses one "warning" line each
+func noticeSection(warnings []string, compact bool) section {
+	sec := section{priority: priorityNotices}
+	if len(warnings) == 0 {
+		return sec
+	}
+	if compact {
+		for _, w := range warnings {
+			sec.lines = append(sec.lines, "warning "+w)
+		}
+		return sec
+	}
+	sec.header = fmt.Sprintf("\nNotice: %d problem(s) while reading stored data:\n", len(warnings))
+	for _, w := range warnings {
+		sec.lines = append(sec.lines, "  ! "+w)
+	}
+	return sec
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRenderSectionsRespectsBudget(t *testing.T) {
+	sections := []section{
+		{priority: prioritySummary, lines: []string{"Snapshot demo: 3 windows, 3 tabs"}},
+		{priority: priorityGit, header: "Git:\n", lines: []string{"  branch main", "  head 0123abcd"}},
+		{priority: priorityWindows, header: "Windows:\n", paged: true, lines: []string{"  - main.go", "  - zsh", "  - café ☕"}},
+		{priority: priorityTabs, header: "Tabs:\n", lines: []string{"  - https://pkg.go.dev", "  - https://github.com", "  - https://go.dev"}},
+	}
+	full := renderSections(sections, outputOptions{})
+	size := utf8.RuneCountInString(full)
+
+	tests := []struct {
+		name      string
+		budget    int
+		truncated bool
+		contains  []string
+		missing   []string
+	}{
+		{"unlimited", 0, false, []string{"https://go.dev"}, nil},
+		{"one above", size + 1, false, []string{"https://go.dev"}, nil},
+		{"exactly at the limit", size, false, []string{"https://go.dev"}, nil},
+		// The last tabs are the first to go
+		{"one below", size - 1, true, []string{"café ☕", "head 0123abcd"}, []string{"https://go.dev"}},
+		// Then the windows; git context and the summary stay
+		{"no room for lists", 110, true, []string{"Snapshot demo", "head 0123abcd", "offset=0"}, []string{"Tabs:", "Windows:"}},
+		{"marker only", 20, true, nil, []string{"Snapshot demo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderSections(sections, outputOptions{maxChars: tt.budget})
+			if n := utf8.RuneCountInString(got); tt.budget > 0 && n > tt.budget {
+				t.Errorf("%d characters, over the budget of %d:\n%s", n, tt.budget, got)
+			}
+			if tt.truncated {
+				if got == full || !strings.Contains(got, "truncated") {
+					t.Errorf("no truncation marker within %d:\n%s", tt.budget, got)
+				}
+			} else if got != full {
+				t.Errorf("output changed within budget %d:\n%s", tt.budget, got)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(got, s) {
+					t.Errorf("missing %q:\n%s", s, got)
+				}
+			}
+			for _, s := range tt.missing {
+				if strings.Contains(got, s) {
+					t.Errorf("kept %q:\n%s", s, got)
+				}
+			}
+		})
+	}
+
+	// No budget up to the full size is ever exceeded
+	for budget := 1; budget <= size; budget++ {
+		got := renderSections(sections, outputOptions{maxChars: budget})
+		if n := utf8.RuneCountInString(got); n > budget {
+			t.Fatalf("budget %d: got %d characters", budget, n)
+		}
+	}
+}
+
+func TestListSnapshotsBudgetBoundary(t *testing.T) {
+	s, _, repo := newTestServer(t)
+	seedDemo(t, repo)
+	// Numbers are float64, as they arrive from JSON
+	full, _ := callText(t, s, "list_snapshots", map[string]interface{}{})
+	size := utf8.RuneCountInString(full)
+
+	for _, budget := range []int{size + 1, size} {
+		if got, _ := callText(t, s, "list_snapshots", map[string]interface{}{"max_chars": float64(budget)}); got != full {
+			t.Errorf("max_chars=%d changed the output:\n%s", budget, got)
+		}
+	}
+	got, _ := callText(t, s, "list_snapshots", map[string]interface{}{"max_chars": float64(size - 1)})
+	if utf8.RuneCountInString(got) > size-1 || !strings.HasSuffix(got, "... truncated, use offset=2\n") {
+		t.Errorf("max_chars=%d:\n%s", size-1, got)
+	}
+	// The marker's offset continues right after the last snapshot shown
+	rest, _ := callText(t, s, "list_snapshots", map[string]interface{}{"offset": float64(2)})
+	if !strings.Contains(rest, "[demo-morning]") || strings.Contains(rest, "[demo-evening]") {
+		t.Errorf("offset=2:\n%s", rest)
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// sharedDir is the team directory snapshots are published to and pulled from
+type sharedDir struct {
+	path   string
+	author string
+}
+
+// WithSharedDir exposes publish_snapshot, list_shared and pull_shared over
+// the directory at path, typically a network share. author is recorded on
+// published snapshots unless the call names another one.
+func WithSharedDir(path, author string) Option {
+	return func(s *MCPServer) {
+		s.shared = &sharedDir{path: path, author: author}
+	}
+}
+
+func (s *MCPServer) handlePublishSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	id, failure := s.resolveRef(ctx, args, "snapshot_id", "publish")
+	if failure != nil {
+		return failure, nil
+	}
+	author := stringArg(args, "author")
+	if author == "" {
+		author = s.shared.author
+	}
+
+	file, err := s.manager.PublishSnapshot(ctx, id, s.shared.path, author)
+	if err != nil {
+		return toolFailure("publish", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Snapshot %s published as %s in %s (sanitized, author %q)", id, file, s.shared.path, author)), nil
+}
+
+func (s *MCPServer) handleListShared(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	entries, err := s.manager.ListShared(s.shared.path)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list shared snapshots: %v", err)), nil
+	}
+	if len(entries) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No snapshots published in %s.\n", s.shared.path)), nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d shared snapshot(s) in %s:\n", len(entries), s.shared.path)
+	for _, e := range entries {
+		if e.Problem != "" {
+			fmt.Fprintf(&b, "- %s: unusable, %s\n", e.File, e.Problem)
+			continue
+		}
+		fmt.Fprintf(&b, "- %s: %s by %s (%s, %d windows)\n", e.File, e.Name, e.Author, e.PublishedAt.Format(time.RFC822), e.Windows)
+	}
+	return mcp.NewToolResultText(b.String()), nil
+}
+
+func (s *MCPServer) handlePullShared(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	snap, err := s.manager.PullShared(ctx, s.shared.path, stringArg(toolArgs(request), "file"))
+	if err != nil {
+		return toolFailure("pull", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Shared snapshot pulled successfully! ID: %s, Name: %s, Author: %s\n%s", snap.ID, snap.Name, snap.Author, formatImportedCounts(snap))), nil
+}
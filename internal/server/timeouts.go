@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultToolTimeoutKey holds the timeout for tools without an explicit entry
+const defaultToolTimeoutKey = "*"
+
+// cancelGrace is how long a cancelled call may take to return its partial result
+const cancelGrace = 2 * time.Second
+
+// Time given to one full capture and one full restore, which touch every
+// window on the desktop
+const (
+	captureTimeout = 60 * time.Second
+	restoreTimeout = 120 * time.Second
+)
+
+// DefaultToolTimeouts returns the per-tool timeouts used when none are configured.
+// Tools that capture or restore get the time of every operation they run.
+func DefaultToolTimeouts() map[string]time.Duration {
+	return map[string]time.Duration{
+		defaultToolTimeoutKey: 15 * time.Second,
+		"capture_snapshot":    captureTimeout,
+		"diff_live":           captureTimeout,
+		"start_session":       captureTimeout,
+		"end_session":         captureTimeout,
+		"restore_snapshot":    captureTimeout + restoreTimeout, // backup_first captures before restoring
+		"restore_window":      restoreTimeout,                  // launch_missing waits for each launched app
+		"rollback_restore":    restoreTimeout,
+		"switch_to":           captureTimeout + restoreTimeout,
+	}
+}
+
+// ParseToolTimeouts parses a spec like "capture_snapshot=45s,restore_snapshot=2m,*=10s".
+// A zero duration disables the timeout for that tool.
+func ParseToolTimeouts(spec string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid tool timeout %q (expected tool=duration)", entry)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration for tool %s: %w", name, err)
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("negative timeout for tool %s", name)
+		}
+		timeouts[strings.TrimSpace(name)] = d
+	}
+	return timeouts, nil
+}
+
+func (s *MCPServer) timeoutFor(tool string) time.Duration {
+	if d, ok := s.timeouts[tool]; ok {
+		return d
+	}
+	return s.timeouts[defaultToolTimeoutKey]
+}
+
+// withTimeout bounds a handler with the tool's timeout. The handler's context is
+// cancelled on expiry and the caller gets a clear timeout error right away, even
+// if the underlying operation is slow to notice the cancellation.
+func (s *MCPServer) withTimeout(name string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		timeout := s.timeoutFor(name)
+		if timeout <= 0 {
+			return handler(ctx, request)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		type outcome struct {
+			result *mcp.CallToolResult
+			err    error
+		}
+		done := make(chan outcome, 1)
+		go func() {
+			result, err := handler(ctx, request)
+			done <- outcome{result, err}
+		}()
+
+		select {
+		case out := <-done:
+			return out.result, out.err
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return mcp.NewToolResultError(fmt.Sprintf("%s timed out after %s; the operation was cancelled", name, timeout)), nil
+			}
+			// A cancelled call gets a moment to return its partial result
+			select {
+			case out := <-done:
+				return out.result, out.err
+			case <-time.After(cancelGrace):
+				return nil, ctx.Err()
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+func TestParseToolTimeouts(t *testing.T) {
+	got, err := ParseToolTimeouts(" capture_snapshot=45s, restore_snapshot=2m,*=10s,,diff_live=0 ")
+	if err != nil {
+		t.Fatalf("ParseToolTimeouts: %v", err)
+	}
+	want := map[string]time.Duration{
+		"capture_snapshot": 45 * time.Second,
+		"restore_snapshot": 2 * time.Minute,
+		"*":                10 * time.Second,
+		"diff_live":        0,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for name, d := range want {
+		if got[name] != d {
+			t.Errorf("%s = %s, want %s", name, got[name], d)
+		}
+	}
+
+	for _, bad := range []string{"capture_snapshot", "capture_snapshot=soon", "capture_snapshot=-1s"} {
+		if _, err := ParseToolTimeouts(bad); err == nil {
+			t.Errorf("ParseToolTimeouts(%q) accepted an invalid spec", bad)
+		}
+	}
+}
+
+func TestDefaultTimeoutsCoverCapturingTools(t *testing.T) {
+	s, _, _ := newTestServer(t)
+	def := s.timeoutFor("list_snapshots")
+	for _, tool := range []string{"capture_snapshot", "restore_snapshot", "switch_to", "restore_window", "rollback_restore", "diff_live", "start_session", "end_session"} {
+		if d := s.timeoutFor(tool); d <= def {
+			t.Errorf("%s has timeout %s, not more than the %s default", tool, d, def)
+		}
+	}
+}
+
+// stuckAdapter never returns from GetWindows until the test ends, ignoring
+// the context like a hung platform call would
+type stuckAdapter struct {
+	*platform.MockAdapter
+	release chan struct{}
+}
+
+func (a *stuckAdapter) GetWindows(ctx context.Context) ([]core.Window, error) {
+	<-a.release
+	return nil, ctx.Err()
+}
+
+func TestSlowOperationTimesOut(t *testing.T) {
+	adapter := &stuckAdapter{MockAdapter: platform.NewMockAdapter(), release: make(chan struct{})}
+	s, _, _ := newTestServerWith(t, adapter, WithToolTimeouts(map[string]time.Duration{"capture_snapshot": 50 * time.Millisecond}))
+	t.Cleanup(func() { close(adapter.release) }) // Runs before the database closes
+
+	start := time.Now()
+	text, isErr := callText(t, s, "capture_snapshot", map[string]interface{}{"name": "slow"})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("the call returned after %s, the timeout did not cut it short", elapsed)
+	}
+	if !isErr || !strings.Contains(text, "capture_snapshot timed out after 50ms") {
+		t.Errorf("got %q (error %v), want a timeout error", text, isErr)
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Start serves over stdio, the transport used by desktop MCP clients
+func (s *MCPServer) Start() error {
+	return s.ServeStdio()
+}
+
+// ServeStdio serves the tool set over stdin/stdout
+func (s *MCPServer) ServeStdio() error {
+	return server.ServeStdio(s.server)
+}
+
+// eventNotificationMethod is the MCP notification carrying manager lifecycle events
+const eventNotificationMethod = "notifications/snapshots/event"
+
+// ServeSSE serves the tool set over HTTP Server-Sent Events on addr (e.g. "localhost:8080").
+// Capture and restore lifecycle events are forwarded to connected clients as notifications.
+func (s *MCPServer) ServeSSE(addr string) error {
+	stop := s.forwardEvents()
+	defer stop()
+
+	sse := server.NewSSEServer(s.server, server.WithBaseURL("http://"+addr))
+	return sse.Start(addr)
+}
+
+// forwardEvents relays manager events to all connected clients until stopped
+func (s *MCPServer) forwardEvents() func() {
+	ch, unsubscribe := s.manager.Events().Subscribe(64)
+	go func() {
+		for e := range ch {
+			s.server.SendNotificationToAllClients(eventNotificationMethod, map[string]any{
+				"type":        e.Type,
+				"snapshot_id": e.SnapshotID,
+				"time":        e.Time,
+				"data":        e.Data,
+			})
+		}
+	}()
+	return unsubscribe
+}
+
+// NewInProcessClient returns an initialized client wired directly to this
+// server, for tests and embedding without spawning a process or touching stdio
+func (s *MCPServer) NewInProcessClient(ctx context.Context) (*client.Client, error) {
+	c, err := client.NewInProcessClient(s.server)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start in-process client: %w", err)
+	}
+
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	init.Params.ClientInfo = mcp.Implementation{Name: "in-process", Version: "1.0"}
+	if _, err := c.Initialize(ctx, init); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to initialize in-process client: %w", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestInProcessClientCallsTools(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newTestServer(t, WithToolPolicy(ToolPolicy{Allow: []string{"capture_snapshot", "list_snapshots"}}))
+
+	c, err := s.NewInProcessClient(ctx)
+	if err != nil {
+		t.Fatalf("NewInProcessClient: %v", err)
+	}
+	defer c.Close()
+
+	tools, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	names := map[string]bool{}
+	for _, tool := range tools.Tools {
+		names[tool.Name] = true
+	}
+	if len(names) != 2 || !names["capture_snapshot"] || !names["list_snapshots"] {
+		t.Errorf("exposed tools = %v, want only the allowed ones", names)
+	}
+
+	call := mcp.CallToolRequest{}
+	call.Params.Name = "capture_snapshot"
+	call.Params.Arguments = map[string]interface{}{"name": "in-process"}
+	if result, err := c.CallTool(ctx, call); err != nil || result.IsError {
+		t.Fatalf("capture_snapshot: %+v, %v", result, err)
+	}
+
+	call.Params.Name = "list_snapshots"
+	call.Params.Arguments = map[string]interface{}{}
+	result, err := c.CallTool(ctx, call)
+	if err != nil || result.IsError {
+		t.Fatalf("list_snapshots: %+v, %v", result, err)
+	}
+	text, _ := result.Content[0].(mcp.TextContent)
+	if !strings.Contains(text.Text, "in-process") {
+		t.Errorf("listing does not show the captured snapshot:\n%s", text.Text)
+	}
+
+	call.Params.Name = "delete_snapshot"
+	if _, err := c.CallTool(ctx, call); err == nil {
+		t.Error("a tool outside the policy was callable")
+	}
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// UsageStore persists the opt-in usage statistics
+type UsageStore interface {
+	RecordUsage(ctx context.Context, rec core.UsageRecord) error
+	ListUsage(ctx context.Context, from, to time.Time) ([]core.UsageRecord, error)
+}
+
+// WithUsageStats records every tool call (tool, option names, outcome,
+// duration) in store and exposes the usage_report tool. Argument values are
+// never recorded. Off unless this option is given.
+func WithUsageStats(store UsageStore) Option {
+	return func(s *MCPServer) {
+		s.usage = store
+	}
+}
+
+// withUsage records the call after the handler returns. Only option names
+// declared in the tool's schema are kept, so neither values nor arbitrary
+// client-supplied keys reach the store.
+func (s *MCPServer) withUsage(tool mcp.Tool, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, err := handler(ctx, request)
+
+		rec := core.UsageRecord{
+			Tool:     tool.Name,
+			Options:  usedOptions(tool, toolArgs(request)),
+			Outcome:  "ok",
+			Duration: time.Since(start),
+			CalledAt: start,
+		}
+		switch {
+		case err != nil:
+			rec.Outcome = "error"
+		case result != nil && result.IsError:
+			rec.Outcome = "tool_error"
+		}
+		// The call's context may already be cancelled by its timeout
+		if recErr := s.usage.RecordUsage(context.Background(), rec); recErr != nil {
+			log.Printf("[usage] %v", recErr)
+		}
+		return result, err
+	}
+}
+
+// usedOptions returns the sorted names of the declared options present in
+// args. A boolean option set to false counts as not used.
+func usedOptions(tool mcp.Tool, args map[string]interface{}) []string {
+	options := []string{}
+	for name, value := range args {
+		if _, declared := tool.InputSchema.Properties[name]; !declared {
+			continue
+		}
+		if b, ok := value.(bool); ok && !b {
+			continue
+		}
+		options = append(options, name)
+	}
+	sort.Strings(options)
+	return options
+}
+
+// toolUsage aggregates the recorded calls of one tool
+type toolUsage struct {
+	tool    string
+	calls   int
+	errors  int
+	total   time.Duration
+	max     time.Duration
+	options map[string]int
+}
+
+func aggregateUsage(records []core.UsageRecord) []*toolUsage {
+	byTool := make(map[string]*toolUsage)
+	for _, rec := range records {
+		u, ok := byTool[rec.Tool]
+		if !ok {
+			u = &toolUsage{tool: rec.Tool, options: make(map[string]int)}
+			byTool[rec.Tool] = u
+		}
+		u.calls++
+		if rec.Outcome != "ok" {
+			u.errors++
+		}
+		u.total += rec.Duration
+		u.max = max(u.max, rec.Duration)
+		for _, opt := range rec.Options {
+			u.options[opt]++
+		}
+	}
+
+	result := make([]*toolUsage, 0, len(byTool))
+	for _, u := range byTool {
+		result = append(result, u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].calls != result[j].calls {
+			return result[i].calls > result[j].calls
+		}
+		return result[i].tool < result[j].tool
+	})
+	return result
+}
+
+func (u *toolUsage) average() time.Duration {
+	return u.total / time.Duration(u.calls)
+}
+
+// sortedOptions returns the option names, most used first
+func (u *toolUsage) sortedOptions() []string {
+	names := make([]string, 0, len(u.options))
+	for name := range u.options {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if u.options[names[i]] != u.options[names[j]] {
+			return u.options[names[i]] > u.options[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// parseDateArg parses a YYYY-MM-DD argument in local time; empty means unset
+func parseDateArg(args map[string]interface{}, key string) (time.Time, error) {
+	v := stringArg(args, key)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q (expected YYYY-MM-DD)", key, v)
+	}
+	return t, nil
+}
+
+func (s *MCPServer) handleUsageReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := toolArgs(request)
+	from, err := parseDateArg(args, "from")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	to, err := parseDateArg(args, "to")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !to.IsZero() {
+		// "to" is inclusive: take the whole day
+		to = to.AddDate(0, 0, 1)
+	}
+
+	records, err := s.usage.ListUsage(ctx, from, to)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to read usage statistics: %v", err)), nil
+	}
+	usage := aggregateUsage(records)
+
+	if boolArg(args, "export_usage_csv", false) {
+		text, err := formatUsageCSV(usage)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to build CSV: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	}
+	return mcp.NewToolResultText(formatUsageReport(usage, len(records))), nil
+}
+
+func formatUsageReport(usage []*toolUsage, calls int) string {
+	if calls == 0 {
+		return "No tool calls recorded in this range."
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Usage: %d call(s) to %d tool(s)\n", calls, len(usage))
+	for _, u := range usage {
+		fmt.Fprintf(&b, "- %s: %d call(s), %d failed, avg %s, max %s\n",
+			u.tool, u.calls, u.errors, u.average().Round(time.Millisecond), u.max.Round(time.Millisecond))
+		if len(u.options) == 0 {
+			continue
+		}
+		var opts []string
+		for _, name := range u.sortedOptions() {
+			opts = append(opts, fmt.Sprintf("%s %d", name, u.options[name]))
+		}
+		fmt.Fprintf(&b, "    options: %s\n", strings.Join(opts, ", "))
+	}
+	return b.String()
+}
+
+// formatUsageCSV writes one row per tool and one per (tool, option) pair
+func formatUsageCSV(usage []*toolUsage) (string, error) {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write([]string{"tool", "option", "calls", "failed", "avg_ms", "max_ms"})
+	for _, u := range usage {
+		w.Write([]string{u.tool, "", fmt.Sprint(u.calls), fmt.Sprint(u.errors),
+			fmt.Sprint(u.average().Milliseconds()), fmt.Sprint(u.max.Milliseconds())})
+		for _, name := range u.sortedOptions() {
+			w.Write([]string{u.tool, name, fmt.Sprint(u.options[name]), "", "", ""})
+		}
+	}
+	w.Flush()
+	return b.String(), w.Error()
+}
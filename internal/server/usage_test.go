@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/db"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+	"github.com/tuusuario/dev-env-snapshots/internal/snapshot"
+)
+
+func TestUsageStatsNeverPersistValues(t *testing.T) {
+	d, err := db.NewDB(filepath.Join(t.TempDir(), "snapshots.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	repo := db.NewRepository(d)
+	s, err := New(WithManager(snapshot.NewManager(repo, platform.NewMockAdapter())), WithUsageStats(repo))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	const secret = "s3cr3t-value"
+	calls := []struct {
+		tool string
+		args map[string]interface{}
+	}{
+		{"list_snapshots", map[string]interface{}{"project": secret, "branch": secret + "-branch", "tags": []interface{}{secret}}},
+		{"get_snapshot", map[string]interface{}{"snapshot_id": secret}},
+		{"diff_snapshots", map[string]interface{}{"source_id": secret, "target_id": secret, "style": secret}},
+		// Keys the schema doesn't declare are dropped, names and all
+		{"list_snapshots", map[string]interface{}{secret: "x", "api_key": secret, "limit": float64(3)}},
+		{"export_snapshot", map[string]interface{}{"snapshot_id": secret, "path": filepath.Join(t.TempDir(), secret), "sanitize": false}},
+	}
+	for _, c := range calls {
+		callText(t, s, c.tool, c.args)
+	}
+
+	records, err := repo.ListUsage(context.Background(), time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("ListUsage: %v", err)
+	}
+	if len(records) != len(calls) {
+		t.Fatalf("%d usage records, want %d", len(records), len(calls))
+	}
+	// Every persisted column, as stored
+	if dump := fmt.Sprintf("%+v", records); strings.Contains(dump, secret) || strings.Contains(dump, "api_key") {
+		t.Errorf("usage stats persisted argument values or undeclared keys:\n%s", dump)
+	}
+
+	got := make(map[string]bool)
+	for _, rec := range records {
+		got[rec.Tool+":"+strings.Join(rec.Options, ",")] = true
+	}
+	for _, want := range []string{
+		"list_snapshots:branch,project,tags",
+		"get_snapshot:snapshot_id",
+		"diff_snapshots:source_id,style,target_id",
+		"list_snapshots:limit",
+		// A false boolean is an option not used
+		"export_snapshot:path,snapshot_id",
+	} {
+		if !got[want] {
+			t.Errorf("no usage record %q in %v", want, got)
+		}
+	}
+
+	report, isErr := callText(t, s, "usage_report", map[string]interface{}{"export_usage_csv": true})
+	if isErr || strings.Contains(report, secret) {
+		t.Errorf("usage_report:\n%s", report)
+	}
+}
@@ -0,0 +1,52 @@
+package snapshot
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/events"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+// drain retorna los tipos de los eventos ya publicados en ch
+func drain(ch <-chan events.Event) []events.Type {
+	var types []events.Type
+	for {
+		select {
+		case e := <-ch:
+			types = append(types, e.Type)
+		default:
+			return types
+		}
+	}
+}
+
+func TestCaptureAndRestoreEventSequence(t *testing.T) {
+	ctx := context.Background()
+	adapter := platform.NewMockAdapter()
+	adapter.Windows = []core.Window{
+		{AppName: "Code", WindowTitle: "main.go", Width: 800, Height: 600, Pid: 1},
+		{AppName: "Terminal", WindowTitle: "bash", Width: 600, Height: 400, Pid: 2},
+	}
+	m, _ := newTestManager(t, adapter)
+	ch, unsubscribe := m.Events().Subscribe(32)
+	defer unsubscribe()
+
+	s, err := m.Capture(ctx, CaptureOptions{Name: "events"})
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	if got, want := drain(ch), []events.Type{events.CaptureStarted, events.CaptureCompleted}; !slices.Equal(got, want) {
+		t.Errorf("capture events = %v, want %v", got, want)
+	}
+
+	if _, err := m.Restore(ctx, s.ID, RestoreOptions{}); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	want := []events.Type{events.RestoreStarted, events.WindowRestored, events.WindowRestored, events.RestoreCompleted}
+	if got := drain(ch); !slices.Equal(got, want) {
+		t.Errorf("restore events = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,197 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// ExportFormatVersion es la versión del documento de export; un import
+// rechaza cualquier otra
+const ExportFormatVersion = 1
+
+// ExportDocument es el snapshot completo en formato portable
+type ExportDocument struct {
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Sanitized  bool           `json:"sanitized,omitempty"` // Pasó por el sanitizer antes de exportarse
+	Snapshot   *core.Snapshot `json:"snapshot"`
+	// Sesiones que empiezan o terminan en el snapshot. Son informativas: el
+	// import no las recrea porque referencian snapshots que no viajan
+	Sessions []core.Session `json:"sessions,omitempty"`
+}
+
+// ExportOptions ajusta lo que ExportSnapshot escribe
+type ExportOptions struct {
+	// Sanitize pasa el snapshot por el sanitizer del manager y omite las
+	// sesiones, como PublishSnapshot
+	Sanitize bool
+}
+
+// ExportSnapshot escribe el snapshot con todos sus componentes como JSON
+func (m *Manager) ExportSnapshot(ctx context.Context, id string, w io.Writer, opts ExportOptions) error {
+	doc, err := m.exportDocument(ctx, id)
+	if err != nil {
+		return err
+	}
+	if opts.Sanitize {
+		m.sanitizeExport(doc)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// exportDocument carga el snapshot y las sesiones relacionadas
+func (m *Manager) exportDocument(ctx context.Context, id string) (*ExportDocument, error) {
+	release, err := m.locks.acquireShared(id, "export")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	s, err := m.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.Warnings = nil
+
+	sessions, err := m.repo.ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var related []core.Session
+	for _, session := range sessions {
+		if session.StartSnapshotID == id || session.EndSnapshotID == id {
+			related = append(related, session)
+		}
+	}
+
+	return &ExportDocument{
+		Version:    ExportFormatVersion,
+		ExportedAt: time.Now(),
+		Snapshot:   s,
+		Sessions:   related,
+	}, nil
+}
+
+// sanitizeExport deja el documento listo para salir de la máquina
+func (m *Manager) sanitizeExport(doc *ExportDocument) {
+	m.sanitizer.SanitizeSnapshot(doc.Snapshot)
+	doc.Sanitized = true
+	doc.Sessions = nil // Referencian snapshots locales
+}
+
+// ImportSnapshot lee un documento de export y lo guarda con un ID nuevo.
+// Todo se escribe en una sola transacción. Como PullShared, nunca ejecuta
+// nada del documento: ver importDocument
+func (m *Manager) ImportSnapshot(ctx context.Context, r io.Reader) (*core.Snapshot, error) {
+	doc, err := ParseExport(r)
+	if err != nil {
+		return nil, err
+	}
+	return m.importDocument(ctx, doc, nil)
+}
+
+// ParseExport lee y valida un documento de export sin guardar nada
+func ParseExport(r io.Reader) (*ExportDocument, error) {
+	var doc ExportDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("malformed snapshot export: %w", err)
+	}
+	if err := validateExport(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// validateExport verifica la versión y los campos que el import necesita
+func validateExport(doc *ExportDocument) error {
+	if doc.Version == 0 {
+		return fmt.Errorf("malformed snapshot export: missing \"version\" (expected %d)", ExportFormatVersion)
+	}
+	if doc.Version != ExportFormatVersion {
+		return fmt.Errorf("unsupported snapshot export version %d (expected %d)", doc.Version, ExportFormatVersion)
+	}
+	if doc.Snapshot == nil {
+		return fmt.Errorf("malformed snapshot export: missing \"snapshot\"")
+	}
+
+	s := doc.Snapshot
+	if s.Name == "" {
+		return fmt.Errorf("malformed snapshot export: snapshot.name is required")
+	}
+	for i, w := range s.Windows {
+		if w.AppName == "" {
+			return fmt.Errorf("malformed snapshot export: windows[%d].app_name is required", i)
+		}
+		if w.OwnerRef < 0 || w.OwnerRef > len(s.Windows) {
+			return fmt.Errorf("malformed snapshot export: windows[%d].owner_ref %d out of range", i, w.OwnerRef)
+		}
+	}
+	return nil
+}
+
+// importDocument valida y guarda el documento. prepare, si no es nil, ajusta
+// el snapshot ya con su ID nuevo justo antes de guardarlo. Venga de un archivo,
+// de JSON inline o de un directorio compartido, el documento no es de fiar: se
+// guarda como Untrusted sin importar lo que diga su campo "untrusted", sin
+// AutoRestart en los procesos y sanitizado, así el restore no lanza sus apps
+// ni sus procesos
+func (m *Manager) importDocument(ctx context.Context, doc *ExportDocument, prepare func(*core.Snapshot)) (*core.Snapshot, error) {
+	if err := validateExport(doc); err != nil {
+		return nil, err
+	}
+
+	s := doc.Snapshot
+	// El import deriva del snapshot exportado, que puede no existir en esta base
+	s.ParentIDs = nil
+	if s.ID != "" {
+		s.ParentIDs = []string{s.ID}
+	}
+	s.Origin = core.OriginImported
+	s.ID = uuid.New().String()
+	s.Warnings = nil
+	s.Untrusted = true
+	for i := range s.Processes {
+		s.Processes[i].AutoRestart = false
+	}
+	if prepare != nil {
+		prepare(s)
+	}
+	m.sanitizer.SanitizeSnapshot(s)
+	if err := m.repo.SaveSnapshot(ctx, s); err != nil {
+		return nil, fmt.Errorf("failed to import snapshot: %w", err)
+	}
+	return s, nil
+}
+
+// DiffExternal compara el snapshot guardado id con el de un documento de
+// export, sin importarlo. Si el documento está sanitizado, el snapshot local
+// se sanitiza igual antes de comparar, para que las rutas y secretos
+// enmascarados no aparezcan como cambios
+func (m *Manager) DiffExternal(ctx context.Context, id string, r io.Reader) (*DiffResult, error) {
+	doc, err := ParseExport(r)
+	if err != nil {
+		return nil, err
+	}
+	local, err := m.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Sanitized {
+		m.sanitizer.SanitizeSnapshot(local)
+	}
+	diff := diffSnapshots(local, doc.Snapshot)
+	// El ID exportado puede ser el mismo que el local
+	diff.TargetID = "external"
+	if doc.Snapshot.ID != "" {
+		diff.TargetID += ":" + doc.Snapshot.ID
+	}
+	return diff, nil
+}
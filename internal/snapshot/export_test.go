@@ -0,0 +1,186 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+// trustingExport es un documento escrito a mano que se declara confiable y
+// trae un proceso con AutoRestart y una app para lanzar
+const trustingExport = `{
+  "version": 1,
+  "snapshot": {
+    "id": "elsewhere",
+    "name": "handcrafted",
+    "untrusted": false,
+    "windows": [{"app_name": "evil", "app_path": "/opt/evil/evil", "launch_args": ["--payload"], "window_title": "Nothing matches this", "width": 800, "height": 600}],
+    "processes": [{"process_name": "evil", "command": "/opt/evil/evil --daemon", "auto_restart": true}]
+  }
+}`
+
+func TestImportNeverRunsLaunchData(t *testing.T) {
+	ctx := context.Background()
+	adapter := &launchRecorder{MockAdapter: platform.NewMockAdapter()}
+	m, _ := newTestManager(t, adapter)
+
+	imported, err := m.ImportSnapshot(ctx, strings.NewReader(trustingExport))
+	if err != nil {
+		t.Fatalf("ImportSnapshot: %v", err)
+	}
+	loaded, err := m.Load(ctx, imported.ID)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !loaded.Untrusted {
+		t.Error("imported snapshot kept the document's untrusted:false")
+	}
+	for _, p := range loaded.Processes {
+		if p.AutoRestart {
+			t.Errorf("process %s kept AutoRestart after import", p.ProcessName)
+		}
+	}
+
+	report, err := m.Restore(ctx, imported.ID, RestoreOptions{LaunchMissing: true, LaunchTimeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if len(adapter.launched) > 0 || len(adapter.processes) > 0 {
+		t.Fatalf("restore of an imported snapshot ran launched=%v processes=%v", adapter.launched, adapter.processes)
+	}
+	if !strings.Contains(strings.Join(report.Notes, "\n"), "imported snapshot") {
+		t.Errorf("notes do not explain the skipped launch: %v", report.Notes)
+	}
+}
+
+func TestDiffExternalAgainstAnEditedExport(t *testing.T) {
+	ctx := context.Background()
+	m, repo := newTestManager(t, platform.NewMockAdapter())
+	before, after := diffFixtures()
+	saveSnapshot(t, repo, before)
+
+	// exportWith exporta el snapshot guardado y le aplica edit al documento,
+	// como si se hubiera tocado la copia en otra máquina
+	exportWith := func(opts ExportOptions, edit func(*core.Snapshot)) string {
+		var buf bytes.Buffer
+		if err := m.ExportSnapshot(ctx, "before", &buf, opts); err != nil {
+			t.Fatalf("ExportSnapshot: %v", err)
+		}
+		doc, err := ParseExport(&buf)
+		if err != nil {
+			t.Fatalf("ParseExport: %v", err)
+		}
+		if edit != nil {
+			edit(doc.Snapshot)
+		}
+		out, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(out)
+	}
+
+	tests := []struct {
+		name    string
+		doc     string
+		check   func(t *testing.T, diff *DiffResult)
+		wantErr string
+	}{
+		{"unchanged copy", exportWith(ExportOptions{}, nil), func(t *testing.T, diff *DiffResult) {
+			if diff.GitChanged || len(diff.AddedWindows)+len(diff.RemovedWindows)+len(diff.MovedWindows)+len(diff.AddedTabs)+len(diff.RemovedTabs) > 0 {
+				t.Errorf("diff of an unchanged copy = %+v, want no changes", diff)
+			}
+		}, ""},
+		{"unchanged sanitized copy", exportWith(ExportOptions{Sanitize: true}, nil), func(t *testing.T, diff *DiffResult) {
+			// El lado local se sanitiza igual: lo enmascarado no cuenta como cambio
+			if len(diff.AddedFiles)+len(diff.RemovedFiles)+len(diff.AddedTerminals)+len(diff.RemovedTerminals)+len(diff.AddedTabs) > 0 {
+				t.Errorf("sanitizing showed up as changes: %+v", diff)
+			}
+		}, ""},
+		{"edited copy", exportWith(ExportOptions{}, func(s *core.Snapshot) {
+			s.GitBranch, s.GitHeadHash, s.GitDirty = after.GitBranch, after.GitHeadHash, after.GitDirty
+			s.Windows, s.BrowserTabs, s.IDEFiles, s.Terminals = after.Windows, after.BrowserTabs, after.IDEFiles, after.Terminals
+		}), func(t *testing.T, diff *DiffResult) {
+			got := fmt.Sprintf("git:%v windows:+%v-%v moved:%d tabs:+%v-%v files:+%v-%v terminals:+%v-%v",
+				diff.GitChanged, diff.AddedWindows, diff.RemovedWindows, len(diff.MovedWindows), diff.AddedTabs, diff.RemovedTabs,
+				diff.AddedFiles, diff.RemovedFiles, diff.AddedTerminals, diff.RemovedTerminals)
+			want := "git:true windows:+[docs]-[general] moved:1 tabs:+[https://pkg.go.dev]-[https://github.com] " +
+				"files:+[/src/app/c.go]-[/src/app/b.go] terminals:+[bash @ /src/app/cmd]-[zsh @ /tmp]"
+			if got != want {
+				t.Errorf("diff = %s\nwant   %s", got, want)
+			}
+		}, ""},
+		{"malformed", `{"snapshot": {"name": "x"}}`, nil, `missing "version"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := m.DiffExternal(ctx, "before", strings.NewReader(tt.doc))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("DiffExternal = %v, want an error with %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DiffExternal: %v", err)
+			}
+			if diff.SourceID != "before" || diff.TargetID != "external:before" {
+				t.Errorf("compared %s with %s, want before with external:before", diff.SourceID, diff.TargetID)
+			}
+			tt.check(t, diff)
+		})
+	}
+
+	// Comparar no importa nada
+	list, err := repo.ListSnapshots(ctx, core.SnapshotFilter{})
+	if err != nil || list.Total != 1 {
+		t.Errorf("%d snapshots stored after the diffs, want 1 (%v)", list.Total, err)
+	}
+}
+
+func TestUntrustedRestoreOpensNoTerminals(t *testing.T) {
+	terminals := []core.Terminal{
+		{TerminalApp: "wt.exe", WorkingDirectory: t.TempDir(), Layout: &core.TerminalLayout{Tabs: []core.TerminalTab{{Title: "x ; new-tab cmd /c calc"}}}},
+		{TerminalApp: "Calculator"},
+	}
+	tabs := []core.BrowserTab{{URL: "https://go.dev", BrowserName: "/Applications/Evil.app"}}
+	tests := []struct {
+		name      string
+		untrusted bool
+		terminals []string
+		tabs      []string
+		note      string
+	}{
+		{"local snapshot", false, []string{"wt.exe", "Calculator"}, []string{"/Applications/Evil.app https://go.dev"}, ""},
+		{"imported snapshot", true, nil, []string{"https://go.dev"}, "2 terminal(s) not reopened: terminal apps and layouts came from an imported snapshot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &launchRecorder{MockAdapter: platform.NewMockAdapter()}
+			m, repo := newTestManager(t, adapter)
+			saveSnapshot(t, repo, &core.Snapshot{ID: "shared", Terminals: terminals, BrowserTabs: tabs, Untrusted: tt.untrusted})
+
+			report, err := m.Restore(context.Background(), "shared", RestoreOptions{})
+			if err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			if !slices.Equal(adapter.terminals, tt.terminals) {
+				t.Errorf("terminals opened = %v, want %v", adapter.terminals, tt.terminals)
+			}
+			if !slices.Equal(adapter.tabs, tt.tabs) {
+				t.Errorf("tabs opened = %v, want %v", adapter.tabs, tt.tabs)
+			}
+			if tt.note != "" && !slices.Contains(report.Notes, tt.note) {
+				t.Errorf("notes %v, want %q", report.Notes, tt.note)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+// initRepo crea un repo con un commit y retorna su ruta y el hash de HEAD
+func initRepo(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("PlainInit: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatalf("Worktree: %v", err)
+	}
+	if _, err := w.Add("main.go"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	hash, err := w.Commit("initial", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	return dir, hash.String()
+}
+
+func TestGitHeadHashRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir, head := initRepo(t)
+	adapter := platform.NewScenarioAdapter(&platform.Scenario{Steps: []platform.ScenarioState{{GitRepo: dir}}})
+	m, _ := newTestManager(t, adapter)
+
+	s, err := m.Capture(ctx, CaptureOptions{Name: "in-repo"})
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	if s.GitHeadHash != head {
+		t.Fatalf("captured head %q, want %q", s.GitHeadHash, head)
+	}
+
+	loaded, err := m.Load(ctx, s.ID)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.GitHeadHash != head || loaded.GitBranch == "" || loaded.GitDirty {
+		t.Errorf("loaded git context = %q %q dirty=%v, want head %q on a clean branch",
+			loaded.GitHeadHash, loaded.GitBranch, loaded.GitDirty, head)
+	}
+
+	list, err := m.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list.Snapshots) != 1 || list.Snapshots[0].GitHeadHash != head {
+		t.Errorf("listed head %+v, want %q", list.Snapshots, head)
+	}
+}
@@ -0,0 +1,172 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/db"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+// newTestManager arma un Manager sobre una base SQLite temporal
+func newTestManager(t *testing.T, adapter core.PlatformAdapter) (*Manager, *db.SQLiteRepository) {
+	t.Helper()
+	d, err := db.NewDB(filepath.Join(t.TempDir(), "snapshots.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	repo := db.NewRepository(d)
+	return NewManager(repo, adapter), repo
+}
+
+// positionRecorder es un mock que anota, en orden, a qué ventana grabada se
+// llevó cada ventana viva
+type positionRecorder struct {
+	*platform.MockAdapter
+	mu     sync.Mutex
+	titles []string
+}
+
+func (r *positionRecorder) PositionWindow(ctx context.Context, live core.Window, target core.Window) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.titles = append(r.titles, target.WindowTitle)
+	return nil
+}
+
+func (r *positionRecorder) positioned() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.titles...)
+}
+
+// saveSnapshot guarda s con sus componentes, fallando el test si no puede
+func saveSnapshot(t *testing.T, repo *db.SQLiteRepository, s *core.Snapshot) {
+	t.Helper()
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now()
+		s.UpdatedAt = s.CreatedAt
+	}
+	if s.Name == "" {
+		s.Name = s.ID
+	}
+	if err := repo.SaveSnapshot(context.Background(), s); err != nil {
+		t.Fatalf("SaveSnapshot %s: %v", s.ID, err)
+	}
+}
+
+// movingAdapter es un mock cuyas ventanas vivas sí se mueven: PositionWindow
+// aplica la geometría pedida, pasada por adjust si no es nil (el clamp o el
+// snapping que haría el sistema)
+type movingAdapter struct {
+	*platform.MockAdapter
+	adjust func(core.Window) core.Window
+	moves  int
+}
+
+func (a *movingAdapter) PositionWindow(ctx context.Context, live core.Window, target core.Window) error {
+	for i, w := range a.Windows {
+		if w.Pid != live.Pid || w.WindowTitle != live.WindowTitle {
+			continue
+		}
+		placed := w
+		placed.X, placed.Y, placed.Width, placed.Height = target.X, target.Y, target.Width, target.Height
+		if a.adjust != nil {
+			placed = a.adjust(placed)
+		}
+		a.Windows[i] = placed
+		a.moves++
+		return nil
+	}
+	return fmt.Errorf("window %q is gone", live.WindowTitle)
+}
+
+// bulkRecorder es un mock con restauración en bloque que anota qué ventanas
+// grabadas recibe por RestoreWindows y RestoreWindow, y las da por restauradas
+type bulkRecorder struct {
+	*platform.MockAdapter
+	mu     sync.Mutex
+	titles []string
+}
+
+func (r *bulkRecorder) RestoreWindows(ctx context.Context, windows []core.Window) ([]core.Window, []error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range windows {
+		r.titles = append(r.titles, w.WindowTitle)
+	}
+	return append([]core.Window(nil), windows...), make([]error, len(windows)), nil
+}
+
+func (r *bulkRecorder) RestoreWindow(ctx context.Context, window core.Window) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.titles = append(r.titles, window.WindowTitle)
+	return nil
+}
+
+func (r *bulkRecorder) restored() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.titles...)
+}
+
+// gateAdapter es un mock cuyo PositionWindow avisa por entered y se bloquea
+// hasta que se cierre release, para dejar un restore a mitad de camino.
+// Con panics, PositionWindow entra en pánico en vez de bloquearse
+type gateAdapter struct {
+	*platform.MockAdapter
+	entered chan struct{}
+	release chan struct{}
+	panics  bool
+	once    sync.Once
+}
+
+func newGateAdapter(windows []core.Window) *gateAdapter {
+	a := &gateAdapter{MockAdapter: platform.NewMockAdapter(), entered: make(chan struct{}), release: make(chan struct{})}
+	a.Windows = windows
+	return a
+}
+
+func (a *gateAdapter) PositionWindow(ctx context.Context, live core.Window, target core.Window) error {
+	a.once.Do(func() { close(a.entered) })
+	if a.panics {
+		panic("adapter crashed")
+	}
+	<-a.release
+	return nil
+}
+
+var updateGolden = flag.Bool("update", false, "reescribe los golden de testdata")
+
+// assertGolden compara got byte a byte con testdata/<name>.golden; con
+// -update lo reescribe
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v (run with -update to create it)", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from %s:\n--- got\n%s\n--- want\n%s", name, path, got, want)
+	}
+}
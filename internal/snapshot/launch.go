@@ -0,0 +1,93 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+// DefaultLaunchTimeout es la espera máxima por la ventana de una app lanzada
+const DefaultLaunchTimeout = 10 * time.Second
+
+// launchPollInterval es cada cuánto se buscan ventanas nuevas tras lanzar
+const launchPollInterval = 250 * time.Millisecond
+
+// launchAndPlace lanza la app de w, espera a que aparezca una ventana nueva
+// suya (preferentemente del PID lanzado), le aplica la geometría de w y
+// retorna esa ventana tal como apareció
+func (m *Manager) launchAndPlace(ctx context.Context, w core.Window, timeout time.Duration) (core.Window, error) {
+	launcher, ok := m.platform.(core.AppLauncher)
+	if !ok {
+		return core.Window{}, fmt.Errorf("the %s adapter cannot launch applications", m.platform.Name())
+	}
+	if timeout <= 0 {
+		timeout = DefaultLaunchTimeout
+	}
+
+	before, err := m.platform.GetWindows(ctx)
+	if err != nil {
+		return core.Window{}, fmt.Errorf("failed to get current windows: %w", err)
+	}
+	existing := make(map[string]bool, len(before))
+	for _, lw := range before {
+		existing[liveWindowKey(lw)] = true
+	}
+
+	pid, err := launcher.LaunchApp(ctx, w)
+	if err != nil {
+		return core.Window{}, err
+	}
+
+	// Con PID conocido se espera una ventana de ese proceso; solo pasada la
+	// mitad del plazo se acepta otra ventana nueva de la app (apps de una
+	// sola instancia que le pasan el pedido al proceso existente)
+	start := time.Now()
+	deadline := start.Add(timeout)
+	for {
+		live, err := m.platform.GetWindows(ctx)
+		if err != nil {
+			return core.Window{}, fmt.Errorf("failed to get current windows: %w", err)
+		}
+		if lw := newWindowOf(live, existing, w, pid); lw != nil && (pid == 0 || lw.Pid == pid || time.Since(start) > timeout/2) {
+			return *lw, m.platform.PositionWindow(ctx, *lw, w)
+		}
+		if time.Now().After(deadline) {
+			return core.Window{}, fmt.Errorf("launched %s but no window appeared within %s", w.AppName, timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return core.Window{}, ctx.Err()
+		case <-time.After(launchPollInterval):
+		}
+	}
+}
+
+// newWindowOf busca entre las ventanas vivas una que no existía antes del
+// lanzamiento y pertenece al proceso lanzado o, si no se conoce, a la app.
+// La ventana retornada lleva el PID y el rect enumerados: PositionWindow la
+// resuelve por ellos y no por título, así una primera instancia con el mismo
+// título no recibe la geometría de la nueva
+func newWindowOf(live []core.Window, existing map[string]bool, target core.Window, pid int) *core.Window {
+	var byApp *core.Window
+	for i, lw := range live {
+		if existing[liveWindowKey(lw)] {
+			continue
+		}
+		if pid != 0 && lw.Pid == pid {
+			return &live[i]
+		}
+		if byApp == nil && platform.SameApp(lw, target) {
+			byApp = &live[i]
+		}
+	}
+	return byApp
+}
+
+// liveWindowKey identifica una ventana viva entre dos enumeraciones
+func liveWindowKey(w core.Window) string {
+	return fmt.Sprintf("%d\x00%s\x00%s", w.Pid, strings.ToLower(w.AppName), w.WindowTitle)
+}
@@ -0,0 +1,61 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// maxLineageDepth corta cadenas de ancestros anormalmente largas
+const maxLineageDepth = 32
+
+// LineageNode es un snapshot en el árbol de ancestros de otro
+type LineageNode struct {
+	ID       string
+	Snapshot *core.Snapshot // nil si el snapshot ya no existe en esta base
+	Parents  []*LineageNode
+	Cycle    bool // El ID ya aparece más abajo en la misma rama; no se expande
+}
+
+// Lineage arma el árbol de ancestros de un snapshot siguiendo ParentIDs
+func (m *Manager) Lineage(ctx context.Context, id string) (*LineageNode, error) {
+	root, err := m.repo.GetSnapshotByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	if root == nil {
+		return nil, &core.NotFoundError{SnapshotID: id}
+	}
+	return m.lineageOf(ctx, root, map[string]bool{}, 0)
+}
+
+func (m *Manager) lineageOf(ctx context.Context, s *core.Snapshot, branch map[string]bool, depth int) (*LineageNode, error) {
+	node := &LineageNode{ID: s.ID, Snapshot: s}
+	if depth >= maxLineageDepth {
+		return node, nil
+	}
+	branch[s.ID] = true
+	defer delete(branch, s.ID)
+
+	for _, parentID := range s.ParentIDs {
+		if branch[parentID] {
+			node.Parents = append(node.Parents, &LineageNode{ID: parentID, Cycle: true})
+			continue
+		}
+		parent, err := m.repo.GetSnapshotByID(ctx, parentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get snapshot %s: %w", parentID, err)
+		}
+		if parent == nil {
+			node.Parents = append(node.Parents, &LineageNode{ID: parentID})
+			continue
+		}
+		parentNode, err := m.lineageOf(ctx, parent, branch, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		node.Parents = append(node.Parents, parentNode)
+	}
+	return node, nil
+}
@@ -0,0 +1,116 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+func TestDerivedSnapshotsRecordParentAndOrigin(t *testing.T) {
+	ctx := context.Background()
+	adapter := platform.NewMockAdapter()
+	adapter.Windows = []core.Window{{AppName: "Code", WindowTitle: "main.go", Width: 800, Height: 600, Pid: 1}}
+	m, repo := newTestManager(t, adapter)
+
+	captured, err := m.Capture(ctx, CaptureOptions{Name: "work"})
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	switched, err := m.SwitchTo(ctx, captured.ID, CaptureOptions{}, RestoreOptions{})
+	if err != nil {
+		t.Fatalf("SwitchTo: %v", err)
+	}
+	var exported bytes.Buffer
+	if err := m.ExportSnapshot(ctx, captured.ID, &exported, ExportOptions{}); err != nil {
+		t.Fatalf("ExportSnapshot: %v", err)
+	}
+	imported, err := m.ImportSnapshot(ctx, &exported)
+	if err != nil {
+		t.Fatalf("ImportSnapshot: %v", err)
+	}
+
+	// Lo que se valida es lo que queda en la base, no el valor devuelto
+	tests := []struct {
+		name    string
+		id      string
+		origin  string
+		parents []string
+	}{
+		{"captured", captured.ID, core.OriginCaptured, nil},
+		{"backup", switched.Backup.ID, core.OriginBackup, nil},
+		{"imported", imported.ID, core.OriginImported, []string{captured.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := repo.GetSnapshotByID(ctx, tt.id)
+			if err != nil || s == nil {
+				t.Fatalf("GetSnapshotByID %s: %v", tt.id, err)
+			}
+			if s.Origin != tt.origin {
+				t.Errorf("origin = %q, want %q", s.Origin, tt.origin)
+			}
+			if !slices.Equal(s.ParentIDs, tt.parents) {
+				t.Errorf("parents = %v, want %v", s.ParentIDs, tt.parents)
+			}
+		})
+	}
+
+	// El import no pisa al original
+	if imported.ID == captured.ID {
+		t.Errorf("import reused the exported ID %s", captured.ID)
+	}
+}
+
+func TestLineageWalksParents(t *testing.T) {
+	ctx := context.Background()
+	m, repo := newTestManager(t, platform.NewMockAdapter())
+	saveSnapshot(t, repo, &core.Snapshot{ID: "root", Origin: core.OriginCaptured})
+	saveSnapshot(t, repo, &core.Snapshot{ID: "copy", Origin: core.OriginImported, ParentIDs: []string{"root"}})
+	saveSnapshot(t, repo, &core.Snapshot{ID: "orphan", Origin: core.OriginImported, ParentIDs: []string{"gone"}})
+	saveSnapshot(t, repo, &core.Snapshot{ID: "loop-a", ParentIDs: []string{"loop-b"}})
+	saveSnapshot(t, repo, &core.Snapshot{ID: "loop-b", ParentIDs: []string{"loop-a"}})
+
+	tests := []struct {
+		name string
+		id   string
+		want string // La rama de la izquierda, "id" por nodo; "?" si falta y "!" si es ciclo
+	}{
+		{"no parents", "root", "root"},
+		{"one level", "copy", "copy>root"},
+		{"missing parent", "orphan", "orphan>gone?"},
+		{"cycle", "loop-a", "loop-a>loop-b>loop-a!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := m.Lineage(ctx, tt.id)
+			if err != nil {
+				t.Fatalf("Lineage: %v", err)
+			}
+			if got := lineagePath(node); got != tt.want {
+				t.Errorf("lineage = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := m.Lineage(ctx, "missing"); err == nil {
+		t.Error("lineage of a missing snapshot did not fail")
+	}
+}
+
+// lineagePath resume la primera rama del árbol para compararla en una línea
+func lineagePath(node *LineageNode) string {
+	path := node.ID
+	switch {
+	case node.Cycle:
+		return path + "!"
+	case node.Snapshot == nil:
+		return path + "?"
+	case len(node.Parents) == 0:
+		return path
+	}
+	return path + ">" + lineagePath(node.Parents[0])
+}
@@ -0,0 +1,99 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrSnapshotBusy indica que otra operación tiene tomado el snapshot
+var ErrSnapshotBusy = errors.New("snapshot is busy")
+
+// BusyError detalla qué operación tiene tomado el snapshot.
+// errors.Is(err, ErrSnapshotBusy) es true para este error
+type BusyError struct {
+	SnapshotID string
+	Operation  string
+}
+
+func (e *BusyError) Error() string {
+	return fmt.Sprintf("snapshot %s is busy: %s in progress", e.SnapshotID, e.Operation)
+}
+
+func (e *BusyError) Is(target error) bool {
+	return target == ErrSnapshotBusy
+}
+
+// snapshotLocks es un registro en proceso de locks por ID de snapshot.
+// Las lecturas largas (restore, export) toman el lock compartido y pueden
+// convivir; delete y update lo toman exclusivo y fallan en vez de esperar.
+type snapshotLocks struct {
+	mu   sync.Mutex
+	held map[string]*lockEntry
+}
+
+type lockEntry struct {
+	ops       []string // operaciones compartidas en curso, en orden de llegada
+	exclusive string   // operación exclusiva en curso, "" si no hay
+}
+
+func newSnapshotLocks() *snapshotLocks {
+	return &snapshotLocks{held: make(map[string]*lockEntry)}
+}
+
+// acquireShared toma el lock para una lectura. Falla si hay una operación exclusiva
+func (l *snapshotLocks) acquireShared(id, op string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := l.held[id]
+	if e != nil && e.exclusive != "" {
+		return nil, &BusyError{SnapshotID: id, Operation: e.exclusive}
+	}
+	if e == nil {
+		e = &lockEntry{}
+		l.held[id] = e
+	}
+	e.ops = append(e.ops, op)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			for i, o := range e.ops {
+				if o == op {
+					e.ops = append(e.ops[:i], e.ops[i+1:]...)
+					break
+				}
+			}
+			if len(e.ops) == 0 && e.exclusive == "" {
+				delete(l.held, id)
+			}
+		})
+	}, nil
+}
+
+// acquireExclusive toma el lock para modificar o borrar. Falla si hay cualquier otra operación
+func (l *snapshotLocks) acquireExclusive(id, op string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e := l.held[id]; e != nil {
+		holder := e.exclusive
+		if holder == "" && len(e.ops) > 0 {
+			holder = e.ops[0]
+		}
+		return nil, &BusyError{SnapshotID: id, Operation: holder}
+	}
+	l.held[id] = &lockEntry{exclusive: op}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			delete(l.held, id)
+		})
+	}, nil
+}
@@ -2,85 +2,258 @@ package snapshot
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/events"
 	"github.com/tuusuario/dev-env-snapshots/internal/git"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
 	"github.com/tuusuario/dev-env-snapshots/internal/sanitize"
 )
 
 type Manager struct {
-	repo      core.Repository
-	platform  core.PlatformAdapter
-	sanitizer *sanitize.Sanitizer
+	repo       core.Repository
+	platform   core.PlatformAdapter
+	sanitizer  *sanitize.Sanitizer
+	placements *placementRegistry
+	events     *events.Bus
+	regions    []Region
+	neverTouch []string
+	pinnedTag  string
+	locks      *snapshotLocks
+	captureMu  sync.Mutex // Un capture a la vez; TryCapture no espera
 }
 
 func NewManager(repo core.Repository, platform core.PlatformAdapter) *Manager {
 	return &Manager{
-		repo:      repo,
-		platform:  platform,
-		sanitizer: sanitize.NewSanitizer(sanitize.DefaultOptions()),
+		repo:       repo,
+		platform:   platform,
+		sanitizer:  sanitize.NewSanitizer(sanitize.DefaultOptions()),
+		placements: newPlacementRegistry(),
+		events:     events.NewBus(),
+		locks:      newSnapshotLocks(),
+		pinnedTag:  DefaultPinnedTag,
 	}
 }
 
+// Events retorna el bus donde el manager publica el ciclo de vida de captures y restores
+func (m *Manager) Events() *events.Bus {
+	return m.events
+}
+
+// Capabilities retorna el nombre del adapter activo y lo que declara soportar;
+// declared es false si el adapter no implementa core.CapabilityReporter
+func (m *Manager) Capabilities() (adapter string, caps core.Capabilities, declared bool) {
+	reporter, ok := m.platform.(core.CapabilityReporter)
+	if !ok {
+		return m.platform.Name(), core.Capabilities{}, false
+	}
+	return m.platform.Name(), reporter.Capabilities(), true
+}
+
 // SetSanitizationOptions permite configurar la sanitización
 func (m *Manager) SetSanitizationOptions(opts sanitize.SanitizationOptions) {
 	m.sanitizer = sanitize.NewSanitizer(opts)
 }
 
+// SetRegions configura las regiones con nombre usadas para grabar y recolocar ventanas
+func (m *Manager) SetRegions(regions []Region) {
+	m.regions = regions
+}
+
+// SetNeverTouch configura las apps que ningún restore mueve (p.ej. una
+// videollamada a pantalla completa); se suman a RestoreOptions.NeverTouch
+func (m *Manager) SetNeverTouch(apps []string) {
+	m.neverTouch = apps
+}
+
 type CaptureOptions struct {
 	Name             string
 	Description      string
 	Tags             []string
 	IncludeBrowsable bool
-	IncludeTerminals bool
-	Sanitize         bool // Si es true, sanitiza datos sensibles
+	// BrowserDeepCapture lee URLs reales de Chrome/Edge vía DevTools si el adapter lo soporta
+	BrowserDeepCapture bool
+	IncludeTerminals   bool
+	IncludeProcesses   bool   // Procesos en background (servidores de desarrollo, docker-compose...)
+	RecordRegions      bool   // Graba en cada ventana la región configurada que la contiene
+	Sanitize           bool   // Si es true, sanitiza datos sensibles
+	BackupFor          string // Respaldo automático: ID del snapshot cuyo restore precede
+	// RestartProcesses marca con AutoRestart, para que el restore los relance,
+	// los procesos capturados con ese nombre (sin distinguir mayúsculas ni
+	// extensión) o ese PID. Implica IncludeProcesses
+	RestartProcesses []string
+	// Monitors limita las ventanas capturadas a las que tienen el centro en
+	// estos monitores (numerados desde 1, en orden de enumeración). Vacío = todos
+	Monitors []int
 }
 
+// ErrNoLiveWindows indica que el restore no encontró ninguna ventana viva
+// contra la cual hacer matching
+var ErrNoLiveWindows = errors.New("no live windows available to match against")
+
+// ErrCaptureInFlight indica que TryCapture no capturó porque ya había otro capture en curso
+var ErrCaptureInFlight = errors.New("a capture is already in progress")
+
+// Capture graba el estado actual del entorno. Los captures concurrentes se
+// hacen de a uno
 func (m *Manager) Capture(ctx context.Context, opts CaptureOptions) (*core.Snapshot, error) {
+	m.captureMu.Lock()
+	defer m.captureMu.Unlock()
+	return m.capture(ctx, opts)
+}
+
+// TryCapture es Capture, pero falla enseguida con ErrCaptureInFlight si hay
+// otro capture en curso (p.ej. el automático cuando el usuario captura a mano)
+func (m *Manager) TryCapture(ctx context.Context, opts CaptureOptions) (*core.Snapshot, error) {
+	if !m.captureMu.TryLock() {
+		return nil, ErrCaptureInFlight
+	}
+	defer m.captureMu.Unlock()
+	return m.capture(ctx, opts)
+}
+
+func (m *Manager) capture(ctx context.Context, opts CaptureOptions) (*core.Snapshot, error) {
 	s := &core.Snapshot{
 		ID:          uuid.New().String(),
 		Name:        opts.Name,
 		Description: opts.Description,
 		Tags:        opts.Tags,
+		BackupFor:   opts.BackupFor,
+		Origin:      core.OriginCaptured,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
+	if opts.BackupFor != "" {
+		s.Origin = core.OriginBackup
+	}
+	m.events.Publish(events.Event{Type: events.CaptureStarted, SnapshotID: s.ID, Data: map[string]interface{}{"name": s.Name}})
+	timer := newPhaseTimer()
+
+	if err := m.collect(ctx, s, opts, timer); err != nil {
+		return nil, err
+	}
+
+	// 8. Save to DB: cabecera, componentes y checksum en una sola
+	// transacción, así un timeout o una cancelación a mitad del guardado no
+	// deja un snapshot a medias y sin checksum
+	if err := m.repo.SaveSnapshot(ctx, s); err != nil {
+		return nil, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	timer.done("save")
+
+	// Las métricas son informativas: si no se pueden guardar, el capture sigue valiendo
+	_ = m.repo.SaveCaptureMetrics(ctx, core.CaptureMetrics{
+		SnapshotID:   s.ID,
+		SnapshotName: s.Name,
+		CapturedAt:   s.CreatedAt,
+		Phases:       timer.phases,
+	})
 
+	m.events.Publish(events.Event{Type: events.CaptureCompleted, SnapshotID: s.ID, Data: map[string]interface{}{
+		"windows":   len(s.Windows),
+		"terminals": len(s.Terminals),
+		"processes": len(s.Processes),
+	}})
+	return s, nil
+}
+
+// collect lee el entorno actual en s según opts (pasos 1 a 7 del capture), sin
+// guardar nada
+func (m *Manager) collect(ctx context.Context, s *core.Snapshot, opts CaptureOptions, timer *phaseTimer) error {
 	// 1. Capture Windows
 	windows, err := m.platform.GetWindows(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to capture windows: %w", err)
+		return fmt.Errorf("failed to capture windows: %w", err)
+	}
+	lister, canList := m.platform.(core.MonitorLister)
+	var monitorErr error
+	if canList {
+		s.Monitors, monitorErr = lister.GetMonitors(ctx)
+		switch {
+		case errors.Is(monitorErr, errors.ErrUnsupported):
+			s.Monitors, monitorErr, canList = nil, nil, false
+		case monitorErr != nil:
+			s.Monitors = nil
+			s.Warnings = append(s.Warnings, fmt.Sprintf("monitor layout not captured: %v", monitorErr))
+		}
+	}
+	if len(opts.Monitors) > 0 {
+		switch {
+		case !canList:
+			return fmt.Errorf("cannot filter by monitor: the %s adapter cannot list monitors", m.platform.Name())
+		case monitorErr != nil:
+			return fmt.Errorf("cannot filter by monitor: %w", monitorErr)
+		}
+		if windows, err = platform.WindowsOnMonitors(windows, s.Monitors, opts.Monitors); err != nil {
+			return err
+		}
+	}
+	platform.AssignSnapGroups(windows)
+	if canList && monitorErr == nil {
+		platform.AssignMonitors(windows, s.Monitors)
+	}
+	if opts.RecordRegions {
+		for i := range windows {
+			windows[i].Region = RegionFor(windows[i], m.regions)
+		}
 	}
 	s.Windows = windows
+	if untitled := countUntitled(windows); untitled > 0 {
+		s.Warnings = append(s.Warnings, fmt.Sprintf("%d window(s) captured without a readable title; they will be matched by app and size only", untitled))
+	}
+
+	timer.done("windows")
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("capture cancelled: %w", err)
+	}
 
 	// 2. Capture Terminals
 	if opts.IncludeTerminals {
 		terminals, err := m.platform.GetTerminals(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to capture terminals: %w", err)
+			return fmt.Errorf("failed to capture terminals: %w", err)
 		}
 		s.Terminals = terminals
+		timer.done("terminals")
 	}
 
 	// 3. Capture Git Context
+	repoPath := ""
+	if locator, ok := m.platform.(core.GitRepoLocator); ok {
+		repoPath = locator.GitRepoPath()
+	}
 	detector := git.NewDetector()
-	gitCtx, err := detector.DetectContext(ctx, "")
+	gitCtx, err := detector.DetectContext(ctx, repoPath)
 	if err == nil && gitCtx != nil {
 		s.GitBranch = gitCtx.Branch
 		s.GitRepo = gitCtx.RepoPath
 		s.GitDirty = gitCtx.IsDirty
 		s.GitHeadHash = gitCtx.HeadHash
 	}
+	timer.done("git")
 
 	// 4. Capture Browsers
 	if opts.IncludeBrowsable {
-		browsers, err := m.platform.GetBrowserTabs(ctx)
+		getTabs := m.platform.GetBrowserTabs
+		if deep, ok := m.platform.(core.DeepTabCapturer); ok && opts.BrowserDeepCapture {
+			getTabs = deep.GetBrowserTabsDeep
+		}
+		browsers, err := getTabs(ctx)
 		if err == nil && len(browsers) > 0 {
 			s.BrowserTabs = browsers
 		}
+		timer.done("browser_tabs")
 	}
 
 	// 5. Capture IDEs
@@ -88,72 +261,366 @@ func (m *Manager) Capture(ctx context.Context, opts CaptureOptions) (*core.Snaps
 	if err == nil && len(ideFiles) > 0 {
 		s.IDEFiles = ideFiles
 	}
+	timer.done("ide_files")
 
-	// 6. Sanitize if requested
+	// 6. Capture background processes
+	if opts.IncludeProcesses || len(opts.RestartProcesses) > 0 {
+		processes, err := m.platform.GetProcesses(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to capture processes: %w", err)
+		}
+		for _, ref := range markAutoRestart(processes, opts.RestartProcesses) {
+			s.Warnings = append(s.Warnings, fmt.Sprintf("no captured process matches %q, nothing marked for restart", ref))
+		}
+		s.Processes = processes
+		timer.done("processes")
+	}
+
+	// 7. Sanitize if requested
 	if opts.Sanitize {
 		m.sanitizer.SanitizeSnapshot(s)
 	}
 
-	// 7. Save to DB
-	if err := m.repo.CreateSnapshot(ctx, s); err != nil {
-		return nil, fmt.Errorf("failed to save snapshot metadata: %w", err)
+	// Nothing has been persisted yet: bail out cleanly if the caller gave up
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("capture cancelled: %w", err)
 	}
+	return nil
+}
 
-	if len(s.Windows) > 0 {
-		if err := m.repo.SaveWindows(ctx, s.ID, s.Windows); err != nil {
-			return nil, fmt.Errorf("failed to save windows: %w", err)
+// markAutoRestart marca con AutoRestart los procesos que nombra refs, por
+// nombre o por PID, y retorna las referencias que no encontraron ninguno
+func markAutoRestart(processes []core.Process, refs []string) (unmatched []string) {
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+		pid, _ := strconv.Atoi(ref)
+		found := false
+		for i, p := range processes {
+			if (pid > 0 && p.Pid == pid) || sameAppName(p.ProcessName, ref) {
+				processes[i].AutoRestart = true
+				found = true
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, ref)
 		}
 	}
+	return unmatched
+}
+
+// phaseTimer mide cada fase de un capture desde el fin de la anterior
+type phaseTimer struct {
+	last   time.Time
+	phases []core.PhaseTiming
+}
+
+func newPhaseTimer() *phaseTimer {
+	return &phaseTimer{last: time.Now()}
+}
+
+// done cierra la fase en curso. Las fases salteadas no se registran y su
+// tiempo, despreciable, queda en la siguiente
+func (t *phaseTimer) done(phase string) {
+	now := time.Now()
+	t.phases = append(t.phases, core.PhaseTiming{Phase: phase, Duration: now.Sub(t.last)})
+	t.last = now
+}
+
+// VerifyResult es el resultado de verificar la integridad de un snapshot
+type VerifyResult struct {
+	SnapshotID string
+	Stored     string
+	Computed   string
+	Valid      bool
+	Message    string
+}
 
-	if len(s.Terminals) > 0 {
-		if err := m.repo.SaveTerminals(ctx, s.ID, s.Terminals); err != nil {
-			return nil, fmt.Errorf("failed to save terminals: %w", err)
+// remapMonitors lleva a un monitor conectado las ventanas cuyo monitor
+// original ya no está (p.ej. tras desconectar el portátil del dock)
+func (m *Manager) remapMonitors(ctx context.Context, s *core.Snapshot, report *RestoreReport) {
+	lister, ok := m.platform.(core.MonitorLister)
+	if !ok || len(s.Monitors) == 0 {
+		return
+	}
+	live, err := lister.GetMonitors(ctx)
+	if err != nil || len(live) == 0 {
+		return
+	}
+	for i, w := range s.Windows {
+		placed, target, moved := platform.RemapToMonitors(w, s.Monitors, live)
+		switch {
+		case moved && w.Monitor == "":
+			report.Notes = append(report.Notes, fmt.Sprintf("%s: was off-screen, moved to %s", w.WindowTitle, target.DeviceName))
+		case moved:
+			report.Notes = append(report.Notes, fmt.Sprintf("%s: monitor %s is not connected, moved to %s", w.WindowTitle, w.Monitor, target.DeviceName))
 		}
+		s.Windows[i] = placed
 	}
+}
 
-	if len(s.BrowserTabs) > 0 {
-		if err := m.repo.SaveBrowserTabs(ctx, s.ID, s.BrowserTabs); err != nil {
-			return nil, fmt.Errorf("failed to save browser tabs: %w", err)
+// countUntitled cuenta las ventanas capturadas con título placeholder
+func countUntitled(windows []core.Window) int {
+	n := 0
+	for _, w := range windows {
+		if platform.IsUntitled(w) {
+			n++
 		}
 	}
+	return n
+}
 
-	if len(s.IDEFiles) > 0 {
-		if err := m.repo.SaveIDEFiles(ctx, s.ID, s.IDEFiles); err != nil {
-			return nil, fmt.Errorf("failed to save ide files: %w", err)
-		}
+// Verify recalcula el checksum de un snapshot y lo compara con el guardado
+func (m *Manager) Verify(ctx context.Context, id string) (*VerifyResult, error) {
+	s, err := m.repo.GetSnapshotByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	if s == nil {
+		return nil, &core.NotFoundError{SnapshotID: id}
 	}
 
-	return s, nil
+	stored, computed, err := m.repo.VerifyChecksum(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify checksum: %w", err)
+	}
+
+	result := &VerifyResult{SnapshotID: id, Stored: stored, Computed: computed}
+	switch {
+	case stored == "":
+		result.Message = "No checksum recorded for this snapshot (captured before integrity checks existed)"
+	case stored == computed:
+		result.Valid = true
+		result.Message = "Stored data matches its checksum"
+	default:
+		result.Message = "Stored data does NOT match its checksum: the snapshot was modified or partially written"
+	}
+	return result, nil
 }
 
 type RestoreOptions struct {
 	ValidateBeforeRestore bool // Verifica que las apps existan antes de restaurar
 	SkipMissingApps       bool // Si true, continúa aunque falten apps
 	DryRun                bool // Si true, solo reporta qué haría sin ejecutar
+	RespectManualChanges  bool // Si true, no toca ventanas que el usuario movió después de un restore previo
+	// ForcePosition ignora el matcher: a cada ventana capturada le asigna la
+	// siguiente ventana viva de la misma app, en orden de enumeración. Es
+	// deliberadamente ingenuo: con varias instancias de una app no garantiza
+	// que cada una reciba "su" geometría, solo que todas queden en posiciones
+	// capturadas. Útil cuando los títulos cambiaron y el matcher no encuentra nada.
+	// Respeta RespectManualChanges y el dry run muestra esta misma asignación
+	ForcePosition bool
+	MaxTabs       int  // Tope de pestañas a reabrir; 0 = DefaultMaxTabs
+	SkipTabs      bool // No reabre las pestañas del navegador
+	SkipTerminals bool // No reabre las terminales
+	UseRegions    bool // Recoloca las ventanas grabadas con región en la región actual de ese nombre
+	// LaunchMissing lanza la app de las ventanas sin match que tienen AppPath
+	// grabado y posiciona la ventana nueva cuando aparece
+	LaunchMissing bool
+	LaunchTimeout time.Duration // Espera máxima por cada ventana lanzada; 0 = DefaultLaunchTimeout
+	// MatchProfile elige los pesos del matcher (ver platform.MatchProfile);
+	// vacío usa el matcher propio del adapter
+	MatchProfile string
+	// PreserveCurrentFocus devuelve el foco, al terminar, a la ventana que lo
+	// tenía antes del restore
+	PreserveCurrentFocus bool
+	// NeverTouch son apps protegidas: sus ventanas grabadas se saltean y sus
+	// ventanas vivas no son candidatas del matcher
+	NeverTouch []string
+	// MinDriftPixels, si es > 0, deja quietas las ventanas cuya ventana viva
+	// ya está a esa distancia o menos del target en posición y tamaño (evita
+	// el parpadeo de reposicionar ventanas casi correctas). No aplica con
+	// ForcePosition
+	MinDriftPixels int
+
+	window *WindowSelector // Lo fija RestoreWindow: restaura solo esas ventanas
 }
 
-func (m *Manager) Restore(ctx context.Context, snapshotID string, opts RestoreOptions) (*RestoreReport, error) {
+// Load obtiene un snapshot con todos sus componentes desde el repositorio
+func (m *Manager) Load(ctx context.Context, snapshotID string) (*core.Snapshot, error) {
 	s, err := m.repo.GetSnapshotByID(ctx, snapshotID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get snapshot: %w", err)
 	}
 	if s == nil {
-		return nil, fmt.Errorf("snapshot not found")
+		return nil, &core.NotFoundError{SnapshotID: snapshotID}
 	}
 
-	// Fetch windows from DB
-	windows, err := m.repo.GetWindows(ctx, snapshotID)
-	if err != nil {
+	if s.Windows, err = m.repo.GetWindows(ctx, snapshotID); err != nil {
 		return nil, fmt.Errorf("failed to get windows: %w", err)
 	}
-	s.Windows = windows
+	if s.Terminals, err = m.repo.GetTerminals(ctx, snapshotID); err != nil {
+		return nil, fmt.Errorf("failed to get terminals: %w", err)
+	}
+	if s.BrowserTabs, err = m.repo.GetBrowserTabs(ctx, snapshotID); err != nil {
+		return nil, fmt.Errorf("failed to get browser tabs: %w", err)
+	}
+	if s.IDEFiles, err = m.repo.GetIDEFiles(ctx, snapshotID); err != nil {
+		return nil, fmt.Errorf("failed to get ide files: %w", err)
+	}
+	if s.Processes, err = m.repo.GetProcesses(ctx, snapshotID); err != nil {
+		return nil, fmt.Errorf("failed to get processes: %w", err)
+	}
+	if s.Monitors, err = m.repo.GetMonitors(ctx, snapshotID); err != nil {
+		return nil, fmt.Errorf("failed to get monitors: %w", err)
+	}
+	return s, nil
+}
+
+// MinRefPrefix es el largo mínimo de un prefijo de ID en ResolveSnapshotRef
+const MinRefPrefix = 6
+
+// ResolveSnapshotRef traduce un ID completo, un prefijo de ID único (de al
+// menos MinRefPrefix caracteres) o un nombre exacto, sin distinguir
+// mayúsculas, al ID del snapshot. Si hay varios candidatos falla listándolos
+// en vez de elegir uno
+func (m *Manager) ResolveSnapshotRef(ctx context.Context, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", fmt.Errorf("snapshot reference is empty")
+	}
+	candidates, err := m.repo.FindSnapshotsByRef(ctx, ref, MinRefPrefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve snapshot %q: %w", ref, err)
+	}
+	for _, c := range candidates {
+		if c.ID == ref {
+			return c.ID, nil
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		if len(ref) < MinRefPrefix {
+			return "", fmt.Errorf("%w (ID prefixes need at least %d characters)", &core.NotFoundError{SnapshotID: ref}, MinRefPrefix)
+		}
+		return "", &core.NotFoundError{SnapshotID: ref}
+	case 1:
+		return candidates[0].ID, nil
+	}
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = fmt.Sprintf("%s (%s)", c.ID, c.Name)
+	}
+	return "", fmt.Errorf("snapshot %q is ambiguous, it matches %s; use the full ID", ref, strings.Join(names, ", "))
+}
+
+// Restore aplica un snapshot al entorno actual. Con PreserveCurrentFocus
+// recuerda la ventana con foco antes de mover nada y se lo devuelve al final
+func (m *Manager) Restore(ctx context.Context, snapshotID string, opts RestoreOptions) (*RestoreReport, error) {
+	if !opts.PreserveCurrentFocus || opts.DryRun {
+		return m.restore(ctx, snapshotID, opts)
+	}
+
+	var refocus func(context.Context) error
+	var focusErr error
+	if keeper, ok := m.platform.(core.FocusKeeper); ok {
+		refocus, focusErr = keeper.SaveFocus(ctx)
+	} else {
+		focusErr = errors.ErrUnsupported
+	}
+
+	report, err := m.restore(ctx, snapshotID, opts)
+	if report == nil {
+		return report, err
+	}
+	switch {
+	case errors.Is(focusErr, errors.ErrUnsupported):
+		report.Notes = append(report.Notes, "Focus not preserved: the platform can't restore the focus")
+	case focusErr != nil:
+		report.Notes = append(report.Notes, fmt.Sprintf("Focus not preserved: %v", focusErr))
+	default:
+		// Aunque el restore se haya cancelado, el foco vuelve a su ventana
+		if ferr := refocus(context.WithoutCancel(ctx)); ferr != nil {
+			report.Notes = append(report.Notes, fmt.Sprintf("Focus not preserved: %v", ferr))
+		}
+	}
+	return report, err
+}
+
+// restore es Restore sin la preservación del foco
+func (m *Manager) restore(ctx context.Context, snapshotID string, opts RestoreOptions) (*RestoreReport, error) {
+	matcher := platform.DefaultMatcher()
+	if opts.MatchProfile != "" {
+		cfg, err := platform.MatchProfile(opts.MatchProfile)
+		if err != nil {
+			return nil, err
+		}
+		matcher = platform.NewMatcher(cfg)
+		ctx = platform.WithMatcher(ctx, matcher)
+	}
+
+	// Holding the lock keeps the snapshot from being deleted mid-restore
+	release, err := m.locks.acquireShared(snapshotID, "restore")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Hydrate the full snapshot (windows, terminals, tabs, IDE files, processes)
+	s, err := m.Load(ctx, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.window != nil {
+		if err := selectWindows(s, *opts.window, matcher); err != nil {
+			return nil, err
+		}
+	}
 
 	report := &RestoreReport{
-		SnapshotID:   snapshotID,
-		TotalWindows: len(s.Windows),
-		StartTime:    time.Now(),
+		SnapshotID: snapshotID,
+		Warnings:   s.Warnings,
+		StartTime:  time.Now(),
+	}
+
+	// Las apps protegidas quedan afuera antes de cualquier matching. Los
+	// OwnerRef se renumeran; un diálogo cuyo owner es protegido pierde el owner
+	protected := append(append([]string{}, m.neverTouch...), opts.NeverTouch...)
+	if len(protected) > 0 {
+		ctx = platform.WithProtectedApps(ctx, protected)
+		s.Windows = platform.FilterWindows(s.Windows, func(_ int, w core.Window) bool {
+			if platform.IsProtectedApp(protected, w.AppName) {
+				report.ProtectedWindows = append(report.ProtectedWindows, fmt.Sprintf("%s (%s)", w.WindowTitle, w.AppName))
+				return false
+			}
+			return true
+		})
+	}
+	report.TotalWindows = len(s.Windows)
+
+	m.remapMonitors(ctx, s, report)
+
+	if opts.UseRegions {
+		for i, w := range s.Windows {
+			if w.Region == "" {
+				continue
+			}
+			placed, ok := placeInRegion(w, m.regions)
+			if !ok {
+				report.Notes = append(report.Notes, fmt.Sprintf("%s: region %q is not configured, using captured position", w.WindowTitle, w.Region))
+				continue
+			}
+			s.Windows[i] = placed
+		}
 	}
 
+	for i, w := range s.Windows {
+		clamped, problems := platform.ClampGeometry(w)
+		if len(problems) > 0 {
+			report.Notes = append(report.Notes, fmt.Sprintf("%s: out-of-range geometry (%s)", w.WindowTitle, strings.Join(problems, ", ")))
+			s.Windows[i] = clamped
+		}
+	}
+
+	m.events.Publish(events.Event{Type: events.RestoreStarted, SnapshotID: snapshotID, Data: map[string]interface{}{
+		"windows": len(s.Windows),
+		"dry_run": opts.DryRun,
+	}})
+
 	// Validación pre-restore
 	if opts.ValidateBeforeRestore {
 		missing := m.validateApps(ctx, s.Windows)
@@ -162,56 +629,669 @@ func (m *Manager) Restore(ctx context.Context, snapshotID string, opts RestoreOp
 		if len(missing) > 0 && !opts.SkipMissingApps {
 			report.Success = false
 			report.Error = fmt.Sprintf("missing applications: %v", missing)
+			m.publishRestoreCompleted(report)
 			return report, fmt.Errorf("cannot restore: missing applications")
 		}
 	}
 
 	// Dry run mode
 	if opts.DryRun {
+		if !opts.ValidateBeforeRestore {
+			report.MissingApps = m.validateApps(ctx, s.Windows)
+		}
+
+		// Simular el matching contra las ventanas vivas, sin mover nada, con la
+		// misma asignación uno a uno (por índice) que usa el restore real
+		live, err := m.platform.GetWindows(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current windows: %w", err)
+		}
+		live = platform.WithoutProtected(ctx, live)
+		var matches []*platform.MatchResult
+		if opts.ForcePosition {
+			// Sin scoring: la misma asignación por app y orden que forcePositions
+			assignment, _ := m.forceAssign(s.Windows, live, opts.RespectManualChanges)
+			matches = make([]*platform.MatchResult, len(s.Windows))
+			for i, c := range assignment {
+				if c >= 0 {
+					matches[i] = &platform.MatchResult{Window: live[c]}
+				}
+			}
+		} else {
+			matches = matcher.MatchWindows(s.Windows, live)
+		}
+
+		// Las ventanas sin match se lanzarían como en el restore real: una vez
+		// por app, nunca desde un snapshot importado y nunca con ForcePosition
+		launchMissing := opts.LaunchMissing && !opts.ForcePosition && !s.Untrusted
+		if opts.LaunchMissing && s.Untrusted {
+			report.Notes = append(report.Notes, "Applications would not be launched: launch data came from an imported snapshot")
+		}
+		if _, ok := m.platform.(core.AppLauncher); launchMissing && !ok {
+			report.Notes = append(report.Notes, fmt.Sprintf("Applications would not be launched: the %s adapter cannot launch applications", m.platform.Name()))
+			launchMissing = false
+		}
+		launched := make(map[string]bool)
+
+		for _, item := range orderOwnersFirst(s.Windows) {
+			w := item.window
+			planned := PlannedWindow{
+				WindowTitle: w.WindowTitle,
+				AppName:     w.AppName,
+				X:           w.X,
+				Y:           w.Y,
+				Width:       w.Width,
+				Height:      w.Height,
+				State:       w.State,
+				GroupID:     w.GroupID,
+			}
+			if match := matches[item.pos-1]; match != nil {
+				planned.MatchedTitle = match.Window.WindowTitle
+				planned.MatchScore = match.Score
+				planned.OtherDesktop = match.Window.OtherDesktop
+			} else if appKey := strings.ToLower(w.AppPath); launchMissing && w.AppPath != "" && !launched[appKey] {
+				launched[appKey] = true
+				planned.LaunchPath = w.AppPath
+				planned.LaunchArgs = platform.LaunchArgList(w)
+			}
+			report.Plan = append(report.Plan, planned)
+		}
 		report.Success = true
 		report.DryRun = true
 		report.Message = "Dry run completed - no changes made"
+		m.publishRestoreCompleted(report)
 		return report, nil
 	}
 
-	// Restore windows
-	for _, w := range s.Windows {
-		if err := m.platform.RestoreWindow(ctx, w); err != nil {
-			report.FailedWindows = append(report.FailedWindows, w.WindowTitle)
-			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", w.WindowTitle, err))
+	if opts.ForcePosition {
+		if err := m.forcePositions(ctx, s.Windows, opts.RespectManualChanges, report); err != nil {
+			return report, err
+		}
+		if report.Cancelled {
+			return m.finishReport(report), nil
+		}
+		m.restoreComponents(ctx, s, opts, report)
+		return m.finishReport(report), nil
+	}
+
+	// Ventanas vivas que un restore anterior posicionó y el usuario movió después
+	manuallyMoved := make(map[string]bool)
+	if opts.RespectManualChanges {
+		live, err := m.platform.GetWindows(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current windows: %w", err)
+		}
+		for _, lw := range live {
+			if p, moved := m.placements.movedByUser(lw); moved {
+				manuallyMoved[p.Saved] = true
+			}
+		}
+	}
+
+	// Una enumeración previa de las ventanas vivas, para MinDriftPixels y para
+	// detectar que no hay ninguna contra la cual hacer matching
+	var live []core.Window
+	if len(s.Windows) > 0 {
+		if live, err = m.platform.GetWindows(ctx); err != nil {
+			if ctx.Err() != nil {
+				cancelWindows(report, orderOwnersFirst(s.Windows))
+				return m.finishReport(report), nil
+			}
+			return nil, fmt.Errorf("failed to get current windows: %w", err)
+		}
+		live = platform.WithoutProtected(ctx, live)
+	}
+
+	// Ventanas que ya están donde las quiere el snapshot, por posición en s.Windows
+	inPlace := make(map[int]bool)
+	if opts.MinDriftPixels > 0 {
+		for i, c := range matcher.AssignWindows(s.Windows, live) {
+			if c >= 0 && windowDrift(s.Windows[i], live[c]) <= opts.MinDriftPixels {
+				inPlace[i+1] = true
+			}
+		}
+	}
+
+	// Restore windows (owners first so owned dialogs land after their owner).
+	// Las que el usuario movió a mano, y las owned de esas, quedan afuera
+	var pending []orderedWindow
+	eligible := make(map[int]bool)
+	restored := make(map[int]bool)
+	for _, item := range orderOwnersFirst(s.Windows) {
+		w := item.window
+		if w.OwnerRef != 0 && !eligible[w.OwnerRef] {
+			report.SkippedWindows = append(report.SkippedWindows, w.WindowTitle)
 			continue
 		}
+		if manuallyMoved[savedKey(w)] {
+			report.ManuallyAdjusted = append(report.ManuallyAdjusted, w.WindowTitle)
+			continue
+		}
+		eligible[item.pos] = true
+		if inPlace[item.pos] {
+			report.AlreadyCorrect = append(report.AlreadyCorrect, w.WindowTitle)
+			restored[item.pos] = true
+			continue
+		}
+		pending = append(pending, item)
+	}
+
+	// Una sola enumeración y asignación uno a uno para todas las ventanas
+	targets := make([]core.Window, len(pending))
+	for i, item := range pending {
+		targets[i] = item.window
+	}
+	// Sin ventanas vivas (p.ej. una falla transitoria de la enumeración) cada
+	// ventana fallaría por separado: se lanzan las apps si LaunchMissing lo
+	// permite, si no se falla una sola vez
+	// Las rutas y argumentos de un snapshot importado no se ejecutan
+	launchMissing := opts.LaunchMissing && !s.Untrusted
+	if opts.LaunchMissing && s.Untrusted {
+		report.Notes = append(report.Notes, "Applications not launched: launch data came from an imported snapshot")
+	}
+	var placed []core.Window
+	var results []error
+	if len(pending) > 0 && len(live) == 0 {
+		if !launchMissing {
+			report.Error = ErrNoLiveWindows.Error()
+			report.EndTime = time.Now()
+			report.Duration = report.EndTime.Sub(report.StartTime)
+			m.publishRestoreCompleted(report)
+			return report, ErrNoLiveWindows
+		}
+		report.Notes = append(report.Notes, "No live windows to match against, launching the snapshot's applications")
+		placed = make([]core.Window, len(pending))
+		results = make([]error, len(pending))
+		for i := range results {
+			results[i] = ErrNoLiveWindows
+		}
+	} else {
+		placed, results, err = platform.RestoreWindows(ctx, m.platform, targets)
+		if err != nil && results == nil {
+			if ctx.Err() != nil {
+				cancelWindows(report, pending)
+				return m.finishReport(report), nil
+			}
+			return report, err
+		}
+	}
+
+	var moved []movedWindow
+	defer func() { m.recordPlacements(ctx, snapshotID, moved) }()
+	launched := make(map[string]bool) // Apps ya lanzadas en este restore, para no abrirlas dos veces
+	for i, item := range pending {
+		// Las ventanas posicionadas antes de una cancelación cuentan como
+		// restauradas; las demás quedan como canceladas y no se toca nada más
+		if cerr := ctx.Err(); cerr != nil && errors.Is(results[i], cerr) {
+			cancelWindows(report, pending[i:])
+			return m.finishReport(report), nil
+		}
+		w := item.window
+		lw := placed[i]
+		if err := results[i]; err != nil {
+			appKey := strings.ToLower(w.AppPath)
+			if !launchMissing || w.AppPath == "" || launched[appKey] {
+				report.FailedWindows = append(report.FailedWindows, w.WindowTitle)
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", w.WindowTitle, err))
+				continue
+			}
+			if ctx.Err() != nil {
+				cancelWindows(report, pending[i:])
+				return m.finishReport(report), nil
+			}
+			launched[appKey] = true
+			var lerr error
+			if lw, lerr = m.launchAndPlace(ctx, w, opts.LaunchTimeout); lerr != nil {
+				if ctx.Err() != nil && errors.Is(lerr, ctx.Err()) {
+					cancelWindows(report, pending[i:])
+					return m.finishReport(report), nil
+				}
+				report.FailedWindows = append(report.FailedWindows, w.WindowTitle)
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v; launch failed: %v", w.WindowTitle, err, lerr))
+				continue
+			}
+			report.LaunchedWindows++
+		}
+		moved = append(moved, movedWindow{saved: w, live: lw})
+		if lw.OtherDesktop {
+			report.OtherDesktop = append(report.OtherDesktop, w.WindowTitle)
+		}
+		m.events.Publish(events.Event{Type: events.WindowRestored, SnapshotID: snapshotID, Data: map[string]interface{}{"window_title": w.WindowTitle}})
+		restored[item.pos] = true
 		report.RestoredWindows++
 	}
 
+	report.PartialGroups = partialGroups(s.Windows, restored)
+	if ctx.Err() != nil {
+		// Cancelado después de la última ventana: no se abre nada más
+		report.Cancelled = true
+		return m.finishReport(report), nil
+	}
+
+	m.restoreComponents(ctx, s, opts, report)
+	return m.finishReport(report), nil
+}
+
+// restoreComponents reabre terminales, pestañas y procesos después de las
+// ventanas. De un snapshot Untrusted no se ejecuta nada que venga del
+// archivo: las terminales no se abren (la app y el layout de wt.exe son
+// datos importados) y las pestañas van al navegador predeterminado, no a la
+// app que nombra el snapshot
+func (m *Manager) restoreComponents(ctx context.Context, s *core.Snapshot, opts RestoreOptions, report *RestoreReport) {
+	if !opts.SkipTerminals {
+		if s.Untrusted && len(s.Terminals) > 0 {
+			report.Notes = append(report.Notes, fmt.Sprintf("%d terminal(s) not reopened: terminal apps and layouts came from an imported snapshot", len(s.Terminals)))
+		} else {
+			m.restoreTerminals(ctx, s.Terminals, report)
+		}
+	}
+	if !opts.SkipTabs {
+		tabs := s.BrowserTabs
+		if s.Untrusted && slices.ContainsFunc(tabs, func(t core.BrowserTab) bool { return t.BrowserName != "" }) {
+			tabs = slices.Clone(tabs)
+			for i := range tabs {
+				tabs[i].BrowserName = ""
+			}
+			report.Notes = append(report.Notes, "Tabs opened in the default browser: browsers named by an imported snapshot are not launched")
+		}
+		m.restoreTabs(ctx, tabs, opts.MaxTabs, report)
+	}
+	m.restoreProcesses(ctx, s, report)
+}
+
+// cancelWindows marca el restore como cancelado con las ventanas que no se
+// llegaron a intentar
+func cancelWindows(report *RestoreReport, remaining []orderedWindow) {
+	report.Cancelled = true
+	for _, item := range remaining {
+		report.CancelledWindows = append(report.CancelledWindows, item.window.WindowTitle)
+	}
+}
+
+// partialGroups describe los snap groups de los que solo se restauró una parte
+func partialGroups(windows []core.Window, restored map[int]bool) []string {
+	type groupState struct {
+		total, done int
+		missing     []string
+	}
+	groups := make(map[int]*groupState)
+	var order []int
+	for i, w := range windows {
+		if w.GroupID == 0 {
+			continue
+		}
+		g, ok := groups[w.GroupID]
+		if !ok {
+			g = &groupState{}
+			groups[w.GroupID] = g
+			order = append(order, w.GroupID)
+		}
+		g.total++
+		if restored[i+1] {
+			g.done++
+		} else {
+			g.missing = append(g.missing, w.WindowTitle)
+		}
+	}
+
+	var partial []string
+	for _, id := range order {
+		g := groups[id]
+		if g.done > 0 && g.done < g.total {
+			partial = append(partial, fmt.Sprintf("group %d: restored %d/%d windows, missing %s", id, g.done, g.total, strings.Join(g.missing, ", ")))
+		}
+	}
+	return partial
+}
+
+// restoreTerminals reabre las terminales del snapshot. Si el directorio
+// grabado ya no existe se usa el home del usuario y se anota en el reporte
+func (m *Manager) restoreTerminals(ctx context.Context, terminals []core.Terminal, report *RestoreReport) {
+	for i, t := range terminals {
+		if ctx.Err() != nil {
+			report.Cancelled = true
+			report.Notes = append(report.Notes, fmt.Sprintf("%d terminal(s) not reopened: restore cancelled", len(terminals)-i))
+			return
+		}
+		if t.WorkingDirectory == "" {
+			report.Notes = append(report.Notes, fmt.Sprintf("%s: no working directory recorded, opened in home directory", t.TerminalApp))
+		} else if info, err := os.Stat(t.WorkingDirectory); err != nil || !info.IsDir() {
+			report.Notes = append(report.Notes, fmt.Sprintf("%s: %s no longer exists, opened in home directory", t.TerminalApp, t.WorkingDirectory))
+			t.WorkingDirectory = ""
+		}
+
+		if err := m.platform.RestoreTerminal(ctx, t); err != nil {
+			report.FailedTerminals = append(report.FailedTerminals, t.TerminalApp)
+			report.Errors = append(report.Errors, fmt.Sprintf("terminal %s: %v", t.TerminalApp, err))
+			continue
+		}
+		report.RestoredTerminals++
+	}
+}
+
+// restoreTabs reabre las pestañas según PrioritizeTabs. Si el adapter lo
+// soporta, las de cada ventana del navegador vuelven juntas en una ventana
+// nueva; si no, se abren de a una en tandas. Las que exceden maxTabs quedan
+// en el reporte
+func (m *Manager) restoreTabs(ctx context.Context, tabs []core.BrowserTab, maxTabs int, report *RestoreReport) {
+	if maxTabs <= 0 {
+		maxTabs = DefaultMaxTabs
+	}
+	open, withheld := PrioritizeTabs(tabs, maxTabs)
+	for _, t := range withheld {
+		report.WithheldTabs = append(report.WithheldTabs, t.URL)
+	}
+
+	opener, _ := m.platform.(core.BrowserWindowOpener)
+	done, pinned := 0, 0
+	for gi, group := range groupTabsByWindow(open) {
+		if gi > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(tabBatchDelay):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		for _, t := range group {
+			if t.IsPinned {
+				pinned++
+			}
+		}
+
+		if opener != nil && group[0].BrowserName != "" {
+			urls := make([]string, len(group))
+			for i, t := range group {
+				urls[i] = t.URL
+			}
+			err := opener.OpenBrowserWindow(ctx, group[0].BrowserName, urls)
+			if err == nil {
+				report.RestoredTabs += len(group)
+				done += len(group)
+				continue
+			}
+			if !errors.Is(err, errors.ErrUnsupported) {
+				report.Notes = append(report.Notes, fmt.Sprintf("%s: could not open a window with its %d tab(s), opened them one by one (%v)", group[0].BrowserName, len(group), err))
+			}
+		}
+
+		for i, t := range group {
+			if i > 0 && i%tabBatchSize == 0 {
+				select {
+				case <-ctx.Done():
+				case <-time.After(tabBatchDelay):
+				}
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			done++
+			if err := m.platform.OpenURL(ctx, t.URL, t.BrowserName); err != nil {
+				report.FailedTabs++
+				report.Errors = append(report.Errors, fmt.Sprintf("tab %s: %v", t.URL, err))
+				continue
+			}
+			report.RestoredTabs++
+		}
+	}
+
+	if ctx.Err() != nil && done < len(open) {
+		report.Cancelled = true
+		report.Notes = append(report.Notes, fmt.Sprintf("%d tab(s) not reopened: restore cancelled", len(open)-done))
+	}
+	if pinned > 0 {
+		report.Notes = append(report.Notes, fmt.Sprintf("%d pinned tab(s) reopened unpinned: browsers have no command-line flag to pin a tab", pinned))
+	}
+}
+
+// restoreProcesses relanza los procesos que el capture marcó con AutoRestart
+// (CaptureOptions.RestartProcesses). Los de un snapshot Untrusted nunca se
+// ejecutan
+func (m *Manager) restoreProcesses(ctx context.Context, s *core.Snapshot, report *RestoreReport) {
+	for _, p := range s.Processes {
+		if !p.AutoRestart {
+			continue
+		}
+		if s.Untrusted {
+			report.Notes = append(report.Notes, fmt.Sprintf("%s not relaunched: command came from an imported snapshot", p.ProcessName))
+			continue
+		}
+		if ctx.Err() != nil {
+			report.Cancelled = true
+			report.Notes = append(report.Notes, fmt.Sprintf("%s not relaunched: restore cancelled", p.ProcessName))
+			continue
+		}
+		if err := m.platform.StartProcess(ctx, p); err != nil {
+			report.FailedProcesses = append(report.FailedProcesses, p.ProcessName)
+			report.Errors = append(report.Errors, fmt.Sprintf("process %s: %v", p.ProcessName, err))
+			continue
+		}
+		report.RestoredProcesses++
+	}
+}
+
+// finishReport completa tiempos, éxito y mensaje de un restore ejecutado
+func (m *Manager) finishReport(report *RestoreReport) *RestoreReport {
 	report.EndTime = time.Now()
 	report.Duration = report.EndTime.Sub(report.StartTime)
-	report.Success = report.RestoredWindows > 0
+	inPlace := len(report.AlreadyCorrect)
+	report.Success = report.RestoredWindows+inPlace > 0 && !report.Cancelled
 
-	if report.RestoredWindows == report.TotalWindows {
+	if report.Cancelled {
+		report.Message = fmt.Sprintf("Restore cancelled after %d/%d windows", report.RestoredWindows, report.TotalWindows)
+	} else if report.RestoredWindows+inPlace == report.TotalWindows {
 		report.Message = "All windows restored successfully"
+		if inPlace > 0 {
+			report.Message = fmt.Sprintf("All windows in place (%d moved, %d already correct)", report.RestoredWindows, inPlace)
+		}
 	} else {
 		report.Message = fmt.Sprintf("Restored %d/%d windows", report.RestoredWindows, report.TotalWindows)
+		if inPlace > 0 {
+			report.Message += fmt.Sprintf(", %d already correct", inPlace)
+		}
 	}
 
-	return report, nil
+	m.publishRestoreCompleted(report)
+	return report
+}
+
+func (m *Manager) publishRestoreCompleted(report *RestoreReport) {
+	m.events.Publish(events.Event{Type: events.RestoreCompleted, SnapshotID: report.SnapshotID, Data: map[string]interface{}{
+		"restored":  report.RestoredWindows,
+		"failed":    len(report.FailedWindows),
+		"success":   report.Success,
+		"dry_run":   report.DryRun,
+		"cancelled": report.Cancelled,
+	}})
+}
+
+// forcePositions implementa RestoreOptions.ForcePosition: posiciona cada
+// ventana sobre la viva que le da forceAssign, sin scoring de títulos
+func (m *Manager) forcePositions(ctx context.Context, windows []core.Window, respectManual bool, report *RestoreReport) error {
+	live, err := m.platform.GetWindows(ctx)
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to get current windows: %w", err)
+	}
+	live = platform.WithoutProtected(ctx, live)
+	assignment, manual := m.forceAssign(windows, live, respectManual)
+
+	var moved []movedWindow
+	defer func() { m.recordPlacements(ctx, report.SnapshotID, moved) }()
+
+	for i, w := range windows {
+		if ctx.Err() != nil {
+			report.Cancelled = true
+			for _, rest := range windows[i:] {
+				report.CancelledWindows = append(report.CancelledWindows, rest.WindowTitle)
+			}
+			return nil
+		}
+		if manual[i] {
+			report.ManuallyAdjusted = append(report.ManuallyAdjusted, w.WindowTitle)
+			continue
+		}
+		if assignment[i] < 0 {
+			report.FailedWindows = append(report.FailedWindows, w.WindowTitle)
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: no live window of %s left to position", w.WindowTitle, w.AppName))
+			continue
+		}
+		target := live[assignment[i]]
+
+		if err := m.platform.PositionWindow(ctx, target, w); err != nil {
+			report.FailedWindows = append(report.FailedWindows, w.WindowTitle)
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", w.WindowTitle, err))
+			continue
+		}
+		moved = append(moved, movedWindow{saved: w, live: target})
+		if target.OtherDesktop {
+			report.OtherDesktop = append(report.OtherDesktop, w.WindowTitle)
+		}
+		m.events.Publish(events.Event{Type: events.WindowRestored, SnapshotID: report.SnapshotID, Data: map[string]interface{}{"window_title": w.WindowTitle}})
+		report.RestoredWindows++
+	}
+	return nil
+}
+
+// forceAssign es la asignación de ForcePosition, compartida por el restore y
+// el dry run: a cada ventana grabada le toca la siguiente ventana viva de la
+// misma app, en orden de enumeración. assignment[i] es el índice en live de
+// la de windows[i], -1 si no quedó ninguna. Con respectManual, las ventanas
+// vivas que el usuario movió después de un restore previo no se asignan y
+// las grabadas que estaban en ellas quedan marcadas en manual
+func (m *Manager) forceAssign(windows, live []core.Window, respectManual bool) (assignment []int, manual []bool) {
+	movedSaved := make(map[string]bool)
+	byApp := make(map[string][]int)
+	for j, lw := range live {
+		if respectManual {
+			if p, moved := m.placements.movedByUser(lw); moved {
+				movedSaved[p.Saved] = true
+				continue
+			}
+		}
+		app := strings.ToLower(lw.AppName)
+		byApp[app] = append(byApp[app], j)
+	}
+
+	assignment = make([]int, len(windows))
+	manual = make([]bool, len(windows))
+	for i, w := range windows {
+		assignment[i] = -1
+		if movedSaved[savedKey(w)] {
+			manual[i] = true
+			continue
+		}
+		app := strings.ToLower(w.AppName)
+		if candidates := byApp[app]; len(candidates) > 0 {
+			assignment[i] = candidates[0]
+			byApp[app] = candidates[1:]
+		}
+	}
+	return assignment, manual
 }
 
 // RestoreReport contiene el resultado detallado de una restauración
 type RestoreReport struct {
-	SnapshotID      string
-	TotalWindows    int
-	RestoredWindows int
-	FailedWindows   []string
-	MissingApps     []string
-	Errors          []string
-	Success         bool
-	DryRun          bool
-	Error           string
-	Message         string
-	StartTime       time.Time
-	EndTime         time.Time
-	Duration        time.Duration
+	SnapshotID        string          `json:"snapshot_id"`
+	TotalWindows      int             `json:"total_windows"`
+	RestoredWindows   int             `json:"restored_windows"`
+	LaunchedWindows   int             `json:"launched_windows"` // De las restauradas, cuántas se abrieron lanzando la app
+	FailedWindows     []string        `json:"failed_windows,omitempty"`
+	SkippedWindows    []string        `json:"skipped_windows,omitempty"`   // Ventanas owned cuyo owner no se restauró
+	ManuallyAdjusted  []string        `json:"manually_adjusted,omitempty"` // Ventanas movidas por el usuario tras un restore previo, no se tocaron
+	AlreadyCorrect    []string        `json:"already_correct,omitempty"`   // Dentro de MinDriftPixels del target, no se movieron
+	PartialGroups     []string        `json:"partial_groups,omitempty"`    // Snap groups restaurados solo en parte
+	CancelledWindows  []string        `json:"cancelled_windows,omitempty"` // No se llegaron a intentar por la cancelación
+	ProtectedWindows  []string        `json:"protected_windows,omitempty"` // De apps en NeverTouch, no se tocaron
+	OtherDesktop      []string        `json:"other_desktop,omitempty"`     // Restauradas sobre una ventana de otro escritorio virtual: quedaron allí
+	MissingApps       []string        `json:"missing_apps,omitempty"`
+	RestoredTerminals int             `json:"restored_terminals"`
+	FailedTerminals   []string        `json:"failed_terminals,omitempty"`
+	RestoredTabs      int             `json:"restored_tabs"`
+	FailedTabs        int             `json:"failed_tabs"`
+	WithheldTabs      []string        `json:"withheld_tabs,omitempty"` // URLs no reabiertas por el tope de pestañas
+	RestoredProcesses int             `json:"restored_processes"`
+	FailedProcesses   []string        `json:"failed_processes,omitempty"`
+	Notes             []string        `json:"notes,omitempty"` // Ajustes hechos durante el restore (p.ej. directorio de terminal inexistente)
+	Errors            []string        `json:"errors,omitempty"`
+	Warnings          []string        `json:"warnings,omitempty"` // Problemas de lectura del snapshot (p.ej. tags corruptos)
+	Plan              []PlannedWindow `json:"plan,omitempty"`     // Solo en dry run: qué se movería y a dónde
+	Success           bool            `json:"success"`
+	DryRun            bool            `json:"dry_run"`
+	Cancelled         bool            `json:"cancelled"` // Se canceló a mitad de camino; el reporte es parcial
+	Error             string          `json:"error,omitempty"`
+	Message           string          `json:"message"`
+	StartTime         time.Time       `json:"start_time"`
+	EndTime           time.Time       `json:"end_time"`
+	Duration          time.Duration   `json:"duration"`
+}
+
+// PlannedWindow describe la posición que un restore aplicaría a una ventana
+// y qué ventana viva recibiría esa posición según el matcher
+type PlannedWindow struct {
+	WindowTitle  string `json:"window_title"`
+	AppName      string `json:"app_name"`
+	X            int    `json:"x"`
+	Y            int    `json:"y"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	State        string `json:"state,omitempty"`
+	MatchedTitle string `json:"matched_title,omitempty"` // Vacío si ninguna ventana viva supera el umbral
+	MatchScore   int    `json:"match_score,omitempty"`
+	OtherDesktop bool   `json:"other_desktop,omitempty"` // La ventana viva está en otro escritorio virtual
+	GroupID      int    `json:"group_id,omitempty"`
+	// Sin match y con LaunchMissing: el ejecutable y los argumentos con que
+	// se lanzaría la app. Vacío si no se lanzaría nada
+	LaunchPath string   `json:"launch_path,omitempty"`
+	LaunchArgs []string `json:"launch_args,omitempty"`
+}
+
+// orderedWindow es una ventana junto a su posición (1-based) en el snapshot
+type orderedWindow struct {
+	pos    int
+	window core.Window
+}
+
+// orderOwnersFirst ordena las ventanas para que cada owner se restaure antes
+// que sus ventanas owned, preservando el orden de captura en cada nivel y
+// manteniendo contiguos los miembros de cada snap group
+func orderOwnersFirst(windows []core.Window) []orderedWindow {
+	depth := func(w core.Window) int {
+		d := 0
+		for ref := w.OwnerRef; ref > 0 && ref <= len(windows) && d <= len(windows); ref = windows[ref-1].OwnerRef {
+			d++
+		}
+		return d
+	}
+
+	ordered := make([]orderedWindow, len(windows))
+	depths := make([]int, len(windows))
+	for i, w := range windows {
+		ordered[i] = orderedWindow{pos: i + 1, window: w}
+		depths[i] = depth(w)
+	}
+	// Las ventanas de un mismo snap group se restauran juntas, en la posición del primer miembro
+	anchors := make([]int, len(windows))
+	first := make(map[int]int)
+	for i, w := range windows {
+		anchors[i] = i
+		if w.GroupID == 0 {
+			continue
+		}
+		if f, ok := first[w.GroupID]; ok {
+			anchors[i] = f
+		} else {
+			first[w.GroupID] = i
+		}
+	}
+	sort.SliceStable(ordered, func(a, b int) bool {
+		pa, pb := ordered[a].pos-1, ordered[b].pos-1
+		if depths[pa] != depths[pb] {
+			return depths[pa] < depths[pb]
+		}
+		return anchors[pa] < anchors[pb]
+	})
+	return ordered
 }
 
 // validateApps verifica qué aplicaciones están instaladas
@@ -246,46 +1326,297 @@ func (m *Manager) validateApps(ctx context.Context, windows []core.Window) []str
 	return missing
 }
 
-func (m *Manager) List(ctx context.Context) ([]core.Snapshot, error) {
-	return m.repo.ListSnapshots(ctx, core.SnapshotFilter{Limit: 50})
+// DefaultListLimit es la cantidad de snapshots que lista List
+const DefaultListLimit = 50
+
+func (m *Manager) List(ctx context.Context) (*core.SnapshotList, error) {
+	return m.ListFiltered(ctx, core.SnapshotFilter{})
+}
+
+// ListFiltered lista los snapshots que cumplen el filtro, del más nuevo al más
+// viejo. Sin Limit usa DefaultListLimit
+func (m *Manager) ListFiltered(ctx context.Context, filter core.SnapshotFilter) (*core.SnapshotList, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultListLimit
+	}
+	return m.repo.ListSnapshots(ctx, filter)
+}
+
+// CaptureMetrics retorna los tiempos de los últimos captures, del más nuevo al
+// más viejo; solo los de snapshotID si no está vacío
+func (m *Manager) CaptureMetrics(ctx context.Context, snapshotID string, limit int) ([]core.CaptureMetrics, error) {
+	return m.repo.ListCaptureMetrics(ctx, snapshotID, limit)
 }
 
+// StorageBreakdown retorna el tamaño estimado de cada snapshot, de mayor a menor
+func (m *Manager) StorageBreakdown(ctx context.Context) ([]core.StorageUsage, error) {
+	return m.repo.GetStorageBreakdown(ctx)
+}
+
+// Delete borra el snapshot. Retorna un BusyError si otra operación lo tiene tomado
 func (m *Manager) Delete(ctx context.Context, id string) error {
+	release, err := m.locks.acquireExclusive(id, "delete")
+	if err != nil {
+		return err
+	}
+	defer release()
 	return m.repo.DeleteSnapshot(ctx, id)
 }
 
+// SnapshotUpdate son los cambios de UpdateSnapshot; lo que queda en nil no se toca
+type SnapshotUpdate struct {
+	Name        *string
+	Description *string
+	Tags        []string // Reemplaza los tags si SetTags
+	SetTags     bool
+	AddTags     []string // Se aplican después de Tags
+	RemoveTags  []string
+}
+
+// UpdateSnapshot cambia nombre, descripción y tags. El lock exclusivo
+// serializa las actualizaciones, así dos merges de tags no se pisan
+func (m *Manager) UpdateSnapshot(ctx context.Context, id string, update SnapshotUpdate) (*core.Snapshot, error) {
+	if update.Name != nil && strings.TrimSpace(*update.Name) == "" {
+		return nil, fmt.Errorf("name cannot be empty")
+	}
+
+	release, err := m.locks.acquireExclusive(id, "update")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	s, err := m.repo.GetSnapshotByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	if s == nil {
+		return nil, &core.NotFoundError{SnapshotID: id}
+	}
+
+	if update.Name != nil {
+		s.Name = strings.TrimSpace(*update.Name)
+	}
+	if update.Description != nil {
+		s.Description = *update.Description
+	}
+	tags := s.Tags
+	if update.SetTags {
+		tags = update.Tags
+	}
+	s.Tags = mergeTags(tags, update.AddTags, update.RemoveTags)
+
+	if err := m.repo.UpdateSnapshot(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// mergeTags agrega y quita tags sin duplicados; el resultado queda ordenado
+func mergeTags(tags, add, remove []string) []string {
+	set := make(map[string]bool)
+	for _, t := range append(append([]string{}, tags...), add...) {
+		set[t] = true
+	}
+	for _, t := range remove {
+		delete(set, t)
+	}
+	merged := make([]string, 0, len(set))
+	for t := range set {
+		merged = append(merged, t)
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+// DiffResult es lo que cambió del snapshot SourceID al TargetID. Las listas
+// siguen el orden de captura
 type DiffResult struct {
-	SourceID       string
-	TargetID       string
-	GitChanged     bool
-	AddedWindows   []string
-	RemovedWindows []string
-	CommonWindows  int
+	SourceID         string
+	TargetID         string
+	GitChanged       bool // Cambió algo de Git: repo, branch, HEAD o estado dirty
+	Git              GitDiff
+	AddedWindows     []string
+	RemovedWindows   []string
+	CommonWindows    int
+	MovedWindows     []WindowMove // Mismo título, distinta posición o tamaño
+	AddedTabs        []string     // URLs
+	RemovedTabs      []string
+	AddedFiles       []string // Rutas de archivos abiertos en el IDE
+	RemovedFiles     []string
+	AddedTerminals   []string // "app @ directorio"
+	RemovedTerminals []string
+	NotCompared      []string // Componentes que un lado no leyó (p.ej. "terminals" en DiffLive)
+	Warnings         []string
+}
+
+// GitDiff es el contexto Git de los dos snapshots
+type GitDiff struct {
+	RepoFrom, RepoTo     string
+	BranchFrom, BranchTo string
+	HeadFrom, HeadTo     string
+	DirtyFrom, DirtyTo   bool
+}
+
+// Changed indica si cambió el repo, la branch, el HEAD o el estado dirty
+func (g GitDiff) Changed() bool {
+	return g.RepoFrom != g.RepoTo || g.BranchFrom != g.BranchTo || g.HeadFrom != g.HeadTo || g.DirtyFrom != g.DirtyTo
+}
+
+// WindowGeometry es la posición y el tamaño de una ventana
+type WindowGeometry struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
 }
 
+// WindowMove es una ventana presente en los dos snapshots con otra geometría
+type WindowMove struct {
+	WindowTitle string         `json:"window_title"`
+	AppName     string         `json:"app_name"`
+	Before      WindowGeometry `json:"before"`
+	After       WindowGeometry `json:"after"`
+}
+
+// Diff compara dos snapshots completos: ventanas (abiertas, cerradas y
+// movidas), pestañas, archivos del IDE, terminales y contexto Git
 func (m *Manager) Diff(ctx context.Context, id1, id2 string) (*DiffResult, error) {
-	s1, err := m.repo.GetSnapshotByID(ctx, id1)
+	s1, err := m.Load(ctx, id1)
+	if err != nil {
+		return nil, err
+	}
+	s2, err := m.Load(ctx, id2)
 	if err != nil {
 		return nil, err
 	}
-	s2, err := m.repo.GetSnapshotByID(ctx, id2)
+	return diffSnapshots(s1, s2), nil
+}
+
+// LiveSnapshotID identifica al entorno actual en los diffs contra lo vivo
+const LiveSnapshotID = "live"
+
+// DiffLive compara el snapshot guardado con el entorno actual, leído con opts
+// como un capture pero sin guardarlo. Los componentes que opts no lee no se
+// comparan (no aparecen como cerrados) y se listan en NotCompared
+func (m *Manager) DiffLive(ctx context.Context, snapshotID string, opts CaptureOptions) (*DiffResult, error) {
+	stored, err := m.Load(ctx, snapshotID)
 	if err != nil {
 		return nil, err
 	}
 
-	if s1 == nil || s2 == nil {
-		return nil, fmt.Errorf("one or both snapshots not found")
+	live := &core.Snapshot{ID: LiveSnapshotID, CreatedAt: time.Now()}
+	if err := m.collect(ctx, live, opts, newPhaseTimer()); err != nil {
+		return nil, err
 	}
 
-	w1, _ := m.repo.GetWindows(ctx, id1)
-	w2, _ := m.repo.GetWindows(ctx, id2)
+	diff := diffSnapshots(stored, live)
+	if !opts.IncludeTerminals {
+		diff.AddedTerminals, diff.RemovedTerminals = nil, nil
+		diff.NotCompared = append(diff.NotCompared, "terminals")
+	}
+	if !opts.IncludeBrowsable {
+		diff.AddedTabs, diff.RemovedTabs = nil, nil
+		diff.NotCompared = append(diff.NotCompared, "browser tabs")
+	}
+	return diff, nil
+}
 
+// diffSnapshots compara dos snapshots ya cargados con sus componentes
+func diffSnapshots(s1, s2 *core.Snapshot) *DiffResult {
 	diff := &DiffResult{
-		SourceID:   id1,
-		TargetID:   id2,
-		GitChanged: s1.GitBranch != s2.GitBranch || s1.GitRepo != s2.GitRepo,
+		SourceID: s1.ID,
+		TargetID: s2.ID,
+		Git: GitDiff{
+			RepoFrom: s1.GitRepo, RepoTo: s2.GitRepo,
+			BranchFrom: s1.GitBranch, BranchTo: s2.GitBranch,
+			HeadFrom: s1.GitHeadHash, HeadTo: s2.GitHeadHash,
+			DirtyFrom: s1.GitDirty, DirtyTo: s2.GitDirty,
+		},
+		Warnings: append(s1.Warnings, s2.Warnings...),
+	}
+	diff.GitChanged = diff.Git.Changed()
+	diffWindowTitles(diff, s1.Windows, s2.Windows)
+	diff.MovedWindows = movedWindows(s1.Windows, s2.Windows)
+	diff.AddedTabs, diff.RemovedTabs = diffKeys(s1.BrowserTabs, s2.BrowserTabs, func(t core.BrowserTab) string { return t.URL })
+	diff.AddedFiles, diff.RemovedFiles = diffKeys(s1.IDEFiles, s2.IDEFiles, func(f core.IDEFile) string { return f.FilePath })
+	diff.AddedTerminals, diff.RemovedTerminals = diffKeys(s1.Terminals, s2.Terminals, func(t core.Terminal) string {
+		return t.TerminalApp + " @ " + t.WorkingDirectory
+	})
+	return diff
+}
+
+// diffKeys compara dos listas de componentes por la clave que da key: added
+// son las claves solo presentes en after, removed las solo presentes en before
+func diffKeys[T any](before, after []T, key func(T) string) (added, removed []string) {
+	inBefore := make(map[string]bool, len(before))
+	for _, item := range before {
+		inBefore[key(item)] = true
+	}
+	inAfter := make(map[string]bool, len(after))
+	for _, item := range after {
+		inAfter[key(item)] = true
 	}
 
+	seen := make(map[string]bool)
+	for _, item := range after {
+		if k := key(item); !inBefore[k] && !seen[k] {
+			seen[k] = true
+			added = append(added, k)
+		}
+	}
+	for _, item := range before {
+		if k := key(item); !inAfter[k] && !seen[k] {
+			seen[k] = true
+			removed = append(removed, k)
+		}
+	}
+	return added, removed
+}
+
+// movedWindows compara la geometría de las ventanas con el mismo título en
+// los dos snapshots (la primera de cada título)
+func movedWindows(before, after []core.Window) []WindowMove {
+	first := make(map[string]core.Window, len(before))
+	for _, w := range before {
+		if _, ok := first[w.WindowTitle]; !ok {
+			first[w.WindowTitle] = w
+		}
+	}
+
+	var moved []WindowMove
+	seen := make(map[string]bool)
+	for _, w := range after {
+		b, ok := first[w.WindowTitle]
+		if !ok || seen[w.WindowTitle] {
+			continue
+		}
+		seen[w.WindowTitle] = true
+		if windowDrift(b, w) == 0 {
+			continue
+		}
+		moved = append(moved, WindowMove{
+			WindowTitle: w.WindowTitle,
+			AppName:     w.AppName,
+			Before:      WindowGeometry{X: b.X, Y: b.Y, Width: b.Width, Height: b.Height},
+			After:       WindowGeometry{X: w.X, Y: w.Y, Width: w.Width, Height: w.Height},
+		})
+	}
+	return moved
+}
+
+// DriftScore es la fracción de ventanas distintas que se abrieron o cerraron
+// entre los dos snapshots: 0 = mismas ventanas, 1 = ninguna en común
+func (d *DiffResult) DriftScore() float64 {
+	changed := len(d.AddedWindows) + len(d.RemovedWindows)
+	if changed == 0 {
+		return 0
+	}
+	return float64(changed) / float64(changed+d.CommonWindows)
+}
+
+// diffWindowTitles compara las ventanas por título
+func diffWindowTitles(diff *DiffResult, w1, w2 []core.Window) {
 	titles1 := make(map[string]bool)
 	for _, w := range w1 {
 		titles1[w.WindowTitle] = true
@@ -296,18 +1627,28 @@ func (m *Manager) Diff(ctx context.Context, id1, id2 string) (*DiffResult, error
 		titles2[w.WindowTitle] = true
 	}
 
-	for t := range titles2 {
-		if !titles1[t] {
-			diff.AddedWindows = append(diff.AddedWindows, t)
+	// Se recorren los slices (no los mapas) para que el resultado siga el
+	// orden de captura y sea estable entre llamadas
+	seen := make(map[string]bool)
+	for _, w := range w2 {
+		if seen[w.WindowTitle] {
+			continue
+		}
+		seen[w.WindowTitle] = true
+		if !titles1[w.WindowTitle] {
+			diff.AddedWindows = append(diff.AddedWindows, w.WindowTitle)
 		} else {
 			diff.CommonWindows++
 		}
 	}
-	for t := range titles1 {
-		if !titles2[t] {
-			diff.RemovedWindows = append(diff.RemovedWindows, t)
+	seen = make(map[string]bool)
+	for _, w := range w1 {
+		if seen[w.WindowTitle] {
+			continue
+		}
+		seen[w.WindowTitle] = true
+		if !titles2[w.WindowTitle] {
+			diff.RemovedWindows = append(diff.RemovedWindows, w.WindowTitle)
 		}
 	}
-
-	return diff, nil
 }
@@ -0,0 +1,1072 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+func TestRestoreReportsOtherDesktopWindows(t *testing.T) {
+	ctx := context.Background()
+	adapter := platform.NewMockAdapter()
+	adapter.Windows = []core.Window{
+		{AppName: "Code", WindowTitle: "main.go - project", X: 0, Y: 0, Width: 800, Height: 600, Pid: 10},
+		{AppName: "Slack", WindowTitle: "Slack", X: 0, Y: 0, Width: 800, Height: 600, Pid: 11, OtherDesktop: true},
+	}
+	m, repo := newTestManager(t, adapter)
+
+	now := time.Now()
+	s := &core.Snapshot{ID: "desk", Name: "desk", CreatedAt: now, UpdatedAt: now, Windows: []core.Window{
+		{AppName: "Code", WindowTitle: "main.go - project", X: 100, Y: 100, Width: 1200, Height: 800},
+		{AppName: "Slack", WindowTitle: "Slack", X: 1300, Y: 100, Width: 600, Height: 800},
+	}}
+	if err := repo.SaveSnapshot(ctx, s); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+
+	plan, err := m.Restore(ctx, s.ID, RestoreOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	other := map[string]bool{}
+	for _, p := range plan.Plan {
+		other[p.WindowTitle] = p.OtherDesktop
+	}
+	if other["main.go - project"] || !other["Slack"] {
+		t.Errorf("plan other-desktop flags = %v, want only Slack", other)
+	}
+
+	report, err := m.Restore(ctx, s.ID, RestoreOptions{})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if len(report.OtherDesktop) != 1 || report.OtherDesktop[0] != "Slack" {
+		t.Errorf("report.OtherDesktop = %v, want [Slack]", report.OtherDesktop)
+	}
+	if report.RestoredWindows != 2 {
+		t.Errorf("restored %d windows, want 2 (other-desktop windows are still positioned)", report.RestoredWindows)
+	}
+}
+
+func TestOrderOwnersFirst(t *testing.T) {
+	windows := []core.Window{
+		{WindowTitle: "dialog", OwnerRef: 3},
+		{WindowTitle: "editor"},
+		{WindowTitle: "main"},
+		{WindowTitle: "nested", OwnerRef: 1},
+		{WindowTitle: "cycle-a", OwnerRef: 6},
+		{WindowTitle: "cycle-b", OwnerRef: 5},
+	}
+	var got []string
+	for _, item := range orderOwnersFirst(windows) {
+		got = append(got, item.window.WindowTitle)
+		if windows[item.pos-1].WindowTitle != item.window.WindowTitle {
+			t.Errorf("%s carries position %d", item.window.WindowTitle, item.pos)
+		}
+	}
+	// Un ciclo de OwnerRef no cuelga: queda al final con profundidad acotada
+	want := []string{"editor", "main", "dialog", "nested", "cycle-a", "cycle-b"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestOwnerRefRoundTripAndOrderedRestore(t *testing.T) {
+	ctx := context.Background()
+	adapter := &positionRecorder{MockAdapter: platform.NewMockAdapter()}
+	adapter.Windows = []core.Window{
+		{AppName: "Code", WindowTitle: "Find in files", Width: 400, Height: 300, Pid: 1},
+		{AppName: "Code", WindowTitle: "main.go - project", Width: 1200, Height: 800, Pid: 1},
+	}
+	m, repo := newTestManager(t, adapter)
+
+	// El diálogo se grabó antes que su owner
+	saveSnapshot(t, repo, &core.Snapshot{ID: "owned", Windows: []core.Window{
+		{AppName: "Code", WindowTitle: "Find in files", Width: 400, Height: 300, OwnerRef: 2},
+		{AppName: "Code", WindowTitle: "main.go - project", Width: 1200, Height: 800},
+		{AppName: "Gone", WindowTitle: "Gone main", Width: 800, Height: 600},
+		{AppName: "Gone", WindowTitle: "Gone dialog", Width: 300, Height: 200, OwnerRef: 3},
+	}})
+
+	loaded, err := m.Load(ctx, "owned")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	refs := []int{2, 0, 0, 3}
+	for i, w := range loaded.Windows {
+		if w.OwnerRef != refs[i] {
+			t.Errorf("%s: OwnerRef = %d after reload, want %d", w.WindowTitle, w.OwnerRef, refs[i])
+		}
+	}
+
+	report, err := m.Restore(ctx, "owned", RestoreOptions{SkipMissingApps: true})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got := adapter.positioned(); strings.Join(got, "|") != "main.go - project|Find in files" {
+		t.Errorf("positioned %v, want the owner before its dialog", got)
+	}
+	if report.RestoredWindows != 2 || len(report.FailedWindows) != 2 {
+		t.Errorf("restored %d, failed %v; want the Code pair restored and both Gone windows failed", report.RestoredWindows, report.FailedWindows)
+	}
+}
+
+func TestForcePositionIgnoresTitles(t *testing.T) {
+	adapter := &movingAdapter{MockAdapter: platform.NewMockAdapter()}
+	adapter.Windows = []core.Window{
+		{AppName: "Code", WindowTitle: "Welcome", Pid: 1},
+		{AppName: "Terminal", WindowTitle: "zsh", Pid: 2},
+		{AppName: "code", WindowTitle: "Untitled-1", Pid: 3},
+	}
+	m, repo := newTestManager(t, adapter)
+	saveSnapshot(t, repo, &core.Snapshot{ID: "layout", Windows: []core.Window{
+		{AppName: "Code", WindowTitle: "api - main.go", X: 0, Y: 0, Width: 960, Height: 1080},
+		{AppName: "Code", WindowTitle: "web - index.ts", X: 960, Y: 0, Width: 960, Height: 1080},
+		{AppName: "Terminal", WindowTitle: "npm run dev", X: 0, Y: 1080, Width: 1920, Height: 400},
+		{AppName: "Slack", WindowTitle: "general", X: 100, Y: 100, Width: 800, Height: 600},
+	}})
+
+	report, err := m.Restore(context.Background(), "layout", RestoreOptions{ForcePosition: true, SkipMissingApps: true})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if report.RestoredWindows != 3 || adapter.moves != 3 {
+		t.Errorf("restored %d windows with %d moves, want 3", report.RestoredWindows, adapter.moves)
+	}
+	if len(report.FailedWindows) != 1 || report.FailedWindows[0] != "general" {
+		t.Errorf("failed windows = %v, want only the Slack window", report.FailedWindows)
+	}
+
+	// Las ventanas vivas de cada app se asignan en orden, sin mirar títulos
+	want := map[int]int{1: 0, 3: 960, 2: 0}
+	wantY := map[int]int{1: 0, 3: 0, 2: 1080}
+	for _, w := range adapter.Windows {
+		if w.X != want[w.Pid] || w.Y != wantY[w.Pid] {
+			t.Errorf("%s (pid %d) at %d,%d, want %d,%d", w.WindowTitle, w.Pid, w.X, w.Y, want[w.Pid], wantY[w.Pid])
+		}
+	}
+}
+
+func TestForcePositionRespectsManualChangesAndPlansTheSame(t *testing.T) {
+	ctx := context.Background()
+	adapter := &movingAdapter{MockAdapter: platform.NewMockAdapter()}
+	adapter.Windows = []core.Window{
+		{AppName: "Code", WindowTitle: "Welcome", Pid: 1},
+		{AppName: "Code", WindowTitle: "Untitled-1", Pid: 2},
+	}
+	m, repo := newTestManager(t, adapter)
+	saveSnapshot(t, repo, &core.Snapshot{ID: "layout", Windows: []core.Window{
+		{AppName: "Code", WindowTitle: "api - main.go", X: 0, Y: 0, Width: 960, Height: 1080},
+		{AppName: "Code", WindowTitle: "web - index.ts", X: 960, Y: 0, Width: 960, Height: 1080},
+	}})
+	plan := func(opts RestoreOptions) string {
+		t.Helper()
+		opts.DryRun, opts.ForcePosition = true, true
+		report, err := m.Restore(ctx, "layout", opts)
+		if err != nil {
+			t.Fatalf("dry run: %v", err)
+		}
+		var got []string
+		for _, p := range report.Plan {
+			got = append(got, p.WindowTitle+"->"+p.MatchedTitle)
+		}
+		return strings.Join(got, "|")
+	}
+
+	// Los títulos no se parecen: el plan es la asignación por orden, no la del matcher
+	if got, want := plan(RestoreOptions{}), "api - main.go->Welcome|web - index.ts->Untitled-1"; got != want {
+		t.Errorf("plan = %q, want %q", got, want)
+	}
+	if _, err := m.Restore(ctx, "layout", RestoreOptions{ForcePosition: true}); err != nil {
+		t.Fatalf("first restore: %v", err)
+	}
+
+	// El usuario mueve la primera ventana: ni se toca ni se le da a otra grabada
+	adapter.Windows[0].X = 500
+	if got, want := plan(RestoreOptions{RespectManualChanges: true}), "api - main.go->|web - index.ts->Untitled-1"; got != want {
+		t.Errorf("plan respecting manual changes = %q, want %q", got, want)
+	}
+	report, err := m.Restore(ctx, "layout", RestoreOptions{ForcePosition: true, RespectManualChanges: true})
+	if err != nil {
+		t.Fatalf("second restore: %v", err)
+	}
+	if len(report.ManuallyAdjusted) != 1 || report.ManuallyAdjusted[0] != "api - main.go" || report.RestoredWindows != 1 {
+		t.Errorf("manually adjusted %v, restored %d; want the api window left alone and one restored", report.ManuallyAdjusted, report.RestoredWindows)
+	}
+	if adapter.Windows[0].X != 500 || adapter.Windows[1].X != 960 {
+		t.Errorf("windows at x=%d and x=%d, want 500 (untouched) and 960", adapter.Windows[0].X, adapter.Windows[1].X)
+	}
+}
+
+func TestUntitledWindowsCaptureAndRestore(t *testing.T) {
+	ctx := context.Background()
+	adapter := &movingAdapter{MockAdapter: platform.NewMockAdapter()}
+	adapter.Windows = []core.Window{
+		{AppName: "Slack", WindowTitle: platform.UntitledTitle("Slack"), X: 10, Y: 10, Width: 1200, Height: 800, Pid: 1},
+		{AppName: "Code", WindowTitle: "main.go", X: 0, Y: 0, Width: 1000, Height: 700, Pid: 2},
+	}
+	m, _ := newTestManager(t, adapter)
+
+	s, err := m.Capture(ctx, CaptureOptions{Name: "untitled"})
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	if len(s.Windows) != 2 {
+		t.Fatalf("captured %d windows, want the untitled one too", len(s.Windows))
+	}
+	if len(s.Warnings) != 1 || !strings.HasPrefix(s.Warnings[0], "1 window(s) captured without a readable title") {
+		t.Errorf("warnings = %q", s.Warnings)
+	}
+
+	// Tras un reinicio la ventana sin título vuelve en otro lugar y con otro pid
+	adapter.Windows[0].X, adapter.Windows[0].Pid = 700, 3
+	report, err := m.Restore(ctx, s.ID, RestoreOptions{})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if report.RestoredWindows != 2 || adapter.Windows[0].X != 10 {
+		t.Errorf("restored %d windows, untitled one at x=%d; want 2 and x=10", report.RestoredWindows, adapter.Windows[0].X)
+	}
+}
+
+func TestNeverTouchSkipsProtectedApps(t *testing.T) {
+	live := []core.Window{
+		{AppName: "zoom.exe", WindowTitle: "Zoom Meeting", Width: 1920, Height: 1080, Pid: 1},
+		{AppName: "Code", WindowTitle: "main.go - project", Width: 1200, Height: 800, Pid: 2},
+		{AppName: "Code", WindowTitle: "Find in files", Width: 400, Height: 300, Pid: 2},
+	}
+	// El diálogo va después de la ventana protegida: sin renumerar, su
+	// OwnerRef apuntaría a sí mismo
+	saved := []core.Window{
+		{AppName: "Zoom", WindowTitle: "Zoom Meeting", X: 0, Y: 0, Width: 1920, Height: 1080},
+		{AppName: "Code", WindowTitle: "main.go - project", X: 100, Y: 100, Width: 1200, Height: 800},
+		{AppName: "Code", WindowTitle: "Find in files", X: 300, Y: 300, Width: 400, Height: 300, OwnerRef: 2},
+		{AppName: "Zoom", WindowTitle: "Share screen", X: 10, Y: 10, Width: 600, Height: 400, OwnerRef: 1},
+	}
+
+	tests := []struct {
+		name       string
+		adapter    func() (core.PlatformAdapter, func() []string)
+		neverTouch []string
+		configured []string
+	}{
+		{
+			name: "option, positioned one by one",
+			adapter: func() (core.PlatformAdapter, func() []string) {
+				a := &positionRecorder{MockAdapter: platform.NewMockAdapter()}
+				a.Windows = live
+				return a, a.positioned
+			},
+			neverTouch: []string{"zoom"},
+		},
+		{
+			name: "configured, bulk restore",
+			adapter: func() (core.PlatformAdapter, func() []string) {
+				a := &bulkRecorder{MockAdapter: platform.NewMockAdapter()}
+				a.Windows = live
+				return a, a.restored
+			},
+			configured: []string{"Zoom.exe"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter, touched := tt.adapter()
+			m, repo := newTestManager(t, adapter)
+			m.SetNeverTouch(tt.configured)
+			saveSnapshot(t, repo, &core.Snapshot{ID: "call", Windows: saved})
+
+			report, err := m.Restore(context.Background(), "call", RestoreOptions{NeverTouch: tt.neverTouch})
+			if err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			if got := touched(); strings.Join(got, "|") != "main.go - project|Find in files" {
+				t.Errorf("restored %v, want the Code window and then its dialog", got)
+			}
+			want := "Zoom Meeting (Zoom)|Share screen (Zoom)"
+			if got := strings.Join(report.ProtectedWindows, "|"); got != want {
+				t.Errorf("protected = %q, want %q", got, want)
+			}
+			if report.TotalWindows != 2 || report.RestoredWindows != 2 || len(report.SkippedWindows) != 0 {
+				t.Errorf("total %d, restored %d, skipped %v; want 2, 2 and none", report.TotalWindows, report.RestoredWindows, report.SkippedWindows)
+			}
+		})
+	}
+}
+
+func TestRestartProcessesMarkedAtCapture(t *testing.T) {
+	ctx := context.Background()
+	adapter := &launchRecorder{MockAdapter: platform.NewScenarioAdapter(&platform.Scenario{Name: "dev", Steps: []platform.ScenarioState{{
+		Windows: []core.Window{{AppName: "Code", WindowTitle: "main.go", Width: 800, Height: 600}},
+		Processes: []core.Process{
+			{ProcessName: "node.exe", Command: "node server.js", Pid: 100},
+			{ProcessName: "postgres", Command: "postgres -D data", Pid: 4242},
+			{ProcessName: "redis-server", Command: "redis-server", Pid: 300},
+		},
+	}}})}
+	m, _ := newTestManager(t, adapter)
+
+	// Sin restart_processes los procesos quedan como referencia y no se relanzan
+	plain, err := m.Capture(ctx, CaptureOptions{Name: "plain", IncludeProcesses: true})
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	if _, err := m.Restore(ctx, plain.ID, RestoreOptions{}); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if len(adapter.processes) != 0 {
+		t.Fatalf("restore relaunched unmarked processes %v", adapter.processes)
+	}
+
+	marked, err := m.Capture(ctx, CaptureOptions{Name: "marked", RestartProcesses: []string{"Node", "4242", "docker-compose"}})
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	if len(marked.Processes) != 3 {
+		t.Fatalf("captured %d processes, want 3 (restart_processes implies include_processes)", len(marked.Processes))
+	}
+	if len(marked.Warnings) != 1 || !strings.Contains(marked.Warnings[0], `"docker-compose"`) {
+		t.Errorf("warnings = %q, want one about docker-compose", marked.Warnings)
+	}
+
+	report, err := m.Restore(ctx, marked.ID, RestoreOptions{})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got := strings.Join(adapter.processes, "|"); got != "node server.js|postgres -D data" || report.RestoredProcesses != 2 {
+		t.Errorf("relaunched %q (%d reported), want node and postgres", got, report.RestoredProcesses)
+	}
+}
+
+func TestMeteredAdapterBehavesLikeItsDelegate(t *testing.T) {
+	ctx := context.Background()
+	bare := struct{ core.PlatformAdapter }{platform.NewMockAdapter()}
+
+	for _, tt := range []struct {
+		name    string
+		adapter core.PlatformAdapter
+	}{
+		{"plain", bare},
+		{"metered", platform.NewMeteredAdapter(bare).Adapter()},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t, tt.adapter)
+			if _, _, declared := m.Capabilities(); declared {
+				t.Error("capabilities declared for an adapter that declares none")
+			}
+			s, err := m.Capture(ctx, CaptureOptions{Name: "bare"})
+			if err != nil || len(s.Warnings) != 0 {
+				t.Fatalf("Capture = %v, warnings %q; want no monitor warning", err, s.Warnings)
+			}
+			_, err = m.Capture(ctx, CaptureOptions{Name: "filtered", Monitors: []int{1}})
+			if err == nil || !strings.Contains(err.Error(), "cannot list monitors") {
+				t.Errorf("monitor-filtered capture error = %v, want the adapter cannot list monitors", err)
+			}
+		})
+	}
+}
+
+func TestDeleteWaitsOutARestoreInProgress(t *testing.T) {
+	ctx := context.Background()
+	windows := []core.Window{{AppName: "Code", WindowTitle: "main.go", X: 0, Y: 0, Width: 800, Height: 600, Pid: 1}}
+	adapter := newGateAdapter(windows)
+	m, repo := newTestManager(t, adapter)
+	saveSnapshot(t, repo, &core.Snapshot{ID: "busy", Windows: windows})
+
+	type result struct {
+		report *RestoreReport
+		err    error
+	}
+	done := make(chan result)
+	go func() {
+		report, err := m.Restore(ctx, "busy", RestoreOptions{})
+		done <- result{report, err}
+	}()
+	<-adapter.entered
+
+	// A mitad del restore el borrado y la edición fallan nombrando al restore
+	var busy *BusyError
+	if err := m.Delete(ctx, "busy"); !errors.As(err, &busy) || busy.Operation != "restore" {
+		t.Errorf("Delete mid-restore = %v, want busy by restore", err)
+	}
+	name := "renamed"
+	if _, err := m.UpdateSnapshot(ctx, "busy", SnapshotUpdate{Name: &name}); !errors.Is(err, ErrSnapshotBusy) {
+		t.Errorf("UpdateSnapshot mid-restore = %v, want busy", err)
+	}
+
+	close(adapter.release)
+	res := <-done
+	if res.err != nil || res.report.RestoredWindows != 1 {
+		t.Fatalf("restore = %+v, %v; want it to finish with its window", res.report, res.err)
+	}
+	if err := m.Delete(ctx, "busy"); err != nil {
+		t.Fatalf("Delete after the restore: %v", err)
+	}
+	if _, err := m.Restore(ctx, "busy", RestoreOptions{}); !errors.Is(err, core.ErrSnapshotNotFound) {
+		t.Errorf("restore after delete = %v, want not found", err)
+	}
+}
+
+func TestLockReleasedWhenRestorePanics(t *testing.T) {
+	ctx := context.Background()
+	windows := []core.Window{{AppName: "Code", WindowTitle: "main.go", Width: 800, Height: 600, Pid: 1}}
+	adapter := newGateAdapter(windows)
+	adapter.panics = true
+	m, repo := newTestManager(t, adapter)
+	saveSnapshot(t, repo, &core.Snapshot{ID: "crash", Windows: windows})
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("restore did not panic")
+			}
+		}()
+		m.Restore(ctx, "crash", RestoreOptions{})
+	}()
+	if err := m.Delete(ctx, "crash"); err != nil {
+		t.Errorf("Delete after a panicked restore: %v", err)
+	}
+}
+
+// Correr con -race: restores y deletes del mismo snapshot desde varias
+// goroutines, como varios clientes SSE a la vez
+func TestConcurrentRestoreAndDelete(t *testing.T) {
+	ctx := context.Background()
+	for round := 0; round < 10; round++ {
+		adapter := platform.NewMockAdapter()
+		m, repo := newTestManager(t, adapter)
+		id := fmt.Sprintf("shared-%d", round)
+		saveSnapshot(t, repo, &core.Snapshot{ID: id, Windows: []core.Window{
+			{AppName: "Code", WindowTitle: "main.go", Width: 800, Height: 600},
+			{AppName: "Terminal", WindowTitle: "zsh", Width: 600, Height: 400},
+		}})
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		deleted := 0
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				report, err := m.Restore(ctx, id, RestoreOptions{})
+				switch {
+				case errors.Is(err, ErrSnapshotBusy), errors.Is(err, core.ErrSnapshotNotFound):
+				case err != nil:
+					t.Errorf("restore: %v", err)
+				case report.TotalWindows != 2:
+					// Un restore que empezó lee el snapshot entero
+					t.Errorf("restore saw %d windows, want 2", report.TotalWindows)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				err := m.Delete(ctx, id)
+				switch {
+				case err == nil:
+					mu.Lock()
+					deleted++
+					mu.Unlock()
+				case errors.Is(err, ErrSnapshotBusy), errors.Is(err, core.ErrSnapshotNotFound):
+				default:
+					t.Errorf("delete: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if err := m.Delete(ctx, id); err == nil {
+			deleted++
+		}
+		if deleted != 1 {
+			t.Errorf("round %d: snapshot deleted %d times, want exactly once", round, deleted)
+		}
+		if n := len(m.locks.held); n != 0 {
+			t.Errorf("round %d: %d locks still held", round, n)
+		}
+	}
+}
+
+func TestRestoreClampsAndReportsExtremeGeometry(t *testing.T) {
+	adapter := &movingAdapter{MockAdapter: platform.NewMockAdapter()}
+	adapter.Windows = []core.Window{{AppName: "Code", WindowTitle: "main.go", Width: 800, Height: 600, Pid: 1}}
+	m, repo := newTestManager(t, adapter)
+	saveSnapshot(t, repo, &core.Snapshot{ID: "corrupt", Windows: []core.Window{
+		{AppName: "Code", WindowTitle: "main.go", X: math.MaxInt, Y: math.MinInt, Width: math.MaxInt, Height: -1},
+	}})
+
+	report, err := m.Restore(context.Background(), "corrupt", RestoreOptions{})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	// Lo que llega al adapter ya está dentro de rango
+	w := adapter.Windows[0]
+	if w.X != platform.MaxCoordinate || w.Y != platform.MinCoordinate || w.Width != platform.MaxWindowSize || w.Height != 0 {
+		t.Errorf("adapter got %d,%d %dx%d, want the clamped geometry", w.X, w.Y, w.Width, w.Height)
+	}
+	notes := strings.Join(report.Notes, "\n")
+	if !strings.Contains(notes, "main.go: out-of-range geometry") || !strings.Contains(notes, fmt.Sprintf("x=%d clamped to %d", math.MaxInt, platform.MaxCoordinate)) {
+		t.Errorf("clamping not reported:\n%s", notes)
+	}
+}
+
+func TestMatchProfileChoosesPerRestore(t *testing.T) {
+	ctx := context.Background()
+	adapter := platform.NewMockAdapter()
+	// Tras un reinicio el título cambió; app y tamaño siguen iguales
+	adapter.Windows = []core.Window{{AppName: "Code", WindowTitle: "Welcome", Width: 1200, Height: 800, Pid: 1}}
+	m, repo := newTestManager(t, adapter)
+	saveSnapshot(t, repo, &core.Snapshot{ID: "reboot", Windows: []core.Window{
+		{AppName: "Code", WindowTitle: "main.go - api", Width: 1200, Height: 800},
+	}})
+
+	tests := []struct {
+		profile string
+		want    string
+	}{
+		{"", "Welcome"},
+		{platform.ProfileApp, "Welcome"},
+		{platform.ProfileTitle, ""},
+	}
+	for _, tt := range tests {
+		report, err := m.Restore(ctx, "reboot", RestoreOptions{DryRun: true, MatchProfile: tt.profile})
+		if err != nil {
+			t.Fatalf("profile %q: %v", tt.profile, err)
+		}
+		if got := report.Plan[0].MatchedTitle; got != tt.want {
+			t.Errorf("profile %q matched %q, want %q", tt.profile, got, tt.want)
+		}
+	}
+	if _, err := m.Restore(ctx, "reboot", RestoreOptions{DryRun: true, MatchProfile: "fuzzy"}); err == nil {
+		t.Error("an unknown profile was accepted")
+	}
+}
+
+func TestCaptureKeepsOnlyAllowedMonitors(t *testing.T) {
+	ctx := context.Background()
+	adapter := platform.NewMockAdapter()
+	adapter.Monitors = []core.Monitor{
+		{DeviceName: `\\.\DISPLAY1`, Width: 1920, Height: 1080, Primary: true},
+		{DeviceName: `\\.\DISPLAY2`, X: 1920, Width: 2560, Height: 1440},
+	}
+	adapter.Windows = []core.Window{
+		{AppName: "Code", WindowTitle: "main.go", X: 100, Y: 100, Width: 800, Height: 600, Pid: 1},
+		{AppName: "Chrome", WindowTitle: "docs", X: 2000, Y: 100, Width: 1200, Height: 900, Pid: 2},
+	}
+	m, repo := newTestManager(t, adapter)
+
+	s, err := m.Capture(ctx, CaptureOptions{Name: "external", Monitors: []int{2}})
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	windows, err := repo.GetWindows(ctx, s.ID)
+	if err != nil || len(windows) != 1 || windows[0].WindowTitle != "docs" {
+		t.Fatalf("stored windows = %+v, %v; want only the one on monitor 2", windows, err)
+	}
+	// El layout de monitores se guarda completo aunque se filtre
+	if len(s.Monitors) != 2 {
+		t.Errorf("%d monitors recorded, want 2", len(s.Monitors))
+	}
+
+	if _, err := m.Capture(ctx, CaptureOptions{Monitors: []int{3}}); err == nil {
+		t.Error("capturing a monitor that does not exist did not fail")
+	}
+	blind, _ := newTestManager(t, monitorlessAdapter{adapter})
+	if _, err := blind.Capture(ctx, CaptureOptions{Monitors: []int{1}}); err == nil || !strings.Contains(err.Error(), "cannot list monitors") {
+		t.Errorf("filtering without a monitor lister: %v", err)
+	}
+}
+
+// monitorlessAdapter esconde GetMonitors, como un adapter que no sabe listarlos
+type monitorlessAdapter struct {
+	core.PlatformAdapter
+}
+
+// cancellingAdapter cancela el restore durante el PositionWindow número
+// cancelAt y anota toda llamada que llegue después de la cancelación
+type cancellingAdapter struct {
+	*platform.MockAdapter
+	cancel    context.CancelFunc
+	cancelAt  int
+	positions int
+	late      []string
+}
+
+func (a *cancellingAdapter) PositionWindow(ctx context.Context, live core.Window, target core.Window) error {
+	if ctx.Err() != nil {
+		a.late = append(a.late, "window "+target.WindowTitle)
+	}
+	a.positions++
+	if a.positions == a.cancelAt {
+		a.cancel()
+	}
+	return nil
+}
+
+func (a *cancellingAdapter) RestoreTerminal(ctx context.Context, t core.Terminal) error {
+	a.late = append(a.late, "terminal "+t.TerminalApp)
+	return nil
+}
+
+func (a *cancellingAdapter) OpenURL(ctx context.Context, url string, browser string) error {
+	a.late = append(a.late, "tab "+url)
+	return nil
+}
+
+func TestCancelledRestoreStopsAndReportsPartialProgress(t *testing.T) {
+	windows := []core.Window{
+		{AppName: "Code", WindowTitle: "main.go", Width: 800, Height: 600, Pid: 1},
+		{AppName: "Terminal", WindowTitle: "zsh", Width: 600, Height: 400, Pid: 2},
+		{AppName: "Chrome", WindowTitle: "docs", Width: 1200, Height: 900, Pid: 3},
+		{AppName: "Slack", WindowTitle: "general", Width: 900, Height: 700, Pid: 4},
+	}
+	tests := []struct {
+		name      string
+		cancelAt  int
+		cancelled []string
+	}{
+		{"after the first window", 1, []string{"zsh", "docs", "general"}},
+		{"mid-way", 2, []string{"docs", "general"}},
+		// Todas las ventanas quedaron, pero ya no se abren terminales ni pestañas
+		{"after the last window", 4, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			adapter := &cancellingAdapter{MockAdapter: platform.NewMockAdapter(), cancel: cancel, cancelAt: tt.cancelAt}
+			adapter.Windows = windows
+			m, repo := newTestManager(t, adapter)
+			saved := make([]core.Window, len(windows))
+			for i, w := range windows {
+				w.X = 100 * (i + 1) // Distinta de la viva para que haya que moverla
+				saved[i] = w
+			}
+			saveSnapshot(t, repo, &core.Snapshot{ID: "work", Windows: saved,
+				Terminals:   []core.Terminal{{TerminalApp: "bash"}},
+				BrowserTabs: []core.BrowserTab{{URL: "https://go.dev", BrowserName: "chrome"}},
+			})
+
+			report, err := m.Restore(ctx, "work", RestoreOptions{})
+			if err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			if len(adapter.late) > 0 {
+				t.Errorf("ran after the cancel: %v", adapter.late)
+			}
+			if !report.Cancelled || report.Success {
+				t.Errorf("cancelled = %v, success = %v; want a cancelled, unsuccessful report", report.Cancelled, report.Success)
+			}
+			if report.RestoredWindows != tt.cancelAt || report.RestoredTerminals != 0 || report.RestoredTabs != 0 {
+				t.Errorf("restored %d windows, %d terminals, %d tabs; want %d windows only",
+					report.RestoredWindows, report.RestoredTerminals, report.RestoredTabs, tt.cancelAt)
+			}
+			if !slices.Equal(report.CancelledWindows, tt.cancelled) {
+				t.Errorf("cancelled windows = %v, want %v", report.CancelledWindows, tt.cancelled)
+			}
+			if want := fmt.Sprintf("Restore cancelled after %d/4 windows", tt.cancelAt); report.Message != want {
+				t.Errorf("message = %q, want %q", report.Message, want)
+			}
+
+			// El lock se libera igual que en un restore completo
+			if err := m.Delete(context.Background(), "work"); err != nil {
+				t.Errorf("Delete after the cancelled restore: %v", err)
+			}
+		})
+	}
+}
+
+// focusAdapter es un mock con foco: posicionar una ventana se lo roba, como
+// en el escritorio real, y SaveFocus permite devolverlo
+type focusAdapter struct {
+	*platform.MockAdapter
+	focused    string
+	saveErr    error
+	refocusErr error
+	saves      int
+}
+
+func (a *focusAdapter) PositionWindow(ctx context.Context, live core.Window, target core.Window) error {
+	a.focused = target.WindowTitle
+	return nil
+}
+
+func (a *focusAdapter) SaveFocus(ctx context.Context) (func(context.Context) error, error) {
+	a.saves++
+	if a.saveErr != nil {
+		return nil, a.saveErr
+	}
+	original := a.focused
+	return func(context.Context) error {
+		if a.refocusErr != nil {
+			return a.refocusErr
+		}
+		a.focused = original
+		return nil
+	}, nil
+}
+
+func TestPreserveCurrentFocus(t *testing.T) {
+	windows := []core.Window{
+		{AppName: "Code", WindowTitle: "main.go", Width: 800, Height: 600, Pid: 1},
+		{AppName: "Terminal", WindowTitle: "zsh", Width: 600, Height: 400, Pid: 2},
+	}
+	tests := []struct {
+		name       string
+		opts       RestoreOptions
+		saveErr    error
+		refocusErr error
+		focused    string
+		note       string
+	}{
+		{"off", RestoreOptions{}, nil, nil, "zsh", ""},
+		{"on", RestoreOptions{PreserveCurrentFocus: true}, nil, nil, "chat", ""},
+		{"dry run moves nothing", RestoreOptions{PreserveCurrentFocus: true, DryRun: true}, nil, nil, "chat", ""},
+		{"focus not readable", RestoreOptions{PreserveCurrentFocus: true}, errors.New("no foreground window"), nil, "zsh", "Focus not preserved: no foreground window"},
+		{"refocus refused", RestoreOptions{PreserveCurrentFocus: true}, nil, errors.New("denied"), "zsh", "Focus not preserved: denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &focusAdapter{MockAdapter: platform.NewMockAdapter(), focused: "chat", saveErr: tt.saveErr, refocusErr: tt.refocusErr}
+			adapter.Windows = windows
+			m, repo := newTestManager(t, adapter)
+			saved := []core.Window{windows[0], windows[1]}
+			saved[0].X, saved[1].X = 100, 900
+			saveSnapshot(t, repo, &core.Snapshot{ID: "work", Windows: saved})
+
+			report, err := m.Restore(context.Background(), "work", tt.opts)
+			if err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			if adapter.focused != tt.focused {
+				t.Errorf("focus on %q, want %q", adapter.focused, tt.focused)
+			}
+			notes := strings.Join(report.Notes, "\n")
+			if tt.note != "" && !strings.Contains(notes, tt.note) {
+				t.Errorf("notes %q, want %q", notes, tt.note)
+			}
+			if tt.note == "" && strings.Contains(notes, "Focus") {
+				t.Errorf("unexpected focus note: %q", notes)
+			}
+			if skip := !tt.opts.PreserveCurrentFocus || tt.opts.DryRun; skip && adapter.saves != 0 {
+				t.Errorf("SaveFocus called %d times without preserving focus", adapter.saves)
+			}
+		})
+	}
+
+	// Un adapter que no sabe devolver el foco lo dice en vez de fallar
+	adapter := platform.NewMockAdapter()
+	adapter.Windows = windows
+	m, repo := newTestManager(t, adapter)
+	saveSnapshot(t, repo, &core.Snapshot{ID: "work", Windows: windows})
+	report, err := m.Restore(context.Background(), "work", RestoreOptions{PreserveCurrentFocus: true})
+	if err != nil || !slices.Contains(report.Notes, "Focus not preserved: the platform can't restore the focus") {
+		t.Errorf("restore without a focus keeper = %v, notes %v", err, report.Notes)
+	}
+}
+
+func TestResolveSnapshotRef(t *testing.T) {
+	ctx := context.Background()
+	m, repo := newTestManager(t, platform.NewMockAdapter())
+	for _, s := range []*core.Snapshot{
+		{ID: "abcdef12", Name: "Short ID"},
+		{ID: "abcdef12-aaaa", Name: "Morning Work"},
+		{ID: "abcdef34-bbbb", Name: "Review"},
+		{ID: "99999999-cccc", Name: "Demo"},
+		{ID: "88888888-dddd", Name: "demo"},
+	} {
+		saveSnapshot(t, repo, s)
+	}
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{"full ID", "abcdef34-bbbb", "abcdef34-bbbb", ""},
+		{"full ID that prefixes another", "abcdef12", "abcdef12", ""},
+		{"unique prefix", "abcdef3", "abcdef34-bbbb", ""},
+		{"prefix collision", "abcdef", "", `"abcdef" is ambiguous, it matches`},
+		{"prefix too short", "abcd", "", "ID prefixes need at least 6 characters"},
+		{"wildcards are literal", "abcdef_2", "", "not found"},
+		{"name ignores case", "MORNING work", "abcdef12-aaaa", ""},
+		{"name is trimmed", "  Review ", "abcdef34-bbbb", ""},
+		{"name collision", "DEMO", "", "88888888-dddd (demo), 99999999-cccc (Demo)"}, // El más nuevo primero,
+		{"empty", "   ", "", "snapshot reference is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.ResolveSnapshotRef(ctx, tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ResolveSnapshotRef(%q) = %q, %v; want an error with %q", tt.ref, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ResolveSnapshotRef(%q) = %q, %v; want %q", tt.ref, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaptureRecordsPhaseMetrics(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(t, platform.NewMockAdapter())
+	first, err := m.Capture(ctx, CaptureOptions{Name: "first"})
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	if _, err := m.Capture(ctx, CaptureOptions{Name: "second"}); err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+
+	metrics, err := m.CaptureMetrics(ctx, first.ID, 0)
+	if err != nil || len(metrics) != 1 {
+		t.Fatalf("CaptureMetrics(%s) = %+v, %v; want one capture", first.ID, metrics, err)
+	}
+	var phases []string
+	for _, p := range metrics[0].Phases {
+		phases = append(phases, p.Phase)
+	}
+	// windows abre y save cierra; las fases del medio dependen del adapter
+	if len(phases) < 2 || phases[0] != "windows" || phases[len(phases)-1] != "save" {
+		t.Errorf("phases = %v, want windows first and save last", phases)
+	}
+	if metrics[0].SnapshotName != "first" {
+		t.Errorf("metrics for %q, want first", metrics[0].SnapshotName)
+	}
+	if all, err := m.CaptureMetrics(ctx, "", 0); err != nil || len(all) != 2 {
+		t.Errorf("%d captures with metrics, want 2 (%v)", len(all), err)
+	}
+}
+
+// emptyDesktop es un mock sin ventanas vivas hasta que se lanza una app: cada
+// LaunchApp abre una ventana nueva de esa app
+type emptyDesktop struct {
+	*platform.MockAdapter
+	live     []core.Window
+	launched []string
+}
+
+func (d *emptyDesktop) GetWindows(ctx context.Context) ([]core.Window, error) {
+	return append([]core.Window(nil), d.live...), nil
+}
+
+func (d *emptyDesktop) LaunchApp(ctx context.Context, w core.Window) (int, error) {
+	d.launched = append(d.launched, w.AppName)
+	pid := 100 + len(d.launched)
+	d.live = append(d.live, core.Window{AppName: w.AppName, AppPath: w.AppPath, WindowTitle: w.WindowTitle, Width: 400, Height: 300, Pid: pid})
+	return pid, nil
+}
+
+func (d *emptyDesktop) PositionWindow(ctx context.Context, live core.Window, target core.Window) error {
+	return nil
+}
+
+func TestRestoreWithNoLiveWindows(t *testing.T) {
+	windows := []core.Window{
+		{AppName: "Code", AppPath: "/usr/bin/code", WindowTitle: "main.go", Width: 800, Height: 600},
+		{AppName: "Terminal", AppPath: "/usr/bin/xterm", WindowTitle: "zsh", Width: 600, Height: 400},
+	}
+	tests := []struct {
+		name      string
+		untrusted bool
+		launch    bool
+		wantErr   error
+		launched  []string
+		note      string
+	}{
+		{"relaunch off", false, false, ErrNoLiveWindows, nil, ""},
+		{"relaunch on", false, true, nil, []string{"Code", "Terminal"}, "No live windows to match against, launching the snapshot's applications"},
+		{"relaunch on for an imported snapshot", true, true, ErrNoLiveWindows, nil, "Applications not launched: launch data came from an imported snapshot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &emptyDesktop{MockAdapter: platform.NewMockAdapter()}
+			m, repo := newTestManager(t, adapter)
+			saveSnapshot(t, repo, &core.Snapshot{ID: "work", Windows: windows, Untrusted: tt.untrusted})
+
+			report, err := m.Restore(context.Background(), "work", RestoreOptions{LaunchMissing: tt.launch, LaunchTimeout: time.Second})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Restore = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(adapter.launched, tt.launched) {
+				t.Errorf("launched %v, want %v", adapter.launched, tt.launched)
+			}
+			if tt.note != "" && !slices.Contains(report.Notes, tt.note) {
+				t.Errorf("notes %v, want %q", report.Notes, tt.note)
+			}
+			if tt.wantErr != nil {
+				// Un solo error, no uno por ventana
+				if report.Error != tt.wantErr.Error() || len(report.FailedWindows) != 0 {
+					t.Errorf("error %q with failed windows %v; want a single %q", report.Error, report.FailedWindows, tt.wantErr)
+				}
+				return
+			}
+			if report.LaunchedWindows != 2 || report.RestoredWindows != 2 || !report.Success {
+				t.Errorf("launched %d, restored %d, success %v; want both windows launched", report.LaunchedWindows, report.RestoredWindows, report.Success)
+			}
+		})
+	}
+
+	// Un snapshot sin ventanas no necesita ventanas vivas
+	m, repo := newTestManager(t, &emptyDesktop{MockAdapter: platform.NewMockAdapter()})
+	saveSnapshot(t, repo, &core.Snapshot{ID: "terminals-only", Terminals: []core.Terminal{{TerminalApp: "bash"}}})
+	if _, err := m.Restore(context.Background(), "terminals-only", RestoreOptions{}); err != nil {
+		t.Errorf("restoring a snapshot without windows: %v", err)
+	}
+}
+
+// diffFixtures arma dos snapshots que difieren en todas las categorías del diff
+func diffFixtures() (before, after *core.Snapshot) {
+	before = &core.Snapshot{ID: "before", GitRepo: "/src/app", GitBranch: "main", GitHeadHash: "aaa111",
+		Windows: []core.Window{
+			{AppName: "Code", WindowTitle: "main.go", X: 0, Y: 0, Width: 800, Height: 600},
+			{AppName: "Terminal", WindowTitle: "zsh", X: 800, Y: 0, Width: 600, Height: 400},
+			{AppName: "Slack", WindowTitle: "general", X: 0, Y: 600, Width: 900, Height: 400},
+		},
+		BrowserTabs: []core.BrowserTab{{URL: "https://go.dev"}, {URL: "https://github.com"}},
+		IDEFiles:    []core.IDEFile{{IDEName: "Code", FilePath: "/src/app/a.go"}, {IDEName: "Code", FilePath: "/src/app/b.go"}},
+		Terminals:   []core.Terminal{{TerminalApp: "bash", WorkingDirectory: "/src/app"}, {TerminalApp: "zsh", WorkingDirectory: "/tmp"}},
+	}
+	after = &core.Snapshot{ID: "after", GitRepo: "/src/app", GitBranch: "feature", GitHeadHash: "bbb222", GitDirty: true,
+		Windows: []core.Window{
+			{AppName: "Code", WindowTitle: "main.go", X: 100, Y: 0, Width: 800, Height: 700},
+			{AppName: "Terminal", WindowTitle: "zsh", X: 800, Y: 0, Width: 600, Height: 400},
+			{AppName: "Chrome", WindowTitle: "docs", X: 1400, Y: 0, Width: 1000, Height: 800},
+		},
+		BrowserTabs: []core.BrowserTab{{URL: "https://go.dev"}, {URL: "https://pkg.go.dev"}},
+		IDEFiles:    []core.IDEFile{{IDEName: "Code", FilePath: "/src/app/a.go"}, {IDEName: "Code", FilePath: "/src/app/c.go"}},
+		Terminals:   []core.Terminal{{TerminalApp: "bash", WorkingDirectory: "/src/app"}, {TerminalApp: "bash", WorkingDirectory: "/src/app/cmd"}},
+	}
+	return before, after
+}
+
+func TestDiffCoversEveryCategory(t *testing.T) {
+	ctx := context.Background()
+	m, repo := newTestManager(t, platform.NewMockAdapter())
+	before, after := diffFixtures()
+	saveSnapshot(t, repo, before)
+	saveSnapshot(t, repo, after)
+
+	git := GitDiff{RepoFrom: "/src/app", RepoTo: "/src/app", BranchFrom: "main", BranchTo: "feature", HeadFrom: "aaa111", HeadTo: "bbb222", DirtyTo: true}
+	reversedGit := GitDiff{RepoFrom: "/src/app", RepoTo: "/src/app", BranchFrom: "feature", BranchTo: "main", HeadFrom: "bbb222", HeadTo: "aaa111", DirtyFrom: true}
+	tests := []struct {
+		name     string
+		from, to string
+		want     *DiffResult
+	}{
+		{"identical", "before", "before", &DiffResult{SourceID: "before", TargetID: "before", CommonWindows: 3,
+			Git: GitDiff{RepoFrom: "/src/app", RepoTo: "/src/app", BranchFrom: "main", BranchTo: "main", HeadFrom: "aaa111", HeadTo: "aaa111"}}},
+		{"every category", "before", "after", &DiffResult{
+			SourceID: "before", TargetID: "after",
+			GitChanged: true, Git: git,
+			AddedWindows: []string{"docs"}, RemovedWindows: []string{"general"}, CommonWindows: 2,
+			MovedWindows: []WindowMove{{WindowTitle: "main.go", AppName: "Code",
+				Before: WindowGeometry{X: 0, Y: 0, Width: 800, Height: 600}, After: WindowGeometry{X: 100, Y: 0, Width: 800, Height: 700}}},
+			AddedTabs: []string{"https://pkg.go.dev"}, RemovedTabs: []string{"https://github.com"},
+			AddedFiles: []string{"/src/app/c.go"}, RemovedFiles: []string{"/src/app/b.go"},
+			AddedTerminals: []string{"bash @ /src/app/cmd"}, RemovedTerminals: []string{"zsh @ /tmp"},
+		}},
+		{"reversed", "after", "before", &DiffResult{
+			SourceID: "after", TargetID: "before",
+			GitChanged: true, Git: reversedGit,
+			AddedWindows: []string{"general"}, RemovedWindows: []string{"docs"}, CommonWindows: 2,
+			MovedWindows: []WindowMove{{WindowTitle: "main.go", AppName: "Code",
+				Before: WindowGeometry{X: 100, Y: 0, Width: 800, Height: 700}, After: WindowGeometry{X: 0, Y: 0, Width: 800, Height: 600}}},
+			AddedTabs: []string{"https://github.com"}, RemovedTabs: []string{"https://pkg.go.dev"},
+			AddedFiles: []string{"/src/app/b.go"}, RemovedFiles: []string{"/src/app/c.go"},
+			AddedTerminals: []string{"zsh @ /tmp"}, RemovedTerminals: []string{"bash @ /src/app/cmd"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Diff(ctx, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Diff: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := m.Diff(ctx, "before", "missing"); !errors.Is(err, core.ErrSnapshotNotFound) {
+		t.Errorf("diff against a missing snapshot = %v, want not found", err)
+	}
+}
+
+func TestDryRunPlansLaunches(t *testing.T) {
+	windows := []core.Window{
+		{AppName: "Code", WindowTitle: "main.go", Width: 800, Height: 600},
+		{AppName: "Editor", AppPath: "/usr/bin/editor", LaunchArgs: []byte(`["--new-window", "/src/my app"]`), WindowTitle: "notes.txt", Width: 800, Height: 600},
+		{AppName: "Editor", AppPath: "/usr/bin/editor", WindowTitle: "todo.txt", Width: 800, Height: 600},
+	}
+	type launch struct {
+		path string
+		args []string
+	}
+	tests := []struct {
+		name      string
+		opts      RestoreOptions
+		untrusted bool
+		want      []launch // Por ventana del plan
+		note      string
+	}{
+		{"without launch_missing", RestoreOptions{DryRun: true}, false, []launch{{}, {}, {}}, ""},
+		// Sin AppPath no hay qué lanzar, y cada app se lanza una sola vez
+		{"with launch_missing", RestoreOptions{DryRun: true, LaunchMissing: true}, false,
+			[]launch{{}, {"/usr/bin/editor", []string{"--new-window", "/src/my app"}}, {}}, ""},
+		{"imported snapshot", RestoreOptions{DryRun: true, LaunchMissing: true}, true, []launch{{}, {}, {}},
+			"Applications would not be launched: launch data came from an imported snapshot"},
+		{"force position never launches", RestoreOptions{DryRun: true, LaunchMissing: true, ForcePosition: true}, false, []launch{{}, {}, {}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &launchRecorder{MockAdapter: platform.NewMockAdapter()}
+			adapter.Windows = []core.Window{{AppName: "Slack", WindowTitle: "general", Width: 300, Height: 200, Pid: 9}}
+			m, repo := newTestManager(t, adapter)
+			saveSnapshot(t, repo, &core.Snapshot{ID: "work", Windows: windows, Untrusted: tt.untrusted})
+
+			report, err := m.Restore(context.Background(), "work", tt.opts)
+			if err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			if len(report.Plan) != len(tt.want) {
+				t.Fatalf("%d planned windows, want %d", len(report.Plan), len(tt.want))
+			}
+			for i, p := range report.Plan {
+				if p.LaunchPath != tt.want[i].path || !slices.Equal(p.LaunchArgs, tt.want[i].args) {
+					t.Errorf("%s would launch %q %q, want %q %q", p.WindowTitle, p.LaunchPath, p.LaunchArgs, tt.want[i].path, tt.want[i].args)
+				}
+			}
+			if tt.note != "" && !slices.Contains(report.Notes, tt.note) {
+				t.Errorf("notes %v, want %q", report.Notes, tt.note)
+			}
+			if len(adapter.launched) > 0 {
+				t.Errorf("a dry run launched %v", adapter.launched)
+			}
+		})
+	}
+
+	// Un adapter que no sabe lanzar apps no promete lanzarlas
+	adapter := platform.NewMockAdapter()
+	adapter.Windows = []core.Window{{AppName: "Slack", WindowTitle: "general", Pid: 9}}
+	m, repo := newTestManager(t, adapter)
+	saveSnapshot(t, repo, &core.Snapshot{ID: "work", Windows: windows})
+	report, err := m.Restore(context.Background(), "work", RestoreOptions{DryRun: true, LaunchMissing: true})
+	if err != nil || report.Plan[1].LaunchPath != "" || !slices.Contains(report.Notes, "Applications would not be launched: the mock adapter cannot launch applications") {
+		t.Errorf("dry run without a launcher = %v, plan %+v, notes %v", err, report.Plan, report.Notes)
+	}
+}
@@ -0,0 +1,134 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// manualChangeTolerance es la diferencia en píxeles por debajo de la cual no
+// se considera que el usuario movió una ventana (bordes, DPI, snapping)
+const manualChangeTolerance = 8
+
+// placement registra dónde dejó un restore a una ventana
+type placement struct {
+	SnapshotID string
+	Saved      string // savedKey de la ventana grabada que se restauró en ella
+	X          int
+	Y          int
+	Width      int
+	Height     int
+	At         time.Time
+}
+
+// placementRegistry recuerda, durante la sesión del servidor, qué ventanas
+// posicionó cada restore. Se indexa por la ventana viva (proceso, app y
+// título vivo), no por la grabada: una ventana matcheada por un título
+// parecido se sigue encontrando. Si la ventana cambia de título se pierde el
+// rastro.
+type placementRegistry struct {
+	mu    sync.Mutex
+	byKey map[string]placement
+}
+
+func newPlacementRegistry() *placementRegistry {
+	return &placementRegistry{byKey: make(map[string]placement)}
+}
+
+// liveKey identifica una ventana viva en el registro
+func liveKey(w core.Window) string {
+	return fmt.Sprintf("%d\x00%s\x00%s", w.Pid, strings.ToLower(w.AppName), w.WindowTitle)
+}
+
+// savedKey identifica una ventana grabada entre restores
+func savedKey(w core.Window) string {
+	return strings.ToLower(w.AppName) + "\x00" + w.WindowTitle
+}
+
+// record guarda que el restore de snapshotID puso la ventana grabada saved en
+// la ventana viva live; la geometría es la de live, leída después de moverla
+func (r *placementRegistry) record(snapshotID string, saved, live core.Window) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byKey[liveKey(live)] = placement{
+		SnapshotID: snapshotID,
+		Saved:      savedKey(saved),
+		X:          live.X,
+		Y:          live.Y,
+		Width:      live.Width,
+		Height:     live.Height,
+		At:         time.Now(),
+	}
+}
+
+// movedByUser indica si la ventana viva fue movida desde que un restore la
+// posicionó. Retorna false si ningún restore de esta sesión la tocó.
+func (r *placementRegistry) movedByUser(live core.Window) (placement, bool) {
+	r.mu.Lock()
+	p, ok := r.byKey[liveKey(live)]
+	r.mu.Unlock()
+	if !ok {
+		return placement{}, false
+	}
+
+	return p, abs(live.X-p.X) > manualChangeTolerance ||
+		abs(live.Y-p.Y) > manualChangeTolerance ||
+		abs(live.Width-p.Width) > manualChangeTolerance ||
+		abs(live.Height-p.Height) > manualChangeTolerance
+}
+
+// movedWindow es una ventana grabada que un restore posicionó sobre live
+// (tal como se enumeró antes de moverla)
+type movedWindow struct {
+	saved core.Window
+	live  core.Window
+}
+
+// recordPlacements registra las ventanas que movió un restore con la
+// geometría leída de vuelta después de moverlas: si el sistema las ajustó
+// (clamp, snapping) eso no cuenta después como un cambio manual. Si la
+// lectura falla se registra la geometría pedida
+func (m *Manager) recordPlacements(ctx context.Context, snapshotID string, moved []movedWindow) {
+	if len(moved) == 0 {
+		return
+	}
+	after, err := m.platform.GetWindows(context.WithoutCancel(ctx))
+	if err != nil {
+		after = nil
+	}
+	for _, mv := range moved {
+		got := mv.live
+		got.X, got.Y, got.Width, got.Height = mv.saved.X, mv.saved.Y, mv.saved.Width, mv.saved.Height
+		best := -1
+		for i, lw := range after {
+			if liveKey(lw) != liveKey(mv.live) {
+				continue
+			}
+			if best < 0 || windowDrift(mv.saved, lw) < windowDrift(mv.saved, after[best]) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			got = after[best]
+		}
+		m.placements.record(snapshotID, mv.saved, got)
+	}
+}
+
+// windowDrift es la mayor diferencia en píxeles, en posición o tamaño, entre
+// la geometría target y la de la ventana viva
+func windowDrift(target, live core.Window) int {
+	return max(abs(live.X-target.X), abs(live.Y-target.Y),
+		abs(live.Width-target.Width), abs(live.Height-target.Height))
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,112 @@
+package snapshot
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+func TestRespectManualChangesAcrossRestores(t *testing.T) {
+	ctx := context.Background()
+	adapter := &movingAdapter{MockAdapter: platform.NewMockAdapter()}
+	adapter.Windows = []core.Window{
+		{AppName: "Code", WindowTitle: "main.go - project", Width: 1000, Height: 700, Pid: 1},
+		{AppName: "Terminal", WindowTitle: "bash", Width: 600, Height: 400, Pid: 2},
+	}
+	// El sistema deja las ventanas 3px más abajo de lo pedido (borde
+	// invisible, snapping): no es un cambio manual
+	adapter.adjust = func(w core.Window) core.Window {
+		w.Y += 3
+		return w
+	}
+	m, repo := newTestManager(t, adapter)
+	saveSnapshot(t, repo, &core.Snapshot{ID: "layout", Windows: []core.Window{
+		// Título parecido pero distinto: la ventana se sigue por la viva
+		{AppName: "Code", WindowTitle: "util.go - project", X: 100, Y: 100, Width: 1200, Height: 800},
+		{AppName: "Terminal", WindowTitle: "bash", X: 1300, Y: 100, Width: 600, Height: 800},
+	}})
+
+	if report, err := m.Restore(ctx, "layout", RestoreOptions{}); err != nil || report.RestoredWindows != 2 {
+		t.Fatalf("first restore: %+v, %v", report, err)
+	}
+
+	// El usuario mueve el editor; la terminal queda donde el restore la dejó
+	adapter.Windows[0].X = 500
+
+	report, err := m.Restore(ctx, "layout", RestoreOptions{RespectManualChanges: true})
+	if err != nil {
+		t.Fatalf("second restore: %v", err)
+	}
+	if len(report.ManuallyAdjusted) != 1 || report.ManuallyAdjusted[0] != "util.go - project" {
+		t.Errorf("manually adjusted = %v, want only the moved editor", report.ManuallyAdjusted)
+	}
+	if adapter.Windows[0].X != 500 {
+		t.Errorf("the manually moved window was repositioned to x=%d", adapter.Windows[0].X)
+	}
+	if report.RestoredWindows != 1 {
+		t.Errorf("restored %d windows, want the terminal only", report.RestoredWindows)
+	}
+
+	// Sin la opción se vuelve a poner todo en su lugar
+	if _, err := m.Restore(ctx, "layout", RestoreOptions{}); err != nil {
+		t.Fatalf("third restore: %v", err)
+	}
+	if adapter.Windows[0].X != 100 {
+		t.Errorf("editor at x=%d after a plain restore, want 100", adapter.Windows[0].X)
+	}
+}
+
+func TestMinDriftPixelsSkipsWindowsInPlace(t *testing.T) {
+	saved := []core.Window{
+		{AppName: "Code", WindowTitle: "main.go", X: 0, Y: 0, Width: 1000, Height: 800},
+		{AppName: "Terminal", WindowTitle: "zsh", X: 1000, Y: 0, Width: 600, Height: 400},
+		{AppName: "Chrome", WindowTitle: "docs", X: 1000, Y: 400, Width: 900, Height: 600},
+	}
+	// Vivas: el editor corrido 3px, la terminal 10px más ancha y el browser
+	// 50px más abajo
+	live := []core.Window{
+		{AppName: "Code", WindowTitle: "main.go", X: 3, Y: 0, Width: 1000, Height: 800, Pid: 1},
+		{AppName: "Terminal", WindowTitle: "zsh", X: 1000, Y: 0, Width: 610, Height: 400, Pid: 2},
+		{AppName: "Chrome", WindowTitle: "docs", X: 1000, Y: 450, Width: 900, Height: 600, Pid: 3},
+	}
+
+	tests := []struct {
+		name    string
+		opts    RestoreOptions
+		moves   int
+		correct []string
+		message string
+	}{
+		{"off", RestoreOptions{}, 3, nil, "All windows restored successfully"},
+		{"below every drift", RestoreOptions{MinDriftPixels: 2}, 3, nil, "All windows restored successfully"},
+		{"exactly the drift", RestoreOptions{MinDriftPixels: 3}, 2, []string{"main.go"}, "All windows in place (2 moved, 1 already correct)"},
+		{"between drifts", RestoreOptions{MinDriftPixels: 10}, 1, []string{"main.go", "zsh"}, "All windows in place (1 moved, 2 already correct)"},
+		{"above every drift", RestoreOptions{MinDriftPixels: 100}, 0, []string{"main.go", "zsh", "docs"}, "All windows in place (0 moved, 3 already correct)"},
+		{"ignored when forcing positions", RestoreOptions{MinDriftPixels: 100, ForcePosition: true}, 3, nil, "All windows restored successfully"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &movingAdapter{MockAdapter: platform.NewMockAdapter()}
+			adapter.Windows = append([]core.Window(nil), live...)
+			m, repo := newTestManager(t, adapter)
+			saveSnapshot(t, repo, &core.Snapshot{ID: "layout", Windows: saved})
+
+			report, err := m.Restore(context.Background(), "layout", tt.opts)
+			if err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			if adapter.moves != tt.moves {
+				t.Errorf("%d windows moved, want %d", adapter.moves, tt.moves)
+			}
+			if !slices.Equal(report.AlreadyCorrect, tt.correct) {
+				t.Errorf("already correct = %v, want %v", report.AlreadyCorrect, tt.correct)
+			}
+			if !report.Success || report.Message != tt.message {
+				t.Errorf("success %v, message %q; want success and %q", report.Success, report.Message, tt.message)
+			}
+		})
+	}
+}
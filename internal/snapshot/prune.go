@@ -0,0 +1,96 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// DefaultPinnedTag marca los snapshots que Prune nunca borra
+const DefaultPinnedTag = "pinned"
+
+// PruneOptions define qué snapshots borra Prune. Hace falta OlderThan o
+// KeepLast; con ambos se borra lo que cumple los dos
+type PruneOptions struct {
+	OlderThan time.Duration // Solo los creados hace más de esto; 0 = cualquier edad
+	KeepLast  int           // Conserva los N más nuevos de los que pasan el filtro; 0 = ninguno
+	Tag       string        // Solo los que tienen este tag; vacío = todos
+	DryRun    bool          // Solo lista lo que se borraría
+}
+
+// PruneResult es lo que Prune borró, o borraría en dry run
+type PruneResult struct {
+	DryRun    bool
+	Pruned    []core.Snapshot // Borrados, o a borrar en dry run
+	ChildRows int64           // Filas de componentes borradas con ellos
+	Pinned    int             // Candidatos salvados por el tag de protección
+	Busy      []string        // En uso por otra operación, no se tocaron
+}
+
+// SetPinnedTag configura el tag que protege snapshots de Prune
+func (m *Manager) SetPinnedTag(tag string) {
+	m.pinnedTag = tag
+}
+
+// Prune borra los snapshots viejos según opts en una sola transacción. Los
+// que tienen el tag de protección nunca se borran; los que están en uso se
+// saltean y se reportan
+func (m *Manager) Prune(ctx context.Context, opts PruneOptions) (*PruneResult, error) {
+	if opts.OlderThan <= 0 && opts.KeepLast <= 0 {
+		return nil, fmt.Errorf("prune needs older_than or keep_last")
+	}
+	if opts.KeepLast < 0 {
+		return nil, fmt.Errorf("keep_last cannot be negative")
+	}
+
+	filter := core.SnapshotFilter{}
+	if opts.Tag != "" {
+		filter.Tags = []string{opts.Tag}
+	}
+	list, err := m.repo.ListSnapshots(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	result := &PruneResult{DryRun: opts.DryRun}
+	cutoff := time.Now().Add(-opts.OlderThan)
+	var candidates []core.Snapshot
+	// La lista viene del más nuevo al más viejo
+	for i, s := range list.Snapshots {
+		if i < opts.KeepLast || (opts.OlderThan > 0 && !s.CreatedAt.Before(cutoff)) {
+			continue
+		}
+		if m.pinnedTag != "" && hasTag(s.Tags, m.pinnedTag) {
+			result.Pinned++
+			continue
+		}
+		candidates = append(candidates, s)
+	}
+	if opts.DryRun {
+		result.Pruned = candidates
+		return result, nil
+	}
+
+	// Los locks se sostienen hasta el final para que nada empiece a usar un
+	// snapshot que está por borrarse
+	var ids []string
+	for _, s := range candidates {
+		release, err := m.locks.acquireExclusive(s.ID, "prune")
+		if err != nil {
+			result.Busy = append(result.Busy, s.ID)
+			continue
+		}
+		defer release()
+		ids = append(ids, s.ID)
+		result.Pruned = append(result.Pruned, s)
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+	if result.ChildRows, err = m.repo.DeleteSnapshots(ctx, ids); err != nil {
+		return nil, fmt.Errorf("failed to prune snapshots: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// Region es una zona con nombre dentro de un monitor (p.ej. las tres columnas
+// de un ultrawide). Las ventanas se graban por región y al restaurar ocupan
+// la región actual con ese nombre, aunque cambien los píxeles
+type Region struct {
+	Name   string `json:"name"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// LoadRegions lee un array JSON de regiones y lo valida
+func LoadRegions(path string) ([]Region, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read regions: %w", err)
+	}
+
+	var regions []Region
+	if err := json.Unmarshal(data, &regions); err != nil {
+		return nil, fmt.Errorf("failed to parse regions %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for i, r := range regions {
+		if r.Name == "" {
+			return nil, fmt.Errorf("regions[%d]: name is required", i)
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("regions[%d]: duplicate name %q", i, r.Name)
+		}
+		if r.Width <= 0 || r.Height <= 0 {
+			return nil, fmt.Errorf("regions[%d] %q: width and height must be positive", i, r.Name)
+		}
+		seen[r.Name] = true
+	}
+	return regions, nil
+}
+
+// RegionFor retorna la región que contiene el centro de la ventana, o ""
+func RegionFor(w core.Window, regions []Region) string {
+	cx, cy := w.X+w.Width/2, w.Y+w.Height/2
+	for _, r := range regions {
+		if cx >= r.X && cx < r.X+r.Width && cy >= r.Y && cy < r.Y+r.Height {
+			return r.Name
+		}
+	}
+	return ""
+}
+
+// placeInRegion ajusta la ventana a la región actual con su nombre.
+// ok es false si la región ya no está configurada
+func placeInRegion(w core.Window, regions []Region) (core.Window, bool) {
+	for _, r := range regions {
+		if r.Name == w.Region {
+			w.X, w.Y, w.Width, w.Height = r.X, r.Y, r.Width, r.Height
+			return w, true
+		}
+	}
+	return w, false
+}
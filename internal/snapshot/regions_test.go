@@ -0,0 +1,100 @@
+package snapshot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+// thirds divide un ultrawide de width píxeles en tres columnas
+func thirds(width, height int) []Region {
+	w := width / 3
+	return []Region{
+		{Name: "left", X: 0, Y: 0, Width: w, Height: height},
+		{Name: "center", X: w, Y: 0, Width: w, Height: height},
+		{Name: "right", X: 2 * w, Y: 0, Width: width - 2*w, Height: height},
+	}
+}
+
+func TestRegionFor(t *testing.T) {
+	regions := thirds(3840, 1600)
+	tests := []struct {
+		name string
+		w    core.Window
+		want string
+	}{
+		{"inside", core.Window{X: 100, Y: 100, Width: 800, Height: 600}, "left"},
+		{"spanning two regions goes by its center", core.Window{X: 1000, Y: 0, Width: 1000, Height: 800}, "center"},
+		{"center on the boundary belongs to the next", core.Window{X: 1180, Y: 0, Width: 200, Height: 200}, "center"},
+		{"last pixel", core.Window{X: 3838, Y: 1598, Width: 2, Height: 2}, "right"},
+		{"past the right edge", core.Window{X: 3840, Y: 0, Width: 2, Height: 2}, ""},
+		{"outside every region", core.Window{X: -2000, Y: 0, Width: 800, Height: 600}, ""},
+		{"below the monitor", core.Window{X: 100, Y: 1700, Width: 800, Height: 600}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RegionFor(tt.w, regions); got != tt.want {
+				t.Errorf("RegionFor = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegionsRecordedAndRestoredByName(t *testing.T) {
+	ctx := context.Background()
+	adapter := &movingAdapter{MockAdapter: platform.NewMockAdapter()}
+	adapter.Windows = []core.Window{
+		{AppName: "Code", WindowTitle: "main.go", X: 1300, Y: 20, Width: 1200, Height: 1500, Pid: 1},
+		{AppName: "Slack", WindowTitle: "general", X: 2600, Y: 0, Width: 1240, Height: 1600, Pid: 2},
+		{AppName: "Terminal", WindowTitle: "zsh", X: -1000, Y: 0, Width: 800, Height: 600, Pid: 3},
+	}
+	m, _ := newTestManager(t, adapter)
+	m.SetRegions(thirds(3840, 1600))
+
+	s, err := m.Capture(ctx, CaptureOptions{Name: "ultrawide", RecordRegions: true})
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	var tagged []string
+	for _, w := range s.Windows {
+		tagged = append(tagged, w.WindowTitle+"="+w.Region)
+	}
+	if got, want := strings.Join(tagged, " "), "main.go=center general=right zsh="; got != want {
+		t.Fatalf("regions = %q, want %q", got, want)
+	}
+
+	// Otra resolución y sin la región derecha: las ventanas con región
+	// ocupan la región actual, el resto vuelve a sus píxeles
+	m.SetRegions(thirds(2560, 1080)[:2])
+	for i := range adapter.Windows {
+		adapter.Windows[i].X += 50
+	}
+	report, err := m.Restore(ctx, s.ID, RestoreOptions{UseRegions: true})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	want := map[string][4]int{
+		"main.go": {853, 0, 853, 1080},
+		"general": {2600, 0, 1240, 1600},
+		"zsh":     {-1000, 0, 800, 600},
+	}
+	for _, w := range adapter.Windows {
+		if got := [4]int{w.X, w.Y, w.Width, w.Height}; got != want[w.WindowTitle] {
+			t.Errorf("%s placed at %v, want %v", w.WindowTitle, got, want[w.WindowTitle])
+		}
+	}
+	if notes := strings.Join(report.Notes, "\n"); !strings.Contains(notes, `general: region "right" is not configured`) {
+		t.Errorf("no note for the missing region:\n%s", notes)
+	}
+
+	// Sin UseRegions manda la geometría grabada
+	if _, err := m.Restore(ctx, s.ID, RestoreOptions{}); err != nil {
+		t.Fatalf("Restore without regions: %v", err)
+	}
+	if w := adapter.Windows[0]; w.X != 1300 || w.Width != 1200 {
+		t.Errorf("main.go at x=%d width=%d, want its captured 1300/1200", w.X, w.Width)
+	}
+}
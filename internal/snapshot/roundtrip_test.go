@@ -0,0 +1,111 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+// components retorna los componentes de s como JSON, sin los IDs que asigna
+// la base
+func components(t *testing.T, s *core.Snapshot) []byte {
+	t.Helper()
+	c := *s
+	c.Windows = append([]core.Window(nil), s.Windows...)
+	for i := range c.Windows {
+		c.Windows[i].ID, c.Windows[i].SnapshotID = 0, ""
+	}
+	c.Terminals = append([]core.Terminal(nil), s.Terminals...)
+	for i := range c.Terminals {
+		c.Terminals[i].ID, c.Terminals[i].SnapshotID = 0, ""
+	}
+	c.BrowserTabs = append([]core.BrowserTab(nil), s.BrowserTabs...)
+	for i := range c.BrowserTabs {
+		c.BrowserTabs[i].ID, c.BrowserTabs[i].SnapshotID = 0, ""
+	}
+	c.IDEFiles = append([]core.IDEFile(nil), s.IDEFiles...)
+	for i := range c.IDEFiles {
+		c.IDEFiles[i].ID, c.IDEFiles[i].SnapshotID = 0, ""
+	}
+	c.Processes = append([]core.Process(nil), s.Processes...)
+	for i := range c.Processes {
+		c.Processes[i].ID, c.Processes[i].SnapshotID = 0, ""
+	}
+	c.Monitors = append([]core.Monitor(nil), s.Monitors...)
+	for i := range c.Monitors {
+		c.Monitors[i].ID, c.Monitors[i].SnapshotID = 0, ""
+	}
+	data, err := json.Marshal(struct {
+		Windows     []core.Window
+		Terminals   []core.Terminal
+		BrowserTabs []core.BrowserTab
+		IDEFiles    []core.IDEFile
+		Processes   []core.Process
+		Monitors    []core.Monitor
+	}{c.Windows, c.Terminals, c.BrowserTabs, c.IDEFiles, c.Processes, c.Monitors})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	return data
+}
+
+func TestLoadRoundTripsAllComponents(t *testing.T) {
+	ctx := context.Background()
+	adapter := platform.NewMockAdapter()
+	m, repo := newTestManager(t, adapter)
+	saved := &core.Snapshot{
+		ID: "full",
+		Windows: []core.Window{
+			{AppName: "Code", AppPath: `C:\Code\Code.exe`, WindowTitle: "main.go - api", X: -8, Y: 0, Width: 1936, Height: 1048,
+				State: "maximized", ZIndex: 1, LaunchArgs: json.RawMessage(`{"args":["--reuse-window","C:\\src\\api"]}`)},
+			{AppName: "Code", WindowTitle: "Find in files", X: 300, Y: 200, Width: 400, Height: 300, OwnerRef: 1, GroupID: 2,
+				Region: "left", Monitor: `\\.\DISPLAY2`, RelX: 300, RelY: 200},
+		},
+		Terminals: []core.Terminal{{
+			TerminalApp: "WindowsTerminal", WorkingDirectory: `C:\src\api`, ActiveCommand: "go test ./...", ShellType: "pwsh",
+			EnvVars: map[string]string{"GOFLAGS": "-count=1", "EMPTY": "", "QUOTED": `"a b"`},
+			Layout: &core.TerminalLayout{Tabs: []core.TerminalTab{{Title: "api", Panes: []core.TerminalPane{
+				{Directory: `C:\src\api`, Profile: "PowerShell"},
+				{Directory: `C:\src\web`, Split: "vertical", Size: 0.35},
+			}}}},
+		}},
+		BrowserTabs: []core.BrowserTab{
+			{BrowserName: "Chrome", URL: "http://localhost:3000/?q=ñ&x=1", Title: "App – dev", TabIndex: 0, WindowIndex: 0, IsPinned: true},
+			{BrowserName: "Chrome", URL: "https://pkg.go.dev/slices", Title: "slices", TabIndex: 1, WindowIndex: 1},
+		},
+		IDEFiles: []core.IDEFile{
+			{IDEName: "Code", FilePath: `C:\src\api\main.go`, CursorLine: 42, CursorColumn: 7, IsActive: true},
+		},
+		Processes: []core.Process{
+			{ProcessName: "node", Command: "npm run dev", WorkingDirectory: `C:\src\web`, Pid: 4242, AutoRestart: true},
+		},
+		Monitors: []core.Monitor{
+			{DeviceName: `\\.\DISPLAY1`, Width: 1920, Height: 1080, Primary: true, DPI: 96, WorkWidth: 1920, WorkHeight: 1040},
+			{DeviceName: `\\.\DISPLAY2`, X: 1920, Width: 2560, Height: 1440, DPI: 144},
+		},
+	}
+	want := components(t, saved)
+	saveSnapshot(t, repo, saved)
+
+	loaded, err := m.Load(ctx, "full")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := components(t, loaded); !bytes.Equal(got, want) {
+		t.Errorf("components changed across the database:\n got %s\nwant %s", got, want)
+	}
+
+	// Restore trabaja sobre el snapshot hidratado, no solo sobre las ventanas
+	adapter.Windows = []core.Window{{AppName: "Code", WindowTitle: "main.go - api", Pid: 1}}
+	report, err := m.Restore(ctx, "full", RestoreOptions{})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if report.RestoredTerminals != 1 || report.RestoredTabs != 2 {
+		t.Errorf("restored %d terminals and %d tabs, want 1 and 2", report.RestoredTerminals, report.RestoredTabs)
+	}
+}
@@ -0,0 +1,303 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+	"github.com/tuusuario/dev-env-snapshots/internal/sanitize"
+)
+
+// Shells para los que se puede generar un script de restauración
+const (
+	ScriptPowerShell = "powershell"
+	ScriptBash       = "bash"
+)
+
+// scriptData es lo que ven las plantillas, ya sanitizado y revisado
+type scriptData struct {
+	Name      string
+	ID        string
+	Captured  string
+	Launches  []scriptLaunch
+	Windows   []core.Window
+	Terminals []scriptTerminal
+	URLs      []string
+	Skipped   []string
+}
+
+type scriptLaunch struct {
+	Path string
+	Args []string
+}
+
+type scriptTerminal struct {
+	App     string
+	Dir     string
+	WTArgs  []string // Argumentos de wt.exe; vacío si no hay layout ni directorio
+	Command string   // Comando que corría al capturar, solo como comentario
+}
+
+// RestoreScript escribe un script que relanza las apps, terminales y
+// pestañas del snapshot para el shell indicado. Es best-effort: las ventanas
+// solo quedan como comentarios con su posición. El snapshot pasa por la
+// sanitización del manager; si aun así algo parece una credencial, falla sin
+// escribir nada
+func (m *Manager) RestoreScript(ctx context.Context, id, shell string, w io.Writer) error {
+	tmpl, ok := scriptTemplates[strings.ToLower(shell)]
+	if !ok {
+		return fmt.Errorf("unknown script shell %q (want %s or %s)", shell, ScriptPowerShell, ScriptBash)
+	}
+
+	release, err := m.locks.acquireShared(id, "export")
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	s, err := m.Load(ctx, id)
+	if err != nil {
+		return err
+	}
+	m.sanitizer.SanitizeSnapshot(s)
+
+	data, findings := buildScriptData(s)
+	if len(findings) > 0 {
+		return fmt.Errorf("refusing to generate the script, it would embed what looks like secrets:\n  - %s\nClose or clean up those windows, terminals or tabs and capture again",
+			strings.Join(findings, "\n  - "))
+	}
+	return tmpl.Execute(w, data)
+}
+
+// buildScriptData arma los datos de la plantilla y retorna dónde se
+// encontraron posibles credenciales
+func buildScriptData(s *core.Snapshot) (*scriptData, []string) {
+	data := &scriptData{
+		Name:     s.Name,
+		ID:       s.ID,
+		Captured: s.CreatedAt.UTC().Format("2006-01-02 15:04 UTC"),
+		Windows:  s.Windows,
+	}
+	var findings []string
+	check := func(where, text string) {
+		if kinds := sanitize.FindSecrets(text); len(kinds) > 0 {
+			findings = append(findings, fmt.Sprintf("%s (%s)", where, strings.Join(kinds, ", ")))
+		}
+	}
+	check("snapshot name", s.Name)
+
+	// Las apps de terminal y de navegador se abren con sus propias secciones
+	covered := make(map[string]bool)
+	for _, t := range s.Terminals {
+		covered[strings.ToLower(t.TerminalApp)] = true
+	}
+	for _, tab := range s.BrowserTabs {
+		covered[strings.ToLower(tab.BrowserName)] = true
+	}
+
+	launched := make(map[string]bool)
+	for i, w := range s.Windows {
+		where := fmt.Sprintf("window %d (%s)", i+1, w.AppName)
+		check(where+" title", w.WindowTitle)
+		if w.AppPath == "" || covered[strings.ToLower(w.AppName)] {
+			continue
+		}
+		args := platform.LaunchArgList(w)
+		check(where+" launch command", w.AppPath+" "+strings.Join(args, " "))
+		key := w.AppPath + "\x00" + strings.Join(args, "\x00")
+		if launched[key] {
+			continue
+		}
+		launched[key] = true
+		data.Launches = append(data.Launches, scriptLaunch{Path: w.AppPath, Args: args})
+	}
+
+	for i, t := range s.Terminals {
+		where := fmt.Sprintf("terminal %d (%s)", i+1, t.TerminalApp)
+		term := scriptTerminal{App: t.TerminalApp, Dir: t.WorkingDirectory, Command: t.ActiveCommand}
+		layout := t.Layout
+		if layout == nil && t.WorkingDirectory != "" {
+			layout = &core.TerminalLayout{Tabs: []core.TerminalTab{{Panes: []core.TerminalPane{{Directory: t.WorkingDirectory}}}}}
+		}
+		if layout != nil {
+			term.WTArgs = platform.WTLayoutArgs(*layout, t.WorkingDirectory)
+		}
+		check(where+" directory", t.WorkingDirectory+" "+strings.Join(term.WTArgs, " "))
+		check(where+" command", t.ActiveCommand)
+		data.Terminals = append(data.Terminals, term)
+	}
+
+	seen := make(map[string]bool)
+	for i, tab := range s.BrowserTabs {
+		check(fmt.Sprintf("tab %d URL", i+1), tab.URL)
+		check(fmt.Sprintf("tab %d title", i+1), tab.Title)
+		if seen[tab.URL] {
+			continue
+		}
+		seen[tab.URL] = true
+		if u, err := url.Parse(tab.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			data.Skipped = append(data.Skipped, "non-web tab "+tab.URL)
+			continue
+		}
+		data.URLs = append(data.URLs, tab.URL)
+	}
+	return data, findings
+}
+
+// oneLine deja el texto en una sola línea para que quepa en un comentario
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// psQuote arma un string de PowerShell entre comillas dobles. La máscara de
+// usuario se reemplaza por $env:USERNAME, que PowerShell expande
+func psQuote(s string) string {
+	parts := strings.Split(oneLine(s), sanitize.UserMask)
+	for i, p := range parts {
+		p = strings.NewReplacer("`", "``", "$", "`$", `"`, "`\"").Replace(p)
+		parts[i] = p
+	}
+	return `"` + strings.Join(parts, "$env:USERNAME") + `"`
+}
+
+// psArgList arma el -ArgumentList de Start-Process. Start-Process une los
+// argumentos con espacios, así que los que tienen espacios van entre comillas
+func psArgList(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if strings.ContainsAny(a, " \t") {
+			a = `"` + a + `"`
+		}
+		quoted[i] = psQuote(a)
+	}
+	return "@(" + strings.Join(quoted, ", ") + ")"
+}
+
+// shQuote arma un string de bash entre comillas dobles; la máscara de
+// usuario se reemplaza por ${USER}
+func shQuote(s string) string {
+	parts := strings.Split(oneLine(s), sanitize.UserMask)
+	for i, p := range parts {
+		parts[i] = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(p)
+	}
+	return `"` + strings.Join(parts, "${USER}") + `"`
+}
+
+func shArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+const powerShellScript = `# Restore script for snapshot {{psq .Name}} ({{.ID}}), captured {{.Captured}}
+# Generated by dev-env-snapshots. BEST-EFFORT: it relaunches applications,
+# terminals and browser tabs but cannot place windows; their captured
+# positions are listed as comments. Review it before running.
+{{- if .Launches}}
+
+# Applications
+{{- range .Launches}}
+Start-Process -FilePath {{psq .Path}}{{if .Args}} -ArgumentList {{psargs .Args}}{{end}}
+{{- end}}
+{{- end}}
+{{- if .Terminals}}
+
+# Terminals
+{{- range .Terminals}}
+{{- if .Command}}
+# was running: {{line .Command}}
+{{- end}}
+{{- if .WTArgs}}
+Start-Process -FilePath "wt.exe" -ArgumentList {{psargs .WTArgs}}
+{{- else}}
+Start-Process -FilePath "wt.exe"
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .URLs}}
+
+# Browser tabs (default browser)
+{{- range .URLs}}
+Start-Process {{psq .}}
+{{- end}}
+{{- end}}
+{{- if .Windows}}
+
+# Window positions (x,y width x height), place them by hand or with the server
+{{- range .Windows}}
+# [{{line .AppName}}] {{line .WindowTitle}}: {{.X}},{{.Y}} {{.Width}}x{{.Height}}{{if .State}} {{.State}}{{end}}
+{{- end}}
+{{- end}}
+{{- if .Skipped}}
+
+# Skipped
+{{- range .Skipped}}
+# {{line .}}
+{{- end}}
+{{- end}}
+`
+
+const bashScript = `#!/usr/bin/env bash
+# Restore script for snapshot {{shq .Name}} ({{.ID}}), captured {{.Captured}}
+# Generated by dev-env-snapshots. BEST-EFFORT: it relaunches applications,
+# terminals and browser tabs but cannot place windows; their captured
+# positions are listed as comments. Review it before running.
+
+open_url() {
+  if command -v xdg-open >/dev/null 2>&1; then xdg-open "$1"; else open "$1"; fi >/dev/null 2>&1 &
+}
+{{- if .Launches}}
+
+# Applications
+{{- range .Launches}}
+nohup {{shq .Path}}{{if .Args}} {{shargs .Args}}{{end}} >/dev/null 2>&1 &
+{{- end}}
+{{- end}}
+{{- if .Terminals}}
+
+# Terminals
+{{- range .Terminals}}
+{{- if .Command}}
+# was running: {{line .Command}}
+{{- end}}
+(cd {{if .Dir}}{{shq .Dir}}{{else}}~{{end}} && nohup "${TERMINAL:-x-terminal-emulator}" >/dev/null 2>&1 &)
+{{- end}}
+{{- end}}
+{{- if .URLs}}
+
+# Browser tabs (default browser)
+{{- range .URLs}}
+open_url {{shq .}}
+{{- end}}
+{{- end}}
+{{- if .Windows}}
+
+# Window positions (x,y width x height), place them by hand or with the server
+{{- range .Windows}}
+# [{{line .AppName}}] {{line .WindowTitle}}: {{.X}},{{.Y}} {{.Width}}x{{.Height}}{{if .State}} {{.State}}{{end}}
+{{- end}}
+{{- end}}
+{{- if .Skipped}}
+
+# Skipped
+{{- range .Skipped}}
+# {{line .}}
+{{- end}}
+{{- end}}
+`
+
+var scriptTemplates = map[string]*template.Template{
+	ScriptPowerShell: template.Must(template.New(ScriptPowerShell).Funcs(template.FuncMap{
+		"psq": psQuote, "psargs": psArgList, "line": oneLine,
+	}).Parse(powerShellScript)),
+	ScriptBash: template.Must(template.New(ScriptBash).Funcs(template.FuncMap{
+		"shq": shQuote, "shargs": shArgs, "line": oneLine,
+	}).Parse(bashScript)),
+}
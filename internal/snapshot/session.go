@@ -0,0 +1,181 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// SessionTag marca los snapshots de inicio y fin de las sesiones de foco
+const SessionTag = "session"
+
+// SessionSummary describe cómo evolucionó el entorno durante una sesión
+type SessionSummary struct {
+	Session       core.Session
+	Duration      time.Duration
+	Overran       bool // Duró más que lo planificado
+	WindowsOpened []string
+	WindowsClosed []string
+	CommonWindows int
+	TabsAtStart   int
+	TabsAtEnd     int
+	TabsOpened    int // URLs presentes al final y no al inicio
+	TabsClosed    int
+	BranchFrom    string
+	BranchTo      string
+	CommitFrom    string
+	CommitTo      string
+	Warnings      []string
+}
+
+// StartSession captura el snapshot de inicio y registra la sesión. Solo puede
+// haber una sesión activa a la vez
+func (m *Manager) StartSession(ctx context.Context, label string, planned time.Duration, capture CaptureOptions) (*core.Session, *core.Snapshot, error) {
+	active, err := m.ActiveSession(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if active != nil {
+		return nil, nil, fmt.Errorf("session %s (%q) is already running since %s; end it first",
+			active.ID, active.Label, active.StartedAt.Format(time.RFC822))
+	}
+
+	if capture.Name == "" {
+		capture.Name = "Session start: " + label
+	}
+	capture.Tags = append([]string{SessionTag}, capture.Tags...)
+	start, err := m.Capture(ctx, capture)
+	if err != nil {
+		return nil, nil, fmt.Errorf("session start capture failed: %w", err)
+	}
+
+	session := &core.Session{
+		ID:              uuid.New().String(),
+		Label:           label,
+		StartSnapshotID: start.ID,
+		StartedAt:       start.CreatedAt,
+		Planned:         planned,
+	}
+	if err := m.repo.CreateSession(ctx, session); err != nil {
+		return nil, start, err
+	}
+	return session, start, nil
+}
+
+// ActiveSession retorna la sesión sin terminar, o nil si no hay ninguna
+func (m *Manager) ActiveSession(ctx context.Context) (*core.Session, error) {
+	sessions, err := m.repo.ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sessions {
+		if s.Active() {
+			return &s, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListSessions retorna todas las sesiones, de la más nueva a la más vieja
+func (m *Manager) ListSessions(ctx context.Context) ([]core.Session, error) {
+	return m.repo.ListSessions(ctx)
+}
+
+// EndSession captura el snapshot de fin de la sesión (la activa si sessionID
+// está vacío), guarda el par y retorna el resumen de la sesión
+func (m *Manager) EndSession(ctx context.Context, sessionID string, capture CaptureOptions) (*SessionSummary, error) {
+	var session *core.Session
+	var err error
+	if sessionID == "" {
+		session, err = m.ActiveSession(ctx)
+		if err == nil && session == nil {
+			err = fmt.Errorf("no active session")
+		}
+	} else {
+		session, err = m.repo.GetSession(ctx, sessionID)
+		if err == nil && session == nil {
+			err = fmt.Errorf("session %s not found", sessionID)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !session.Active() {
+		return nil, fmt.Errorf("session %s already ended at %s", session.ID, session.EndedAt.Format(time.RFC822))
+	}
+
+	if capture.Name == "" {
+		capture.Name = "Session end: " + session.Label
+	}
+	capture.Tags = append([]string{SessionTag}, capture.Tags...)
+	end, err := m.Capture(ctx, capture)
+	if err != nil {
+		return nil, fmt.Errorf("session end capture failed, the session is still running: %w", err)
+	}
+
+	summary := m.summarizeSession(ctx, session, end)
+	session.EndSnapshotID = end.ID
+	session.EndedAt = end.CreatedAt
+	session.DriftScore = summary.DriftScore()
+	if err := m.repo.EndSession(ctx, session); err != nil {
+		return nil, err
+	}
+	summary.Session = *session
+	summary.Duration = session.EndedAt.Sub(session.StartedAt)
+	summary.Overran = session.Planned > 0 && summary.Duration > session.Planned
+	return summary, nil
+}
+
+// summarizeSession compara el snapshot de inicio con el de fin. Si el de
+// inicio ya no existe se compara contra un entorno vacío con un warning
+func (m *Manager) summarizeSession(ctx context.Context, session *core.Session, end *core.Snapshot) *SessionSummary {
+	summary := &SessionSummary{}
+	start, err := m.Load(ctx, session.StartSnapshotID)
+	if err != nil {
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf("start snapshot %s unavailable (%v), comparing against an empty environment", session.StartSnapshotID, err))
+		start = &core.Snapshot{}
+	}
+	summary.Warnings = append(summary.Warnings, start.Warnings...)
+
+	diff := &DiffResult{SourceID: start.ID, TargetID: end.ID}
+	diffWindowTitles(diff, start.Windows, end.Windows)
+	summary.WindowsOpened = diff.AddedWindows
+	summary.WindowsClosed = diff.RemovedWindows
+	summary.CommonWindows = diff.CommonWindows
+
+	summary.TabsAtStart = len(start.BrowserTabs)
+	summary.TabsAtEnd = len(end.BrowserTabs)
+	startURLs := tabURLs(start.BrowserTabs)
+	endURLs := tabURLs(end.BrowserTabs)
+	for url := range endURLs {
+		if !startURLs[url] {
+			summary.TabsOpened++
+		}
+	}
+	for url := range startURLs {
+		if !endURLs[url] {
+			summary.TabsClosed++
+		}
+	}
+
+	summary.BranchFrom, summary.BranchTo = start.GitBranch, end.GitBranch
+	summary.CommitFrom, summary.CommitTo = start.GitHeadHash, end.GitHeadHash
+	return summary
+}
+
+// DriftScore es la fracción de ventanas abiertas o cerradas durante la sesión
+func (s *SessionSummary) DriftScore() float64 {
+	diff := DiffResult{AddedWindows: s.WindowsOpened, RemovedWindows: s.WindowsClosed, CommonWindows: s.CommonWindows}
+	return diff.DriftScore()
+}
+
+func tabURLs(tabs []core.BrowserTab) map[string]bool {
+	urls := make(map[string]bool, len(tabs))
+	for _, t := range tabs {
+		urls[t.URL] = true
+	}
+	return urls
+}
@@ -0,0 +1,190 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// sharedSuffix identifica los archivos publicados en un directorio compartido
+const sharedSuffix = ".snapshot.json"
+
+// SharedDocument es el archivo que se publica: el export con su autor y el
+// checksum del export, que se verifica al traerlo
+type SharedDocument struct {
+	Author      string          `json:"author"`
+	PublishedAt time.Time       `json:"published_at"`
+	Checksum    string          `json:"checksum"` // sha256 del export compactado
+	Export      json.RawMessage `json:"export"`
+}
+
+// SharedEntry describe un archivo del directorio compartido
+type SharedEntry struct {
+	File        string
+	Author      string
+	Name        string
+	PublishedAt time.Time
+	Windows     int
+	Problem     string // Vacío si el archivo se puede traer
+}
+
+// PublishSnapshot exporta el snapshot sanitizado al directorio compartido.
+// Escribe un temporal y lo renombra, así nadie lee un archivo a medias, y el
+// nombre lleva fecha, autor y un sufijo aleatorio para no pisar a otro
+func (m *Manager) PublishSnapshot(ctx context.Context, id, dir, author string) (string, error) {
+	doc, err := m.exportDocument(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	m.sanitizeExport(doc)
+
+	export, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	shared, err := json.MarshalIndent(SharedDocument{
+		Author:      author,
+		PublishedAt: doc.ExportedAt,
+		Checksum:    exportChecksum(export),
+		Export:      export,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-%s-%s-%s%s", doc.ExportedAt.UTC().Format("20060102T150405Z"),
+		fileSlug(author), fileSlug(doc.Snapshot.Name), hex.EncodeToString(suffix), sharedSuffix)
+
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("cannot write to shared directory: %w", err)
+	}
+	_, err = tmp.Write(shared)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(dir, name))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to publish snapshot: %w", err)
+	}
+	return name, nil
+}
+
+// ListShared lista los snapshots publicados, del más nuevo al más viejo. Los
+// archivos ilegibles o con checksum incorrecto se listan con su problema
+func (m *Manager) ListShared(dir string) ([]SharedEntry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read shared directory: %w", err)
+	}
+	entries := []SharedEntry{}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), sharedSuffix) {
+			continue
+		}
+		entry := SharedEntry{File: f.Name()}
+		shared, doc, err := readShared(dir, f.Name())
+		if shared != nil {
+			entry.Author, entry.PublishedAt = shared.Author, shared.PublishedAt
+		}
+		if doc != nil && doc.Snapshot != nil {
+			entry.Name, entry.Windows = doc.Snapshot.Name, len(doc.Snapshot.Windows)
+		}
+		if err != nil {
+			entry.Problem = err.Error()
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].PublishedAt.Equal(entries[j].PublishedAt) {
+			return entries[i].PublishedAt.After(entries[j].PublishedAt)
+		}
+		return entries[i].File < entries[j].File
+	})
+	return entries, nil
+}
+
+// PullShared importa un snapshot publicado con origen imported y su autor.
+// Verifica el checksum; como todo import, vuelve a sanitizar y nunca ejecuta
+// nada del archivo (ver importDocument)
+func (m *Manager) PullShared(ctx context.Context, dir, file string) (*core.Snapshot, error) {
+	shared, doc, err := readShared(dir, file)
+	if err != nil {
+		return nil, err
+	}
+	return m.importDocument(ctx, doc, func(s *core.Snapshot) {
+		s.Author = shared.Author
+	})
+}
+
+// readShared lee y verifica un archivo publicado. file debe ser un nombre
+// del directorio, no una ruta
+func readShared(dir, file string) (*SharedDocument, *ExportDocument, error) {
+	if file != filepath.Base(file) || !strings.HasSuffix(file, sharedSuffix) {
+		return nil, nil, fmt.Errorf("invalid shared snapshot name %q", file)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read shared snapshot: %w", err)
+	}
+	var shared SharedDocument
+	if err := json.Unmarshal(raw, &shared); err != nil {
+		return nil, nil, fmt.Errorf("malformed shared snapshot: %w", err)
+	}
+	if got := exportChecksum(shared.Export); got != shared.Checksum {
+		return &shared, nil, fmt.Errorf("checksum mismatch (file says %s, content is %s): modified or partially copied", shared.Checksum, got)
+	}
+	var doc ExportDocument
+	if err := json.Unmarshal(shared.Export, &doc); err != nil {
+		return &shared, nil, fmt.Errorf("malformed snapshot export: %w", err)
+	}
+	return &shared, &doc, nil
+}
+
+// exportChecksum es el sha256 del export compactado, así el indentado del
+// archivo no cambia el resultado
+func exportChecksum(export []byte) string {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, export); err != nil {
+		compact.Reset()
+		compact.Write(export)
+	}
+	sum := sha256.Sum256(compact.Bytes())
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+var slugUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// fileSlug reduce un texto a minúsculas, dígitos y guiones para el nombre de archivo
+func fileSlug(s string) string {
+	slug := strings.Trim(slugUnsafe.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if len(slug) > 32 {
+		slug = strings.TrimRight(slug[:32], "-")
+	}
+	if slug == "" {
+		return "unnamed"
+	}
+	return slug
+}
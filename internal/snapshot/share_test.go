@@ -0,0 +1,238 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+// launchRecorder es un mock que anota lo que se le pide ejecutar
+type launchRecorder struct {
+	*platform.MockAdapter
+	mu        sync.Mutex
+	launched  []string
+	processes []string
+	terminals []string
+	tabs      []string // "navegador url"
+}
+
+func (r *launchRecorder) LaunchApp(ctx context.Context, w core.Window) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.launched = append(r.launched, w.AppPath)
+	return 0, nil
+}
+
+func (r *launchRecorder) StartProcess(ctx context.Context, p core.Process) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.processes = append(r.processes, p.Command)
+	return nil
+}
+
+func (r *launchRecorder) RestoreTerminal(ctx context.Context, t core.Terminal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.terminals = append(r.terminals, t.TerminalApp)
+	return nil
+}
+
+func (r *launchRecorder) OpenURL(ctx context.Context, url string, browser string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tabs = append(r.tabs, strings.TrimSpace(browser+" "+url))
+	return nil
+}
+
+func TestPullSharedNeverRunsLaunchData(t *testing.T) {
+	ctx := context.Background()
+	adapter := &launchRecorder{MockAdapter: platform.NewMockAdapter()}
+	m, repo := newTestManager(t, adapter)
+
+	s := &core.Snapshot{
+		ID:        "published",
+		Name:      "shared",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Windows: []core.Window{{
+			AppName:     "evil",
+			AppPath:     "/opt/evil/evil",
+			LaunchArgs:  json.RawMessage(`["--payload"]`),
+			WindowTitle: "Nothing matches this",
+			Width:       800,
+			Height:      600,
+		}},
+		Processes: []core.Process{{ProcessName: "evil", Command: "/opt/evil/evil --daemon", AutoRestart: true}},
+	}
+	if err := repo.SaveSnapshot(ctx, s); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+
+	dir := t.TempDir()
+	file, err := m.PublishSnapshot(ctx, s.ID, dir, "alice")
+	if err != nil {
+		t.Fatalf("PublishSnapshot: %v", err)
+	}
+	pulled, err := m.PullShared(ctx, dir, file)
+	if err != nil {
+		t.Fatalf("PullShared: %v", err)
+	}
+
+	loaded, err := m.Load(ctx, pulled.ID)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !loaded.Untrusted {
+		t.Error("pulled snapshot is not marked untrusted")
+	}
+	for _, p := range loaded.Processes {
+		if p.AutoRestart {
+			t.Errorf("process %s kept AutoRestart after pull", p.ProcessName)
+		}
+	}
+
+	report, err := m.Restore(ctx, pulled.ID, RestoreOptions{LaunchMissing: true, LaunchTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if len(adapter.launched) > 0 || len(adapter.processes) > 0 {
+		t.Fatalf("restore of a pulled snapshot ran launched=%v processes=%v", adapter.launched, adapter.processes)
+	}
+	if !strings.Contains(strings.Join(report.Notes, "\n"), "imported snapshot") {
+		t.Errorf("notes do not explain the skipped launch: %v", report.Notes)
+	}
+
+	// El mismo snapshot local sí lanza su app: el bloqueo es por el pull
+	if _, err := m.Restore(ctx, s.ID, RestoreOptions{LaunchMissing: true, LaunchTimeout: 100 * time.Millisecond}); err != nil {
+		t.Fatalf("Restore local: %v", err)
+	}
+	if len(adapter.launched) != 1 || len(adapter.processes) != 1 {
+		t.Errorf("local restore: launched=%v processes=%v, want one of each", adapter.launched, adapter.processes)
+	}
+}
+
+func TestPublishAndPullBetweenTwoInstances(t *testing.T) {
+	ctx := context.Background()
+	shared := t.TempDir()
+	alice, aliceRepo := newTestManager(t, platform.NewMockAdapter())
+	bob, bobRepo := newTestManager(t, platform.NewMockAdapter())
+	saveSnapshot(t, aliceRepo, &core.Snapshot{ID: "alice-work", Name: "API debugging", Tags: []string{"api"}, Windows: []core.Window{
+		{AppName: "Code", WindowTitle: "server.go", Width: 1200, Height: 800},
+		{AppName: "Terminal", WindowTitle: "zsh", Width: 600, Height: 400},
+	}})
+
+	file, err := alice.PublishSnapshot(ctx, "alice-work", shared, "Alice")
+	if err != nil {
+		t.Fatalf("PublishSnapshot: %v", err)
+	}
+
+	// Bob ve lo que Alice publicó sin importarlo
+	entries, err := bob.ListShared(shared)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("ListShared = %+v, %v; want the published file", entries, err)
+	}
+	if e := entries[0]; e.File != file || e.Author != "Alice" || e.Name != "API debugging" || e.Windows != 2 || e.Problem != "" {
+		t.Errorf("entry = %+v", e)
+	}
+
+	pulled, err := bob.PullShared(ctx, shared, file)
+	if err != nil {
+		t.Fatalf("PullShared: %v", err)
+	}
+	got, err := bobRepo.GetSnapshotByID(ctx, pulled.ID)
+	if err != nil || got == nil {
+		t.Fatalf("pulled snapshot not in Bob's database: %v", err)
+	}
+	if got.Name != "API debugging" || got.Author != "Alice" || got.Origin != core.OriginImported || !got.Untrusted {
+		t.Errorf("pulled = name %q, author %q, origin %q, untrusted %v", got.Name, got.Author, got.Origin, got.Untrusted)
+	}
+	if windows, err := bobRepo.GetWindows(ctx, pulled.ID); err != nil || len(windows) != 2 {
+		t.Errorf("pulled windows = %d, %v; want 2", len(windows), err)
+	}
+	// Publicar no agrega nada a la base de Alice
+	if list, err := aliceRepo.ListSnapshots(ctx, core.SnapshotFilter{}); err != nil || list.Total != 1 {
+		t.Errorf("Alice has %d snapshots after publishing, want 1 (%v)", list.Total, err)
+	}
+}
+
+func TestPullRejectsTamperedFiles(t *testing.T) {
+	ctx := context.Background()
+	shared := t.TempDir()
+	alice, aliceRepo := newTestManager(t, platform.NewMockAdapter())
+	bob, bobRepo := newTestManager(t, platform.NewMockAdapter())
+	saveSnapshot(t, aliceRepo, &core.Snapshot{ID: "alice-work", Name: "review", Windows: []core.Window{{AppName: "Code", WindowTitle: "main.go"}}})
+	file, err := alice.PublishSnapshot(ctx, "alice-work", shared, "Alice")
+	if err != nil {
+		t.Fatalf("PublishSnapshot: %v", err)
+	}
+
+	// Alguien cambia el contenido sin actualizar el checksum
+	path := filepath.Join(shared, file)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(raw), "main.go", "evil.go", 1)
+	if tampered == string(raw) {
+		t.Fatal("the export does not contain the window title")
+	}
+	if err := os.WriteFile(path, []byte(tampered), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := bob.ListShared(shared)
+	if err != nil || len(entries) != 1 || !strings.Contains(entries[0].Problem, "checksum mismatch") {
+		t.Errorf("ListShared = %+v, %v; want the file listed with a checksum problem", entries, err)
+	}
+	if _, err := bob.PullShared(ctx, shared, file); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("PullShared of a tampered file = %v, want a checksum mismatch", err)
+	}
+	if list, err := bobRepo.ListSnapshots(ctx, core.SnapshotFilter{}); err != nil || list.Total != 0 {
+		t.Errorf("Bob has %d snapshots after the failed pull, want 0 (%v)", list.Total, err)
+	}
+}
+
+func TestReadSharedRejectsPaths(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	shared := filepath.Join(root, "shared")
+	if err := os.Mkdir(shared, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	m, repo := newTestManager(t, platform.NewMockAdapter())
+	saveSnapshot(t, repo, &core.Snapshot{ID: "work", Name: "work"})
+	// Un archivo válido fuera del directorio compartido: solo el nombre lo protege
+	outside, err := m.PublishSnapshot(ctx, "work", root, "Alice")
+	if err != nil {
+		t.Fatalf("PublishSnapshot: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"parent directory", filepath.Join("..", outside)},
+		{"subdirectory", filepath.Join("sub", outside)},
+		{"absolute path", filepath.Join(root, outside)},
+		{"wrong suffix", "notes.txt"},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := readShared(shared, tt.file); err == nil || !strings.Contains(err.Error(), "invalid shared snapshot name") {
+				t.Errorf("readShared(%q) = %v, want an invalid name error", tt.file, err)
+			}
+			if _, err := m.PullShared(ctx, shared, tt.file); err == nil {
+				t.Errorf("PullShared(%q) succeeded", tt.file)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// BackupTag marca los snapshots creados automáticamente antes de un switch
+const BackupTag = "auto-backup"
+
+// MaxBackups es la cantidad de respaldos automáticos que se conservan; al
+// crear uno nuevo se borran los más viejos
+const MaxBackups = 10
+
+// SwitchResult agrupa el respaldo del entorno previo y el reporte del restore
+type SwitchResult struct {
+	Backup *core.Snapshot
+	Report *RestoreReport
+}
+
+// SwitchTo guarda el entorno actual en un snapshot etiquetado con BackupTag y
+// restaura targetID. El target se valida antes de capturar para no dejar
+// respaldos huérfanos; si el restore falla el respaldo se conserva y se
+// retorna junto al error para poder volver atrás.
+func (m *Manager) SwitchTo(ctx context.Context, targetID string, capture CaptureOptions, restore RestoreOptions) (*SwitchResult, error) {
+	target, err := m.repo.GetSnapshotByID(ctx, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	if target == nil {
+		return nil, &core.NotFoundError{SnapshotID: targetID}
+	}
+
+	if capture.Name == "" {
+		capture.Name = fmt.Sprintf("Before %s (%s)", target.Name, time.Now().Format("2006-01-02 15:04"))
+	}
+	if capture.Description == "" {
+		capture.Description = fmt.Sprintf("Automatic backup taken before switching to snapshot %s", target.ID)
+	}
+	capture.Tags = append([]string{BackupTag}, capture.Tags...)
+	capture.BackupFor = target.ID
+
+	backup, err := m.Capture(ctx, capture)
+	if err != nil {
+		return nil, fmt.Errorf("backup capture failed, nothing was restored: %w", err)
+	}
+
+	result := &SwitchResult{Backup: backup}
+	result.Report, err = m.Restore(ctx, targetID, restore)
+	m.pruneBackups(ctx, targetID)
+	if err != nil {
+		return result, fmt.Errorf("restore failed (backup %s kept): %w", backup.ID, err)
+	}
+	return result, nil
+}
+
+// ListBackups retorna los respaldos automáticos, del más nuevo al más viejo
+func (m *Manager) ListBackups(ctx context.Context) (*core.SnapshotList, error) {
+	return m.repo.ListSnapshots(ctx, core.SnapshotFilter{Tags: []string{BackupTag}})
+}
+
+// BackupTargets retorna, por ID, el nombre de los snapshots cuyo restore
+// precedieron los respaldos. Se buscan por ID, no en un listado paginado; los
+// borrados no aparecen
+func (m *Manager) BackupTargets(ctx context.Context, backups []core.Snapshot) (map[string]string, error) {
+	names := make(map[string]string)
+	looked := make(map[string]bool)
+	for _, b := range backups {
+		if b.BackupFor == "" || looked[b.BackupFor] {
+			continue
+		}
+		looked[b.BackupFor] = true
+		target, err := m.repo.GetSnapshotByID(ctx, b.BackupFor)
+		if err != nil {
+			return nil, err
+		}
+		if target != nil {
+			names[b.BackupFor] = target.Name
+		}
+	}
+	return names, nil
+}
+
+// pruneBackups borra los respaldos que exceden MaxBackups salvo keep (el
+// snapshot recién restaurado). Los que están en uso se saltean y se
+// reintentan en el próximo respaldo
+func (m *Manager) pruneBackups(ctx context.Context, keep string) {
+	list, err := m.ListBackups(ctx)
+	if err != nil || len(list.Snapshots) <= MaxBackups {
+		return
+	}
+	for _, old := range list.Snapshots[MaxBackups:] {
+		if old.ID == keep {
+			continue
+		}
+		if err := m.Delete(ctx, old.ID); err != nil {
+			log.Printf("[Backups] Could not prune %s: %v", old.ID, err)
+		}
+	}
+}
+
+// Rollback restaura un respaldo automático; con backupID vacío usa el más reciente
+func (m *Manager) Rollback(ctx context.Context, backupID string, opts RestoreOptions) (*core.Snapshot, *RestoreReport, error) {
+	var backup *core.Snapshot
+	if backupID == "" {
+		list, err := m.ListBackups(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(list.Snapshots) == 0 {
+			return nil, nil, fmt.Errorf("no auto-backups found")
+		}
+		backup = &list.Snapshots[0]
+	} else {
+		s, err := m.repo.GetSnapshotByID(ctx, backupID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get snapshot: %w", err)
+		}
+		if s == nil {
+			return nil, nil, &core.NotFoundError{SnapshotID: backupID}
+		}
+		if !hasTag(s.Tags, BackupTag) {
+			return nil, nil, fmt.Errorf("snapshot %s is not an auto-backup", backupID)
+		}
+		backup = s
+	}
+
+	report, err := m.Restore(ctx, backup.ID, opts)
+	return backup, report, err
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
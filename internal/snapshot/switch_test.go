@@ -0,0 +1,136 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+func TestSwitchToBacksUpAndRestores(t *testing.T) {
+	ctx := context.Background()
+	adapter := &movingAdapter{MockAdapter: platform.NewMockAdapter()}
+	adapter.Windows = []core.Window{
+		{AppName: "Code", WindowTitle: "main.go", X: 10, Y: 20, Width: 800, Height: 600, Pid: 1},
+		{AppName: "Terminal", WindowTitle: "zsh", X: 820, Y: 20, Width: 600, Height: 400, Pid: 2},
+	}
+	m, repo := newTestManager(t, adapter)
+	saveSnapshot(t, repo, &core.Snapshot{ID: "target", Name: "review", Windows: []core.Window{
+		{AppName: "Code", WindowTitle: "main.go", X: 0, Y: 0, Width: 1920, Height: 1080},
+		{AppName: "Terminal", WindowTitle: "zsh", X: 1920, Y: 0, Width: 640, Height: 1080},
+	}})
+
+	res, err := m.SwitchTo(ctx, "target", CaptureOptions{}, RestoreOptions{})
+	if err != nil {
+		t.Fatalf("SwitchTo: %v", err)
+	}
+
+	// El respaldo quedó guardado con el layout previo y apunta al target
+	backup, err := repo.GetSnapshotByID(ctx, res.Backup.ID)
+	if err != nil || backup == nil {
+		t.Fatalf("backup %s not stored: %v", res.Backup.ID, err)
+	}
+	if !hasTag(backup.Tags, BackupTag) || backup.BackupFor != "target" {
+		t.Errorf("backup tags %v, backup_for %q; want %s and target", backup.Tags, backup.BackupFor, BackupTag)
+	}
+	windows, err := repo.GetWindows(ctx, backup.ID)
+	if err != nil || len(windows) != 2 || windows[0].X != 10 || windows[1].X != 820 {
+		t.Errorf("backup windows = %+v, %v; want the layout before the switch", windows, err)
+	}
+
+	// Y el target se restauró sobre las ventanas vivas
+	if res.Report == nil || res.Report.RestoredWindows != 2 {
+		t.Fatalf("report = %+v, want 2 windows restored", res.Report)
+	}
+	if a, b := adapter.Windows[0], adapter.Windows[1]; a.X != 0 || a.Width != 1920 || b.X != 1920 || b.Width != 640 {
+		t.Errorf("live windows at %+v and %+v, want the target layout", a, b)
+	}
+
+	// Un target inexistente no deja respaldos huérfanos
+	if _, err := m.SwitchTo(ctx, "missing", CaptureOptions{}, RestoreOptions{}); !errors.Is(err, core.ErrSnapshotNotFound) {
+		t.Errorf("switching to a missing snapshot: %v", err)
+	}
+	list, err := m.ListBackups(ctx)
+	if err != nil || len(list.Snapshots) != 1 {
+		t.Errorf("%d backups after the failed switch, want 1 (%v)", len(list.Snapshots), err)
+	}
+}
+
+func TestRollbackRestoresTheChosenBackup(t *testing.T) {
+	ctx := context.Background()
+	adapter := &movingAdapter{MockAdapter: platform.NewMockAdapter()}
+	adapter.Windows = []core.Window{{AppName: "Code", WindowTitle: "main.go", X: 10, Width: 800, Height: 600, Pid: 1}}
+	m, repo := newTestManager(t, adapter)
+	for id, x := range map[string]int{"a": 100, "b": 200} {
+		saveSnapshot(t, repo, &core.Snapshot{ID: id, Windows: []core.Window{{AppName: "Code", WindowTitle: "main.go", X: x, Width: 800, Height: 600}}})
+	}
+
+	first, err := m.SwitchTo(ctx, "a", CaptureOptions{}, RestoreOptions{})
+	if err != nil {
+		t.Fatalf("switch to a: %v", err)
+	}
+	second, err := m.SwitchTo(ctx, "b", CaptureOptions{}, RestoreOptions{})
+	if err != nil {
+		t.Fatalf("switch to b: %v", err)
+	}
+
+	// Se listan del más nuevo al más viejo, cada uno con el switch que precedió
+	list, err := m.ListBackups(ctx)
+	if err != nil {
+		t.Fatalf("ListBackups: %v", err)
+	}
+	var linked []string
+	for _, b := range list.Snapshots {
+		linked = append(linked, b.ID+"->"+b.BackupFor)
+	}
+	if got, want := strings.Join(linked, " "), second.Backup.ID+"->b "+first.Backup.ID+"->a"; got != want {
+		t.Errorf("backups = %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		name     string
+		backupID string
+		wantID   string
+		wantX    int
+	}{
+		{"chosen backup", first.Backup.ID, first.Backup.ID, 10},
+		{"newest by default", "", second.Backup.ID, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup, report, err := m.Rollback(ctx, tt.backupID, RestoreOptions{})
+			if err != nil {
+				t.Fatalf("Rollback: %v", err)
+			}
+			if backup.ID != tt.wantID || report.RestoredWindows != 1 {
+				t.Errorf("rolled back to %s restoring %d windows, want %s and 1", backup.ID, report.RestoredWindows, tt.wantID)
+			}
+			if x := adapter.Windows[0].X; x != tt.wantX {
+				t.Errorf("window at x=%d, want %d", x, tt.wantX)
+			}
+		})
+	}
+
+	if _, _, err := m.Rollback(ctx, "a", RestoreOptions{}); err == nil || !strings.Contains(err.Error(), "not an auto-backup") {
+		t.Errorf("rolling back to a regular snapshot: %v", err)
+	}
+}
+
+func TestSwitchKeepsOnlyMaxBackups(t *testing.T) {
+	ctx := context.Background()
+	m, repo := newTestManager(t, platform.NewMockAdapter())
+	saveSnapshot(t, repo, &core.Snapshot{ID: "target"})
+
+	for i := 0; i < MaxBackups+2; i++ {
+		if _, err := m.SwitchTo(ctx, "target", CaptureOptions{}, RestoreOptions{}); err != nil {
+			t.Fatalf("switch %d: %v", i, err)
+		}
+	}
+	list, err := m.ListBackups(ctx)
+	if err != nil || len(list.Snapshots) != MaxBackups {
+		t.Errorf("%d backups kept, want %d (%v)", len(list.Snapshots), MaxBackups, err)
+	}
+}
@@ -0,0 +1,107 @@
+package snapshot
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+)
+
+// DefaultMaxTabs es el tope de pestañas que reabre un restore si la llamada no indica otro
+const DefaultMaxTabs = 30
+
+// Las pestañas se abren en tandas para no congelar el navegador
+const (
+	tabBatchSize  = 5
+	tabBatchDelay = 500 * time.Millisecond
+)
+
+// groupTabsByWindow parte las pestañas, ya ordenadas por navegador, ventana y
+// pestaña, en un grupo por ventana del navegador
+func groupTabsByWindow(tabs []core.BrowserTab) [][]core.BrowserTab {
+	var groups [][]core.BrowserTab
+	for i, t := range tabs {
+		if i == 0 || t.BrowserName != tabs[i-1].BrowserName || t.WindowIndex != tabs[i-1].WindowIndex {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], t)
+	}
+	return groups
+}
+
+// PrioritizeTabs elige hasta limit pestañas con URL para reabrir. Orden de
+// prioridad: fijadas primero, luego diversidad de dominio (una pestaña por
+// dominio antes de repetir), y dentro de un dominio las más recientes (mayor
+// índice de pestaña). Las elegidas vuelven en su orden original por
+// navegador/ventana/pestaña; withheld conserva el orden de prioridad.
+func PrioritizeTabs(tabs []core.BrowserTab, limit int) (open, withheld []core.BrowserTab) {
+	var candidates []core.BrowserTab
+	for _, t := range tabs {
+		if t.URL != "" {
+			candidates = append(candidates, t)
+		}
+	}
+
+	// Recencia: dentro de cada dominio, de mayor a menor índice
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.WindowIndex != b.WindowIndex {
+			return a.WindowIndex > b.WindowIndex
+		}
+		return a.TabIndex > b.TabIndex
+	})
+
+	var pinned []core.BrowserTab
+	byDomain := make(map[string][]core.BrowserTab)
+	var domains []string
+	for _, t := range candidates {
+		if t.IsPinned {
+			pinned = append(pinned, t)
+			continue
+		}
+		d := tabDomain(t.URL)
+		if _, ok := byDomain[d]; !ok {
+			domains = append(domains, d)
+		}
+		byDomain[d] = append(byDomain[d], t)
+	}
+
+	// Round-robin entre dominios
+	ranked := pinned
+	for round := 0; len(ranked) < len(candidates); round++ {
+		for _, d := range domains {
+			if round < len(byDomain[d]) {
+				ranked = append(ranked, byDomain[d][round])
+			}
+		}
+	}
+
+	if limit <= 0 || limit > len(ranked) {
+		limit = len(ranked)
+	}
+	open = append([]core.BrowserTab(nil), ranked[:limit]...)
+	withheld = ranked[limit:]
+
+	sort.SliceStable(open, func(i, j int) bool {
+		a, b := open[i], open[j]
+		if a.BrowserName != b.BrowserName {
+			return a.BrowserName < b.BrowserName
+		}
+		if a.WindowIndex != b.WindowIndex {
+			return a.WindowIndex < b.WindowIndex
+		}
+		return a.TabIndex < b.TabIndex
+	})
+	return open, withheld
+}
+
+// tabDomain normaliza el host de una URL ("www." no cuenta como dominio distinto)
+func tabDomain(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
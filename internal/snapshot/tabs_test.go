@@ -0,0 +1,101 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+func TestPrioritizeTabs(t *testing.T) {
+	tabs := []core.BrowserTab{
+		{URL: "https://mail.google.com", TabIndex: 0, IsPinned: true},
+		{URL: "https://github.com/a", TabIndex: 1},
+		{URL: "https://github.com/b", TabIndex: 2},
+		{URL: "https://www.github.com/c", TabIndex: 3},
+		{URL: "https://docs.go.dev", TabIndex: 4},
+		{URL: "", TabIndex: 5, Title: "New Tab"},
+		{URL: "https://news.ycombinator.com", WindowIndex: 1, TabIndex: 0},
+	}
+	urls := func(tabs []core.BrowserTab) string {
+		var out []string
+		for _, t := range tabs {
+			out = append(out, strings.TrimPrefix(t.URL, "https://"))
+		}
+		return strings.Join(out, " ")
+	}
+
+	tests := []struct {
+		name     string
+		limit    int
+		open     string
+		withheld string
+	}{
+		// Prioridad: la fijada, luego una por dominio empezando por la
+		// ventana y la pestaña más recientes, luego las repetidas
+		{"no limit", 0, "mail.google.com github.com/a github.com/b www.github.com/c docs.go.dev news.ycombinator.com", ""},
+		{"limit above count", 50, "mail.google.com github.com/a github.com/b www.github.com/c docs.go.dev news.ycombinator.com", ""},
+		{"pinned first", 1, "mail.google.com", "news.ycombinator.com docs.go.dev www.github.com/c github.com/b github.com/a"},
+		{"one per domain before repeats", 4, "mail.google.com www.github.com/c docs.go.dev news.ycombinator.com", "github.com/b github.com/a"},
+		{"recency within a domain", 5, "mail.google.com github.com/b www.github.com/c docs.go.dev news.ycombinator.com", "github.com/a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open, withheld := PrioritizeTabs(tabs, tt.limit)
+			if got := urls(open); got != tt.open {
+				t.Errorf("open = %q, want %q", got, tt.open)
+			}
+			if got := urls(withheld); got != tt.withheld {
+				t.Errorf("withheld = %q, want %q", got, tt.withheld)
+			}
+		})
+	}
+}
+
+// urlRecorder es un mock que anota cuándo se abrió cada URL
+type urlRecorder struct {
+	*platform.MockAdapter
+	mu     sync.Mutex
+	opened []time.Time
+}
+
+func (r *urlRecorder) OpenURL(ctx context.Context, url string, browser string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.opened = append(r.opened, time.Now())
+	return nil
+}
+
+func TestRestoreTabsCapsAndThrottles(t *testing.T) {
+	adapter := &urlRecorder{MockAdapter: platform.NewMockAdapter()}
+	m, repo := newTestManager(t, adapter)
+	var tabs []core.BrowserTab
+	for i := 0; i < tabBatchSize+3; i++ {
+		tabs = append(tabs, core.BrowserTab{BrowserName: "chrome", URL: fmt.Sprintf("https://site%d.example", i), TabIndex: i})
+	}
+	saveSnapshot(t, repo, &core.Snapshot{ID: "tabs", BrowserTabs: tabs})
+
+	report, err := m.Restore(context.Background(), "tabs", RestoreOptions{MaxTabs: tabBatchSize + 1})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if report.RestoredTabs != tabBatchSize+1 || len(adapter.opened) != tabBatchSize+1 {
+		t.Fatalf("restored %d tabs with %d opens, want %d", report.RestoredTabs, len(adapter.opened), tabBatchSize+1)
+	}
+	// Las más viejas quedan afuera y se informan con su URL
+	if got := strings.Join(report.WithheldTabs, " "); got != "https://site1.example https://site0.example" {
+		t.Errorf("withheld = %q", got)
+	}
+
+	// Una tanda entera se abre de corrido; la siguiente espera
+	first := adapter.opened[tabBatchSize-1].Sub(adapter.opened[0])
+	gap := adapter.opened[tabBatchSize].Sub(adapter.opened[tabBatchSize-1])
+	if first >= tabBatchDelay || gap < tabBatchDelay {
+		t.Errorf("first batch took %s and the gap before the next was %s; want under and over %s", first, gap, tabBatchDelay)
+	}
+}
@@ -0,0 +1,104 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tuusuario/dev-env-snapshots/internal/core"
+	"github.com/tuusuario/dev-env-snapshots/internal/platform"
+)
+
+// WindowSelector elige qué ventanas grabadas restaura RestoreWindow
+type WindowSelector struct {
+	Title   string // Título aproximado: se restaura la ventana que mejor lo matchea
+	AppName string // Sin Title, todas las ventanas de la app; con Title, limita la búsqueda a ella
+}
+
+// RestoreWindow restaura solo las ventanas del snapshot que elige sel, sin
+// terminales, pestañas ni procesos. Si el título matchea varias ventanas casi
+// igual de bien falla listándolas en vez de elegir una
+func (m *Manager) RestoreWindow(ctx context.Context, snapshotID string, sel WindowSelector, opts RestoreOptions) (*RestoreReport, error) {
+	sel.Title, sel.AppName = strings.TrimSpace(sel.Title), strings.TrimSpace(sel.AppName)
+	if sel.Title == "" && sel.AppName == "" {
+		return nil, fmt.Errorf("a window title or an app name is required")
+	}
+	opts.window = &sel
+	return m.Restore(ctx, snapshotID, opts)
+}
+
+// selectWindows deja en s solo las ventanas que elige sel y descarta el resto
+// de los componentes. Los OwnerRef se renumeran a las nuevas posiciones; el
+// de una ventana cuyo owner quedó afuera se borra
+func selectWindows(s *core.Snapshot, sel WindowSelector, matcher *platform.WindowMatcher) error {
+	var candidates []int // Índices en s.Windows
+	for i, w := range s.Windows {
+		if sel.AppName == "" || sameAppName(w.AppName, sel.AppName) {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("snapshot %s has no window of %q", s.ID, sel.AppName)
+	}
+
+	chosen := candidates
+	if sel.Title != "" {
+		best, err := pickWindow(s, candidates, sel, matcher)
+		if err != nil {
+			return err
+		}
+		chosen = []int{best}
+	}
+
+	selected := make(map[int]bool, len(chosen))
+	for _, idx := range chosen {
+		selected[idx] = true
+	}
+	s.Windows = platform.FilterWindows(s.Windows, func(i int, _ core.Window) bool { return selected[i] })
+	s.Terminals, s.BrowserTabs, s.Processes = nil, nil, nil
+	return nil
+}
+
+// pickWindow elige entre candidates la ventana grabada cuyo título mejor
+// matchea sel.Title. El umbral baja al de un match parcial: "main.go" tiene
+// que encontrar "main.go - proyecto - Visual Studio Code"
+func pickWindow(s *core.Snapshot, candidates []int, sel WindowSelector, matcher *platform.WindowMatcher) (int, error) {
+	picker := *matcher
+	picker.MinimumScore = min(picker.MinimumScore, picker.PartialTitleScore)
+	target := core.Window{WindowTitle: sel.Title, AppName: sel.AppName}
+
+	type scored struct{ idx, score int }
+	var matches []scored
+	for _, idx := range candidates {
+		// De a una candidata, para saber a qué índice corresponde cada score
+		if found := picker.FindMatches(target, []core.Window{s.Windows[idx]}, 1); len(found) > 0 {
+			matches = append(matches, scored{idx, found[0].Score})
+		}
+	}
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("no window in snapshot %s matches %q", s.ID, sel.Title)
+	}
+	sort.SliceStable(matches, func(a, b int) bool { return matches[a].score > matches[b].score })
+
+	var tied []string
+	for _, c := range matches {
+		if matches[0].score-c.score >= platform.AmbiguityMargin {
+			break
+		}
+		w := s.Windows[c.idx]
+		tied = append(tied, fmt.Sprintf("%q (%s)", w.WindowTitle, w.AppName))
+	}
+	if len(tied) > 1 {
+		return 0, fmt.Errorf("window %q is ambiguous, it matches %s; use a more specific title or an app name", sel.Title, strings.Join(tied, ", "))
+	}
+	return matches[0].idx, nil
+}
+
+// sameAppName compara nombres de app sin distinguir mayúsculas y con o sin
+// extensión ("code" encuentra "Code.exe")
+func sameAppName(appName, want string) bool {
+	return strings.EqualFold(appName, want) ||
+		strings.EqualFold(strings.TrimSuffix(appName, filepath.Ext(appName)), strings.TrimSuffix(want, filepath.Ext(want)))
+}